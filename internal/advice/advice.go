@@ -0,0 +1,34 @@
+// Package advice contains the LLM backends used to generate financial advice.
+package advice
+
+import (
+	"context"
+	"fmt"
+	"tbank-go/internal/config"
+)
+
+const (
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai"
+	ProviderFake   = "fake"
+)
+
+// AdviceProvider turns a prompt into a free-form text answer.
+type AdviceProvider interface {
+	GenerateAdvice(ctx context.Context, prompt string) (string, error)
+	Close() error
+}
+
+// NewProvider builds the provider selected in the configuration.
+func NewProvider(ctx context.Context, cfg config.Advice) (AdviceProvider, error) {
+	switch cfg.Provider {
+	case ProviderGemini:
+		return NewGeminiProvider(ctx, cfg.APIKey, cfg.Model)
+	case ProviderOpenAI:
+		return NewOpenAIProvider(cfg.BaseURL, cfg.APIKey, cfg.Model, cfg.Timeout)
+	case ProviderFake:
+		return NewFakeProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown advice provider %q", cfg.Provider)
+	}
+}
@@ -0,0 +1,125 @@
+package advice
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"tbank-go/internal/config"
+	"testing"
+	"time"
+)
+
+// newChatServer answers chat completions with status and body and checks the request the
+// provider sends.
+func newChatServer(t *testing.T, status int, body string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if req.Model != "test-model" || len(req.Messages) != 1 || req.Messages[0].Role != "user" || req.Messages[0].Content != "prompt" {
+			t.Errorf("chat request = %+v", req)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOpenAIProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr string
+	}{
+		{name: "answer", status: http.StatusOK,
+			body: `{"choices": [{"message": {"role": "assistant", "content": "Spend less on taxis."}}, {"message": {"content": "ignored"}}]}`,
+			want: "Spend less on taxis."},
+		{name: "server error", status: http.StatusTooManyRequests, body: `{"error": "rate limited"}`,
+			wantErr: `chat completions returned 429 Too Many Requests: {"error": "rate limited"}`},
+		{name: "no choices", status: http.StatusOK, body: `{"choices": []}`,
+			wantErr: "chat completions response has no choices"},
+		{name: "not JSON", status: http.StatusOK, body: `<html>`,
+			wantErr: "decode chat completions response"},
+	}
+	for _, tt := range tests {
+		server := newChatServer(t, tt.status, tt.body)
+		provider, err := NewOpenAIProvider(server.URL+"/v1/", "test-key", "test-model", 5*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := provider.GenerateAdvice(context.Background(), "prompt")
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: %q, %v, want error %q", tt.name, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+		provider.Close()
+	}
+}
+
+func TestFakeProvider(t *testing.T) {
+	provider := NewFakeProvider()
+	ctx := context.Background()
+	first, err := provider.GenerateAdvice(ctx, "line one\nline two\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := provider.GenerateAdvice(ctx, "line one\nline two\n")
+	other, _ := provider.GenerateAdvice(ctx, "line one\nline 2\n")
+	if first != second || first == other || !strings.Contains(first, "проанализировано строк запроса: 2") {
+		t.Errorf("answers %q, %q, %q", first, second, other)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := provider.GenerateAdvice(canceled, "prompt"); err != context.Canceled {
+		t.Errorf("canceled request: %v", err)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		cfg     config.Advice
+		wantErr string
+	}{
+		{cfg: config.Advice{Provider: ProviderFake}},
+		{cfg: config.Advice{Provider: ProviderOpenAI, BaseURL: "http://localhost:11434/v1", Model: "llama3"}},
+		{cfg: config.Advice{Provider: ProviderOpenAI, Model: "llama3"}, wantErr: "advice.base_url"},
+		{cfg: config.Advice{Provider: ProviderOpenAI, BaseURL: "http://localhost:11434/v1"}, wantErr: "advice.model"},
+		{cfg: config.Advice{Provider: ProviderGemini}, wantErr: "advice.api_key"},
+		{cfg: config.Advice{Provider: "llama"}, wantErr: `unknown advice provider "llama"`},
+	}
+	for _, tt := range tests {
+		provider, err := NewProvider(context.Background(), tt.cfg)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: %v, want error %q", tt.cfg.Provider, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.cfg.Provider, err)
+			continue
+		}
+		provider.Close()
+	}
+}
@@ -0,0 +1,31 @@
+package advice
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// FakeProvider returns a deterministic answer derived from the prompt without any network access.
+// It is meant for CI and local development.
+type FakeProvider struct{}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{}
+}
+
+func (p *FakeProvider) GenerateAdvice(ctx context.Context, prompt string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(prompt))
+	lines := len(strings.Split(strings.TrimSpace(prompt), "\n"))
+	return fmt.Sprintf("Тестовый совет: проанализировано строк запроса: %d (fingerprint %s).", lines, hex.EncodeToString(sum[:4])), nil
+}
+
+func (p *FakeProvider) Close() error {
+	return nil
+}
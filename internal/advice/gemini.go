@@ -0,0 +1,55 @@
+package advice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+)
+
+const defaultGeminiModel = "gemini-1.5-flash"
+
+// GeminiProvider calls the Google Gemini API. The client is created once and shared by all requests.
+type GeminiProvider struct {
+	client *genai.Client
+	model  *genai.GenerativeModel
+}
+
+func NewGeminiProvider(ctx context.Context, apiKey string, model string) (*GeminiProvider, error) {
+	if apiKey == "" {
+		return nil, errors.New("gemini provider requires advice.api_key")
+	}
+	if model == "" {
+		model = defaultGeminiModel
+	}
+
+	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	if err != nil {
+		return nil, fmt.Errorf("initialize Gemini client: %w", err)
+	}
+
+	return &GeminiProvider{client: client, model: client.GenerativeModel(model)}, nil
+}
+
+func (p *GeminiProvider) GenerateAdvice(ctx context.Context, prompt string) (string, error) {
+	resp, err := p.model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return "", err
+	}
+
+	var advice string
+	for _, cand := range resp.Candidates {
+		if cand.Content != nil {
+			for _, part := range cand.Content.Parts {
+				advice += fmt.Sprintf("%s", part)
+			}
+		}
+	}
+	return advice, nil
+}
+
+func (p *GeminiProvider) Close() error {
+	return p.client.Close()
+}
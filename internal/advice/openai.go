@@ -0,0 +1,100 @@
+package advice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIProvider talks to any server implementing the OpenAI chat completions API,
+// e.g. OpenAI itself, llama.cpp's server or Ollama.
+type OpenAIProvider struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// NewOpenAIProvider expects baseURL to point at the API root, e.g. http://localhost:11434/v1.
+func NewOpenAIProvider(baseURL string, apiKey string, model string, timeout time.Duration) (*OpenAIProvider, error) {
+	if baseURL == "" {
+		return nil, errors.New("openai provider requires advice.base_url")
+	}
+	if model == "" {
+		return nil, errors.New("openai provider requires advice.model")
+	}
+
+	return &OpenAIProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (p *OpenAIProvider) GenerateAdvice(ctx context.Context, prompt string) (string, error) {
+	body, err := json.Marshal(chatRequest{
+		Model:    p.model,
+		Messages: []chatMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("chat completions returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var completion chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return "", fmt.Errorf("decode chat completions response: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return "", errors.New("chat completions response has no choices")
+	}
+
+	return completion.Choices[0].Message.Content, nil
+}
+
+func (p *OpenAIProvider) Close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
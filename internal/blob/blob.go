@@ -0,0 +1,70 @@
+// Package blob contains the stores keeping uploaded files outside the database.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"tbank-go/internal/config"
+)
+
+const (
+	StoreLocal = "local"
+	StoreS3    = "s3"
+)
+
+// ErrNotFound is returned by Get when there is no blob under the key.
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps blobs under slash-separated keys such as "expense/<id>/<name>".
+type Store interface {
+	// Put stores data under key, replacing the blob already there.
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// Get opens the blob under key. It fails with ErrNotFound when there is none.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob under key. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStore builds the store selected in the configuration.
+func NewStore(cfg config.Attachments) (Store, error) {
+	switch cfg.Store {
+	case StoreLocal:
+		return NewLocalStore(cfg.Dir)
+	case StoreS3:
+		return NewS3Store(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown attachment store %q", cfg.Store)
+	}
+}
+
+// Remove deletes the blobs under the keys, skipping empty ones. It tries every key and returns
+// the first error.
+func Remove(ctx context.Context, store Store, keys ...string) error {
+	var first error
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := store.Delete(ctx, key); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// validKey reports whether key is a relative slash-separated path without empty, "." or ".."
+// segments, so that it cannot escape the root of a store.
+func validKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." || strings.ContainsRune(segment, '\\') {
+			return false
+		}
+	}
+	return true
+}
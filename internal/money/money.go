@@ -0,0 +1,270 @@
+// Package money implements exact monetary amounts stored as integer minor units.
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used for amounts that arrive without an explicit currency code.
+const DefaultCurrency = "RUB"
+
+// Money is an amount in minor units (kopecks for RUB) together with its ISO 4217 currency code.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+var (
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrInvalidCurrency  = errors.New("invalid currency code")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// exponents lists currencies whose minor unit is not 1/100.
+var exponents = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+	"TND": 3,
+}
+
+// Exponent returns the number of minor-unit digits of the currency.
+func Exponent(currency string) int {
+	if e, ok := exponents[currency]; ok {
+		return e
+	}
+	return 2
+}
+
+// ValidCurrency reports whether code looks like an ISO 4217 alphabetic code.
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// New returns an amount of minor units in the given currency.
+func New(minor int64, currency string) Money {
+	return Money{Amount: minor, Currency: currency}
+}
+
+// Parse converts a decimal string such as "99.99", "-1 234,5" or "100" into Money.
+// It fails instead of rounding when the value has more fractional digits than the currency allows.
+func Parse(s string, currency string) (Money, error) {
+	s = strings.TrimSpace(s)
+	s = strings.NewReplacer(" ", "", " ", "", "_", "").Replace(s)
+	s = strings.Replace(s, ",", ".", 1)
+	if s == "" {
+		return Money{}, ErrInvalidAmount
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return Money{}, fmt.Errorf("%w: %q has no digits", ErrInvalidAmount, s)
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	exp := Exponent(currency)
+	fracPart = strings.TrimRight(fracPart, "0")
+	if len(fracPart) > exp {
+		return Money{}, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidAmount, s, exp)
+	}
+	fracPart += strings.Repeat("0", exp-len(fracPart))
+
+	for _, part := range []string{intPart, fracPart} {
+		for _, c := range part {
+			if c < '0' || c > '9' {
+				return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+			}
+		}
+	}
+
+	minor, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if negative {
+		minor = -minor
+	}
+
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// OrDefault returns m in the given currency when m was decoded without one.
+// Amounts without a currency are parsed with DefaultCurrency's exponent, so they are rescaled here.
+func (m Money) OrDefault(currency string) (Money, error) {
+	if m.Currency != "" {
+		return m, nil
+	}
+
+	amount := m.Amount
+	for diff := Exponent(currency) - Exponent(DefaultCurrency); diff != 0; {
+		if diff > 0 {
+			amount *= 10
+			diff--
+		} else {
+			if amount%10 != 0 {
+				return Money{}, fmt.Errorf("%w: too many decimal places for %s", ErrInvalidAmount, currency)
+			}
+			amount /= 10
+			diff++
+		}
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// Decimal formats the amount without the currency, e.g. "99.99".
+func (m Money) Decimal() string {
+	exp := Exponent(m.Currency)
+	sign := ""
+	minor := m.Amount
+	if minor < 0 {
+		sign = "-"
+	}
+
+	digits := strconv.FormatUint(absUint(minor), 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// String formats the amount with its currency, e.g. "99.99 RUB".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Add returns m+o. Both amounts must be in the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns m-o. Both amounts must be in the same currency.
+func (m Money) Sub(o Money) (Money, error) {
+	return m.Add(o.Neg())
+}
+
+// Neg returns -m.
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// IsPositive reports whether the amount is greater than zero.
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+type moneyJSON struct {
+	Value    string `json:"value"`
+	Minor    int64  `json:"minor"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes Money as {"value": "99.99", "minor": 9999, "currency": "RUB"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Value: m.Decimal(), Minor: m.Amount, Currency: m.Currency})
+}
+
+// UnmarshalJSON accepts a bare number (99.99), a decimal string ("99.99") or an object
+// {"value": "99.99", "currency": "USD"} / {"minor": 9999, "currency": "USD"}.
+// Numbers are parsed from their literal text, never through float64.
+// When no currency is given Currency is left empty so the caller can apply its default.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	switch data[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return m.parseValue(s, "")
+	case '{':
+		var obj struct {
+			Value    json.RawMessage `json:"value"`
+			Minor    *int64          `json:"minor"`
+			Currency string          `json:"currency"`
+		}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		if obj.Currency != "" && !ValidCurrency(obj.Currency) {
+			return fmt.Errorf("%w: %q", ErrInvalidCurrency, obj.Currency)
+		}
+		if len(obj.Value) > 0 {
+			value := string(obj.Value)
+			if obj.Value[0] == '"' {
+				if err := json.Unmarshal(obj.Value, &value); err != nil {
+					return err
+				}
+			}
+			return m.parseValue(value, obj.Currency)
+		}
+		if obj.Minor != nil {
+			if obj.Currency == "" {
+				return fmt.Errorf("%w: minor units require a currency", ErrInvalidAmount)
+			}
+			*m = Money{Amount: *obj.Minor, Currency: obj.Currency}
+			return nil
+		}
+		return ErrInvalidAmount
+	default:
+		return m.parseValue(string(data), "")
+	}
+}
+
+func (m *Money) parseValue(value string, currency string) error {
+	exponentCurrency := currency
+	if exponentCurrency == "" {
+		exponentCurrency = DefaultCurrency
+	}
+	parsed, err := Parse(value, exponentCurrency)
+	if err != nil {
+		return err
+	}
+	parsed.Currency = currency
+	*m = parsed
+	return nil
+}
+
+func absUint(v int64) uint64 {
+	if v < 0 {
+		return uint64(-v)
+	}
+	return uint64(v)
+}
@@ -0,0 +1,205 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{input: "99.99", currency: "RUB", want: 9999},
+		{input: "100", currency: "RUB", want: 10000},
+		{input: "0,5", currency: "RUB", want: 50},
+		{input: "-1 234,5", currency: "RUB", want: -123450},
+		{input: "1 000 000,01", currency: "RUB", want: 100000001},
+		{input: "1_000", currency: "RUB", want: 100000},
+		{input: " +12.30 ", currency: "RUB", want: 1230},
+		{input: ".75", currency: "RUB", want: 75},
+		{input: "-0.01", currency: "RUB", want: -1},
+		{input: "10.500", currency: "RUB", want: 1050}, // trailing zeros are not extra precision
+		{input: "0.001", currency: "RUB", wantErr: true},
+		{input: "1.999", currency: "USD", wantErr: true},
+		{input: "1500", currency: "JPY", want: 1500},
+		{input: "1500.00", currency: "JPY", want: 1500},
+		{input: "1500.5", currency: "JPY", wantErr: true},
+		{input: "1.234", currency: "KWD", want: 1234},
+		{input: "0.005", currency: "KWD", want: 5},
+		{input: "1.2345", currency: "KWD", wantErr: true},
+		{input: "92233720368547758.07", currency: "RUB", want: 9223372036854775807},
+		{input: "92233720368547758.08", currency: "RUB", wantErr: true},
+		{input: "99999999999999999999", currency: "JPY", wantErr: true},
+		{input: "", currency: "RUB", wantErr: true},
+		{input: "-", currency: "RUB", wantErr: true},
+		{input: ".", currency: "RUB", wantErr: true},
+		{input: "--5", currency: "RUB", wantErr: true},
+		{input: "5-", currency: "RUB", wantErr: true},
+		{input: "1,5,0", currency: "RUB", wantErr: true},
+		{input: "1.2.3", currency: "RUB", wantErr: true},
+		{input: "1e3", currency: "RUB", wantErr: true},
+		{input: "abc", currency: "RUB", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.input, tt.currency)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("Parse(%q, %s) = %v, %v, want ErrInvalidAmount", tt.input, tt.currency, got, err)
+			}
+			continue
+		}
+		if err != nil || got != New(tt.want, tt.currency) {
+			t.Errorf("Parse(%q, %s) = %v, %v, want %d", tt.input, tt.currency, got, err, tt.want)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: New(9999, "RUB"), want: "99.99"},
+		{money: New(5, "RUB"), want: "0.05"},
+		{money: New(-5, "RUB"), want: "-0.05"},
+		{money: New(0, "RUB"), want: "0.00"},
+		{money: New(1500, "JPY"), want: "1500"},
+		{money: New(-1500, "JPY"), want: "-1500"},
+		{money: New(1234, "KWD"), want: "1.234"},
+		{money: New(5, "KWD"), want: "0.005"},
+		{money: New(-9223372036854775807, "RUB"), want: "-92233720368547758.07"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.Decimal(); got != tt.want {
+			t.Errorf("%d %s: Decimal() = %q, want %q", tt.money.Amount, tt.money.Currency, got, tt.want)
+		}
+		if parsed, err := Parse(tt.want, tt.money.Currency); err != nil || parsed != tt.money {
+			t.Errorf("Parse(%q, %s) = %v, %v, want %v", tt.want, tt.money.Currency, parsed, err, tt.money)
+		}
+	}
+}
+
+func TestOrDefault(t *testing.T) {
+	tests := []struct {
+		input    string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{input: `"1.5"`, currency: "RUB", want: 150},
+		{input: `"1.5"`, currency: "KWD", want: 1500},
+		{input: `"0.01"`, currency: "BHD", want: 10},
+		{input: `"1500"`, currency: "JPY", want: 1500},
+		{input: `"1500.00"`, currency: "JPY", want: 1500},
+		{input: `"1.5"`, currency: "JPY", wantErr: true},
+		{input: `"0.01"`, currency: "JPY", wantErr: true},
+	}
+	for _, tt := range tests {
+		var m Money
+		if err := json.Unmarshal([]byte(tt.input), &m); err != nil {
+			t.Fatalf("unmarshal %s: %v", tt.input, err)
+		}
+		got, err := m.OrDefault(tt.currency)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("%s in %s = %v, %v, want ErrInvalidAmount", tt.input, tt.currency, got, err)
+			}
+			continue
+		}
+		if err != nil || got != New(tt.want, tt.currency) {
+			t.Errorf("%s in %s = %v, %v, want %d", tt.input, tt.currency, got, err, tt.want)
+		}
+	}
+
+	// An explicit currency is kept as is.
+	if got, err := New(150, "USD").OrDefault("JPY"); err != nil || got != New(150, "USD") {
+		t.Errorf("USD amount in JPY = %v, %v", got, err)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Money
+		wantErr error
+	}{
+		{input: `99.99`, want: New(9999, "")},
+		{input: `-0.5`, want: New(-50, "")},
+		{input: `100`, want: New(10000, "")},
+		{input: `0.1`, want: New(10, "")}, // not 0.1000000000000000055511151231257827 as a float64
+		{input: `1e2`, wantErr: ErrInvalidAmount},
+		{input: `0.001`, wantErr: ErrInvalidAmount},
+		{input: `"99,99"`, want: New(9999, "")},
+		{input: `"1 234.50"`, want: New(123450, "")},
+		{input: `"ten"`, wantErr: ErrInvalidAmount},
+		{input: `{"value": "12.34", "currency": "USD"}`, want: New(1234, "USD")},
+		{input: `{"value": 12.34, "currency": "USD"}`, want: New(1234, "USD")},
+		{input: `{"value": "12.345", "currency": "KWD"}`, want: New(12345, "KWD")},
+		{input: `{"value": "12.5", "currency": "JPY"}`, wantErr: ErrInvalidAmount},
+		{input: `{"value": "12.34"}`, want: New(1234, "")},
+		{input: `{"value": "1", "currency": "usd"}`, wantErr: ErrInvalidCurrency},
+		{input: `{"value": "1", "currency": "RUBL"}`, wantErr: ErrInvalidCurrency},
+		{input: `{"minor": 1234, "currency": "USD"}`, want: New(1234, "USD")},
+		{input: `{"minor": -7, "currency": "JPY"}`, want: New(-7, "JPY")},
+		{input: `{"minor": 1234}`, wantErr: ErrInvalidAmount},
+		{input: `{"currency": "USD"}`, wantErr: ErrInvalidAmount},
+		{input: `{}`, wantErr: ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		var got Money
+		err := json.Unmarshal([]byte(tt.input), &got)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("unmarshal %s = %v, %v, want %v", tt.input, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("unmarshal %s = %+v, %v, want %+v", tt.input, got, err, tt.want)
+		}
+	}
+
+	// null leaves the field alone, so a PATCH can tell an absent amount from a zero one.
+	m := New(500, "RUB")
+	if err := json.Unmarshal([]byte(`null`), &m); err != nil || m != New(500, "RUB") {
+		t.Errorf("unmarshal null = %v, %v", m, err)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	for _, m := range []Money{New(9999, "RUB"), New(-5, "KWD"), New(1500, "JPY")} {
+		data, err := json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Money
+		if err := json.Unmarshal(data, &got); err != nil || got != m {
+			t.Errorf("%s round trips to %v, %v", data, got, err)
+		}
+	}
+
+	data, _ := json.Marshal(New(-5, "KWD"))
+	if want := `{"value":"-0.005","minor":-5,"currency":"KWD"}`; string(data) != want {
+		t.Errorf("marshal = %s, want %s", data, want)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	sum, err := New(150, "RUB").Add(New(-200, "RUB"))
+	if err != nil || sum != New(-50, "RUB") {
+		t.Errorf("Add = %v, %v", sum, err)
+	}
+	if _, err := New(150, "RUB").Add(New(150, "USD")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add across currencies: %v", err)
+	}
+	if diff, err := New(150, "RUB").Sub(New(200, "RUB")); err != nil || diff != New(-50, "RUB") {
+		t.Errorf("Sub = %v, %v", diff, err)
+	}
+	if _, err := New(1, "JPY").Sub(New(1, "KWD")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub across currencies: %v", err)
+	}
+}
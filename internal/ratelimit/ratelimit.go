@@ -0,0 +1,136 @@
+// Package ratelimit throttles requests per key (client IP, username, ...) with token buckets.
+package ratelimit
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limiter decides whether another request for key may proceed.
+type Limiter interface {
+	// Allow takes a token for key. When none is left it reports false together with
+	// the time until the next token becomes available.
+	Allow(key string) (bool, time.Duration)
+}
+
+// Memory is an in-process Limiter. Each key gets a bucket of burst tokens that refills
+// at one token per interval. State is lost on restart and not shared between instances.
+type Memory struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    float64
+	buckets  map[string]*bucket
+	calls    int
+	now      func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// sweepEvery is the number of Allow calls between scans that drop full (idle) buckets.
+const sweepEvery = 1024
+
+// NewMemory returns a Memory limiter allowing bursts of burst requests per key and
+// one further request per interval.
+func NewMemory(interval time.Duration, burst int) *Memory {
+	return NewMemoryWithClock(interval, burst, time.Now)
+}
+
+// NewMemoryWithClock is NewMemory with buckets refilled according to now instead of the
+// wall clock, so that tests can control time.
+func NewMemoryWithClock(interval time.Duration, burst int, now func() time.Time) *Memory {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Memory{
+		interval: interval,
+		burst:    float64(burst),
+		buckets:  make(map[string]*bucket),
+		now:      now,
+	}
+}
+
+func (m *Memory) Allow(key string) (bool, time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.calls++
+	if m.calls%sweepEvery == 0 {
+		m.sweep(now)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: m.burst, updated: now}
+		m.buckets[key] = b
+	} else {
+		b.tokens = m.refill(b, now)
+		b.updated = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) * float64(m.interval))
+	return false, wait
+}
+
+func (m *Memory) refill(b *bucket, now time.Time) float64 {
+	if m.interval <= 0 {
+		return m.burst
+	}
+	tokens := b.tokens + float64(now.Sub(b.updated))/float64(m.interval)
+	return math.Min(tokens, m.burst)
+}
+
+// sweep forgets buckets that have refilled completely; they are indistinguishable from new ones.
+func (m *Memory) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if m.refill(b, now) >= m.burst {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+// TooManyRequests writes a 429 response with a Retry-After header rounded up to whole seconds.
+func TooManyRequests(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, "Too many requests", http.StatusTooManyRequests)
+}
+
+// ClientIP returns the host part of the request's remote address.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ByIP limits requests per client IP.
+func ByIP(limiter Limiter, log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := ClientIP(r)
+			if ok, retryAfter := limiter.Allow(ip); !ok {
+				log.Warn("rate limit exceeded", slog.String("ip", ip), slog.String("path", r.URL.Path))
+				TooManyRequests(w, retryAfter)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
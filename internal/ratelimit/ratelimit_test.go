@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// clock is a manually advanced time source.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newClock() *clock {
+	return &clock{now: time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func TestMemoryRefill(t *testing.T) {
+	c := newClock()
+	limiter := NewMemoryWithClock(10*time.Second, 2, c.Now)
+
+	type step struct {
+		advance  time.Duration
+		key      string
+		wantOK   bool
+		wantWait time.Duration
+	}
+	steps := []step{
+		// A new key starts with a full bucket of burst tokens.
+		{key: "a", wantOK: true},
+		{key: "a", wantOK: true},
+		{key: "a", wantWait: 10 * time.Second},
+		// Other keys have buckets of their own.
+		{key: "b", wantOK: true},
+		// The bucket refills continuously, so the wait shrinks.
+		{advance: 4 * time.Second, key: "a", wantWait: 6 * time.Second},
+		{advance: 6 * time.Second, key: "a", wantOK: true},
+		{key: "a", wantWait: 10 * time.Second},
+		// A long pause refills at most burst tokens.
+		{advance: time.Hour, key: "a", wantOK: true},
+		{key: "a", wantOK: true},
+		{key: "a", wantWait: 10 * time.Second},
+	}
+	for i, s := range steps {
+		c.Advance(s.advance)
+		ok, wait := limiter.Allow(s.key)
+		if ok != s.wantOK || wait != s.wantWait {
+			t.Errorf("step %d: Allow(%s) = %v, %v, want %v, %v", i+1, s.key, ok, wait, s.wantOK, s.wantWait)
+		}
+	}
+}
+
+func TestMemorySweep(t *testing.T) {
+	c := newClock()
+	limiter := NewMemoryWithClock(time.Second, 1, c.Now)
+	limiter.Allow("idle")
+	c.Advance(time.Minute)
+	for i := 1; i < sweepEvery; i++ {
+		limiter.Allow("busy")
+	}
+	if _, ok := limiter.buckets["idle"]; ok {
+		t.Error("refilled bucket survived the sweep")
+	}
+	if _, ok := limiter.buckets["busy"]; !ok {
+		t.Error("bucket in use was swept")
+	}
+}
+
+func TestTooManyRequests(t *testing.T) {
+	tests := []struct {
+		retryAfter time.Duration
+		want       string
+	}{
+		{retryAfter: 0, want: "1"},
+		{retryAfter: time.Millisecond, want: "1"},
+		{retryAfter: time.Second, want: "1"},
+		{retryAfter: time.Second + time.Millisecond, want: "2"},
+		{retryAfter: 6 * time.Second, want: "6"},
+		{retryAfter: 59500 * time.Millisecond, want: "60"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		TooManyRequests(w, tt.retryAfter)
+		if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != tt.want {
+			t.Errorf("TooManyRequests(%v): %d, Retry-After %q, want 429, %q", tt.retryAfter, w.Code, w.Header().Get("Retry-After"), tt.want)
+		}
+	}
+}
+
+func TestByIP(t *testing.T) {
+	c := newClock()
+	handler := ByIP(NewMemoryWithClock(2500*time.Millisecond, 1, c.Now), slog.New(slog.NewTextHandler(io.Discard, nil)))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/login", nil)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := request("192.0.2.1:40000"); w.Code != http.StatusNoContent {
+		t.Fatalf("first request: %d", w.Code)
+	}
+	// Another port of the same client shares its bucket.
+	w := request("192.0.2.1:40001")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "3" {
+		t.Errorf("second request: %d, Retry-After %q, want 429, \"3\"", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := request("192.0.2.2:40000"); w.Code != http.StatusNoContent {
+		t.Errorf("other client: %d", w.Code)
+	}
+
+	c.Advance(2500 * time.Millisecond)
+	if w := request("192.0.2.1:40002"); w.Code != http.StatusNoContent {
+		t.Errorf("after the refill: %d", w.Code)
+	}
+}
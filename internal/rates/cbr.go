@@ -0,0 +1,96 @@
+package rates
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"tbank-go/internal/money"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+const dateLayout = "2006-01-02"
+
+// cbrDaily mirrors https://www.cbr.ru/scripts/XML_daily.asp:
+//
+//	<ValCurs Date="02.03.2024" name="Foreign Currency Market">
+//	  <Valute ID="R01235"><NumCode>840</NumCode><CharCode>USD</CharCode><Nominal>1</Nominal>
+//	    <Name>Доллар США</Name><Value>91,6618</Value><VunitRate>91,6618</VunitRate></Valute>
+//	</ValCurs>
+type cbrDaily struct {
+	XMLName xml.Name `xml:"ValCurs"`
+	Date    string   `xml:"Date,attr"`
+	Valutes []struct {
+		CharCode string `xml:"CharCode"`
+		Nominal  string `xml:"Nominal"`
+		Value    string `xml:"Value"`
+	} `xml:"Valute"`
+}
+
+// ParseCBR reads one CBR daily rates document. The file is normally windows-1251 encoded,
+// which the XML prolog declares; UTF-8 documents are accepted too.
+func ParseCBR(r io.Reader) ([]Rate, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		switch strings.ToLower(charset) {
+		case "windows-1251", "cp1251":
+			return charmap.Windows1251.NewDecoder().Reader(input), nil
+		case "utf-8", "":
+			return input, nil
+		}
+		return nil, fmt.Errorf("unsupported charset %q", charset)
+	}
+
+	var doc cbrDaily
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode XML: %w", err)
+	}
+
+	day, err := time.Parse("02.01.2006", doc.Date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ValCurs date %q", doc.Date)
+	}
+	date := day.Format(dateLayout)
+
+	rates := make([]Rate, 0, len(doc.Valutes))
+	for _, v := range doc.Valutes {
+		currency := strings.TrimSpace(v.CharCode)
+		if !money.ValidCurrency(currency) {
+			return nil, fmt.Errorf("invalid currency code %q", v.CharCode)
+		}
+		nominal, err := strconv.ParseInt(strings.TrimSpace(v.Nominal), 10, 64)
+		if err != nil || nominal <= 0 {
+			return nil, fmt.Errorf("%s: invalid nominal %q", currency, v.Nominal)
+		}
+		value, err := parseValue(v.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", currency, err)
+		}
+		rates = append(rates, Rate{Date: date, Currency: currency, Nominal: nominal, Value: value})
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("no rates in document")
+	}
+
+	return rates, nil
+}
+
+// parseValue converts a CBR decimal such as "91,6618" into 1/10000 rubles.
+func parseValue(s string) (int64, error) {
+	s = strings.Replace(strings.TrimSpace(s), ",", ".", 1)
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	fracPart = strings.TrimRight(fracPart, "0")
+	if intPart == "" || len(fracPart) > 4 {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	fracPart += strings.Repeat("0", 4-len(fracPart))
+
+	value, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil || value <= 0 || strings.ContainsAny(intPart+fracPart, "+-") {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return value, nil
+}
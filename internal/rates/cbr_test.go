@@ -0,0 +1,111 @@
+package rates
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseCBR(t *testing.T) {
+	// The document is windows-1251 encoded like the ones served by XML_daily.asp.
+	f, err := os.Open("testdata/XML_daily_2024-03-02.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := ParseCBR(f)
+	if err != nil {
+		t.Fatalf("ParseCBR: %v", err)
+	}
+	want := []Rate{
+		{Date: "2024-03-02", Currency: "AMD", Nominal: 100, Value: 226339},
+		{Date: "2024-03-02", Currency: "USD", Nominal: 1, Value: 916618},
+		{Date: "2024-03-02", Currency: "EUR", Nominal: 1, Value: 991763},
+		{Date: "2024-03-02", Currency: "KZT", Nominal: 100, Value: 203302},
+		{Date: "2024-03-02", Currency: "CNY", Nominal: 1, Value: 126526},
+		{Date: "2024-03-02", Currency: "TRY", Nominal: 10, Value: 292618},
+		{Date: "2024-03-02", Currency: "UAH", Nominal: 10, Value: 241047},
+		{Date: "2024-03-02", Currency: "JPY", Nominal: 100, Value: 610837},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d rates, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("rate %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseCBRErrors(t *testing.T) {
+	valute := func(code, nominal, value string) string {
+		return `<Valute ID="R01235"><NumCode>840</NumCode><CharCode>` + code + `</CharCode><Nominal>` + nominal +
+			`</Nominal><Name>Доллар США</Name><Value>` + value + `</Value></Valute>`
+	}
+	document := func(date string, valutes ...string) string {
+		return `<?xml version="1.0" encoding="UTF-8"?><ValCurs Date="` + date + `" name="Foreign Currency Market">` +
+			strings.Join(valutes, "") + `</ValCurs>`
+	}
+
+	// A UTF-8 document is accepted as well.
+	rates, err := ParseCBR(strings.NewReader(document("29.02.2024", valute("USD", "1", "90,8545"))))
+	if err != nil || len(rates) != 1 || rates[0] != (Rate{Date: "2024-02-29", Currency: "USD", Nominal: 1, Value: 908545}) {
+		t.Errorf("UTF-8 document: %+v, %v", rates, err)
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "unsupported charset", input: `<?xml version="1.0" encoding="KOI8-R"?><ValCurs Date="02.03.2024"></ValCurs>`,
+			wantErr: `unsupported charset "KOI8-R"`},
+		{name: "not XML", input: `{"USD": 91.6618}`, wantErr: "decode XML"},
+		{name: "invalid date", input: document("2024-03-02", valute("USD", "1", "91,6618")), wantErr: `invalid ValCurs date "2024-03-02"`},
+		{name: "no rates", input: document("02.03.2024"), wantErr: "no rates in document"},
+		{name: "invalid currency", input: document("02.03.2024", valute("usd", "1", "91,6618")), wantErr: `invalid currency code "usd"`},
+		{name: "zero nominal", input: document("02.03.2024", valute("USD", "0", "91,6618")), wantErr: `USD: invalid nominal "0"`},
+		{name: "five decimals", input: document("02.03.2024", valute("USD", "1", "91,66181")), wantErr: `USD: invalid value "91.66181"`},
+		{name: "negative value", input: document("02.03.2024", valute("USD", "1", "-91,6618")), wantErr: "USD: invalid value"},
+		{name: "zero value", input: document("02.03.2024", valute("USD", "1", "0,0000")), wantErr: "USD: invalid value"},
+	}
+	for _, tt := range tests {
+		_, err := ParseCBR(strings.NewReader(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: %v, want error %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{input: "91,6618", want: 916618},
+		{input: "91.6618", want: 916618},
+		{input: " 12,65 ", want: 126500},
+		{input: "1", want: 10000},
+		{input: "0,0001", want: 1},
+		{input: "10,000000", want: 100000}, // trailing zeros are not extra precision
+		{input: "0,00001", wantErr: true},
+		{input: ",5", wantErr: true},
+		{input: "+1,5", wantErr: true},
+		{input: "1,2,3", wantErr: true},
+		{input: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseValue(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseValue(%q) = %d, want an error", tt.input, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseValue(%q) = %d, %v, want %d", tt.input, got, err, tt.want)
+		}
+	}
+}
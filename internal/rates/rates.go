@@ -0,0 +1,126 @@
+// Package rates keeps the exchange-rate table and converts amounts between currencies.
+//
+// Rates are quoted the way the Central Bank of Russia publishes them: the price in rubles
+// of Nominal units of a currency. Conversions between two foreign currencies go through
+// the ruble. Rates are loaded offline from the CBR daily XML, nothing is fetched live.
+package rates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"tbank-go/internal/money"
+)
+
+// BaseCurrency is the currency every rate is quoted in.
+const BaseCurrency = "RUB"
+
+// valueScale is the number of Value units in one ruble.
+const valueScale = 10_000
+
+// ErrNoRate is returned when no rate is known for a currency on or before a date.
+var ErrNoRate = errors.New("no exchange rate")
+
+// Rate is the price of Nominal units of Currency on Date.
+type Rate struct {
+	Date     string // YYYY-MM-DD
+	Currency string
+	Nominal  int64
+	Value    int64 // rubles per Nominal units, in 1/10000
+}
+
+// Decimal formats Value as rubles with four decimal places, e.g. "92.5158".
+func (r Rate) Decimal() string {
+	return fmt.Sprintf("%d.%04d", r.Value/valueScale, r.Value%valueScale)
+}
+
+// baseRate is the identity rate of the ruble.
+var baseRate = Rate{Currency: BaseCurrency, Nominal: 1, Value: valueScale}
+
+// Finder looks up the rate in effect for a currency on a date, i.e. the latest one published
+// on or before it. Store implements it.
+type Finder interface {
+	Find(ctx context.Context, currency, date string) (Rate, error)
+}
+
+// Converter converts amounts at the rate of a given date. It remembers the rates it has looked
+// up, so a report converting many transactions queries every (currency, date) pair once.
+// A Converter is not safe for concurrent use.
+type Converter struct {
+	finder Finder
+	cache  map[[2]string]Rate
+}
+
+// NewConverter returns a Converter that takes its rates from finder.
+func NewConverter(finder Finder) *Converter {
+	return &Converter{finder: finder, cache: make(map[[2]string]Rate)}
+}
+
+// Convert returns m in currency to at the rates in effect on date. The result is rounded
+// half away from zero to the minor unit of the target currency.
+func (c *Converter) Convert(ctx context.Context, m money.Money, to string, date string) (money.Money, error) {
+	if m.Currency == to {
+		return m, nil
+	}
+
+	from, err := c.rate(ctx, m.Currency, date)
+	if err != nil {
+		return money.Money{}, err
+	}
+	target, err := c.rate(ctx, to, date)
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	// amount * fromValue/fromNominal / (toValue/toNominal), rescaled between the minor units.
+	num := new(big.Int).SetInt64(m.Amount)
+	num.Mul(num, big.NewInt(from.Value))
+	num.Mul(num, big.NewInt(target.Nominal))
+	num.Mul(num, pow10(money.Exponent(to)))
+	den := new(big.Int).SetInt64(from.Nominal)
+	den.Mul(den, big.NewInt(target.Value))
+	den.Mul(den, pow10(money.Exponent(m.Currency)))
+
+	minor, err := roundDiv(num, den)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("convert %s to %s: %w", m, to, err)
+	}
+	return money.New(minor, to), nil
+}
+
+func (c *Converter) rate(ctx context.Context, currency, date string) (Rate, error) {
+	if currency == BaseCurrency {
+		return baseRate, nil
+	}
+	key := [2]string{currency, date}
+	if rate, ok := c.cache[key]; ok {
+		return rate, nil
+	}
+	rate, err := c.finder.Find(ctx, currency, date)
+	if err != nil {
+		return Rate{}, err
+	}
+	c.cache[key] = rate
+	return rate, nil
+}
+
+func pow10(exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
+
+// roundDiv returns num/den rounded half away from zero.
+func roundDiv(num, den *big.Int) (int64, error) {
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() != 0 && new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(new(big.Int).Abs(den)) >= 0 {
+		if num.Sign()*den.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	if !quo.IsInt64() {
+		return 0, money.ErrInvalidAmount
+	}
+	return quo.Int64(), nil
+}
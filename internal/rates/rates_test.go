@@ -0,0 +1,138 @@
+package rates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"tbank-go/internal/money"
+	"testing"
+)
+
+// fakeFinder serves rates by currency and date and counts the lookups.
+type fakeFinder struct {
+	rates   map[[2]string]Rate
+	lookups int
+}
+
+func newFakeFinder(rates ...Rate) *fakeFinder {
+	f := &fakeFinder{rates: make(map[[2]string]Rate)}
+	for _, rate := range rates {
+		f.rates[[2]string{rate.Currency, rate.Date}] = rate
+	}
+	return f
+}
+
+func (f *fakeFinder) Find(ctx context.Context, currency, date string) (Rate, error) {
+	f.lookups++
+	rate, ok := f.rates[[2]string{currency, date}]
+	if !ok {
+		return Rate{}, fmt.Errorf("%w for %s on %s", ErrNoRate, currency, date)
+	}
+	return rate, nil
+}
+
+func TestConvert(t *testing.T) {
+	const date = "2024-03-02"
+	finder := newFakeFinder(
+		Rate{Date: date, Currency: "USD", Nominal: 1, Value: 916618},
+		Rate{Date: date, Currency: "EUR", Nominal: 1, Value: 991763},
+		Rate{Date: date, Currency: "JPY", Nominal: 100, Value: 610837},
+		Rate{Date: date, Currency: "KWD", Nominal: 1, Value: 2990000},
+		Rate{Date: "2024-03-03", Currency: "USD", Nominal: 1, Value: 20000},
+		Rate{Date: "2024-03-04", Currency: "USD", Nominal: 1, Value: 30000},
+		Rate{Date: "2024-03-05", Currency: "USD", Nominal: 1, Value: 1},
+	)
+
+	tests := []struct {
+		name    string
+		amount  money.Money
+		to      string
+		date    string
+		want    money.Money
+		wantErr error
+	}{
+		{name: "same currency", amount: money.New(12345, "USD"), to: "USD", date: "1999-01-01", want: money.New(12345, "USD")},
+		{name: "to the ruble", amount: money.New(10000, "USD"), to: "RUB", date: date, want: money.New(916618, "RUB")},
+		{name: "from the ruble", amount: money.New(100000, "RUB"), to: "USD", date: date, want: money.New(1091, "USD")}, // 10.909672
+		{name: "nominal of 100", amount: money.New(1000, "JPY"), to: "RUB", date: date, want: money.New(61084, "RUB")},  // 610.837
+		{name: "three decimals", amount: money.New(1000, "KWD"), to: "RUB", date: date, want: money.New(29900, "RUB")},
+		{name: "cross rate", amount: money.New(10000, "USD"), to: "EUR", date: date, want: money.New(9242, "EUR")}, // 92.423089
+		{name: "cross rate to a currency without decimals", amount: money.New(100, "USD"), to: "JPY", date: date, want: money.New(150, "JPY")},
+		{name: "cross rate from a currency without decimals", amount: money.New(150, "JPY"), to: "USD", date: date, want: money.New(100, "USD")},
+		{name: "cross rate of a negative amount", amount: money.New(-10000, "USD"), to: "EUR", date: date, want: money.New(-9242, "EUR")},
+		// 0.01 RUB is exactly half a cent at 2 RUB per USD and one third of a cent at 3 RUB.
+		{name: "half rounds up", amount: money.New(1, "RUB"), to: "USD", date: "2024-03-03", want: money.New(1, "USD")},
+		{name: "negative half rounds down", amount: money.New(-1, "RUB"), to: "USD", date: "2024-03-03", want: money.New(-1, "USD")},
+		{name: "one and a half", amount: money.New(3, "RUB"), to: "USD", date: "2024-03-03", want: money.New(2, "USD")},
+		{name: "below half", amount: money.New(1, "RUB"), to: "USD", date: "2024-03-04", want: money.New(0, "USD")},
+		{name: "above half", amount: money.New(-2, "RUB"), to: "USD", date: "2024-03-04", want: money.New(-1, "USD")},
+		{name: "no rate of the source", amount: money.New(100, "CNY"), to: "RUB", date: date, wantErr: ErrNoRate},
+		{name: "no rate of the target", amount: money.New(100, "RUB"), to: "USD", date: "2024-03-01", wantErr: ErrNoRate},
+		{name: "overflow", amount: money.New(math.MaxInt64, "RUB"), to: "USD", date: "2024-03-05", wantErr: money.ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		got, err := NewConverter(finder).Convert(context.Background(), tt.amount, tt.to, tt.date)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: %v, %v, want %v", tt.name, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: %v, %v, want %v", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestConverterCache(t *testing.T) {
+	finder := newFakeFinder(Rate{Date: "2024-03-02", Currency: "USD", Nominal: 1, Value: 916618})
+	converter := NewConverter(finder)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := converter.Convert(ctx, money.New(100, "USD"), "RUB", "2024-03-02"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := converter.Convert(ctx, money.New(100, "RUB"), "USD", "2024-03-02"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Failed lookups are not cached.
+	for i := 0; i < 2; i++ {
+		if _, err := converter.Convert(ctx, money.New(100, "USD"), "RUB", "2024-03-01"); !errors.Is(err, ErrNoRate) {
+			t.Fatalf("missing rate: %v", err)
+		}
+	}
+	if finder.lookups != 3 {
+		t.Errorf("%d lookups, want 3", finder.lookups)
+	}
+}
+
+func TestRoundDiv(t *testing.T) {
+	tests := []struct {
+		num, den, want int64
+	}{
+		{num: 0, den: 7, want: 0},
+		{num: 6, den: 3, want: 2},
+		{num: 7, den: 3, want: 2},
+		{num: 8, den: 3, want: 3},
+		{num: 5, den: 2, want: 3},
+		{num: -5, den: 2, want: -3},
+		{num: 5, den: -2, want: -3},
+		{num: -5, den: -2, want: 3},
+		{num: -7, den: 3, want: -2},
+		{num: -8, den: 3, want: -3},
+	}
+	for _, tt := range tests {
+		got, err := roundDiv(big.NewInt(tt.num), big.NewInt(tt.den))
+		if err != nil || got != tt.want {
+			t.Errorf("roundDiv(%d, %d) = %d, %v, want %d", tt.num, tt.den, got, err, tt.want)
+		}
+	}
+
+	tooBig := new(big.Int).Mul(big.NewInt(math.MaxInt64), big.NewInt(4))
+	if _, err := roundDiv(tooBig, big.NewInt(3)); !errors.Is(err, money.ErrInvalidAmount) {
+		t.Errorf("roundDiv beyond int64: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package rates
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Store keeps rates in the exchange_rates table.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore returns a Store backed by db.
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Save inserts the rates, replacing the ones already stored for the same currency and date.
+func (s *Store) Save(ctx context.Context, rates []Rate) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO exchange_rates (date, currency, nominal, value) VALUES (?, ?, ?, ?)
+	                                     ON CONFLICT (currency, date) DO UPDATE SET nominal = excluded.nominal, value = excluded.value`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		if _, err := stmt.ExecContext(ctx, rate.Date, rate.Currency, rate.Nominal, rate.Value); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("save %s rate for %s: %w", rate.Currency, rate.Date, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Find returns the latest rate of currency published on or before date.
+func (s *Store) Find(ctx context.Context, currency, date string) (Rate, error) {
+	rate := Rate{Currency: currency}
+	err := s.db.QueryRowContext(ctx, `SELECT date, nominal, value FROM exchange_rates
+	                                  WHERE currency = ? AND date <= ? ORDER BY date DESC LIMIT 1`, currency, date,
+	).Scan(&rate.Date, &rate.Nominal, &rate.Value)
+	if err == sql.ErrNoRows {
+		return Rate{}, fmt.Errorf("%w for %s on %s", ErrNoRate, currency, date)
+	}
+	return rate, err
+}
+
+// List returns, for every known currency, the rate in effect on date.
+func (s *Store) List(ctx context.Context, date string) ([]Rate, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.date, r.currency, r.nominal, r.value FROM exchange_rates r
+		WHERE r.date = (SELECT MAX(date) FROM exchange_rates WHERE currency = r.currency AND date <= ?)
+		ORDER BY r.currency`, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []Rate
+	for rows.Next() {
+		var rate Rate
+		if err := rows.Scan(&rate.Date, &rate.Currency, &rate.Nominal, &rate.Value); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
@@ -0,0 +1,1860 @@
+// Package memory implements the repositories with in-process maps. It is meant for
+// handler tests and local experiments; nothing survives a restart.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"time"
+)
+
+// Store holds all data of the in-memory backend. All repositories share it so that
+// balance counters stay consistent, like they do in the database.
+type Store struct {
+	mu          sync.Mutex
+	nextID      int64
+	users       map[string]*repository.User // by UID
+	incomes     map[int64]repository.Income
+	expenses    map[int64]repository.Expense
+	accounts    map[int64]*repository.Account
+	transfers   map[int64]repository.Transfer
+	goals       map[int64]repository.Goal
+	contacts    map[int64]repository.Contact
+	splits      map[int64]repository.Split
+	settlements map[int64]repository.Settlement
+	categories  map[int64]repository.Category
+	households  map[int64]repository.Household
+	members     map[int64][]repository.HouseholdMember // by household ID
+	invites     map[int64]repository.HouseholdInvite
+	tags        map[int64]repository.Tag
+	tagLinks    map[tagLink]bool
+	attachments map[int64]repository.Attachment
+}
+
+// tagLink links a tag to an income or expense.
+type tagLink struct {
+	kind          string
+	transactionID int64
+	tagID         int64
+}
+
+// NewStore returns an empty store.
+func NewStore() *Store {
+	return &Store{
+		users:       make(map[string]*repository.User),
+		incomes:     make(map[int64]repository.Income),
+		expenses:    make(map[int64]repository.Expense),
+		accounts:    make(map[int64]*repository.Account),
+		transfers:   make(map[int64]repository.Transfer),
+		goals:       make(map[int64]repository.Goal),
+		contacts:    make(map[int64]repository.Contact),
+		splits:      make(map[int64]repository.Split),
+		settlements: make(map[int64]repository.Settlement),
+		categories:  make(map[int64]repository.Category),
+		households:  make(map[int64]repository.Household),
+		members:     make(map[int64][]repository.HouseholdMember),
+		invites:     make(map[int64]repository.HouseholdInvite),
+		tags:        make(map[int64]repository.Tag),
+		tagLinks:    make(map[tagLink]bool),
+		attachments: make(map[int64]repository.Attachment),
+	}
+}
+
+// New returns the repositories of a fresh empty store.
+func New() repository.Repositories {
+	store := NewStore()
+	return repository.Repositories{
+		Users:       store.Users(),
+		Incomes:     store.Incomes(),
+		Expenses:    store.Expenses(),
+		Accounts:    store.Accounts(),
+		Transfers:   store.Transfers(),
+		Goals:       store.Goals(),
+		Contacts:    store.Contacts(),
+		Splits:      store.Splits(),
+		Categories:  store.Categories(),
+		Feed:        store.Feed(),
+		Households:  store.Households(),
+		Tags:        store.Tags(),
+		Attachments: store.Attachments(),
+	}
+}
+
+// Users returns the user repository of the store.
+func (s *Store) Users() repository.UserRepository {
+	return userRepository{s}
+}
+
+// Incomes returns the income repository of the store.
+func (s *Store) Incomes() repository.IncomeRepository {
+	return transactionRepository{store: s, expense: false}
+}
+
+// Expenses returns the expense repository of the store.
+func (s *Store) Expenses() repository.ExpenseRepository {
+	return transactionRepository{store: s, expense: true}
+}
+
+// Accounts returns the account repository of the store.
+func (s *Store) Accounts() repository.AccountRepository {
+	return accountRepository{s}
+}
+
+// Transfers returns the transfer repository of the store.
+func (s *Store) Transfers() repository.TransferRepository {
+	return transferRepository{s}
+}
+
+// Goals returns the goal repository of the store.
+func (s *Store) Goals() repository.GoalRepository {
+	return goalRepository{s}
+}
+
+// Contacts returns the contact repository of the store.
+func (s *Store) Contacts() repository.ContactRepository {
+	return contactRepository{s}
+}
+
+// Splits returns the split repository of the store.
+func (s *Store) Splits() repository.SplitRepository {
+	return splitRepository{s}
+}
+
+// Categories returns the category repository of the store.
+func (s *Store) Categories() repository.CategoryRepository {
+	return categoryRepository{s}
+}
+
+// Households returns the household repository of the store.
+func (s *Store) Households() repository.HouseholdRepository {
+	return householdRepository{s}
+}
+
+// Tags returns the tag repository of the store.
+func (s *Store) Tags() repository.TagRepository {
+	return tagRepository{s}
+}
+
+// Attachments returns the attachment repository of the store.
+func (s *Store) Attachments() repository.AttachmentRepository {
+	return attachmentRepository{s}
+}
+
+// Feed returns the feed repository of the store.
+func (s *Store) Feed() repository.FeedRepository {
+	return feedRepository{s}
+}
+
+type userRepository struct {
+	store *Store
+}
+
+func (r userRepository) Create(_ context.Context, user *repository.User) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[user.UID]; ok {
+		return repository.ErrAlreadyExists
+	}
+	for _, existing := range s.users {
+		if existing.Username == user.Username {
+			return repository.ErrAlreadyExists
+		}
+	}
+
+	s.nextID++
+	stored := *user
+	stored.ID = s.nextID
+	stored.IncomesBalance, stored.ExpensesBalance = 0, 0
+	stored.FailedLogins, stored.LockedUntil, stored.TokenVersion = 0, "", 0
+	if stored.Currency == "" {
+		stored.Currency = money.DefaultCurrency
+	}
+	s.users[stored.UID] = &stored
+	*user = stored
+
+	s.nextID++
+	s.accounts[s.nextID] = &repository.Account{
+		ID:        s.nextID,
+		UserUID:   stored.UID,
+		Name:      repository.DefaultAccountName,
+		Type:      repository.AccountDebitCard,
+		Balance:   money.New(0, stored.Currency),
+		IsDefault: true,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	for _, def := range repository.DefaultCategories {
+		s.nextID++
+		parentID := s.nextID
+		s.categories[parentID] = repository.Category{ID: parentID, UserUID: stored.UID, Name: def.Name, Type: def.Type,
+			Icon: def.Icon, Color: def.Color, CreatedAt: time.Now().UTC().Format(time.RFC3339)}
+		for _, name := range def.Children {
+			s.nextID++
+			s.categories[s.nextID] = repository.Category{ID: s.nextID, UserUID: stored.UID, ParentID: parentID, Name: name,
+				Type: def.Type, Icon: def.Icon, Color: def.Color, CreatedAt: time.Now().UTC().Format(time.RFC3339)}
+		}
+	}
+	return nil
+}
+
+func (r userRepository) GetByUID(_ context.Context, uid string) (repository.User, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[uid]
+	if !ok {
+		return repository.User{}, repository.ErrNotFound
+	}
+	return *user, nil
+}
+
+func (r userRepository) GetByUsername(_ context.Context, username string) (repository.User, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.Username == username {
+			return *user, nil
+		}
+	}
+	return repository.User{}, repository.ErrNotFound
+}
+
+func (r userRepository) UpdatePassword(_ context.Context, uid, passwordHash string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[uid]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	user.PasswordHash = passwordHash
+	user.TokenVersion++
+	return nil
+}
+
+func (r userRepository) UpdateNames(_ context.Context, uid, firstName, secondName string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[uid]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	user.FirstName, user.SecondName = firstName, secondName
+	return nil
+}
+
+func (r userRepository) RecordLoginFailure(_ context.Context, uid string) (int, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[uid]
+	if !ok {
+		return 0, repository.ErrNotFound
+	}
+	user.FailedLogins++
+	return user.FailedLogins, nil
+}
+
+func (r userRepository) LockUntil(_ context.Context, uid, until string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[uid]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	user.LockedUntil = until
+	return nil
+}
+
+func (r userRepository) ResetLoginFailures(_ context.Context, uid string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[uid]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	user.FailedLogins, user.LockedUntil = 0, ""
+	return nil
+}
+
+// transactionRepository serves incomes or expenses depending on the expense flag.
+type transactionRepository struct {
+	store   *Store
+	expense bool
+}
+
+func (r transactionRepository) records() map[int64]repository.Transaction {
+	if r.expense {
+		return r.store.expenses
+	}
+	return r.store.incomes
+}
+
+// kind returns the kind of the tag links of the repository's transactions.
+func (r transactionRepository) kind() string {
+	if r.expense {
+		return repository.EntryExpense
+	}
+	return repository.EntryIncome
+}
+
+// apply adds (factor 1) or removes (factor -1) the effect of t on its account balance and,
+// for transactions in the user's currency, on the user's counter.
+func (r transactionRepository) apply(t repository.Transaction, factor int64) {
+	delta := factor * t.Amount.Amount
+	if user, ok := r.store.users[t.UserUID]; ok && user.Currency == t.Amount.Currency {
+		if r.expense {
+			user.ExpensesBalance += delta
+		} else {
+			user.IncomesBalance += delta
+		}
+	}
+	if account, ok := r.store.accounts[t.AccountID]; ok {
+		if r.expense {
+			account.Balance.Amount -= delta
+		} else {
+			account.Balance.Amount += delta
+		}
+	}
+}
+
+func (r transactionRepository) Create(_ context.Context, t *repository.Transaction) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	t.ID = s.nextID
+	record := *t
+	record.Tags = nil
+	r.records()[t.ID] = record
+	r.apply(*t, 1)
+	for _, tag := range t.Tags {
+		s.tagLinks[tagLink{r.kind(), t.ID, tag.ID}] = true
+	}
+	return nil
+}
+
+func (r transactionRepository) Get(_ context.Context, id int64) (repository.Transaction, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := r.records()[id]
+	if !ok {
+		return repository.Transaction{}, repository.ErrNotFound
+	}
+	t.Tags = s.tagsOf(r.kind(), id)
+	return t, nil
+}
+
+func (r transactionRepository) List(_ context.Context, userUID, from, to string) ([]repository.Transaction, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var transactions []repository.Transaction
+	for _, t := range r.records() {
+		if t.UserUID == userUID && t.Date >= from && t.Date <= to {
+			t.Tags = s.tagsOf(r.kind(), t.ID)
+			transactions = append(transactions, t)
+		}
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		if transactions[i].Date != transactions[j].Date {
+			return transactions[i].Date < transactions[j].Date
+		}
+		return transactions[i].ID < transactions[j].ID
+	})
+	return transactions, nil
+}
+
+func (r transactionRepository) Find(_ context.Context, filter repository.TransactionFilter) (repository.TransactionPage, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cursor := func(t repository.Transaction) repository.Cursor {
+		return repository.Cursor{Date: t.Date, Amount: t.Amount.Amount, ID: t.ID}
+	}
+
+	var page repository.TransactionPage
+	var matching []repository.Transaction
+	for _, t := range r.records() {
+		if !inScope(filter, t) ||
+			(filter.From != "" && t.Date < filter.From) ||
+			(filter.To != "" && t.Date > filter.To) ||
+			(filter.AccountID != 0 && t.AccountID != filter.AccountID) ||
+			(len(filter.CategoryIDs) > 0 && !slices.Contains(filter.CategoryIDs, t.CategoryID)) ||
+			(len(filter.TagIDs) > 0 && !s.hasAnyTag(r.kind(), t.ID, filter.TagIDs)) ||
+			(filter.Currency != "" && t.Amount.Currency != filter.Currency) ||
+			(filter.MinAmount != nil && t.Amount.Amount < *filter.MinAmount) ||
+			(filter.MaxAmount != nil && t.Amount.Amount > *filter.MaxAmount) ||
+			!strings.Contains(strings.ToLower(t.Description), strings.ToLower(filter.Description)) {
+			continue
+		}
+		page.TotalCount++
+		if filter.After != nil && compareCursors(filter, cursor(t), *filter.After) <= 0 {
+			continue
+		}
+		t.Tags = s.tagsOf(r.kind(), t.ID)
+		matching = append(matching, t)
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		return compareCursors(filter, cursor(matching[i]), cursor(matching[j])) < 0
+	})
+
+	if len(matching) > filter.Limit {
+		matching = matching[:filter.Limit]
+		next := cursor(matching[filter.Limit-1])
+		page.Next = &next
+	}
+	page.Transactions = matching
+	return page, nil
+}
+
+// inScope reports whether t belongs to the user or the household the filter selects.
+func inScope(filter repository.TransactionFilter, t repository.Transaction) bool {
+	if filter.HouseholdID != 0 {
+		return t.HouseholdID == filter.HouseholdID
+	}
+	return t.UserUID == filter.UserUID
+}
+
+// compareCursors orders a before b with -1 in the sort order of the filter: by date or amount,
+// then by ID.
+func compareCursors(filter repository.TransactionFilter, a, b repository.Cursor) int {
+	var c int
+	if filter.SortBy == repository.SortByAmount {
+		c = cmp.Compare(a.Amount, b.Amount)
+	} else {
+		c = strings.Compare(a.Date, b.Date)
+	}
+	if c == 0 {
+		c = cmp.Compare(a.ID, b.ID)
+	}
+	if filter.Descending {
+		c = -c
+	}
+	return c
+}
+
+func (r transactionRepository) Update(_ context.Context, t repository.Transaction) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := r.records()[t.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	record := t
+	record.Tags = nil
+	r.records()[t.ID] = record
+	r.apply(old, -1)
+	r.apply(t, 1)
+	return nil
+}
+
+func (r transactionRepository) Delete(_ context.Context, id int64) ([]string, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := r.records()[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	delete(r.records(), id)
+	r.apply(t, -1)
+	for link := range s.tagLinks {
+		if link.kind == r.kind() && link.transactionID == id {
+			delete(s.tagLinks, link)
+		}
+	}
+	var keys []string
+	for attachmentID, a := range s.attachments {
+		if a.Kind == r.kind() && a.TransactionID == id {
+			delete(s.attachments, attachmentID)
+			keys = append(keys, a.Key)
+			if a.ThumbnailKey != "" {
+				keys = append(keys, a.ThumbnailKey)
+			}
+		}
+	}
+	if r.expense {
+		for splitID, split := range s.splits {
+			if split.ExpenseID == id {
+				split.ExpenseID = 0
+				s.splits[splitID] = split
+			}
+		}
+	}
+	return keys, nil
+}
+
+type accountRepository struct {
+	store *Store
+}
+
+func (r accountRepository) Create(_ context.Context, account *repository.Account) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	account.ID = s.nextID
+	account.IsDefault = false
+	if account.CreatedAt == "" {
+		account.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	stored := *account
+	s.accounts[stored.ID] = &stored
+	return nil
+}
+
+func (r accountRepository) Get(_ context.Context, id int64) (repository.Account, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accounts[id]
+	if !ok {
+		return repository.Account{}, repository.ErrNotFound
+	}
+	return *account, nil
+}
+
+func (r accountRepository) GetDefault(_ context.Context, userUID string) (repository.Account, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, account := range s.accounts {
+		if account.UserUID == userUID && account.IsDefault {
+			return *account, nil
+		}
+	}
+	return repository.Account{}, repository.ErrNotFound
+}
+
+func (r accountRepository) List(_ context.Context, userUID string) ([]repository.Account, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var accounts []repository.Account
+	for _, account := range s.accounts {
+		if account.UserUID == userUID {
+			accounts = append(accounts, *account)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].IsDefault != accounts[j].IsDefault {
+			return accounts[i].IsDefault
+		}
+		return accounts[i].ID < accounts[j].ID
+	})
+	return accounts, nil
+}
+
+func (r accountRepository) Update(_ context.Context, account repository.Account) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.accounts[account.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	stored.Name, stored.Type = account.Name, account.Type
+	return nil
+}
+
+func (r accountRepository) SetDefault(_ context.Context, userUID string, id int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target, ok := s.accounts[id]
+	if !ok || target.UserUID != userUID {
+		return repository.ErrNotFound
+	}
+	for _, account := range s.accounts {
+		if account.UserUID == userUID {
+			account.IsDefault = false
+		}
+	}
+	target.IsDefault = true
+	return nil
+}
+
+func (r accountRepository) Delete(_ context.Context, id int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[id]; !ok {
+		return repository.ErrNotFound
+	}
+	for _, records := range []map[int64]repository.Transaction{s.incomes, s.expenses} {
+		for _, t := range records {
+			if t.AccountID == id {
+				return repository.ErrInUse
+			}
+		}
+	}
+	for _, t := range s.transfers {
+		if t.FromAccountID == id || t.ToAccountID == id {
+			return repository.ErrInUse
+		}
+	}
+	// Savings goals keep existing without the account.
+	for goalID, goal := range s.goals {
+		if goal.AccountID == id {
+			goal.AccountID = 0
+			s.goals[goalID] = goal
+		}
+	}
+	delete(s.accounts, id)
+	return nil
+}
+
+type transferRepository struct {
+	store *Store
+}
+
+// move applies (factor 1) or reverts (factor -1) a transfer on both account balances.
+func (r transferRepository) move(t repository.Transfer, factor int64) {
+	if from, ok := r.store.accounts[t.FromAccountID]; ok {
+		from.Balance.Amount -= factor * t.Amount.Amount
+	}
+	if to, ok := r.store.accounts[t.ToAccountID]; ok {
+		to.Balance.Amount += factor * t.ToAmount.Amount
+	}
+}
+
+func (r transferRepository) Create(_ context.Context, t *repository.Transfer) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	t.ID = s.nextID
+	s.transfers[t.ID] = *t
+	r.move(*t, 1)
+	return nil
+}
+
+func (r transferRepository) Get(_ context.Context, id int64) (repository.Transfer, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.transfers[id]
+	if !ok {
+		return repository.Transfer{}, repository.ErrNotFound
+	}
+	return t, nil
+}
+
+func (r transferRepository) List(_ context.Context, userUID, from, to string) ([]repository.Transfer, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var transfers []repository.Transfer
+	for _, t := range s.transfers {
+		if t.UserUID == userUID && t.Date >= from && t.Date <= to {
+			transfers = append(transfers, t)
+		}
+	}
+	sort.Slice(transfers, func(i, j int) bool {
+		if transfers[i].Date != transfers[j].Date {
+			return transfers[i].Date < transfers[j].Date
+		}
+		return transfers[i].ID < transfers[j].ID
+	})
+	return transfers, nil
+}
+
+func (r transferRepository) Delete(_ context.Context, id int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.transfers[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.transfers, id)
+	r.move(t, -1)
+	return nil
+}
+
+type goalRepository struct {
+	store *Store
+}
+
+// saved fills in the sum of the goal's contributions in its currency.
+func (r goalRepository) saved(goal repository.Goal) repository.Goal {
+	goal.Saved = money.New(0, goal.Target.Currency)
+	for _, t := range r.store.transfers {
+		if t.GoalID == goal.ID && t.ToAmount.Currency == goal.Target.Currency {
+			goal.Saved.Amount += t.ToAmount.Amount
+		}
+	}
+	return goal
+}
+
+func (r goalRepository) Create(_ context.Context, goal *repository.Goal) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	goal.ID = s.nextID
+	if goal.CreatedAt == "" {
+		goal.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	goal.Saved = money.New(0, goal.Target.Currency)
+	s.goals[goal.ID] = *goal
+	return nil
+}
+
+func (r goalRepository) Get(_ context.Context, id int64) (repository.Goal, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	goal, ok := s.goals[id]
+	if !ok {
+		return repository.Goal{}, repository.ErrNotFound
+	}
+	return r.saved(goal), nil
+}
+
+func (r goalRepository) List(_ context.Context, userUID string) ([]repository.Goal, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var goals []repository.Goal
+	for _, goal := range s.goals {
+		if goal.UserUID == userUID {
+			goals = append(goals, r.saved(goal))
+		}
+	}
+	sort.Slice(goals, func(i, j int) bool { return goals[i].ID < goals[j].ID })
+	return goals, nil
+}
+
+func (r goalRepository) Update(_ context.Context, goal repository.Goal) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.goals[goal.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	stored.Name = goal.Name
+	stored.Target = goal.Target
+	stored.Deadline = goal.Deadline
+	stored.AccountID = goal.AccountID
+	s.goals[goal.ID] = stored
+	return nil
+}
+
+func (r goalRepository) Delete(_ context.Context, id int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.goals[id]; !ok {
+		return repository.ErrNotFound
+	}
+	for transferID, t := range s.transfers {
+		if t.GoalID == id {
+			t.GoalID = 0
+			s.transfers[transferID] = t
+		}
+	}
+	delete(s.goals, id)
+	return nil
+}
+
+func (r goalRepository) Contributions(_ context.Context, id int64) ([]repository.Transfer, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var transfers []repository.Transfer
+	for _, t := range s.transfers {
+		if t.GoalID == id {
+			transfers = append(transfers, t)
+		}
+	}
+	sort.Slice(transfers, func(i, j int) bool {
+		if transfers[i].Date != transfers[j].Date {
+			return transfers[i].Date < transfers[j].Date
+		}
+		return transfers[i].ID < transfers[j].ID
+	})
+	return transfers, nil
+}
+
+func (r goalRepository) Contributed(_ context.Context, id int64, from, to string) (int64, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	goal, ok := s.goals[id]
+	if !ok {
+		return 0, nil
+	}
+	var sum int64
+	for _, t := range s.transfers {
+		if t.GoalID == id && t.ToAmount.Currency == goal.Target.Currency && t.Date > from && t.Date <= to {
+			sum += t.ToAmount.Amount
+		}
+	}
+	return sum, nil
+}
+
+type contactRepository struct {
+	store *Store
+}
+
+// taken reports whether another contact of the user already has the name.
+func (r contactRepository) taken(contact repository.Contact) bool {
+	for _, existing := range r.store.contacts {
+		if existing.ID != contact.ID && existing.UserUID == contact.UserUID && existing.Name == contact.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r contactRepository) Create(_ context.Context, contact *repository.Contact) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.taken(*contact) {
+		return repository.ErrAlreadyExists
+	}
+	s.nextID++
+	contact.ID = s.nextID
+	if contact.CreatedAt == "" {
+		contact.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	s.contacts[contact.ID] = *contact
+	return nil
+}
+
+func (r contactRepository) Get(_ context.Context, id int64) (repository.Contact, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	contact, ok := s.contacts[id]
+	if !ok {
+		return repository.Contact{}, repository.ErrNotFound
+	}
+	return contact, nil
+}
+
+func (r contactRepository) List(_ context.Context, userUID string) ([]repository.Contact, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var contacts []repository.Contact
+	for _, contact := range s.contacts {
+		if contact.UserUID == userUID {
+			contacts = append(contacts, contact)
+		}
+	}
+	slices.SortFunc(contacts, func(a, b repository.Contact) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return contacts, nil
+}
+
+func (r contactRepository) Rename(_ context.Context, id int64, name string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	contact, ok := s.contacts[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	contact.Name = name
+	if r.taken(contact) {
+		return repository.ErrAlreadyExists
+	}
+	s.contacts[id] = contact
+	return nil
+}
+
+func (r contactRepository) Delete(_ context.Context, id int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.contacts[id]; !ok {
+		return repository.ErrNotFound
+	}
+	for _, split := range s.splits {
+		if split.Payer.ContactID == id ||
+			slices.ContainsFunc(split.Shares, func(share repository.SplitShare) bool { return share.ContactID == id }) {
+			return repository.ErrInUse
+		}
+	}
+	for _, settlement := range s.settlements {
+		if settlement.From.ContactID == id || settlement.To.ContactID == id {
+			return repository.ErrInUse
+		}
+	}
+	delete(s.contacts, id)
+	return nil
+}
+
+type splitRepository struct {
+	store *Store
+}
+
+// named fills in the name of the party like the database join does.
+func (r splitRepository) named(party repository.SplitParty) repository.SplitParty {
+	party.Name = ""
+	if user, ok := r.store.users[party.UserUID]; ok {
+		party.Name = user.Username
+	} else if contact, ok := r.store.contacts[party.ContactID]; ok {
+		party.Name = contact.Name
+	}
+	return party
+}
+
+// withNames returns a copy of the split with the names of its parties filled in.
+func (r splitRepository) withNames(split repository.Split) repository.Split {
+	split.Payer = r.named(split.Payer)
+	split.Shares = slices.Clone(split.Shares)
+	for i := range split.Shares {
+		split.Shares[i].SplitParty = r.named(split.Shares[i].SplitParty)
+	}
+	return split
+}
+
+func (r splitRepository) Create(_ context.Context, split *repository.Split) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if split.ExpenseID != 0 {
+		for _, existing := range s.splits {
+			if existing.ExpenseID == split.ExpenseID {
+				return repository.ErrAlreadyExists
+			}
+		}
+	}
+	s.nextID++
+	split.ID = s.nextID
+	if split.CreatedAt == "" {
+		split.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	stored := *split
+	stored.Shares = slices.Clone(split.Shares)
+	s.splits[split.ID] = stored
+	return nil
+}
+
+func (r splitRepository) Get(_ context.Context, id int64) (repository.Split, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	split, ok := s.splits[id]
+	if !ok {
+		return repository.Split{}, repository.ErrNotFound
+	}
+	return r.withNames(split), nil
+}
+
+func (r splitRepository) List(_ context.Context, userUID string) ([]repository.Split, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var splits []repository.Split
+	for _, split := range s.splits {
+		if split.UserUID == userUID || split.Payer.UserUID == userUID ||
+			slices.ContainsFunc(split.Shares, func(share repository.SplitShare) bool { return share.UserUID == userUID }) {
+			splits = append(splits, r.withNames(split))
+		}
+	}
+	slices.SortFunc(splits, func(a, b repository.Split) int {
+		return cmp.Or(strings.Compare(b.Date, a.Date), cmp.Compare(b.ID, a.ID))
+	})
+	return splits, nil
+}
+
+func (r splitRepository) Delete(_ context.Context, id int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.splits[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.splits, id)
+	return nil
+}
+
+func (r splitRepository) CreateSettlement(_ context.Context, settlement *repository.Settlement) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	settlement.ID = s.nextID
+	if settlement.CreatedAt == "" {
+		settlement.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	s.settlements[settlement.ID] = *settlement
+	return nil
+}
+
+func (r splitRepository) GetSettlement(_ context.Context, id int64) (repository.Settlement, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settlement, ok := s.settlements[id]
+	if !ok {
+		return repository.Settlement{}, repository.ErrNotFound
+	}
+	settlement.From, settlement.To = r.named(settlement.From), r.named(settlement.To)
+	return settlement, nil
+}
+
+func (r splitRepository) Settlements(_ context.Context, userUID string) ([]repository.Settlement, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var settlements []repository.Settlement
+	for _, settlement := range s.settlements {
+		if settlement.UserUID == userUID || settlement.From.UserUID == userUID || settlement.To.UserUID == userUID {
+			settlement.From, settlement.To = r.named(settlement.From), r.named(settlement.To)
+			settlements = append(settlements, settlement)
+		}
+	}
+	slices.SortFunc(settlements, func(a, b repository.Settlement) int {
+		return cmp.Or(strings.Compare(b.Date, a.Date), cmp.Compare(b.ID, a.ID))
+	})
+	return settlements, nil
+}
+
+func (r splitRepository) DeleteSettlement(_ context.Context, id int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.settlements[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.settlements, id)
+	return nil
+}
+
+// categoryRepository keeps categories. The store has no budgets or recurring rules, so renames
+// and merges only touch the transactions.
+type categoryRepository struct {
+	store *Store
+}
+
+// taken reports whether another category of the user and type already has the name.
+func (r categoryRepository) taken(category repository.Category) bool {
+	key := repository.CategoryKey(category.Name)
+	for _, existing := range r.store.categories {
+		if existing.ID != category.ID && existing.UserUID == category.UserUID && existing.Type == category.Type &&
+			repository.CategoryKey(existing.Name) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// transactions returns the income or expense map of the category type.
+func (r categoryRepository) transactions(categoryType string) map[int64]repository.Transaction {
+	if categoryType == repository.CategoryIncome {
+		return r.store.incomes
+	}
+	return r.store.expenses
+}
+
+func (r categoryRepository) Create(_ context.Context, category *repository.Category) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.taken(*category) {
+		return repository.ErrAlreadyExists
+	}
+	s.nextID++
+	category.ID = s.nextID
+	if category.CreatedAt == "" {
+		category.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	s.categories[category.ID] = *category
+	return nil
+}
+
+func (r categoryRepository) Get(_ context.Context, id int64) (repository.Category, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	category, ok := s.categories[id]
+	if !ok {
+		return repository.Category{}, repository.ErrNotFound
+	}
+	return category, nil
+}
+
+func (r categoryRepository) FindByName(_ context.Context, userUID, categoryType, name string) (repository.Category, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := repository.CategoryKey(name)
+	for _, category := range s.categories {
+		if category.UserUID == userUID && category.Type == categoryType && repository.CategoryKey(category.Name) == key {
+			return category, nil
+		}
+	}
+	return repository.Category{}, repository.ErrNotFound
+}
+
+func (r categoryRepository) List(_ context.Context, userUID string) ([]repository.Category, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var categories []repository.Category
+	for _, category := range s.categories {
+		if category.UserUID == userUID {
+			categories = append(categories, category)
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Type != categories[j].Type {
+			return categories[i].Type < categories[j].Type
+		}
+		return repository.CategoryKey(categories[i].Name) < repository.CategoryKey(categories[j].Name)
+	})
+	return categories, nil
+}
+
+func (r categoryRepository) Update(_ context.Context, category repository.Category) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.categories[category.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	category.UserUID, category.Type, category.CreatedAt = old.UserUID, old.Type, old.CreatedAt
+	if r.taken(category) {
+		return repository.ErrAlreadyExists
+	}
+	s.categories[category.ID] = category
+
+	records := r.transactions(category.Type)
+	for id, t := range records {
+		if t.CategoryID == category.ID {
+			t.Category = category.Name
+			records[id] = t
+		}
+	}
+	return nil
+}
+
+func (r categoryRepository) Delete(_ context.Context, id int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	category, ok := s.categories[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	for _, t := range r.transactions(category.Type) {
+		if t.CategoryID == id {
+			return repository.ErrInUse
+		}
+	}
+	for _, child := range s.categories {
+		if child.ParentID == id {
+			return repository.ErrInUse
+		}
+	}
+	delete(s.categories, id)
+	return nil
+}
+
+func (r categoryRepository) Merge(_ context.Context, sourceID, targetID int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source, ok := s.categories[sourceID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	target, ok := s.categories[targetID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+
+	if target.ParentID == source.ID {
+		target.ParentID = source.ParentID
+		s.categories[target.ID] = target
+	}
+	newParent := target.ID
+	if target.ParentID != 0 {
+		newParent = target.ParentID
+	}
+	for id, child := range s.categories {
+		if child.ParentID == source.ID {
+			child.ParentID = newParent
+			s.categories[id] = child
+		}
+	}
+
+	records := r.transactions(source.Type)
+	for id, t := range records {
+		if t.CategoryID == source.ID {
+			t.CategoryID, t.Category = target.ID, target.Name
+			records[id] = t
+		}
+	}
+	delete(s.categories, source.ID)
+	return nil
+}
+
+type feedRepository struct {
+	store *Store
+}
+
+func (r feedRepository) Find(_ context.Context, filter repository.TransactionFilter) (repository.FeedPage, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []repository.FeedEntry
+	for kind, records := range []map[int64]repository.Transaction{s.incomes, s.expenses} {
+		for _, t := range records {
+			if !inScope(filter, t) {
+				continue
+			}
+			e := repository.FeedEntry{
+				Key:         t.ID*4 + int64(kind),
+				Type:        repository.EntryIncome,
+				ID:          t.ID,
+				UserUID:     t.UserUID,
+				AccountID:   t.AccountID,
+				CategoryID:  t.CategoryID,
+				Category:    t.Category,
+				Amount:      t.Amount,
+				Date:        t.Date,
+				Description: t.Description,
+			}
+			if kind == 1 {
+				e.Type, e.Amount = repository.EntryExpense, t.Amount.Neg()
+			}
+			e.Tags = s.tagsOf(e.Type, t.ID)
+			entries = append(entries, e)
+		}
+	}
+	for _, t := range s.transfers {
+		// Transfers are personal, a household's feed has none.
+		if filter.HouseholdID != 0 || t.UserUID != filter.UserUID {
+			continue
+		}
+		entries = append(entries,
+			repository.FeedEntry{Key: t.ID*4 + 2, Type: repository.EntryTransfer, ID: t.ID, UserUID: t.UserUID, AccountID: t.FromAccountID,
+				Amount: t.Amount.Neg(), Date: t.Date, Description: t.Description, CounterpartAccountID: t.ToAccountID},
+			repository.FeedEntry{Key: t.ID*4 + 3, Type: repository.EntryTransfer, ID: t.ID, UserUID: t.UserUID, AccountID: t.ToAccountID,
+				Amount: t.ToAmount, Date: t.Date, Description: t.Description, CounterpartAccountID: t.FromAccountID})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Date != entries[j].Date {
+			return entries[i].Date < entries[j].Date
+		}
+		return entries[i].Key < entries[j].Key
+	})
+
+	cursor := func(e repository.FeedEntry) repository.Cursor {
+		amount := e.Amount.Amount
+		if amount < 0 {
+			amount = -amount
+		}
+		return repository.Cursor{Date: e.Date, Amount: amount, ID: e.Key}
+	}
+
+	var page repository.FeedPage
+	var matching []repository.FeedEntry
+	balances := make(map[string]int64)
+	for _, e := range entries {
+		amount := cursor(e).Amount
+		if (filter.AccountID != 0 && e.AccountID != filter.AccountID) ||
+			(len(filter.CategoryIDs) > 0 && !slices.Contains(filter.CategoryIDs, e.CategoryID)) ||
+			(len(filter.TagIDs) > 0 && !s.hasAnyTag(e.Type, e.ID, filter.TagIDs)) ||
+			(len(filter.Types) > 0 && !slices.Contains(filter.Types, e.Type)) ||
+			(filter.Currency != "" && e.Amount.Currency != filter.Currency) ||
+			(filter.MinAmount != nil && amount < *filter.MinAmount) ||
+			(filter.MaxAmount != nil && amount > *filter.MaxAmount) ||
+			!strings.Contains(strings.ToLower(e.Description), strings.ToLower(filter.Description)) {
+			continue
+		}
+		balances[e.Amount.Currency] += e.Amount.Amount
+		e.Balance = money.New(balances[e.Amount.Currency], e.Amount.Currency)
+
+		if (filter.From != "" && e.Date < filter.From) || (filter.To != "" && e.Date > filter.To) {
+			continue
+		}
+		page.TotalCount++
+		if filter.After != nil && compareCursors(filter, cursor(e), *filter.After) <= 0 {
+			continue
+		}
+		matching = append(matching, e)
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		return compareCursors(filter, cursor(matching[i]), cursor(matching[j])) < 0
+	})
+
+	if len(matching) > filter.Limit {
+		matching = matching[:filter.Limit]
+		next := cursor(matching[filter.Limit-1])
+		page.Next = &next
+	}
+	page.Entries = matching
+	return page, nil
+}
+
+// householdRepository keeps households, their members and invites. The store has no budgets,
+// so deleting a household only unshares the transactions.
+type householdRepository struct {
+	store *Store
+}
+
+func (r householdRepository) Create(_ context.Context, household *repository.Household, ownerUID string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if household.CreatedAt == "" {
+		household.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	s.nextID++
+	household.ID = s.nextID
+	s.households[household.ID] = *household
+	s.members[household.ID] = []repository.HouseholdMember{{UserUID: ownerUID, Role: repository.RoleOwner, JoinedAt: household.CreatedAt}}
+	return nil
+}
+
+func (r householdRepository) Get(_ context.Context, id int64) (repository.Household, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	household, ok := s.households[id]
+	if !ok {
+		return repository.Household{}, repository.ErrNotFound
+	}
+	return household, nil
+}
+
+func (r householdRepository) ListByMember(_ context.Context, userUID string) ([]repository.Membership, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var memberships []repository.Membership
+	for id, members := range s.members {
+		for _, m := range members {
+			if m.UserUID == userUID {
+				memberships = append(memberships, repository.Membership{Household: s.households[id], Role: m.Role})
+			}
+		}
+	}
+	sort.Slice(memberships, func(i, j int) bool {
+		a, b := memberships[i].Household, memberships[j].Household
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+	return memberships, nil
+}
+
+func (r householdRepository) Rename(_ context.Context, id int64, name string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	household, ok := s.households[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	household.Name = name
+	s.households[id] = household
+	return nil
+}
+
+// unshare makes the transactions shared with the household personal again; with a userUID
+// only those of that author.
+func (r householdRepository) unshare(id int64, userUID string) {
+	for _, records := range []map[int64]repository.Transaction{r.store.incomes, r.store.expenses} {
+		for key, t := range records {
+			if t.HouseholdID == id && (userUID == "" || t.UserUID == userUID) {
+				t.HouseholdID = 0
+				records[key] = t
+			}
+		}
+	}
+}
+
+func (r householdRepository) Delete(_ context.Context, id int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.households[id]; !ok {
+		return repository.ErrNotFound
+	}
+	r.unshare(id, "")
+	for inviteID, invite := range s.invites {
+		if invite.HouseholdID == id {
+			delete(s.invites, inviteID)
+		}
+	}
+	delete(s.members, id)
+	delete(s.households, id)
+	return nil
+}
+
+func (r householdRepository) Role(_ context.Context, id int64, userUID string) (string, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, m := range s.members[id] {
+		if m.UserUID == userUID {
+			return m.Role, nil
+		}
+	}
+	return "", repository.ErrNotFound
+}
+
+func (r householdRepository) Members(_ context.Context, id int64) ([]repository.HouseholdMember, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rank := map[string]int{repository.RoleOwner: 0, repository.RoleEditor: 1, repository.RoleViewer: 2}
+	members := slices.Clone(s.members[id])
+	for i := range members {
+		if user, ok := s.users[members[i].UserUID]; ok {
+			members[i].Username = user.Username
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if rank[members[i].Role] != rank[members[j].Role] {
+			return rank[members[i].Role] < rank[members[j].Role]
+		}
+		return members[i].Username < members[j].Username
+	})
+	return members, nil
+}
+
+func (r householdRepository) SetRole(_ context.Context, id int64, userUID, role string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members := s.members[id]
+	i := slices.IndexFunc(members, func(m repository.HouseholdMember) bool { return m.UserUID == userUID })
+	if i < 0 {
+		return repository.ErrNotFound
+	}
+	if role == repository.RoleOwner {
+		for j := range members {
+			if members[j].Role == repository.RoleOwner {
+				members[j].Role = repository.RoleEditor
+			}
+		}
+	}
+	members[i].Role = role
+	return nil
+}
+
+func (r householdRepository) RemoveMember(_ context.Context, id int64, userUID string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members := s.members[id]
+	i := slices.IndexFunc(members, func(m repository.HouseholdMember) bool { return m.UserUID == userUID })
+	if i < 0 {
+		return repository.ErrNotFound
+	}
+	s.members[id] = slices.Delete(members, i, i+1)
+	r.unshare(id, userUID)
+	return nil
+}
+
+func (r householdRepository) CreateInvite(_ context.Context, invite *repository.HouseholdInvite) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slices.ContainsFunc(s.members[invite.HouseholdID], func(m repository.HouseholdMember) bool { return m.UserUID == invite.InviteeUID }) {
+		return repository.ErrAlreadyExists
+	}
+	for _, existing := range s.invites {
+		if existing.HouseholdID == invite.HouseholdID && existing.InviteeUID == invite.InviteeUID {
+			return repository.ErrAlreadyExists
+		}
+	}
+	if invite.CreatedAt == "" {
+		invite.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	s.nextID++
+	invite.ID = s.nextID
+	s.invites[invite.ID] = *invite
+	return nil
+}
+
+// withNames fills in the household and user names of an invite.
+func (r householdRepository) withNames(invite repository.HouseholdInvite) repository.HouseholdInvite {
+	invite.HouseholdName = r.store.households[invite.HouseholdID].Name
+	if user, ok := r.store.users[invite.InviteeUID]; ok {
+		invite.InviteeUsername = user.Username
+	}
+	if user, ok := r.store.users[invite.InviterUID]; ok {
+		invite.InviterUsername = user.Username
+	}
+	return invite
+}
+
+func (r householdRepository) GetInvite(_ context.Context, id int64) (repository.HouseholdInvite, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invite, ok := s.invites[id]
+	if !ok {
+		return repository.HouseholdInvite{}, repository.ErrNotFound
+	}
+	return r.withNames(invite), nil
+}
+
+// findInvites returns the invites matching keep ordered by ID.
+func (r householdRepository) findInvites(keep func(repository.HouseholdInvite) bool) []repository.HouseholdInvite {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var invites []repository.HouseholdInvite
+	for _, invite := range s.invites {
+		if keep(invite) {
+			invites = append(invites, r.withNames(invite))
+		}
+	}
+	sort.Slice(invites, func(i, j int) bool { return invites[i].ID < invites[j].ID })
+	return invites
+}
+
+func (r householdRepository) Invites(_ context.Context, householdID int64) ([]repository.HouseholdInvite, error) {
+	return r.findInvites(func(i repository.HouseholdInvite) bool { return i.HouseholdID == householdID }), nil
+}
+
+func (r householdRepository) InvitesFor(_ context.Context, userUID string) ([]repository.HouseholdInvite, error) {
+	return r.findInvites(func(i repository.HouseholdInvite) bool { return i.InviteeUID == userUID }), nil
+}
+
+func (r householdRepository) AcceptInvite(_ context.Context, id int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invite, ok := s.invites[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.invites, id)
+	if slices.ContainsFunc(s.members[invite.HouseholdID], func(m repository.HouseholdMember) bool { return m.UserUID == invite.InviteeUID }) {
+		return repository.ErrAlreadyExists
+	}
+	s.members[invite.HouseholdID] = append(s.members[invite.HouseholdID], repository.HouseholdMember{
+		UserUID:  invite.InviteeUID,
+		Role:     invite.Role,
+		JoinedAt: time.Now().UTC().Format(time.RFC3339),
+	})
+	return nil
+}
+
+func (r householdRepository) DeleteInvite(_ context.Context, id int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.invites[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.invites, id)
+	return nil
+}
+
+// tagRepository keeps tags; their links to transactions live in the store's tagLinks.
+type tagRepository struct {
+	store *Store
+}
+
+// taken reports whether another tag of the user already has the name.
+func (r tagRepository) taken(tag repository.Tag) bool {
+	key := repository.TagKey(tag.Name)
+	for _, existing := range r.store.tags {
+		if existing.ID != tag.ID && existing.UserUID == tag.UserUID && repository.TagKey(existing.Name) == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (r tagRepository) Create(_ context.Context, tag *repository.Tag) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.taken(*tag) {
+		return repository.ErrAlreadyExists
+	}
+	s.nextID++
+	tag.ID = s.nextID
+	if tag.CreatedAt == "" {
+		tag.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	s.tags[tag.ID] = *tag
+	return nil
+}
+
+func (r tagRepository) Get(_ context.Context, id int64) (repository.Tag, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tag, ok := s.tags[id]
+	if !ok {
+		return repository.Tag{}, repository.ErrNotFound
+	}
+	return tag, nil
+}
+
+func (r tagRepository) FindByName(_ context.Context, userUID, name string) (repository.Tag, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := repository.TagKey(name)
+	for _, tag := range s.tags {
+		if tag.UserUID == userUID && repository.TagKey(tag.Name) == key {
+			return tag, nil
+		}
+	}
+	return repository.Tag{}, repository.ErrNotFound
+}
+
+func (r tagRepository) List(_ context.Context, userUID string) ([]repository.Tag, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tags []repository.Tag
+	for _, tag := range s.tags {
+		if tag.UserUID == userUID {
+			tags = append(tags, tag)
+		}
+	}
+	sortTags(tags)
+	return tags, nil
+}
+
+func (r tagRepository) Update(_ context.Context, tag repository.Tag) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.tags[tag.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	tag.UserUID, tag.CreatedAt = old.UserUID, old.CreatedAt
+	if r.taken(tag) {
+		return repository.ErrAlreadyExists
+	}
+	s.tags[tag.ID] = tag
+	return nil
+}
+
+func (r tagRepository) Delete(_ context.Context, id int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tags[id]; !ok {
+		return repository.ErrNotFound
+	}
+	for link := range s.tagLinks {
+		if link.tagID == id {
+			delete(s.tagLinks, link)
+		}
+	}
+	delete(s.tags, id)
+	return nil
+}
+
+func (r tagRepository) Attach(_ context.Context, kind string, transactionIDs, tagIDs []int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, transactionID := range transactionIDs {
+		for _, tagID := range tagIDs {
+			s.tagLinks[tagLink{kind, transactionID, tagID}] = true
+		}
+	}
+	return nil
+}
+
+func (r tagRepository) Detach(_ context.Context, kind string, transactionIDs, tagIDs []int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, transactionID := range transactionIDs {
+		for _, tagID := range tagIDs {
+			delete(s.tagLinks, tagLink{kind, transactionID, tagID})
+		}
+	}
+	return nil
+}
+
+// tagsOf returns the tags of the transaction of the kind ordered by name. The caller holds the lock.
+func (s *Store) tagsOf(kind string, transactionID int64) []repository.Tag {
+	var tags []repository.Tag
+	for link := range s.tagLinks {
+		if link.kind == kind && link.transactionID == transactionID {
+			tags = append(tags, s.tags[link.tagID])
+		}
+	}
+	sortTags(tags)
+	return tags
+}
+
+// hasAnyTag reports whether the transaction of the kind has one of the tags. The caller holds the lock.
+func (s *Store) hasAnyTag(kind string, transactionID int64, tagIDs []int64) bool {
+	for _, id := range tagIDs {
+		if s.tagLinks[tagLink{kind, transactionID, id}] {
+			return true
+		}
+	}
+	return false
+}
+
+func sortTags(tags []repository.Tag) {
+	slices.SortFunc(tags, func(a, b repository.Tag) int {
+		return cmp.Or(strings.Compare(repository.TagKey(a.Name), repository.TagKey(b.Name)), cmp.Compare(a.ID, b.ID))
+	})
+}
+
+type attachmentRepository struct {
+	store *Store
+}
+
+func (r attachmentRepository) Create(_ context.Context, a *repository.Attachment) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	a.ID = s.nextID
+	if a.CreatedAt == "" {
+		a.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	s.attachments[a.ID] = *a
+	return nil
+}
+
+func (r attachmentRepository) Get(_ context.Context, id int64) (repository.Attachment, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.attachments[id]
+	if !ok {
+		return repository.Attachment{}, repository.ErrNotFound
+	}
+	return a, nil
+}
+
+func (r attachmentRepository) List(_ context.Context, kind string, transactionID int64) ([]repository.Attachment, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []repository.Attachment
+	for _, a := range s.attachments {
+		if a.Kind == kind && a.TransactionID == transactionID {
+			list = append(list, a)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+func (r attachmentRepository) Delete(_ context.Context, id int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.attachments[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.attachments, id)
+	return nil
+}
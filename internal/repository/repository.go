@@ -0,0 +1,717 @@
+// Package repository defines the storage interfaces the HTTP handlers depend on.
+// sqlstore implements them on top of database/sql, memory keeps everything in maps
+// so handlers can be exercised without a database file.
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"tbank-go/internal/money"
+	"unicode/utf8"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist.
+	ErrNotFound = errors.New("record not found")
+	// ErrAlreadyExists is returned when a unique field (such as the username) is taken.
+	ErrAlreadyExists = errors.New("record already exists")
+	// ErrInUse is returned when a record cannot be deleted because other records refer to it.
+	ErrInUse = errors.New("record is in use")
+	// ErrInvalidTagName is returned by ResolveTags for a blank name or one longer than MaxTagNameLength.
+	ErrInvalidTagName = errors.New("invalid tag name")
+)
+
+// User is a stored user together with the balance counters.
+type User struct {
+	ID              int64
+	UID             string
+	Username        string
+	PasswordHash    string
+	FirstName       string
+	SecondName      string
+	RegisteredAt    string
+	Currency        string // the user's main currency; new users get a default account in it
+	IncomesBalance  int64  // sum of all incomes in Currency, in minor units
+	ExpensesBalance int64  // sum of all expenses in Currency, in minor units
+	FailedLogins    int    // wrong passwords in a row since the last successful login
+	LockedUntil     string // RFC 3339; logins are refused before this moment, empty when not locked
+	TokenVersion    int    // embedded in access tokens; tokens with another version are rejected
+}
+
+// Transaction is a stored income or expense.
+type Transaction struct {
+	ID          int64
+	UserUID     string
+	AccountID   int64  // the account the money went to (income) or came from (expense)
+	CategoryID  int64  // 0 for old records whose category could not be matched
+	Category    string // the category name, kept in sync with the category
+	Amount      money.Money
+	Date        string // YYYY-MM-DD
+	Description string
+	HouseholdID int64 // the household the transaction is shared with, 0 for a personal one
+	// Tags are ordered by name. They are filled in when transactions are read and attached by
+	// Create; Update leaves them alone, TagRepository changes them.
+	Tags []Tag
+}
+
+// Sort keys of transaction listings.
+const (
+	SortByDate   = "date"
+	SortByAmount = "amount"
+)
+
+// TransactionFilter selects and orders a page of the incomes or expenses of one user.
+type TransactionFilter struct {
+	UserUID     string
+	HouseholdID int64    // when set, the transactions shared with the household instead of UserUID's
+	From        string   // YYYY-MM-DD inclusive, empty for no lower bound
+	To          string   // YYYY-MM-DD inclusive, empty for no upper bound
+	AccountID   int64    // 0 for all accounts
+	CategoryIDs []int64  // any of these categories, empty for all
+	TagIDs      []int64  // transactions with any of these tags, empty for all
+	Types       []string // feed entry types, empty for all; ignored by the income and expense listings
+	Currency    string   // empty for all currencies
+	MinAmount   *int64   // inclusive, in minor units of Currency
+	MaxAmount   *int64   // inclusive, in minor units of Currency
+	Description string   // case-insensitive substring of the description
+	SortBy      string   // SortByDate (default) or SortByAmount; ties are broken by ID
+	Descending  bool
+	After       *Cursor // the page starts after this position
+	Limit       int
+}
+
+// Cursor is the position of a transaction in a listing sorted by date or amount.
+type Cursor struct {
+	Date   string
+	Amount int64
+	ID     int64 // the transaction ID, or the FeedEntry key in the feed
+}
+
+// TransactionPage is one page of a transaction listing.
+type TransactionPage struct {
+	Transactions []Transaction
+	TotalCount   int     // transactions matching the filter on all pages
+	Next         *Cursor // position of the last transaction of the page, nil on the last page
+}
+
+// Feed entry types.
+const (
+	EntryIncome   = "income"
+	EntryExpense  = "expense"
+	EntryTransfer = "transfer"
+)
+
+// FeedEntry is an income, an expense or one side of a transfer in the transactions feed.
+// A transfer appears twice: as a negative entry of the source account and as a positive
+// entry of the destination account.
+type FeedEntry struct {
+	Key         int64  // unique across types, breaks ties in the sort order
+	Type        string // EntryIncome, EntryExpense or EntryTransfer
+	ID          int64  // ID of the income, expense or transfer
+	UserUID     string // the author, who may be any member in a household's feed
+	AccountID   int64
+	CategoryID  int64
+	Category    string
+	Amount      money.Money // positive for money coming in, negative for money going out
+	Balance     money.Money // running total of Amount over the matching entries in date order, per currency
+	Date        string
+	Description string
+	// CounterpartAccountID is the other account of a transfer.
+	CounterpartAccountID int64
+	// Tags are the tags of an income or expense, ordered by name.
+	Tags []Tag
+}
+
+// FeedPage is one page of the transactions feed.
+type FeedPage struct {
+	Entries    []FeedEntry
+	TotalCount int
+	Next       *Cursor
+}
+
+// Income is a stored income.
+type Income = Transaction
+
+// Expense is a stored expense.
+type Expense = Transaction
+
+// Account types.
+const (
+	AccountCash       = "cash"
+	AccountDebitCard  = "debit_card"
+	AccountCreditCard = "credit_card"
+	AccountSavings    = "savings"
+)
+
+// DefaultAccountName is the name of the account every user gets on registration.
+const DefaultAccountName = "Main account"
+
+// Account is a wallet of the user with its own currency and balance.
+type Account struct {
+	ID        int64
+	UserUID   string
+	Name      string
+	Type      string      // cash, debit_card, credit_card or savings
+	Balance   money.Money // may be negative, e.g. for a credit card
+	IsDefault bool        // incomes and expenses without an account go here
+	CreatedAt string
+}
+
+// Transfer moves money between two accounts of the same user. Amount is taken from the
+// source account in its currency, ToAmount is added to the destination account in its
+// currency; they are equal unless the currencies differ.
+type Transfer struct {
+	ID            int64
+	UserUID       string
+	FromAccountID int64
+	ToAccountID   int64
+	Amount        money.Money
+	ToAmount      money.Money
+	Date          string // YYYY-MM-DD
+	Description   string
+	GoalID        int64 // the savings goal the transfer contributes to, 0 for none
+}
+
+// Goal is an amount the user wants to save, optionally by a deadline. Contributions are transfers
+// with the goal's GoalID; Saved sums those credited in the goal currency.
+type Goal struct {
+	ID        int64
+	UserUID   string
+	Name      string
+	Target    money.Money
+	Saved     money.Money // filled in when goals are read
+	Deadline  string      // YYYY-MM-DD, empty for none
+	AccountID int64       // the account contributions go to, 0 when every contribution names its own
+	CreatedAt string
+}
+
+// Contact is a person without an account a user shares expenses with.
+type Contact struct {
+	ID        int64
+	UserUID   string
+	Name      string
+	CreatedAt string
+}
+
+// SplitParty is a party of a split or a settlement: a registered user or a contact of the user
+// who recorded it. Name, the username or the contact name, is filled in when the record is read.
+type SplitParty struct {
+	UserUID   string
+	ContactID int64
+	Name      string
+}
+
+// SplitShare is what a participant owes for a split, in the split currency.
+type SplitShare struct {
+	SplitParty
+	Amount money.Money
+}
+
+// Split is an expense one party paid and several parties share.
+type Split struct {
+	ID          int64
+	UserUID     string // the user who recorded the split
+	ExpenseID   int64  // the recording user's expense that was split, 0 for none
+	Payer       SplitParty
+	Description string
+	Amount      money.Money
+	Method      string
+	Date        string       // YYYY-MM-DD
+	Shares      []SplitShare // in the order they were added
+	CreatedAt   string
+}
+
+// Settlement is a repayment of split debts from one party to another.
+type Settlement struct {
+	ID          int64
+	UserUID     string // the user who recorded the settlement
+	From        SplitParty
+	To          SplitParty
+	Amount      money.Money
+	Date        string // YYYY-MM-DD
+	Description string
+	CreatedAt   string
+}
+
+// Household roles. The owner manages the household and its members, editors change the
+// shared incomes, expenses and budgets, viewers only see them.
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// CanEdit reports whether a household member with the role may change shared records.
+func CanEdit(role string) bool {
+	return role == RoleOwner || role == RoleEditor
+}
+
+// Household is a group of users, such as a family, that share incomes, expenses and budgets.
+type Household struct {
+	ID        int64
+	Name      string
+	CreatedAt string
+}
+
+// Membership is a household together with the role of one of its members.
+type Membership struct {
+	Household Household
+	Role      string
+}
+
+// HouseholdMember is a user in a household.
+type HouseholdMember struct {
+	UserUID  string
+	Username string
+	Role     string
+	JoinedAt string
+}
+
+// HouseholdInvite asks a registered user to join a household. The names are filled in when
+// the invite is read.
+type HouseholdInvite struct {
+	ID              int64
+	HouseholdID     int64
+	HouseholdName   string
+	InviteeUID      string
+	InviteeUsername string
+	InviterUID      string
+	InviterUsername string
+	Role            string // RoleEditor or RoleViewer
+	CreatedAt       string
+}
+
+// Category types.
+const (
+	CategoryIncome  = "income"
+	CategoryExpense = "expense"
+)
+
+// Category groups incomes or expenses of one user. Categories form a two-level tree: a
+// subcategory has a top-level parent of the same type.
+type Category struct {
+	ID        int64
+	UserUID   string
+	ParentID  int64 // 0 for top-level categories
+	Name      string
+	Type      string // income or expense
+	Icon      string
+	Color     string // #RRGGBB
+	CreatedAt string
+}
+
+// DefaultCategory is a category of the set every user gets on registration.
+type DefaultCategory struct {
+	Name     string
+	Type     string
+	Icon     string
+	Color    string
+	Children []string // subcategory names, they inherit icon and color
+}
+
+// DefaultCategories is the set of categories created for every new user.
+var DefaultCategories = []DefaultCategory{
+	{Name: "Food", Type: CategoryExpense, Icon: "utensils", Color: "#F4511E", Children: []string{"Groceries", "Restaurants"}},
+	{Name: "Transport", Type: CategoryExpense, Icon: "bus", Color: "#1E88E5", Children: []string{"Public transport", "Taxi", "Fuel"}},
+	{Name: "Housing", Type: CategoryExpense, Icon: "home", Color: "#6D4C41", Children: []string{"Rent", "Utilities"}},
+	{Name: "Health", Type: CategoryExpense, Icon: "heart", Color: "#E53935"},
+	{Name: "Shopping", Type: CategoryExpense, Icon: "shopping-bag", Color: "#8E24AA"},
+	{Name: "Entertainment", Type: CategoryExpense, Icon: "film", Color: "#FDD835"},
+	{Name: "Travel", Type: CategoryExpense, Icon: "plane", Color: "#00ACC1"},
+	{Name: "Education", Type: CategoryExpense, Icon: "book", Color: "#3949AB"},
+	{Name: "Other", Type: CategoryExpense, Icon: "dots", Color: "#757575"},
+	{Name: "Salary", Type: CategoryIncome, Icon: "briefcase", Color: "#43A047"},
+	{Name: "Freelance", Type: CategoryIncome, Icon: "laptop", Color: "#7CB342"},
+	{Name: "Gifts", Type: CategoryIncome, Icon: "gift", Color: "#D81B60"},
+	{Name: "Interest", Type: CategoryIncome, Icon: "percent", Color: "#00897B"},
+	{Name: "Other", Type: CategoryIncome, Icon: "dots", Color: "#757575"},
+}
+
+// Tag marks incomes and expenses across categories, such as a trip, a project or "reimbursable".
+type Tag struct {
+	ID        int64
+	UserUID   string
+	Name      string
+	Color     string // #RRGGBB, empty for none
+	CreatedAt string
+}
+
+// Attachment is a receipt or another document uploaded for an income or an expense. The file is
+// kept in a blob store under Key, the thumbnail of an image under ThumbnailKey.
+type Attachment struct {
+	ID            int64
+	UserUID       string // the uploader
+	Kind          string // EntryIncome or EntryExpense
+	TransactionID int64
+	Filename      string
+	ContentType   string
+	Size          int64
+	Key           string
+	ThumbnailKey  string // empty when there is no thumbnail
+	CreatedAt     string
+}
+
+// MaxTagNameLength is the longest tag name in characters.
+const MaxTagNameLength = 32
+
+// TagKey normalizes a tag name for comparison like CategoryKey does for categories.
+func TagKey(name string) string {
+	return CategoryKey(name)
+}
+
+// CategoryKey normalizes a category name for comparison: "  Food " and "food" are the same category.
+func CategoryKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// UserRepository stores users.
+type UserRepository interface {
+	// Create stores a new user with zero balances, a default account in the user's
+	// currency and the DefaultCategories. ID is filled in on success.
+	Create(ctx context.Context, user *User) error
+	GetByUID(ctx context.Context, uid string) (User, error)
+	GetByUsername(ctx context.Context, username string) (User, error)
+	// UpdatePassword stores the new hash and ends every session of the user: the token
+	// version is bumped and all refresh tokens are revoked.
+	UpdatePassword(ctx context.Context, uid, passwordHash string) error
+	UpdateNames(ctx context.Context, uid, firstName, secondName string) error
+	// RecordLoginFailure increments the failed login counter and returns its new value.
+	RecordLoginFailure(ctx context.Context, uid string) (int, error)
+	// LockUntil refuses logins of the user until the given RFC 3339 moment.
+	LockUntil(ctx context.Context, uid, until string) error
+	// ResetLoginFailures clears the failed login counter and any lockout.
+	ResetLoginFailures(ctx context.Context, uid string) error
+}
+
+// IncomeRepository stores incomes and keeps the owner's income balance and the balance
+// of the income's account in sync.
+type IncomeRepository interface {
+	// Create stores the income and adds it to the balance. ID is filled in on success.
+	Create(ctx context.Context, income *Income) error
+	Get(ctx context.Context, id int64) (Income, error)
+	// List returns the user's incomes dated between from and to inclusive.
+	List(ctx context.Context, userUID, from, to string) ([]Income, error)
+	// Find returns a page of the user's incomes matching the filter.
+	Find(ctx context.Context, filter TransactionFilter) (TransactionPage, error)
+	// Update replaces the income and adjusts the balance by the amount difference.
+	Update(ctx context.Context, income Income) error
+	// Delete removes the income, subtracts it from the balance, detaches its tags and deletes the
+	// records of its attachments. It returns the blob keys of the deleted attachments, files and
+	// thumbnails, read in the same transaction; removing the blobs is left to the caller.
+	Delete(ctx context.Context, id int64) ([]string, error)
+}
+
+// ExpenseRepository stores expenses and keeps the owner's expense balance and the balance
+// of the expense's account in sync.
+type ExpenseRepository interface {
+	// Create stores the expense and adds it to the balance. ID is filled in on success.
+	Create(ctx context.Context, expense *Expense) error
+	Get(ctx context.Context, id int64) (Expense, error)
+	// List returns the user's expenses dated between from and to inclusive.
+	List(ctx context.Context, userUID, from, to string) ([]Expense, error)
+	// Find returns a page of the user's expenses matching the filter.
+	Find(ctx context.Context, filter TransactionFilter) (TransactionPage, error)
+	// Update replaces the expense and adjusts the balance by the amount difference.
+	Update(ctx context.Context, expense Expense) error
+	// Delete removes the expense, subtracts it from the balance, detaches its tags and deletes the
+	// records of its attachments. It returns the blob keys of the deleted attachments, files and
+	// thumbnails, read in the same transaction; removing the blobs is left to the caller.
+	Delete(ctx context.Context, id int64) ([]string, error)
+}
+
+// AccountRepository stores accounts.
+type AccountRepository interface {
+	// Create stores the account; its Balance is the opening balance. ID is filled in on success.
+	Create(ctx context.Context, account *Account) error
+	Get(ctx context.Context, id int64) (Account, error)
+	// GetDefault returns the user's default account.
+	GetDefault(ctx context.Context, userUID string) (Account, error)
+	// List returns the user's accounts, the default one first.
+	List(ctx context.Context, userUID string) ([]Account, error)
+	// Update changes the name and type of the account. Currency and balance cannot be changed.
+	Update(ctx context.Context, account Account) error
+	// SetDefault makes the account the user's default one.
+	SetDefault(ctx context.Context, userUID string, id int64) error
+	// Delete removes the account. It fails with ErrInUse while transactions or transfers refer to it.
+	Delete(ctx context.Context, id int64) error
+}
+
+// TransferRepository stores transfers and moves the money between the account balances.
+// Transfers are neither incomes nor expenses.
+type TransferRepository interface {
+	// Create stores the transfer and updates both balances atomically. ID is filled in on success.
+	Create(ctx context.Context, transfer *Transfer) error
+	Get(ctx context.Context, id int64) (Transfer, error)
+	// List returns the user's transfers dated between from and to inclusive.
+	List(ctx context.Context, userUID, from, to string) ([]Transfer, error)
+	// Delete removes the transfer and moves the money back.
+	Delete(ctx context.Context, id int64) error
+}
+
+// GoalRepository stores savings goals. Their contributions are created and deleted through
+// TransferRepository.
+type GoalRepository interface {
+	// Create stores the goal. ID is filled in on success.
+	Create(ctx context.Context, goal *Goal) error
+	Get(ctx context.Context, id int64) (Goal, error)
+	// List returns the user's goals in creation order.
+	List(ctx context.Context, userUID string) ([]Goal, error)
+	// Update changes the name, target, deadline and account of the goal.
+	Update(ctx context.Context, goal Goal) error
+	// Delete removes the goal. Its contributions stay as plain transfers.
+	Delete(ctx context.Context, id int64) error
+	// Contributions returns the transfers contributing to the goal ordered by date.
+	Contributions(ctx context.Context, id int64) ([]Transfer, error)
+	// Contributed sums the contributions credited in the goal currency dated after from up to to inclusive.
+	Contributed(ctx context.Context, id int64, from, to string) (int64, error)
+}
+
+// ContactRepository stores the contacts users split expenses with.
+type ContactRepository interface {
+	// Create stores the contact. It fails with ErrAlreadyExists when the user already has a
+	// contact of the same name. ID is filled in on success.
+	Create(ctx context.Context, contact *Contact) error
+	Get(ctx context.Context, id int64) (Contact, error)
+	// List returns the user's contacts ordered by name.
+	List(ctx context.Context, userUID string) ([]Contact, error)
+	// Rename changes the name of the contact. It fails with ErrAlreadyExists like Create.
+	Rename(ctx context.Context, id int64, name string) error
+	// Delete removes the contact. It fails with ErrInUse while splits or settlements refer to it.
+	Delete(ctx context.Context, id int64) error
+}
+
+// SplitRepository stores splits with their shares and the settlements between their parties.
+type SplitRepository interface {
+	// Create stores the split with its shares. It fails with ErrAlreadyExists when the expense
+	// is already split. ID is filled in on success.
+	Create(ctx context.Context, split *Split) error
+	Get(ctx context.Context, id int64) (Split, error)
+	// List returns the splits the user recorded, paid or takes part in, newest first.
+	List(ctx context.Context, userUID string) ([]Split, error)
+	// Delete removes the split with its shares. The split expense is kept.
+	Delete(ctx context.Context, id int64) error
+	// CreateSettlement stores the settlement. ID is filled in on success.
+	CreateSettlement(ctx context.Context, settlement *Settlement) error
+	GetSettlement(ctx context.Context, id int64) (Settlement, error)
+	// Settlements returns the settlements the user recorded, paid or received, newest first.
+	Settlements(ctx context.Context, userUID string) ([]Settlement, error)
+	DeleteSettlement(ctx context.Context, id int64) error
+}
+
+// HouseholdRepository stores households, their members and the pending invites.
+type HouseholdRepository interface {
+	// Create stores the household with ownerUID as its owner. ID is filled in on success.
+	Create(ctx context.Context, household *Household, ownerUID string) error
+	Get(ctx context.Context, id int64) (Household, error)
+	// ListByMember returns the households the user belongs to, ordered by name.
+	ListByMember(ctx context.Context, userUID string) ([]Membership, error)
+	Rename(ctx context.Context, id int64, name string) error
+	// Delete removes the household with its members, invites and budgets. The shared incomes
+	// and expenses become personal records of their authors again.
+	Delete(ctx context.Context, id int64) error
+	// Role returns the role of the user in the household, or ErrNotFound when the user is not a member.
+	Role(ctx context.Context, id int64, userUID string) (string, error)
+	// Members returns the members of the household, the owner first.
+	Members(ctx context.Context, id int64) ([]HouseholdMember, error)
+	// SetRole changes the role of a member. Making a member the owner turns the current
+	// owner into an editor.
+	SetRole(ctx context.Context, id int64, userUID, role string) error
+	// RemoveMember removes the user from the household. The incomes and expenses the user
+	// shared with it become personal again.
+	RemoveMember(ctx context.Context, id int64, userUID string) error
+	// CreateInvite stores an invite. It fails with ErrAlreadyExists when the invitee is
+	// already a member or invited. ID is filled in on success.
+	CreateInvite(ctx context.Context, invite *HouseholdInvite) error
+	GetInvite(ctx context.Context, id int64) (HouseholdInvite, error)
+	// Invites returns the pending invites of the household.
+	Invites(ctx context.Context, householdID int64) ([]HouseholdInvite, error)
+	// InvitesFor returns the pending invites the user received.
+	InvitesFor(ctx context.Context, userUID string) ([]HouseholdInvite, error)
+	// AcceptInvite makes the invitee a member with the invited role and removes the invite.
+	AcceptInvite(ctx context.Context, id int64) error
+	DeleteInvite(ctx context.Context, id int64) error
+}
+
+// FeedRepository reads incomes, expenses and transfers as one feed.
+type FeedRepository interface {
+	// Find returns a page of the user's feed entries matching the filter or, with HouseholdID,
+	// of the incomes and expenses shared with the household; transfers are personal. Amount
+	// bounds and the amount sort order apply to the absolute amount. The running balance is not
+	// reset by the date range: it includes the matching entries before From.
+	Find(ctx context.Context, filter TransactionFilter) (FeedPage, error)
+}
+
+// CategoryRepository stores categories. Renaming or merging a category also updates the
+// denormalized category name of its transactions, budgets and recurring rules.
+type CategoryRepository interface {
+	// Create stores the category. It fails with ErrAlreadyExists when the user already has a
+	// category of the same type and name. ID is filled in on success.
+	Create(ctx context.Context, category *Category) error
+	Get(ctx context.Context, id int64) (Category, error)
+	// FindByName returns the user's category of the given type by name, compared with CategoryKey.
+	FindByName(ctx context.Context, userUID, categoryType, name string) (Category, error)
+	// List returns the user's categories ordered by type and name.
+	List(ctx context.Context, userUID string) ([]Category, error)
+	// Update changes the name, parent, icon and color of the category.
+	Update(ctx context.Context, category Category) error
+	// Delete removes the category. It fails with ErrInUse while transactions, budgets or
+	// subcategories refer to it.
+	Delete(ctx context.Context, id int64) error
+	// Merge moves the transactions, budgets, recurring rules and subcategories of source to
+	// target and deletes source. A budget of source for a period target already has a budget
+	// for is dropped.
+	Merge(ctx context.Context, sourceID, targetID int64) error
+}
+
+// TagRepository stores tags and their links to incomes and expenses. Links are identified by
+// the kind of the transaction, EntryIncome or EntryExpense, and its ID.
+type TagRepository interface {
+	// Create stores the tag. It fails with ErrAlreadyExists when the user already has a tag of
+	// the same name. ID is filled in on success.
+	Create(ctx context.Context, tag *Tag) error
+	Get(ctx context.Context, id int64) (Tag, error)
+	// FindByName returns the user's tag by name, compared with TagKey.
+	FindByName(ctx context.Context, userUID, name string) (Tag, error)
+	// List returns the user's tags ordered by name.
+	List(ctx context.Context, userUID string) ([]Tag, error)
+	// Update changes the name and color of the tag.
+	Update(ctx context.Context, tag Tag) error
+	// Delete removes the tag from all transactions and deletes it.
+	Delete(ctx context.Context, id int64) error
+	// Attach adds every tag to every transaction of the kind, skipping links that already exist.
+	Attach(ctx context.Context, kind string, transactionIDs, tagIDs []int64) error
+	// Detach removes every tag from every transaction of the kind.
+	Detach(ctx context.Context, kind string, transactionIDs, tagIDs []int64) error
+}
+
+// AttachmentRepository stores the records of attachments; the files themselves are kept in a
+// blob store.
+type AttachmentRepository interface {
+	// Create stores the attachment. ID is filled in on success.
+	Create(ctx context.Context, attachment *Attachment) error
+	Get(ctx context.Context, id int64) (Attachment, error)
+	// List returns the attachments of the transaction of the kind in upload order.
+	List(ctx context.Context, kind string, transactionID int64) ([]Attachment, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// Repositories bundles the repositories of one storage backend.
+type Repositories struct {
+	Users       UserRepository
+	Incomes     IncomeRepository
+	Expenses    ExpenseRepository
+	Accounts    AccountRepository
+	Transfers   TransferRepository
+	Goals       GoalRepository
+	Contacts    ContactRepository
+	Splits      SplitRepository
+	Categories  CategoryRepository
+	Feed        FeedRepository
+	Households  HouseholdRepository
+	Tags        TagRepository
+	Attachments AttachmentRepository
+}
+
+// OwnedAccount returns the user's account with the given ID, or the user's default account
+// when id is 0. Accounts of other users are reported as ErrNotFound.
+func OwnedAccount(ctx context.Context, accounts AccountRepository, userUID string, id int64) (Account, error) {
+	if id == 0 {
+		return accounts.GetDefault(ctx, userUID)
+	}
+	account, err := accounts.Get(ctx, id)
+	if err != nil {
+		return Account{}, err
+	}
+	if account.UserUID != userUID {
+		return Account{}, ErrNotFound
+	}
+	return account, nil
+}
+
+// CanModify reports whether the user may change or delete the transaction: its author may, and
+// so may the owner and the editors of the household it is shared with.
+func CanModify(ctx context.Context, households HouseholdRepository, userUID string, t Transaction) (bool, error) {
+	if t.UserUID == userUID {
+		return true, nil
+	}
+	if t.HouseholdID == 0 {
+		return false, nil
+	}
+	role, err := households.Role(ctx, t.HouseholdID, userUID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return CanEdit(role), nil
+}
+
+// CanView reports whether the user may see the transaction: its author may, and so may every
+// member of the household it is shared with.
+func CanView(ctx context.Context, households HouseholdRepository, userUID string, t Transaction) (bool, error) {
+	if t.UserUID == userUID {
+		return true, nil
+	}
+	if t.HouseholdID == 0 {
+		return false, nil
+	}
+	_, err := households.Role(ctx, t.HouseholdID, userUID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ResolveCategory returns the user's category of the given type by ID or, when id is 0, by name.
+// Categories of other users or of the other type are reported as ErrNotFound.
+func ResolveCategory(ctx context.Context, categories CategoryRepository, userUID, categoryType string, id int64, name string) (Category, error) {
+	if id == 0 {
+		if CategoryKey(name) == "" {
+			return Category{}, ErrNotFound
+		}
+		return categories.FindByName(ctx, userUID, categoryType, name)
+	}
+	category, err := categories.Get(ctx, id)
+	if err != nil {
+		return Category{}, err
+	}
+	if category.UserUID != userUID || category.Type != categoryType {
+		return Category{}, ErrNotFound
+	}
+	return category, nil
+}
+
+// ResolveTags returns the user's tags with the given names, creating the missing ones. Names are
+// compared with TagKey and duplicates are dropped; new tags get the name with its spaces collapsed.
+func ResolveTags(ctx context.Context, tags TagRepository, userUID string, names []string) ([]Tag, error) {
+	var resolved []Tag
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.Join(strings.Fields(name), " ")
+		if name == "" || utf8.RuneCountInString(name) > MaxTagNameLength {
+			return nil, ErrInvalidTagName
+		}
+		if seen[TagKey(name)] {
+			continue
+		}
+		seen[TagKey(name)] = true
+
+		tag, err := tags.FindByName(ctx, userUID, name)
+		if errors.Is(err, ErrNotFound) {
+			tag = Tag{UserUID: userUID, Name: name}
+			err = tags.Create(ctx, &tag)
+			if errors.Is(err, ErrAlreadyExists) {
+				// Created by a concurrent request in the meantime.
+				tag, err = tags.FindByName(ctx, userUID, name)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, tag)
+	}
+	return resolved, nil
+}
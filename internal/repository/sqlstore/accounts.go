@@ -0,0 +1,238 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"tbank-go/internal/repository"
+	"time"
+)
+
+// AccountRepository stores accounts in the accounts table.
+type AccountRepository struct {
+	db *sql.DB
+}
+
+// NewAccountRepository returns an AccountRepository backed by db.
+func NewAccountRepository(db *sql.DB) *AccountRepository {
+	return &AccountRepository{db: db}
+}
+
+const accountColumns = `id, user_uid, name, type, currency, balance, is_default, created_at`
+
+func (r *AccountRepository) Create(ctx context.Context, account *repository.Account) error {
+	if account.CreatedAt == "" {
+		account.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO accounts (user_uid, name, type, currency, balance, is_default, created_at) VALUES (?, ?, ?, ?, ?, 0, ?) RETURNING id`,
+		account.UserUID, account.Name, account.Type, account.Balance.Currency, account.Balance.Amount, account.CreatedAt,
+	).Scan(&account.ID)
+}
+
+func (r *AccountRepository) Get(ctx context.Context, id int64) (repository.Account, error) {
+	return scanAccount(r.db.QueryRowContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE id = ?`, id))
+}
+
+func (r *AccountRepository) GetDefault(ctx context.Context, userUID string) (repository.Account, error) {
+	return scanAccount(r.db.QueryRowContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE user_uid = ? AND is_default = 1`, userUID))
+}
+
+func (r *AccountRepository) List(ctx context.Context, userUID string) ([]repository.Account, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE user_uid = ? ORDER BY is_default DESC, id`, userUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []repository.Account
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+func (r *AccountRepository) Update(ctx context.Context, account repository.Account) error {
+	return execOne(r.db.ExecContext(ctx, `UPDATE accounts SET name = ?, type = ? WHERE id = ?`, account.Name, account.Type, account.ID))
+}
+
+func (r *AccountRepository) SetDefault(ctx context.Context, userUID string, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	// The old default has to go first: the unique index allows one default per user at any time.
+	_, err = tx.ExecContext(ctx, `UPDATE accounts SET is_default = 0 WHERE user_uid = ? AND is_default = 1`, userUID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = execOne(tx.ExecContext(ctx, `UPDATE accounts SET is_default = 1 WHERE id = ? AND user_uid = ?`, id, userUID))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *AccountRepository) Delete(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var references int
+	err = tx.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM income WHERE account_id = ?)
+		     + (SELECT COUNT(*) FROM expenses WHERE account_id = ?)
+		     + (SELECT COUNT(*) FROM transfers WHERE from_account_id = ? OR to_account_id = ?)`,
+		id, id, id, id,
+	).Scan(&references)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if references > 0 {
+		tx.Rollback()
+		return repository.ErrInUse
+	}
+
+	// Savings goals keep existing without the account.
+	_, err = tx.ExecContext(ctx, `UPDATE goals SET account_id = NULL WHERE account_id = ?`, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = execOne(tx.ExecContext(ctx, `DELETE FROM accounts WHERE id = ?`, id))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func scanAccount(row scanner) (repository.Account, error) {
+	var account repository.Account
+	err := row.Scan(&account.ID, &account.UserUID, &account.Name, &account.Type, &account.Balance.Currency,
+		&account.Balance.Amount, &account.IsDefault, &account.CreatedAt)
+	if err == sql.ErrNoRows {
+		return repository.Account{}, repository.ErrNotFound
+	}
+	return account, err
+}
+
+// TransferRepository stores transfers in the transfers table.
+type TransferRepository struct {
+	db *sql.DB
+}
+
+// NewTransferRepository returns a TransferRepository backed by db.
+func NewTransferRepository(db *sql.DB) *TransferRepository {
+	return &TransferRepository{db: db}
+}
+
+const transferColumns = `id, user_uid, from_account_id, to_account_id, amount, currency, to_amount, to_currency, date, description, goal_id`
+
+func (r *TransferRepository) Create(ctx context.Context, t *repository.Transfer) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO transfers (user_uid, from_account_id, to_account_id, amount, currency, to_amount, to_currency, date, description, goal_id, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		t.UserUID, t.FromAccountID, t.ToAccountID, t.Amount.Amount, t.Amount.Currency, t.ToAmount.Amount, t.ToAmount.Currency,
+		t.Date, t.Description, nullID(t.GoalID), time.Now().UTC().Format(time.RFC3339),
+	).Scan(&t.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := moveBalance(ctx, tx, *t, 1); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TransferRepository) Get(ctx context.Context, id int64) (repository.Transfer, error) {
+	return scanTransfer(r.db.QueryRowContext(ctx, `SELECT `+transferColumns+` FROM transfers WHERE id = ?`, id))
+}
+
+func (r *TransferRepository) List(ctx context.Context, userUID, from, to string) ([]repository.Transfer, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+transferColumns+` FROM transfers
+	                                     WHERE user_uid = ? AND date BETWEEN ? AND ? ORDER BY date, id`, userUID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []repository.Transfer
+	for rows.Next() {
+		t, err := scanTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, t)
+	}
+
+	return transfers, rows.Err()
+}
+
+func (r *TransferRepository) Delete(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	t, err := scanTransfer(tx.QueryRowContext(ctx, `DELETE FROM transfers WHERE id = ? RETURNING `+transferColumns, id))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := moveBalance(ctx, tx, t, -1); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// moveBalance applies (factor 1) or reverts (factor -1) a transfer on both account balances.
+func moveBalance(ctx context.Context, tx *sql.Tx, t repository.Transfer, factor int64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE accounts SET balance = balance - ? WHERE id = ?`, factor*t.Amount.Amount, t.FromAccountID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE accounts SET balance = balance + ? WHERE id = ?`, factor*t.ToAmount.Amount, t.ToAccountID)
+	return err
+}
+
+func scanTransfer(row scanner) (repository.Transfer, error) {
+	var t repository.Transfer
+	var description sql.NullString
+	var goalID sql.NullInt64
+	err := row.Scan(&t.ID, &t.UserUID, &t.FromAccountID, &t.ToAccountID, &t.Amount.Amount, &t.Amount.Currency,
+		&t.ToAmount.Amount, &t.ToAmount.Currency, &t.Date, &description, &goalID)
+	if err == sql.ErrNoRows {
+		return repository.Transfer{}, repository.ErrNotFound
+	} else if err != nil {
+		return repository.Transfer{}, err
+	}
+	t.Description = description.String
+	t.GoalID = goalID.Int64
+	return t, nil
+}
@@ -0,0 +1,69 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"tbank-go/internal/repository"
+	"time"
+)
+
+// AttachmentRepository stores the records of attachments in the attachments table.
+type AttachmentRepository struct {
+	db *sql.DB
+}
+
+// NewAttachmentRepository returns an AttachmentRepository backed by db.
+func NewAttachmentRepository(db *sql.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+const attachmentColumns = `id, user_uid, kind, transaction_id, filename, content_type, size, blob_key, thumbnail_key, created_at`
+
+func (r *AttachmentRepository) Create(ctx context.Context, a *repository.Attachment) error {
+	if a.CreatedAt == "" {
+		a.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO attachments (user_uid, kind, transaction_id, filename, content_type, size, blob_key, thumbnail_key, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		a.UserUID, a.Kind, a.TransactionID, a.Filename, a.ContentType, a.Size, a.Key, a.ThumbnailKey, a.CreatedAt,
+	).Scan(&a.ID)
+}
+
+func (r *AttachmentRepository) Get(ctx context.Context, id int64) (repository.Attachment, error) {
+	return scanAttachment(r.db.QueryRowContext(ctx, `SELECT `+attachmentColumns+` FROM attachments WHERE id = ?`, id))
+}
+
+func (r *AttachmentRepository) List(ctx context.Context, kind string, transactionID int64) ([]repository.Attachment, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+attachmentColumns+` FROM attachments
+		WHERE kind = ? AND transaction_id = ? ORDER BY id`, kind, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []repository.Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+func (r *AttachmentRepository) Delete(ctx context.Context, id int64) error {
+	return execOne(r.db.ExecContext(ctx, `DELETE FROM attachments WHERE id = ?`, id))
+}
+
+func scanAttachment(row scanner) (repository.Attachment, error) {
+	var a repository.Attachment
+	err := row.Scan(&a.ID, &a.UserUID, &a.Kind, &a.TransactionID, &a.Filename, &a.ContentType, &a.Size,
+		&a.Key, &a.ThumbnailKey, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return repository.Attachment{}, repository.ErrNotFound
+	}
+	return a, err
+}
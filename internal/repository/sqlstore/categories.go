@@ -0,0 +1,281 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"tbank-go/internal/repository"
+	"time"
+)
+
+// CategoryRepository stores categories in the categories table.
+type CategoryRepository struct {
+	db *sql.DB
+}
+
+// NewCategoryRepository returns a CategoryRepository backed by db.
+func NewCategoryRepository(db *sql.DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+const categoryColumns = `id, user_uid, parent_id, name, type, icon, color, created_at`
+
+// rowQuerier is implemented by both *sql.DB and *sql.Tx.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, category *repository.Category) error {
+	return insertCategory(ctx, r.db, category)
+}
+
+func insertCategory(ctx context.Context, q rowQuerier, category *repository.Category) error {
+	if category.CreatedAt == "" {
+		category.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	err := q.QueryRowContext(ctx,
+		`INSERT INTO categories (user_uid, parent_id, name, name_key, type, icon, color, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		category.UserUID, nullID(category.ParentID), category.Name, repository.CategoryKey(category.Name), category.Type,
+		category.Icon, category.Color, category.CreatedAt,
+	).Scan(&category.ID)
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "unique") {
+		return repository.ErrAlreadyExists
+	}
+	return err
+}
+
+// seedCategories creates the DefaultCategories for a new user.
+func seedCategories(ctx context.Context, tx *sql.Tx, userUID string) error {
+	for _, def := range repository.DefaultCategories {
+		parent := repository.Category{UserUID: userUID, Name: def.Name, Type: def.Type, Icon: def.Icon, Color: def.Color}
+		if err := insertCategory(ctx, tx, &parent); err != nil {
+			return err
+		}
+		for _, name := range def.Children {
+			child := repository.Category{UserUID: userUID, ParentID: parent.ID, Name: name, Type: def.Type, Icon: def.Icon, Color: def.Color}
+			if err := insertCategory(ctx, tx, &child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *CategoryRepository) Get(ctx context.Context, id int64) (repository.Category, error) {
+	return scanCategory(r.db.QueryRowContext(ctx, `SELECT `+categoryColumns+` FROM categories WHERE id = ?`, id))
+}
+
+func (r *CategoryRepository) FindByName(ctx context.Context, userUID, categoryType, name string) (repository.Category, error) {
+	category, err := scanCategory(r.db.QueryRowContext(ctx,
+		`SELECT `+categoryColumns+` FROM categories WHERE user_uid = ? AND type = ? AND name_key = ?`,
+		userUID, categoryType, repository.CategoryKey(name)))
+	if err != repository.ErrNotFound {
+		return category, err
+	}
+	// Databases migrated before storage registered a Unicode-aware lower() got keys with only
+	// ASCII letters folded, so a non-Latin name there may only match exactly.
+	return scanCategory(r.db.QueryRowContext(ctx,
+		`SELECT `+categoryColumns+` FROM categories WHERE user_uid = ? AND type = ? AND name = ?`,
+		userUID, categoryType, strings.TrimSpace(name)))
+}
+
+func (r *CategoryRepository) List(ctx context.Context, userUID string) ([]repository.Category, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+categoryColumns+` FROM categories WHERE user_uid = ? ORDER BY type, name_key`, userUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []repository.Category
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, category repository.Category) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	old, err := scanCategory(tx.QueryRowContext(ctx, `SELECT `+categoryColumns+` FROM categories WHERE id = ?`, category.ID))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE categories SET parent_id = ?, name = ?, name_key = ?, icon = ?, color = ? WHERE id = ?`,
+		nullID(category.ParentID), category.Name, repository.CategoryKey(category.Name), category.Icon, category.Color, category.ID)
+	if err != nil {
+		tx.Rollback()
+		if strings.Contains(strings.ToLower(err.Error()), "unique") {
+			return repository.ErrAlreadyExists
+		}
+		return err
+	}
+
+	if old.Name != category.Name {
+		_, err = tx.ExecContext(ctx, `UPDATE `+categoryTable(old.Type)+` SET category = ? WHERE category_id = ?`, category.Name, category.ID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := renameReferences(ctx, tx, old, category); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *CategoryRepository) Delete(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	category, err := scanCategory(tx.QueryRowContext(ctx, `SELECT `+categoryColumns+` FROM categories WHERE id = ?`, id))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var references int
+	err = tx.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM `+categoryTable(category.Type)+` WHERE category_id = ?)
+		     + (SELECT COUNT(*) FROM budgets WHERE category_id = ?)
+		     + (SELECT COUNT(*) FROM categories WHERE parent_id = ?)`,
+		id, id, id,
+	).Scan(&references)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if references > 0 {
+		tx.Rollback()
+		return repository.ErrInUse
+	}
+
+	err = execOne(tx.ExecContext(ctx, `DELETE FROM categories WHERE id = ?`, id))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *CategoryRepository) Merge(ctx context.Context, sourceID, targetID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	source, err := scanCategory(tx.QueryRowContext(ctx, `SELECT `+categoryColumns+` FROM categories WHERE id = ?`, sourceID))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	target, err := scanCategory(tx.QueryRowContext(ctx, `SELECT `+categoryColumns+` FROM categories WHERE id = ?`, targetID))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// A subcategory merged into its parent's place keeps the tree two levels deep: the
+	// subcategories of source move under target, or next to it when target is one itself.
+	if target.ParentID == source.ID {
+		target.ParentID = source.ParentID
+		_, err = tx.ExecContext(ctx, `UPDATE categories SET parent_id = ? WHERE id = ?`, nullID(target.ParentID), target.ID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	newParent := target.ID
+	if target.ParentID != 0 {
+		newParent = target.ParentID
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE categories SET parent_id = ? WHERE parent_id = ?`, newParent, source.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE `+categoryTable(source.Type)+` SET category_id = ?, category = ? WHERE category_id = ?`,
+		target.ID, target.Name, source.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := renameReferences(ctx, tx, source, target); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = execOne(tx.ExecContext(ctx, `DELETE FROM categories WHERE id = ?`, source.ID))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// renameReferences points the budgets of the category, which refer to it by ID, to target and gives
+// them and the recurring rules, which refer to it by name, the name of target. A budget that would
+// collide with an existing one of the same scope and period is dropped.
+func renameReferences(ctx context.Context, tx *sql.Tx, category, target repository.Category) error {
+	if category.Type == repository.CategoryExpense {
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM budgets
+			WHERE category_id = ? AND EXISTS (
+				SELECT 1 FROM budgets other
+				WHERE other.id <> budgets.id AND other.category_id = ? AND other.period = budgets.period
+				  AND (other.household_id = budgets.household_id
+				       OR (other.household_id IS NULL AND budgets.household_id IS NULL AND other.user_uid = budgets.user_uid)))`,
+			category.ID, target.ID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE budgets SET category_id = ?, category = ? WHERE category_id = ?`,
+			target.ID, target.Name, category.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.ExecContext(ctx, `UPDATE recurring_rules SET category = ? WHERE user_uid = ? AND type = ? AND category = ?`,
+		target.Name, category.UserUID, category.Type, category.Name)
+	return err
+}
+
+// categoryTable returns the transaction table of a category type.
+func categoryTable(categoryType string) string {
+	if categoryType == repository.CategoryIncome {
+		return "income"
+	}
+	return "expenses"
+}
+
+func scanCategory(row scanner) (repository.Category, error) {
+	var category repository.Category
+	var parentID sql.NullInt64
+	err := row.Scan(&category.ID, &category.UserUID, &parentID, &category.Name, &category.Type,
+		&category.Icon, &category.Color, &category.CreatedAt)
+	if err == sql.ErrNoRows {
+		return repository.Category{}, repository.ErrNotFound
+	} else if err != nil {
+		return repository.Category{}, err
+	}
+	category.ParentID = parentID.Int64
+	return category, nil
+}
@@ -0,0 +1,93 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"tbank-go/internal/repository"
+	"time"
+)
+
+// ContactRepository stores contacts in the contacts table.
+type ContactRepository struct {
+	db *sql.DB
+}
+
+// NewContactRepository returns a ContactRepository backed by db.
+func NewContactRepository(db *sql.DB) *ContactRepository {
+	return &ContactRepository{db: db}
+}
+
+const contactColumns = `id, user_uid, name, created_at`
+
+func (r *ContactRepository) Create(ctx context.Context, contact *repository.Contact) error {
+	if contact.CreatedAt == "" {
+		contact.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO contacts (user_uid, name, created_at) VALUES (?, ?, ?) RETURNING id`,
+		contact.UserUID, contact.Name, contact.CreatedAt,
+	).Scan(&contact.ID)
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "unique") {
+		return repository.ErrAlreadyExists
+	}
+	return err
+}
+
+func (r *ContactRepository) Get(ctx context.Context, id int64) (repository.Contact, error) {
+	return scanContact(r.db.QueryRowContext(ctx, `SELECT `+contactColumns+` FROM contacts WHERE id = ?`, id))
+}
+
+func (r *ContactRepository) List(ctx context.Context, userUID string) ([]repository.Contact, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+contactColumns+` FROM contacts WHERE user_uid = ? ORDER BY name, id`, userUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contacts []repository.Contact
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+
+	return contacts, rows.Err()
+}
+
+func (r *ContactRepository) Rename(ctx context.Context, id int64, name string) error {
+	err := execOne(r.db.ExecContext(ctx, `UPDATE contacts SET name = ? WHERE id = ?`, name, id))
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "unique") {
+		return repository.ErrAlreadyExists
+	}
+	return err
+}
+
+func (r *ContactRepository) Delete(ctx context.Context, id int64) error {
+	var uses int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM splits WHERE payer_contact_id = ?)
+		     + (SELECT COUNT(*) FROM split_shares WHERE contact_id = ?)
+		     + (SELECT COUNT(*) FROM settlements WHERE from_contact_id = ? OR to_contact_id = ?)`,
+		id, id, id, id,
+	).Scan(&uses)
+	if err != nil {
+		return err
+	}
+	if uses > 0 {
+		return repository.ErrInUse
+	}
+
+	return execOne(r.db.ExecContext(ctx, `DELETE FROM contacts WHERE id = ?`, id))
+}
+
+func scanContact(row scanner) (repository.Contact, error) {
+	var contact repository.Contact
+	err := row.Scan(&contact.ID, &contact.UserUID, &contact.Name, &contact.CreatedAt)
+	if err == sql.ErrNoRows {
+		return repository.Contact{}, repository.ErrNotFound
+	}
+	return contact, err
+}
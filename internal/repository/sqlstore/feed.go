@@ -0,0 +1,172 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"tbank-go/internal/repository"
+)
+
+// FeedRepository reads the feed from the income, expenses and transfers tables.
+type FeedRepository struct {
+	db *sql.DB
+}
+
+// NewFeedRepository returns a FeedRepository backed by db.
+func NewFeedRepository(db *sql.DB) *FeedRepository {
+	return &FeedRepository{db: db}
+}
+
+// Feed entries of incomes, expenses and transfers with the absolute amount in amount and the
+// signed one in signed. Keys are the row ID times four plus the entry kind. %[1]s stands for the
+// condition selecting the owner's rows.
+const (
+	incomeEntries = `
+	SELECT id * 4 AS entry_key, 'income' AS type, id, user_uid, household_id, account_id, category_id, category,
+	       amount, amount AS signed, currency, date, description, NULL AS counterpart_id
+	FROM income WHERE %[1]s`
+	expenseEntries = `
+	SELECT id * 4 + 1, 'expense', id, user_uid, household_id, account_id, category_id, category,
+	       amount, -amount, currency, date, description, NULL
+	FROM expenses WHERE %[1]s`
+	transferEntries = `
+	SELECT id * 4 + 2, 'transfer', id, user_uid, NULL, from_account_id, NULL, '',
+	       amount, -amount, currency, date, description, to_account_id
+	FROM transfers WHERE %[1]s
+	UNION ALL
+	SELECT id * 4 + 3, 'transfer', id, user_uid, NULL, to_account_id, NULL, '',
+	       to_amount, to_amount, to_currency, date, description, from_account_id
+	FROM transfers WHERE %[1]s`
+)
+
+// feedEntries returns the query of every entry of the filter's user, or of the incomes and
+// expenses shared with its household, with its arguments. Transfers are never shared.
+func feedEntries(filter repository.TransactionFilter) (string, []any) {
+	if filter.HouseholdID != 0 {
+		query := fmt.Sprintf(incomeEntries+` UNION ALL`+expenseEntries, "household_id = ?")
+		return query, []any{filter.HouseholdID, filter.HouseholdID}
+	}
+	query := fmt.Sprintf(incomeEntries+` UNION ALL`+expenseEntries+` UNION ALL`+transferEntries, "user_uid = ?")
+	return query, []any{filter.UserUID, filter.UserUID, filter.UserUID, filter.UserUID}
+}
+
+func (r *FeedRepository) Find(ctx context.Context, filter repository.TransactionFilter) (repository.FeedPage, error) {
+	// The running balance is computed before the date range is applied, so that it carries
+	// over the entries before From.
+	undated := filter
+	undated.From, undated.To = "", ""
+	where, conditionArgs := transactionConditions(undated, "type")
+	entries, args := feedEntries(filter)
+	args = append(args, conditionArgs...)
+	if len(filter.Types) > 0 {
+		where = append(where, "type IN (?"+strings.Repeat(", ?", len(filter.Types)-1)+")")
+		for _, t := range filter.Types {
+			args = append(args, t)
+		}
+	}
+	matching := `WITH entries AS (` + entries + `), matching AS (
+		SELECT entries.*, SUM(signed) OVER (PARTITION BY currency ORDER BY date, entry_key ROWS UNBOUNDED PRECEDING) AS balance
+		FROM entries WHERE ` + strings.Join(where, " AND ") + `)`
+
+	var dated []string
+	if filter.From != "" {
+		dated = append(dated, "date >= ?")
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		dated = append(dated, "date <= ?")
+		args = append(args, filter.To)
+	}
+
+	var page repository.FeedPage
+	err := r.db.QueryRowContext(ctx, matching+` SELECT COUNT(*) FROM matching`+whereClause(dated), args...).Scan(&page.TotalCount)
+	if err != nil {
+		return page, err
+	}
+
+	column, cmp, order := "date", ">", "ASC"
+	if filter.SortBy == repository.SortByAmount {
+		column = "amount"
+	}
+	if filter.Descending {
+		cmp, order = "<", "DESC"
+	}
+	if c := filter.After; c != nil {
+		var value any = c.Date
+		if column == "amount" {
+			value = c.Amount
+		}
+		dated = append(dated, `(`+column+` `+cmp+` ? OR (`+column+` = ? AND entry_key `+cmp+` ?))`)
+		args = append(args, value, value, c.ID)
+	}
+
+	query := matching + `
+		SELECT entry_key, type, id, user_uid, account_id, category_id, category, signed, currency, balance, date, description, counterpart_id
+		FROM matching` + whereClause(dated) + `
+		ORDER BY ` + column + ` ` + order + `, entry_key ` + order + ` LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, query, append(args, filter.Limit+1)...)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e repository.FeedEntry
+		var accountID, categoryID, counterpartID sql.NullInt64
+		var description sql.NullString
+		err := rows.Scan(&e.Key, &e.Type, &e.ID, &e.UserUID, &accountID, &categoryID, &e.Category, &e.Amount.Amount, &e.Amount.Currency,
+			&e.Balance.Amount, &e.Date, &description, &counterpartID)
+		if err != nil {
+			return page, err
+		}
+		e.AccountID = accountID.Int64
+		e.CategoryID = categoryID.Int64
+		e.CounterpartAccountID = counterpartID.Int64
+		e.Description = description.String
+		e.Balance.Currency = e.Amount.Currency
+		page.Entries = append(page.Entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return page, err
+	}
+
+	if len(page.Entries) > filter.Limit {
+		page.Entries = page.Entries[:filter.Limit]
+		last := page.Entries[filter.Limit-1]
+		page.Next = &repository.Cursor{Date: last.Date, Amount: abs(last.Amount.Amount), ID: last.Key}
+	}
+
+	for _, kind := range []string{repository.EntryIncome, repository.EntryExpense} {
+		var ids []int64
+		for _, e := range page.Entries {
+			if e.Type == kind {
+				ids = append(ids, e.ID)
+			}
+		}
+		tags, err := tagsOf(ctx, r.db, kind, ids)
+		if err != nil {
+			return page, err
+		}
+		for i, e := range page.Entries {
+			if e.Type == kind {
+				page.Entries[i].Tags = tags[e.ID]
+			}
+		}
+	}
+	return page, nil
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
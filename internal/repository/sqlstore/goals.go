@@ -0,0 +1,137 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"tbank-go/internal/repository"
+	"time"
+)
+
+// GoalRepository stores savings goals in the goals table.
+type GoalRepository struct {
+	db *sql.DB
+}
+
+// NewGoalRepository returns a GoalRepository backed by db.
+func NewGoalRepository(db *sql.DB) *GoalRepository {
+	return &GoalRepository{db: db}
+}
+
+// goalColumns selects a goal together with the sum of its contributions in the goal currency.
+const goalColumns = `id, user_uid, name, target_amount, currency, deadline, account_id, created_at,
+	(SELECT COALESCE(SUM(to_amount), 0) FROM transfers WHERE goal_id = goals.id AND to_currency = goals.currency)`
+
+func (r *GoalRepository) Create(ctx context.Context, goal *repository.Goal) error {
+	if goal.CreatedAt == "" {
+		goal.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	goal.Saved = goal.Target
+	goal.Saved.Amount = 0
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO goals (user_uid, name, target_amount, currency, deadline, account_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		goal.UserUID, goal.Name, goal.Target.Amount, goal.Target.Currency, nullString(goal.Deadline),
+		nullID(goal.AccountID), goal.CreatedAt,
+	).Scan(&goal.ID)
+}
+
+func (r *GoalRepository) Get(ctx context.Context, id int64) (repository.Goal, error) {
+	return scanGoal(r.db.QueryRowContext(ctx, `SELECT `+goalColumns+` FROM goals WHERE id = ?`, id))
+}
+
+func (r *GoalRepository) List(ctx context.Context, userUID string) ([]repository.Goal, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+goalColumns+` FROM goals WHERE user_uid = ? ORDER BY id`, userUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var goals []repository.Goal
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, goal)
+	}
+
+	return goals, rows.Err()
+}
+
+func (r *GoalRepository) Update(ctx context.Context, goal repository.Goal) error {
+	return execOne(r.db.ExecContext(ctx,
+		`UPDATE goals SET name = ?, target_amount = ?, currency = ?, deadline = ?, account_id = ? WHERE id = ?`,
+		goal.Name, goal.Target.Amount, goal.Target.Currency, nullString(goal.Deadline), nullID(goal.AccountID), goal.ID))
+}
+
+func (r *GoalRepository) Delete(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE transfers SET goal_id = NULL WHERE goal_id = ?`, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := execOne(tx.ExecContext(ctx, `DELETE FROM goals WHERE id = ?`, id)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *GoalRepository) Contributions(ctx context.Context, id int64) ([]repository.Transfer, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+transferColumns+` FROM transfers WHERE goal_id = ? ORDER BY date, id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []repository.Transfer
+	for rows.Next() {
+		t, err := scanTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, t)
+	}
+
+	return transfers, rows.Err()
+}
+
+func (r *GoalRepository) Contributed(ctx context.Context, id int64, from, to string) (int64, error) {
+	var sum int64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(t.to_amount), 0) FROM transfers t JOIN goals g ON g.id = t.goal_id
+		WHERE t.goal_id = ? AND t.to_currency = g.currency AND t.date > ? AND t.date <= ?`,
+		id, from, to,
+	).Scan(&sum)
+	return sum, err
+}
+
+func scanGoal(row scanner) (repository.Goal, error) {
+	var goal repository.Goal
+	var deadline sql.NullString
+	var accountID sql.NullInt64
+	err := row.Scan(&goal.ID, &goal.UserUID, &goal.Name, &goal.Target.Amount, &goal.Target.Currency, &deadline,
+		&accountID, &goal.CreatedAt, &goal.Saved.Amount)
+	if err == sql.ErrNoRows {
+		return repository.Goal{}, repository.ErrNotFound
+	} else if err != nil {
+		return repository.Goal{}, err
+	}
+	goal.Saved.Currency = goal.Target.Currency
+	goal.Deadline = deadline.String
+	goal.AccountID = accountID.Int64
+	return goal, nil
+}
+
+// nullString stores an empty string as NULL.
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,301 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"tbank-go/internal/repository"
+	"time"
+)
+
+// HouseholdRepository stores households in the households, household_members and
+// household_invites tables.
+type HouseholdRepository struct {
+	db *sql.DB
+}
+
+// NewHouseholdRepository returns a HouseholdRepository backed by db.
+func NewHouseholdRepository(db *sql.DB) *HouseholdRepository {
+	return &HouseholdRepository{db: db}
+}
+
+func (r *HouseholdRepository) Create(ctx context.Context, household *repository.Household, ownerUID string) error {
+	if household.CreatedAt == "" {
+		household.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `INSERT INTO households (name, created_at) VALUES (?, ?) RETURNING id`,
+		household.Name, household.CreatedAt).Scan(&household.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO household_members (household_id, user_uid, role, joined_at) VALUES (?, ?, ?, ?)`,
+		household.ID, ownerUID, repository.RoleOwner, household.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *HouseholdRepository) Get(ctx context.Context, id int64) (repository.Household, error) {
+	var household repository.Household
+	err := r.db.QueryRowContext(ctx, `SELECT id, name, created_at FROM households WHERE id = ?`, id).
+		Scan(&household.ID, &household.Name, &household.CreatedAt)
+	if err == sql.ErrNoRows {
+		return repository.Household{}, repository.ErrNotFound
+	}
+	return household, err
+}
+
+func (r *HouseholdRepository) ListByMember(ctx context.Context, userUID string) ([]repository.Membership, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT h.id, h.name, h.created_at, m.role
+		FROM households h JOIN household_members m ON m.household_id = h.id
+		WHERE m.user_uid = ? ORDER BY h.name, h.id`, userUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memberships []repository.Membership
+	for rows.Next() {
+		var m repository.Membership
+		if err := rows.Scan(&m.Household.ID, &m.Household.Name, &m.Household.CreatedAt, &m.Role); err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, m)
+	}
+
+	return memberships, rows.Err()
+}
+
+func (r *HouseholdRepository) Rename(ctx context.Context, id int64, name string) error {
+	return execOne(r.db.ExecContext(ctx, `UPDATE households SET name = ? WHERE id = ?`, name, id))
+}
+
+func (r *HouseholdRepository) Delete(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	// SQLite does not enforce the foreign keys, so the dependent rows are removed explicitly.
+	for _, query := range []string{
+		`UPDATE income SET household_id = NULL WHERE household_id = ?`,
+		`UPDATE expenses SET household_id = NULL WHERE household_id = ?`,
+		`DELETE FROM budgets WHERE household_id = ?`,
+		`DELETE FROM household_invites WHERE household_id = ?`,
+		`DELETE FROM household_members WHERE household_id = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = execOne(tx.ExecContext(ctx, `DELETE FROM households WHERE id = ?`, id))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *HouseholdRepository) Role(ctx context.Context, id int64, userUID string) (string, error) {
+	var role string
+	err := r.db.QueryRowContext(ctx, `SELECT role FROM household_members WHERE household_id = ? AND user_uid = ?`,
+		id, userUID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", repository.ErrNotFound
+	}
+	return role, err
+}
+
+func (r *HouseholdRepository) Members(ctx context.Context, id int64) ([]repository.HouseholdMember, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT m.user_uid, u.username, m.role, m.joined_at
+		FROM household_members m JOIN users u ON u.uid = m.user_uid
+		WHERE m.household_id = ?
+		ORDER BY CASE m.role WHEN 'owner' THEN 0 WHEN 'editor' THEN 1 ELSE 2 END, u.username`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []repository.HouseholdMember
+	for rows.Next() {
+		var m repository.HouseholdMember
+		if err := rows.Scan(&m.UserUID, &m.Username, &m.Role, &m.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
+
+func (r *HouseholdRepository) SetRole(ctx context.Context, id int64, userUID, role string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if role == repository.RoleOwner {
+		_, err = tx.ExecContext(ctx, `UPDATE household_members SET role = ? WHERE household_id = ? AND role = ?`,
+			repository.RoleEditor, id, repository.RoleOwner)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = execOne(tx.ExecContext(ctx, `UPDATE household_members SET role = ? WHERE household_id = ? AND user_uid = ?`,
+		role, id, userUID))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *HouseholdRepository) RemoveMember(ctx context.Context, id int64, userUID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = execOne(tx.ExecContext(ctx, `DELETE FROM household_members WHERE household_id = ? AND user_uid = ?`, id, userUID))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, table := range []string{"income", "expenses"} {
+		_, err := tx.ExecContext(ctx, `UPDATE `+table+` SET household_id = NULL WHERE household_id = ? AND user_uid = ?`, id, userUID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *HouseholdRepository) CreateInvite(ctx context.Context, invite *repository.HouseholdInvite) error {
+	if invite.CreatedAt == "" {
+		invite.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	var members int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM household_members WHERE household_id = ? AND user_uid = ?`,
+		invite.HouseholdID, invite.InviteeUID).Scan(&members)
+	if err != nil {
+		return err
+	}
+	if members > 0 {
+		return repository.ErrAlreadyExists
+	}
+
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO household_invites (household_id, invitee_uid, inviter_uid, role, created_at) VALUES (?, ?, ?, ?, ?) RETURNING id`,
+		invite.HouseholdID, invite.InviteeUID, invite.InviterUID, invite.Role, invite.CreatedAt,
+	).Scan(&invite.ID)
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "unique") {
+		return repository.ErrAlreadyExists
+	}
+	return err
+}
+
+// inviteQuery selects invites with the names of the household and both users.
+const inviteQuery = `
+	SELECT i.id, i.household_id, h.name, i.invitee_uid, invitee.username, i.inviter_uid, COALESCE(inviter.username, ''), i.role, i.created_at
+	FROM household_invites i
+	JOIN households h ON h.id = i.household_id
+	JOIN users invitee ON invitee.uid = i.invitee_uid
+	LEFT JOIN users inviter ON inviter.uid = i.inviter_uid`
+
+func (r *HouseholdRepository) GetInvite(ctx context.Context, id int64) (repository.HouseholdInvite, error) {
+	invites, err := r.queryInvites(ctx, inviteQuery+` WHERE i.id = ?`, id)
+	if err != nil {
+		return repository.HouseholdInvite{}, err
+	}
+	if len(invites) == 0 {
+		return repository.HouseholdInvite{}, repository.ErrNotFound
+	}
+	return invites[0], nil
+}
+
+func (r *HouseholdRepository) Invites(ctx context.Context, householdID int64) ([]repository.HouseholdInvite, error) {
+	return r.queryInvites(ctx, inviteQuery+` WHERE i.household_id = ? ORDER BY i.id`, householdID)
+}
+
+func (r *HouseholdRepository) InvitesFor(ctx context.Context, userUID string) ([]repository.HouseholdInvite, error) {
+	return r.queryInvites(ctx, inviteQuery+` WHERE i.invitee_uid = ? ORDER BY i.id`, userUID)
+}
+
+func (r *HouseholdRepository) queryInvites(ctx context.Context, query string, args ...any) ([]repository.HouseholdInvite, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invites []repository.HouseholdInvite
+	for rows.Next() {
+		var i repository.HouseholdInvite
+		err := rows.Scan(&i.ID, &i.HouseholdID, &i.HouseholdName, &i.InviteeUID, &i.InviteeUsername,
+			&i.InviterUID, &i.InviterUsername, &i.Role, &i.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, i)
+	}
+
+	return invites, rows.Err()
+}
+
+func (r *HouseholdRepository) AcceptInvite(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var householdID int64
+	var inviteeUID, role string
+	err = tx.QueryRowContext(ctx, `DELETE FROM household_invites WHERE id = ? RETURNING household_id, invitee_uid, role`, id).
+		Scan(&householdID, &inviteeUID, &role)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return repository.ErrNotFound
+	} else if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO household_members (household_id, user_uid, role, joined_at) VALUES (?, ?, ?, ?)`,
+		householdID, inviteeUID, role, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		tx.Rollback()
+		if strings.Contains(strings.ToLower(err.Error()), "unique") {
+			return repository.ErrAlreadyExists
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *HouseholdRepository) DeleteInvite(ctx context.Context, id int64) error {
+	return execOne(r.db.ExecContext(ctx, `DELETE FROM household_invites WHERE id = ?`, id))
+}
@@ -0,0 +1,232 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"tbank-go/internal/repository"
+	"time"
+)
+
+// SplitRepository stores splits in the splits and split_shares tables and settlements in the
+// settlements table.
+type SplitRepository struct {
+	db *sql.DB
+}
+
+// NewSplitRepository returns a SplitRepository backed by db.
+func NewSplitRepository(db *sql.DB) *SplitRepository {
+	return &SplitRepository{db: db}
+}
+
+// splitColumns selects a split from splitTables with the name of its payer.
+const splitColumns = `s.id, s.user_uid, s.expense_id, s.payer_uid, s.payer_contact_id, COALESCE(pu.username, pc.name, ''),
+	s.description, s.amount, s.currency, s.method, s.date, s.created_at`
+
+const splitTables = `splits s
+	LEFT JOIN users pu ON pu.uid = s.payer_uid
+	LEFT JOIN contacts pc ON pc.id = s.payer_contact_id`
+
+// visibleSplits matches the splits a user recorded, paid or takes part in. It takes the
+// user's UID three times.
+const visibleSplits = `(s.user_uid = ? OR s.payer_uid = ? OR s.id IN (SELECT split_id FROM split_shares WHERE user_uid = ?))`
+
+func (r *SplitRepository) Create(ctx context.Context, split *repository.Split) error {
+	if split.CreatedAt == "" {
+		split.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO splits (user_uid, expense_id, payer_uid, payer_contact_id, description, amount, currency, method, date, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		split.UserUID, nullID(split.ExpenseID), nullString(split.Payer.UserUID), nullID(split.Payer.ContactID),
+		split.Description, split.Amount.Amount, split.Amount.Currency, split.Method, split.Date, split.CreatedAt,
+	).Scan(&split.ID)
+	if err != nil {
+		tx.Rollback()
+		if strings.Contains(strings.ToLower(err.Error()), "unique") {
+			return repository.ErrAlreadyExists
+		}
+		return err
+	}
+
+	for _, share := range split.Shares {
+		_, err := tx.ExecContext(ctx, `INSERT INTO split_shares (split_id, user_uid, contact_id, amount) VALUES (?, ?, ?, ?)`,
+			split.ID, nullString(share.UserUID), nullID(share.ContactID), share.Amount.Amount)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *SplitRepository) Get(ctx context.Context, id int64) (repository.Split, error) {
+	splits, err := r.find(ctx, `s.id = ?`, id)
+	if err != nil {
+		return repository.Split{}, err
+	}
+	if len(splits) == 0 {
+		return repository.Split{}, repository.ErrNotFound
+	}
+	return splits[0], nil
+}
+
+func (r *SplitRepository) List(ctx context.Context, userUID string) ([]repository.Split, error) {
+	return r.find(ctx, visibleSplits, userUID, userUID, userUID)
+}
+
+// find returns the splits matching condition, a filter on splits aliased s, with their shares.
+func (r *SplitRepository) find(ctx context.Context, condition string, args ...any) ([]repository.Split, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+splitColumns+` FROM `+splitTables+` WHERE `+condition+
+		` ORDER BY s.date DESC, s.id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var splits []repository.Split
+	index := make(map[int64]int)
+	for rows.Next() {
+		var split repository.Split
+		var expenseID, payerContactID sql.NullInt64
+		var payerUID sql.NullString
+		err := rows.Scan(&split.ID, &split.UserUID, &expenseID, &payerUID, &payerContactID, &split.Payer.Name,
+			&split.Description, &split.Amount.Amount, &split.Amount.Currency, &split.Method, &split.Date, &split.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		split.ExpenseID = expenseID.Int64
+		split.Payer.UserUID, split.Payer.ContactID = payerUID.String, payerContactID.Int64
+		index[split.ID] = len(splits)
+		splits = append(splits, split)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	shares, err := r.db.QueryContext(ctx, `
+		SELECT sh.split_id, sh.user_uid, sh.contact_id, COALESCE(u.username, c.name, ''), sh.amount
+		FROM split_shares sh
+		LEFT JOIN users u ON u.uid = sh.user_uid
+		LEFT JOIN contacts c ON c.id = sh.contact_id
+		WHERE sh.split_id IN (SELECT s.id FROM splits s WHERE `+condition+`)
+		ORDER BY sh.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer shares.Close()
+
+	for shares.Next() {
+		var splitID int64
+		var share repository.SplitShare
+		var userUID sql.NullString
+		var contactID sql.NullInt64
+		if err := shares.Scan(&splitID, &userUID, &contactID, &share.Name, &share.Amount.Amount); err != nil {
+			return nil, err
+		}
+		i, ok := index[splitID]
+		if !ok {
+			continue
+		}
+		share.UserUID, share.ContactID = userUID.String, contactID.Int64
+		share.Amount.Currency = splits[i].Amount.Currency
+		splits[i].Shares = append(splits[i].Shares, share)
+	}
+
+	return splits, shares.Err()
+}
+
+func (r *SplitRepository) Delete(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM split_shares WHERE split_id = ?`, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := execOne(tx.ExecContext(ctx, `DELETE FROM splits WHERE id = ?`, id)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SplitRepository) CreateSettlement(ctx context.Context, settlement *repository.Settlement) error {
+	if settlement.CreatedAt == "" {
+		settlement.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO settlements (user_uid, from_uid, from_contact_id, to_uid, to_contact_id, amount, currency, date, description, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		settlement.UserUID, nullString(settlement.From.UserUID), nullID(settlement.From.ContactID),
+		nullString(settlement.To.UserUID), nullID(settlement.To.ContactID), settlement.Amount.Amount, settlement.Amount.Currency,
+		settlement.Date, settlement.Description, settlement.CreatedAt,
+	).Scan(&settlement.ID)
+}
+
+// settlementSelect selects settlements aliased t with the names of both parties.
+const settlementSelect = `
+	SELECT t.id, t.user_uid, t.from_uid, t.from_contact_id, COALESCE(fu.username, fc.name, ''),
+	       t.to_uid, t.to_contact_id, COALESCE(tu.username, tc.name, ''),
+	       t.amount, t.currency, t.date, t.description, t.created_at
+	FROM settlements t
+	LEFT JOIN users fu ON fu.uid = t.from_uid
+	LEFT JOIN contacts fc ON fc.id = t.from_contact_id
+	LEFT JOIN users tu ON tu.uid = t.to_uid
+	LEFT JOIN contacts tc ON tc.id = t.to_contact_id`
+
+func (r *SplitRepository) GetSettlement(ctx context.Context, id int64) (repository.Settlement, error) {
+	return scanSettlement(r.db.QueryRowContext(ctx, settlementSelect+` WHERE t.id = ?`, id))
+}
+
+func (r *SplitRepository) Settlements(ctx context.Context, userUID string) ([]repository.Settlement, error) {
+	rows, err := r.db.QueryContext(ctx, settlementSelect+`
+		WHERE t.user_uid = ? OR t.from_uid = ? OR t.to_uid = ?
+		ORDER BY t.date DESC, t.id DESC`, userUID, userUID, userUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var settlements []repository.Settlement
+	for rows.Next() {
+		settlement, err := scanSettlement(rows)
+		if err != nil {
+			return nil, err
+		}
+		settlements = append(settlements, settlement)
+	}
+
+	return settlements, rows.Err()
+}
+
+func (r *SplitRepository) DeleteSettlement(ctx context.Context, id int64) error {
+	return execOne(r.db.ExecContext(ctx, `DELETE FROM settlements WHERE id = ?`, id))
+}
+
+func scanSettlement(row scanner) (repository.Settlement, error) {
+	var s repository.Settlement
+	var fromUID, toUID sql.NullString
+	var fromContactID, toContactID sql.NullInt64
+	err := row.Scan(&s.ID, &s.UserUID, &fromUID, &fromContactID, &s.From.Name, &toUID, &toContactID, &s.To.Name,
+		&s.Amount.Amount, &s.Amount.Currency, &s.Date, &s.Description, &s.CreatedAt)
+	if err == sql.ErrNoRows {
+		return repository.Settlement{}, repository.ErrNotFound
+	} else if err != nil {
+		return repository.Settlement{}, err
+	}
+	s.From.UserUID, s.From.ContactID = fromUID.String, fromContactID.Int64
+	s.To.UserUID, s.To.ContactID = toUID.String, toContactID.Int64
+	return s, nil
+}
@@ -0,0 +1,27 @@
+// Package sqlstore implements the repositories on top of database/sql and the schema
+// managed by the sqlite migrations.
+package sqlstore
+
+import (
+	"database/sql"
+	"tbank-go/internal/repository"
+)
+
+// New returns the repositories backed by db.
+func New(db *sql.DB) repository.Repositories {
+	return repository.Repositories{
+		Users:       NewUserRepository(db),
+		Incomes:     NewIncomeRepository(db),
+		Expenses:    NewExpenseRepository(db),
+		Accounts:    NewAccountRepository(db),
+		Transfers:   NewTransferRepository(db),
+		Goals:       NewGoalRepository(db),
+		Contacts:    NewContactRepository(db),
+		Splits:      NewSplitRepository(db),
+		Categories:  NewCategoryRepository(db),
+		Feed:        NewFeedRepository(db),
+		Households:  NewHouseholdRepository(db),
+		Tags:        NewTagRepository(db),
+		Attachments: NewAttachmentRepository(db),
+	}
+}
@@ -0,0 +1,484 @@
+package sqlstore_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/repository/sqlstore"
+	"tbank-go/internal/storage/storagetest"
+	"testing"
+)
+
+// The tests run against SQLite and, with -tags postgres and TEST_POSTGRES_DSN set, PostgreSQL.
+
+func createUser(t *testing.T, repos repository.Repositories, uid string) repository.User {
+	t.Helper()
+	user := repository.User{UID: uid, Username: uid, PasswordHash: "hash", RegisteredAt: "2024-01-01T00:00:00Z"}
+	if err := repos.Users.Create(context.Background(), &user); err != nil {
+		t.Fatalf("create user %s: %v", uid, err)
+	}
+	return user
+}
+
+func defaultAccount(t *testing.T, repos repository.Repositories, uid string) repository.Account {
+	t.Helper()
+	account, err := repos.Accounts.GetDefault(context.Background(), uid)
+	if err != nil {
+		t.Fatalf("default account of %s: %v", uid, err)
+	}
+	return account
+}
+
+func category(t *testing.T, repos repository.Repositories, uid, categoryType, name string) repository.Category {
+	t.Helper()
+	c, err := repos.Categories.FindByName(context.Background(), uid, categoryType, name)
+	if err != nil {
+		t.Fatalf("category %s: %v", name, err)
+	}
+	return c
+}
+
+func TestUsers(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		ctx := context.Background()
+		user := createUser(t, repos, "alice")
+
+		if user.ID == 0 || user.Currency != money.DefaultCurrency {
+			t.Errorf("created user = %+v", user)
+		}
+		if err := repos.Users.Create(ctx, &repository.User{UID: "other", Username: "alice"}); !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Errorf("duplicate username: %v", err)
+		}
+
+		stored, err := repos.Users.GetByUsername(ctx, "alice")
+		if err != nil || stored.UID != "alice" || stored.PasswordHash != "hash" {
+			t.Errorf("GetByUsername = %+v, %v", stored, err)
+		}
+		if _, err := repos.Users.GetByUID(ctx, "missing"); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("missing user: %v", err)
+		}
+
+		account := defaultAccount(t, repos, "alice")
+		if account.Name != repository.DefaultAccountName || !account.IsDefault || account.Balance != money.New(0, "RUB") {
+			t.Errorf("default account = %+v", account)
+		}
+		categories, err := repos.Categories.List(ctx, "alice")
+		if err != nil || len(categories) < len(repository.DefaultCategories) {
+			t.Errorf("%d default categories, %v", len(categories), err)
+		}
+
+		if err := repos.Users.UpdateNames(ctx, "alice", "Alice", "Smith"); err != nil {
+			t.Fatal(err)
+		}
+		if err := repos.Users.UpdatePassword(ctx, "alice", "new-hash"); err != nil {
+			t.Fatal(err)
+		}
+		stored, _ = repos.Users.GetByUID(ctx, "alice")
+		if stored.FirstName != "Alice" || stored.SecondName != "Smith" || stored.PasswordHash != "new-hash" || stored.TokenVersion != 1 {
+			t.Errorf("updated user = %+v", stored)
+		}
+		if err := repos.Users.UpdateNames(ctx, "missing", "", ""); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("UpdateNames of a missing user: %v", err)
+		}
+	})
+}
+
+func TestLoginFailures(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		ctx := context.Background()
+		createUser(t, repos, "alice")
+
+		for want := 1; want <= 3; want++ {
+			got, err := repos.Users.RecordLoginFailure(ctx, "alice")
+			if err != nil || got != want {
+				t.Fatalf("RecordLoginFailure = %d, %v, want %d", got, err, want)
+			}
+		}
+		if err := repos.Users.LockUntil(ctx, "alice", "2030-01-01T00:00:00Z"); err != nil {
+			t.Fatal(err)
+		}
+		user, _ := repos.Users.GetByUID(ctx, "alice")
+		if user.FailedLogins != 3 || user.LockedUntil != "2030-01-01T00:00:00Z" {
+			t.Errorf("locked user = %+v", user)
+		}
+
+		if err := repos.Users.ResetLoginFailures(ctx, "alice"); err != nil {
+			t.Fatal(err)
+		}
+		user, _ = repos.Users.GetByUID(ctx, "alice")
+		if user.FailedLogins != 0 || user.LockedUntil != "" {
+			t.Errorf("reset user = %+v", user)
+		}
+		if _, err := repos.Users.RecordLoginFailure(ctx, "missing"); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("RecordLoginFailure of a missing user: %v", err)
+		}
+	})
+}
+
+func TestTransactionBalances(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		ctx := context.Background()
+		createUser(t, repos, "alice")
+		account := defaultAccount(t, repos, "alice")
+		food := category(t, repos, "alice", repository.CategoryExpense, "Food")
+		salary := category(t, repos, "alice", repository.CategoryIncome, "Salary")
+
+		income := repository.Income{UserUID: "alice", AccountID: account.ID, CategoryID: salary.ID, Category: salary.Name,
+			Amount: money.New(100000, "RUB"), Date: "2024-03-01", Description: "March"}
+		if err := repos.Incomes.Create(ctx, &income); err != nil {
+			t.Fatal(err)
+		}
+		expense := repository.Expense{UserUID: "alice", AccountID: account.ID, CategoryID: food.ID, Category: food.Name,
+			Amount: money.New(25050, "RUB"), Date: "2024-03-02"}
+		if err := repos.Expenses.Create(ctx, &expense); err != nil {
+			t.Fatal(err)
+		}
+		assertBalances(t, repos, "alice", 100000, 25050, 74950)
+
+		expense.Amount = money.New(5000, "RUB")
+		if err := repos.Expenses.Update(ctx, expense); err != nil {
+			t.Fatal(err)
+		}
+		assertBalances(t, repos, "alice", 100000, 5000, 95000)
+
+		stored, err := repos.Expenses.Get(ctx, expense.ID)
+		if err != nil || stored.Amount != expense.Amount || stored.CategoryID != food.ID || stored.Date != "2024-03-02" {
+			t.Errorf("updated expense = %+v, %v", stored, err)
+		}
+
+		if _, err := repos.Incomes.Delete(ctx, income.ID); err != nil {
+			t.Fatal(err)
+		}
+		assertBalances(t, repos, "alice", 0, 5000, -5000)
+		if _, err := repos.Incomes.Get(ctx, income.ID); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("deleted income: %v", err)
+		}
+		if _, err := repos.Incomes.Delete(ctx, income.ID); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("second delete: %v", err)
+		}
+	})
+}
+
+func assertBalances(t *testing.T, repos repository.Repositories, uid string, incomes, expenses, account int64) {
+	t.Helper()
+	user, err := repos.Users.GetByUID(context.Background(), uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.IncomesBalance != incomes || user.ExpensesBalance != expenses {
+		t.Errorf("counters = %d, %d, want %d, %d", user.IncomesBalance, user.ExpensesBalance, incomes, expenses)
+	}
+	if got := defaultAccount(t, repos, uid).Balance.Amount; got != account {
+		t.Errorf("account balance = %d, want %d", got, account)
+	}
+}
+
+func TestFindTransactions(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		ctx := context.Background()
+		createUser(t, repos, "alice")
+		createUser(t, repos, "bob")
+		account := defaultAccount(t, repos, "alice")
+		food := category(t, repos, "alice", repository.CategoryExpense, "Food")
+		taxi := category(t, repos, "alice", repository.CategoryExpense, "Taxi")
+
+		for i, amount := range []int64{300, 100, 500, 200, 400} {
+			c := food
+			if i%2 == 1 {
+				c = taxi
+			}
+			e := repository.Expense{UserUID: "alice", AccountID: account.ID, CategoryID: c.ID, Category: c.Name,
+				Amount: money.New(amount, "RUB"), Date: "2024-03-0" + string(rune('1'+i)), Description: "Lunch " + c.Name}
+			if err := repos.Expenses.Create(ctx, &e); err != nil {
+				t.Fatal(err)
+			}
+		}
+		bobAccount := defaultAccount(t, repos, "bob")
+		if err := repos.Expenses.Create(ctx, &repository.Expense{UserUID: "bob", AccountID: bobAccount.ID,
+			Amount: money.New(999, "RUB"), Date: "2024-03-01"}); err != nil {
+			t.Fatal(err)
+		}
+
+		page, err := repos.Expenses.Find(ctx, repository.TransactionFilter{UserUID: "alice", SortBy: repository.SortByAmount,
+			Descending: true, Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.TotalCount != 5 || len(page.Transactions) != 2 || page.Next == nil ||
+			page.Transactions[0].Amount.Amount != 500 || page.Transactions[1].Amount.Amount != 400 {
+			t.Fatalf("first page = %+v", page)
+		}
+		page, err = repos.Expenses.Find(ctx, repository.TransactionFilter{UserUID: "alice", SortBy: repository.SortByAmount,
+			Descending: true, Limit: 2, After: page.Next})
+		if err != nil || len(page.Transactions) != 2 || page.Transactions[0].Amount.Amount != 300 {
+			t.Fatalf("second page = %+v, %v", page, err)
+		}
+
+		page, err = repos.Expenses.Find(ctx, repository.TransactionFilter{UserUID: "alice", CategoryIDs: []int64{taxi.ID},
+			From: "2024-03-02", To: "2024-03-31", Description: "LUNCH", Limit: 10})
+		if err != nil || page.TotalCount != 2 {
+			t.Fatalf("filtered page = %+v, %v", page, err)
+		}
+		for _, e := range page.Transactions {
+			if e.CategoryID != taxi.ID {
+				t.Errorf("expense of another category: %+v", e)
+			}
+		}
+
+		list, err := repos.Expenses.List(ctx, "alice", "2024-03-02", "2024-03-03")
+		if err != nil || len(list) != 2 || list[0].Date != "2024-03-02" {
+			t.Errorf("List = %+v, %v", list, err)
+		}
+	})
+}
+
+func TestTransfers(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		ctx := context.Background()
+		createUser(t, repos, "alice")
+		main := defaultAccount(t, repos, "alice")
+		savings := repository.Account{UserUID: "alice", Name: "Savings", Type: repository.AccountSavings, Balance: money.New(1000, "USD")}
+		if err := repos.Accounts.Create(ctx, &savings); err != nil {
+			t.Fatal(err)
+		}
+
+		transfer := repository.Transfer{UserUID: "alice", FromAccountID: main.ID, ToAccountID: savings.ID,
+			Amount: money.New(90000, "RUB"), ToAmount: money.New(1000, "USD"), Date: "2024-03-01"}
+		if err := repos.Transfers.Create(ctx, &transfer); err != nil {
+			t.Fatal(err)
+		}
+		assertAccount(t, repos, main.ID, -90000)
+		assertAccount(t, repos, savings.ID, 2000)
+
+		if err := repos.Accounts.Delete(ctx, savings.ID); !errors.Is(err, repository.ErrInUse) {
+			t.Errorf("delete of an account with transfers: %v", err)
+		}
+
+		if err := repos.Transfers.Delete(ctx, transfer.ID); err != nil {
+			t.Fatal(err)
+		}
+		assertAccount(t, repos, main.ID, 0)
+		assertAccount(t, repos, savings.ID, 1000)
+
+		if err := repos.Accounts.SetDefault(ctx, "alice", savings.ID); err != nil {
+			t.Fatal(err)
+		}
+		if got := defaultAccount(t, repos, "alice"); got.ID != savings.ID {
+			t.Errorf("default account = %d, want %d", got.ID, savings.ID)
+		}
+	})
+}
+
+func assertAccount(t *testing.T, repos repository.Repositories, id, want int64) {
+	t.Helper()
+	account, err := repos.Accounts.Get(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Balance.Amount != want {
+		t.Errorf("balance of account %d = %d, want %d", id, account.Balance.Amount, want)
+	}
+}
+
+func TestTagsAndAttachments(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		ctx := context.Background()
+		createUser(t, repos, "alice")
+		account := defaultAccount(t, repos, "alice")
+
+		tags, err := repository.ResolveTags(ctx, repos.Tags, "alice", []string{"Trip", " trip ", "work"})
+		if err != nil || len(tags) != 2 {
+			t.Fatalf("ResolveTags = %+v, %v", tags, err)
+		}
+		if err := repos.Tags.Create(ctx, &repository.Tag{UserUID: "alice", Name: "TRIP"}); !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Errorf("duplicate tag: %v", err)
+		}
+
+		expense := repository.Expense{UserUID: "alice", AccountID: account.ID, Amount: money.New(100, "RUB"), Date: "2024-03-01", Tags: tags[:1]}
+		if err := repos.Expenses.Create(ctx, &expense); err != nil {
+			t.Fatal(err)
+		}
+		if err := repos.Tags.Attach(ctx, repository.EntryExpense, []int64{expense.ID}, []int64{tags[0].ID, tags[1].ID}); err != nil {
+			t.Fatal(err)
+		}
+		stored, _ := repos.Expenses.Get(ctx, expense.ID)
+		if len(stored.Tags) != 2 || stored.Tags[0].Name != "Trip" || stored.Tags[1].Name != "work" {
+			t.Errorf("tags = %+v", stored.Tags)
+		}
+
+		page, err := repos.Expenses.Find(ctx, repository.TransactionFilter{UserUID: "alice", TagIDs: []int64{tags[1].ID}, Limit: 10})
+		if err != nil || page.TotalCount != 1 {
+			t.Errorf("tag filter = %+v, %v", page, err)
+		}
+
+		attachment := repository.Attachment{UserUID: "alice", Kind: repository.EntryExpense, TransactionID: expense.ID,
+			Filename: "receipt.jpg", ContentType: "image/jpeg", Size: 7, Key: "expense/1/a.jpg", ThumbnailKey: "expense/1/a_thumb.jpg"}
+		if err := repos.Attachments.Create(ctx, &attachment); err != nil {
+			t.Fatal(err)
+		}
+		list, err := repos.Attachments.List(ctx, repository.EntryExpense, expense.ID)
+		if err != nil || len(list) != 1 || list[0].Key != attachment.Key {
+			t.Errorf("attachments = %+v, %v", list, err)
+		}
+
+		keys, err := repos.Expenses.Delete(ctx, expense.ID)
+		if err != nil || len(keys) != 2 || keys[0] != attachment.Key || keys[1] != attachment.ThumbnailKey {
+			t.Errorf("keys of the deleted attachments = %v, %v", keys, err)
+		}
+		if _, err := repos.Attachments.Get(ctx, attachment.ID); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("attachment of a deleted expense: %v", err)
+		}
+		var links int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM transaction_tags`).Scan(&links); err != nil || links != 0 {
+			t.Errorf("%d tag links left, %v", links, err)
+		}
+	})
+}
+
+func TestGoals(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		ctx := context.Background()
+		createUser(t, repos, "alice")
+		main := defaultAccount(t, repos, "alice")
+		savings := repository.Account{UserUID: "alice", Name: "Savings", Type: repository.AccountSavings, Balance: money.New(0, "USD")}
+		if err := repos.Accounts.Create(ctx, &savings); err != nil {
+			t.Fatal(err)
+		}
+
+		goal := repository.Goal{UserUID: "alice", Name: "Vacation", Target: money.New(100000, "USD"), AccountID: savings.ID}
+		if err := repos.Goals.Create(ctx, &goal); err != nil {
+			t.Fatal(err)
+		}
+		for _, date := range []string{"2024-01-15", "2024-03-01"} {
+			transfer := repository.Transfer{UserUID: "alice", FromAccountID: main.ID, ToAccountID: savings.ID,
+				Amount: money.New(90000, "RUB"), ToAmount: money.New(1000, "USD"), Date: date, GoalID: goal.ID}
+			if err := repos.Transfers.Create(ctx, &transfer); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		stored, err := repos.Goals.Get(ctx, goal.ID)
+		if err != nil || stored.Saved != money.New(2000, "USD") || stored.AccountID != savings.ID || stored.Deadline != "" {
+			t.Errorf("goal = %+v, %v", stored, err)
+		}
+		if recent, err := repos.Goals.Contributed(ctx, goal.ID, "2024-02-01", "2024-03-31"); err != nil || recent != 1000 {
+			t.Errorf("Contributed = %d, %v, want 1000", recent, err)
+		}
+		contributions, err := repos.Goals.Contributions(ctx, goal.ID)
+		if err != nil || len(contributions) != 2 || contributions[0].Date != "2024-01-15" {
+			t.Errorf("contributions = %+v, %v", contributions, err)
+		}
+
+		stored.Name, stored.Deadline = "Trip", "2025-06-01"
+		if err := repos.Goals.Update(ctx, stored); err != nil {
+			t.Fatal(err)
+		}
+		list, err := repos.Goals.List(ctx, "alice")
+		if err != nil || len(list) != 1 || list[0].Name != "Trip" || list[0].Deadline != "2025-06-01" {
+			t.Errorf("goals = %+v, %v", list, err)
+		}
+
+		if err := repos.Goals.Delete(ctx, goal.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repos.Goals.Get(ctx, goal.ID); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("deleted goal: %v", err)
+		}
+		transfers, _ := repos.Transfers.List(ctx, "alice", "2024-01-01", "2024-12-31")
+		if len(transfers) != 2 || transfers[0].GoalID != 0 {
+			t.Errorf("transfers after goal delete = %+v", transfers)
+		}
+		if err := repos.Goals.Delete(ctx, goal.ID); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("second delete: %v", err)
+		}
+	})
+}
+
+func TestSplits(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		ctx := context.Background()
+		createUser(t, repos, "alice")
+		createUser(t, repos, "bob")
+		createUser(t, repos, "carol")
+
+		anna := repository.Contact{UserUID: "alice", Name: "Anna"}
+		if err := repos.Contacts.Create(ctx, &anna); err != nil {
+			t.Fatal(err)
+		}
+		if err := repos.Contacts.Create(ctx, &repository.Contact{UserUID: "alice", Name: "Anna"}); !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Errorf("duplicate contact: %v", err)
+		}
+
+		account := defaultAccount(t, repos, "alice")
+		expense := repository.Expense{UserUID: "alice", AccountID: account.ID, Amount: money.New(3000, "RUB"), Date: "2024-03-01"}
+		if err := repos.Expenses.Create(ctx, &expense); err != nil {
+			t.Fatal(err)
+		}
+		split := repository.Split{UserUID: "alice", ExpenseID: expense.ID, Payer: repository.SplitParty{UserUID: "alice"},
+			Description: "Dinner", Amount: money.New(3000, "RUB"), Method: "equal", Date: "2024-03-01",
+			Shares: []repository.SplitShare{
+				{SplitParty: repository.SplitParty{UserUID: "bob"}, Amount: money.New(1500, "RUB")},
+				{SplitParty: repository.SplitParty{ContactID: anna.ID}, Amount: money.New(1500, "RUB")},
+			}}
+		if err := repos.Splits.Create(ctx, &split); err != nil {
+			t.Fatal(err)
+		}
+		again := split
+		if err := repos.Splits.Create(ctx, &again); !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Errorf("second split of the expense: %v", err)
+		}
+
+		stored, err := repos.Splits.Get(ctx, split.ID)
+		if err != nil || stored.Payer.Name != "alice" || len(stored.Shares) != 2 || stored.Shares[0].Name != "bob" ||
+			stored.Shares[1].Name != "Anna" || stored.Shares[1].Amount != money.New(1500, "RUB") {
+			t.Errorf("split = %+v, %v", stored, err)
+		}
+		for uid, want := range map[string]int{"alice": 1, "bob": 1, "carol": 0} {
+			if list, err := repos.Splits.List(ctx, uid); err != nil || len(list) != want {
+				t.Errorf("splits of %s = %+v, %v", uid, list, err)
+			}
+		}
+
+		settlement := repository.Settlement{UserUID: "alice", From: repository.SplitParty{ContactID: anna.ID},
+			To: repository.SplitParty{UserUID: "alice"}, Amount: money.New(1500, "RUB"), Date: "2024-03-02"}
+		if err := repos.Splits.CreateSettlement(ctx, &settlement); err != nil {
+			t.Fatal(err)
+		}
+		if err := repos.Contacts.Rename(ctx, anna.ID, "Ann"); err != nil {
+			t.Fatal(err)
+		}
+		settlements, err := repos.Splits.Settlements(ctx, "alice")
+		if err != nil || len(settlements) != 1 || settlements[0].From.Name != "Ann" || settlements[0].To.Name != "alice" {
+			t.Errorf("settlements = %+v, %v", settlements, err)
+		}
+		if list, err := repos.Splits.Settlements(ctx, "bob"); err != nil || len(list) != 0 {
+			t.Errorf("settlements of bob = %+v, %v", list, err)
+		}
+
+		if err := repos.Contacts.Delete(ctx, anna.ID); !errors.Is(err, repository.ErrInUse) {
+			t.Errorf("delete of a contact in a split: %v", err)
+		}
+		if err := repos.Splits.DeleteSettlement(ctx, settlement.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := repos.Splits.Delete(ctx, split.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repos.Splits.Get(ctx, split.ID); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("deleted split: %v", err)
+		}
+		if err := repos.Contacts.Delete(ctx, anna.ID); err != nil {
+			t.Errorf("delete of an unused contact: %v", err)
+		}
+	})
+}
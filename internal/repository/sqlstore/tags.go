@@ -0,0 +1,198 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"tbank-go/internal/repository"
+	"time"
+)
+
+// TagRepository stores tags in the tags table and their links in transaction_tags.
+type TagRepository struct {
+	db *sql.DB
+}
+
+// NewTagRepository returns a TagRepository backed by db.
+func NewTagRepository(db *sql.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+const tagColumns = `id, user_uid, name, color, created_at`
+
+func (r *TagRepository) Create(ctx context.Context, tag *repository.Tag) error {
+	if tag.CreatedAt == "" {
+		tag.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO tags (user_uid, name, name_key, color, created_at) VALUES (?, ?, ?, ?, ?) RETURNING id`,
+		tag.UserUID, tag.Name, repository.TagKey(tag.Name), tag.Color, tag.CreatedAt,
+	).Scan(&tag.ID)
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "unique") {
+		return repository.ErrAlreadyExists
+	}
+	return err
+}
+
+func (r *TagRepository) Get(ctx context.Context, id int64) (repository.Tag, error) {
+	return scanTag(r.db.QueryRowContext(ctx, `SELECT `+tagColumns+` FROM tags WHERE id = ?`, id))
+}
+
+func (r *TagRepository) FindByName(ctx context.Context, userUID, name string) (repository.Tag, error) {
+	return scanTag(r.db.QueryRowContext(ctx, `SELECT `+tagColumns+` FROM tags WHERE user_uid = ? AND name_key = ?`,
+		userUID, repository.TagKey(name)))
+}
+
+func (r *TagRepository) List(ctx context.Context, userUID string) ([]repository.Tag, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+tagColumns+` FROM tags WHERE user_uid = ? ORDER BY name_key`, userUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []repository.Tag
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+func (r *TagRepository) Update(ctx context.Context, tag repository.Tag) error {
+	err := execOne(r.db.ExecContext(ctx, `UPDATE tags SET name = ?, name_key = ?, color = ? WHERE id = ?`,
+		tag.Name, repository.TagKey(tag.Name), tag.Color, tag.ID))
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "unique") {
+		return repository.ErrAlreadyExists
+	}
+	return err
+}
+
+func (r *TagRepository) Delete(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM transaction_tags WHERE tag_id = ?`, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = execOne(tx.ExecContext(ctx, `DELETE FROM tags WHERE id = ?`, id))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TagRepository) Attach(ctx context.Context, kind string, transactionIDs, tagIDs []int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := attachTags(ctx, tx, kind, transactionIDs, tagIDs); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TagRepository) Detach(ctx context.Context, kind string, transactionIDs, tagIDs []int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, transactionID := range transactionIDs {
+		for _, tagID := range tagIDs {
+			_, err := tx.ExecContext(ctx, `DELETE FROM transaction_tags WHERE kind = ? AND transaction_id = ? AND tag_id = ?`,
+				kind, transactionID, tagID)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// attachTags links every tag to every transaction of the kind inside tx, skipping existing links.
+func attachTags(ctx context.Context, tx *sql.Tx, kind string, transactionIDs, tagIDs []int64) error {
+	for _, transactionID := range transactionIDs {
+		for _, tagID := range tagIDs {
+			_, err := tx.ExecContext(ctx, `INSERT INTO transaction_tags (kind, transaction_id, tag_id) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`,
+				kind, transactionID, tagID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// tagBatch bounds the number of IDs in one IN list of tagsOf.
+const tagBatch = 500
+
+// tagsOf returns the tags of the transactions of the kind with the given IDs, ordered by name.
+func tagsOf(ctx context.Context, db *sql.DB, kind string, ids []int64) (map[int64][]repository.Tag, error) {
+	tags := make(map[int64][]repository.Tag)
+	for start := 0; start < len(ids); start += tagBatch {
+		batch := ids[start:min(start+tagBatch, len(ids))]
+		args := []any{kind}
+		for _, id := range batch {
+			args = append(args, id)
+		}
+
+		rows, err := db.QueryContext(ctx, `
+			SELECT l.transaction_id, t.id, t.user_uid, t.name, t.color, t.created_at
+			FROM transaction_tags l JOIN tags t ON t.id = l.tag_id
+			WHERE l.kind = ? AND l.transaction_id IN (?`+strings.Repeat(", ?", len(batch)-1)+`)
+			ORDER BY t.name_key, t.id`, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var transactionID int64
+			var tag repository.Tag
+			if err := rows.Scan(&transactionID, &tag.ID, &tag.UserUID, &tag.Name, &tag.Color, &tag.CreatedAt); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			tags[transactionID] = append(tags[transactionID], tag)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+// tagCondition returns the WHERE condition matching transactions with any of the tags. kind is
+// the SQL expression of the transaction kind; the ID column of the transaction is id.
+func tagCondition(kind string, tagIDs []int64) (string, []any) {
+	args := make([]any, 0, len(tagIDs))
+	for _, id := range tagIDs {
+		args = append(args, id)
+	}
+	return `id IN (SELECT transaction_id FROM transaction_tags WHERE kind = ` + kind +
+		` AND tag_id IN (?` + strings.Repeat(", ?", len(tagIDs)-1) + `))`, args
+}
+
+func scanTag(row scanner) (repository.Tag, error) {
+	var tag repository.Tag
+	err := row.Scan(&tag.ID, &tag.UserUID, &tag.Name, &tag.Color, &tag.CreatedAt)
+	if err == sql.ErrNoRows {
+		return repository.Tag{}, repository.ErrNotFound
+	}
+	return tag, err
+}
@@ -0,0 +1,377 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+)
+
+// transactionStore implements both the income and the expense repository; they only
+// differ in the table, the user balance counter they maintain and the direction in which
+// they move the account balance.
+type transactionStore struct {
+	db            *sql.DB
+	table         string
+	kind          string // repository.EntryIncome or repository.EntryExpense, the kind of tag links
+	balanceColumn string
+	accountSign   int64 // +1 for incomes, -1 for expenses
+	// detach holds the statements run with the ID of a deleted transaction to unlink the rows
+	// of other tables referring to it; SQLite does not enforce the foreign keys.
+	detach []string
+}
+
+// IncomeRepository stores incomes in the income table.
+type IncomeRepository struct {
+	transactionStore
+}
+
+// NewIncomeRepository returns an IncomeRepository backed by db.
+func NewIncomeRepository(db *sql.DB) *IncomeRepository {
+	return &IncomeRepository{transactionStore{db: db, table: "income", kind: repository.EntryIncome, balanceColumn: "incomes_balance", accountSign: 1,
+		detach: []string{
+			`DELETE FROM transaction_tags WHERE kind = 'income' AND transaction_id = ?`,
+		}}}
+}
+
+// ExpenseRepository stores expenses in the expenses table.
+type ExpenseRepository struct {
+	transactionStore
+}
+
+// NewExpenseRepository returns an ExpenseRepository backed by db.
+func NewExpenseRepository(db *sql.DB) *ExpenseRepository {
+	return &ExpenseRepository{transactionStore{db: db, table: "expenses", kind: repository.EntryExpense, balanceColumn: "expenses_balance", accountSign: -1,
+		detach: []string{
+			`UPDATE splits SET expense_id = NULL WHERE expense_id = ?`,
+			`DELETE FROM transaction_tags WHERE kind = 'expense' AND transaction_id = ?`,
+		}}}
+}
+
+func (s transactionStore) Create(ctx context.Context, t *repository.Transaction) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := s.CreateTx(ctx, tx, t); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateTx is Create within a database transaction of the caller, for code that stores the
+// transaction together with rows of its own, such as the recurring scheduler and the statement
+// import. The caller commits or rolls back tx.
+func (s transactionStore) CreateTx(ctx context.Context, tx *sql.Tx, t *repository.Transaction) error {
+	query := `INSERT INTO ` + s.table + ` (user_uid, account_id, category_id, category, amount, currency, date, description, household_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`
+	err := tx.QueryRowContext(ctx, query, t.UserUID, nullID(t.AccountID), nullID(t.CategoryID), t.Category, t.Amount.Amount, t.Amount.Currency, t.Date, t.Description, nullID(t.HouseholdID)).Scan(&t.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.apply(ctx, tx, t.UserUID, t.AccountID, t.Amount, 1); err != nil {
+		return err
+	}
+
+	tagIDs := make([]int64, 0, len(t.Tags))
+	for _, tag := range t.Tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+	return attachTags(ctx, tx, s.kind, []int64{t.ID}, tagIDs)
+}
+
+func (s transactionStore) Get(ctx context.Context, id int64) (repository.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM ` + s.table + ` WHERE id = ?`
+	t, err := scanTransaction(s.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return t, err
+	}
+	transactions := []repository.Transaction{t}
+	err = s.fillTags(ctx, transactions)
+	return transactions[0], err
+}
+
+func (s transactionStore) List(ctx context.Context, userUID, from, to string) ([]repository.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM ` + s.table + `
+	          WHERE user_uid = ? AND date BETWEEN ? AND ? ORDER BY date, id`
+	rows, err := s.db.QueryContext(ctx, query, userUID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []repository.Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return transactions, s.fillTags(ctx, transactions)
+}
+
+func (s transactionStore) Find(ctx context.Context, filter repository.TransactionFilter) (repository.TransactionPage, error) {
+	where, args := transactionConditions(filter, `'`+s.kind+`'`)
+
+	var page repository.TransactionPage
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+s.table+` WHERE `+strings.Join(where, " AND "), args...).Scan(&page.TotalCount)
+	if err != nil {
+		return page, err
+	}
+
+	column, cmp, order := "date", ">", "ASC"
+	if filter.SortBy == repository.SortByAmount {
+		column = "amount"
+	}
+	if filter.Descending {
+		cmp, order = "<", "DESC"
+	}
+	if c := filter.After; c != nil {
+		var value any = c.Date
+		if column == "amount" {
+			value = c.Amount
+		}
+		where = append(where, `(`+column+` `+cmp+` ? OR (`+column+` = ? AND id `+cmp+` ?))`)
+		args = append(args, value, value, c.ID)
+	}
+
+	// One extra row tells whether another page follows.
+	query := `SELECT ` + transactionColumns + ` FROM ` + s.table + ` WHERE ` + strings.Join(where, " AND ") +
+		` ORDER BY ` + column + ` ` + order + `, id ` + order + ` LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, query, append(args, filter.Limit+1)...)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return page, err
+		}
+		page.Transactions = append(page.Transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return page, err
+	}
+
+	if len(page.Transactions) > filter.Limit {
+		page.Transactions = page.Transactions[:filter.Limit]
+		last := page.Transactions[filter.Limit-1]
+		page.Next = &repository.Cursor{Date: last.Date, Amount: last.Amount.Amount, ID: last.ID}
+	}
+	return page, s.fillTags(ctx, page.Transactions)
+}
+
+// fillTags sets the Tags of the transactions.
+func (s transactionStore) fillTags(ctx context.Context, transactions []repository.Transaction) error {
+	ids := make([]int64, 0, len(transactions))
+	for _, t := range transactions {
+		ids = append(ids, t.ID)
+	}
+	tags, err := tagsOf(ctx, s.db, s.kind, ids)
+	if err != nil {
+		return err
+	}
+	for i := range transactions {
+		transactions[i].Tags = tags[transactions[i].ID]
+	}
+	return nil
+}
+
+// transactionConditions translates the filter, except for the cursor, into WHERE conditions.
+// kind is the SQL expression of the transaction kind the tag filter compares with.
+func transactionConditions(filter repository.TransactionFilter, kind string) ([]string, []any) {
+	where := []string{"user_uid = ?"}
+	args := []any{filter.UserUID}
+	if filter.HouseholdID != 0 {
+		where[0], args[0] = "household_id = ?", filter.HouseholdID
+	}
+	if filter.From != "" {
+		where = append(where, "date >= ?")
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		where = append(where, "date <= ?")
+		args = append(args, filter.To)
+	}
+	if filter.AccountID != 0 {
+		where = append(where, "account_id = ?")
+		args = append(args, filter.AccountID)
+	}
+	if len(filter.CategoryIDs) > 0 {
+		where = append(where, "category_id IN (?"+strings.Repeat(", ?", len(filter.CategoryIDs)-1)+")")
+		for _, id := range filter.CategoryIDs {
+			args = append(args, id)
+		}
+	}
+	if len(filter.TagIDs) > 0 {
+		condition, tagArgs := tagCondition(kind, filter.TagIDs)
+		where = append(where, condition)
+		args = append(args, tagArgs...)
+	}
+	if filter.Currency != "" {
+		where = append(where, "currency = ?")
+		args = append(args, filter.Currency)
+	}
+	if filter.MinAmount != nil {
+		where = append(where, "amount >= ?")
+		args = append(args, *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		where = append(where, "amount <= ?")
+		args = append(args, *filter.MaxAmount)
+	}
+	if filter.Description != "" {
+		pattern := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(filter.Description)
+		where = append(where, `LOWER(description) LIKE LOWER(?) ESCAPE '\'`)
+		args = append(args, "%"+pattern+"%")
+	}
+	return where, args
+}
+
+func (s transactionStore) Update(ctx context.Context, t repository.Transaction) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	old, err := scanTransaction(tx.QueryRowContext(ctx, `SELECT `+transactionColumns+` FROM `+s.table+` WHERE id = ?`, t.ID))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query := `UPDATE ` + s.table + ` SET account_id = ?, category_id = ?, category = ?, amount = ?, currency = ?, date = ?, description = ?, household_id = ? WHERE id = ?`
+	_, err = tx.ExecContext(ctx, query, nullID(t.AccountID), nullID(t.CategoryID), t.Category, t.Amount.Amount, t.Amount.Currency, t.Date, t.Description, nullID(t.HouseholdID), t.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if old.AccountID != t.AccountID || old.Amount != t.Amount {
+		if err := s.apply(ctx, tx, old.UserUID, old.AccountID, old.Amount, -1); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := s.apply(ctx, tx, t.UserUID, t.AccountID, t.Amount, 1); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s transactionStore) Delete(ctx context.Context, id int64) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	old, err := scanTransaction(tx.QueryRowContext(ctx, `DELETE FROM `+s.table+` WHERE id = ? RETURNING `+transactionColumns, id))
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := s.apply(ctx, tx, old.UserUID, old.AccountID, old.Amount, -1); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	for _, query := range s.detach {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	keys, err := deleteAttachments(ctx, tx, s.kind, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return keys, tx.Commit()
+}
+
+// deleteAttachments deletes the attachment records of a transaction and returns the keys of
+// their blobs. Reading the keys from the deleted rows covers an attachment uploaded while the
+// transaction was being deleted, which a listing made beforehand would miss.
+func deleteAttachments(ctx context.Context, tx *sql.Tx, kind string, transactionID int64) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `DELETE FROM attachments WHERE kind = ? AND transaction_id = ? RETURNING blob_key, thumbnail_key`, kind, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key, thumbnailKey string
+		if err := rows.Scan(&key, &thumbnailKey); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		if thumbnailKey != "" {
+			keys = append(keys, thumbnailKey)
+		}
+	}
+	return keys, rows.Err()
+}
+
+// apply adds (factor 1) or removes (factor -1) the effect of a transaction on the balance of
+// its account and on the user's counter. The counter only covers the user's own currency.
+func (s transactionStore) apply(ctx context.Context, tx *sql.Tx, userUID string, accountID int64, amount money.Money, factor int64) error {
+	query := `UPDATE users SET ` + s.balanceColumn + ` = ` + s.balanceColumn + ` + ? WHERE uid = ? AND currency = ?`
+	_, err := tx.ExecContext(ctx, query, factor*amount.Amount, userUID, amount.Currency)
+	if err != nil {
+		return err
+	}
+	if accountID == 0 {
+		return nil
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE accounts SET balance = balance + ? WHERE id = ?`, s.accountSign*factor*amount.Amount, accountID)
+	return err
+}
+
+// nullID stores a zero ID as NULL.
+func nullID(id int64) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+const transactionColumns = `id, user_uid, account_id, category_id, category, amount, currency, date, description, household_id`
+
+// scanner is implemented by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTransaction(row scanner) (repository.Transaction, error) {
+	var t repository.Transaction
+	var accountID, categoryID, householdID sql.NullInt64
+	var description sql.NullString
+	err := row.Scan(&t.ID, &t.UserUID, &accountID, &categoryID, &t.Category, &t.Amount.Amount, &t.Amount.Currency, &t.Date, &description, &householdID)
+	if err == sql.ErrNoRows {
+		return repository.Transaction{}, repository.ErrNotFound
+	} else if err != nil {
+		return repository.Transaction{}, err
+	}
+	t.AccountID = accountID.Int64
+	t.CategoryID = categoryID.Int64
+	t.Description = description.String
+	t.HouseholdID = householdID.Int64
+	return t, nil
+}
@@ -0,0 +1,149 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"time"
+)
+
+// UserRepository stores users in the users table.
+type UserRepository struct {
+	db *sql.DB
+}
+
+// NewUserRepository returns a UserRepository backed by db.
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+const userColumns = `id, uid, username, password, first_name, second_name, registered_at, currency, incomes_balance, expenses_balance, failed_logins, locked_until, token_version`
+
+func (r *UserRepository) Create(ctx context.Context, user *repository.User) error {
+	if user.Currency == "" {
+		user.Currency = money.DefaultCurrency
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO users (uid, username, password, registered_at, first_name, second_name, incomes_balance, expenses_balance, currency)
+		 VALUES (?, ?, ?, ?, ?, ?, 0, 0, ?) RETURNING id`,
+		user.UID, user.Username, user.PasswordHash, user.RegisteredAt, user.FirstName, user.SecondName, user.Currency,
+	).Scan(&user.ID)
+	if err != nil {
+		tx.Rollback()
+		if strings.Contains(strings.ToLower(err.Error()), "unique") {
+			return repository.ErrAlreadyExists
+		}
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO accounts (user_uid, name, type, currency, balance, is_default, created_at) VALUES (?, ?, ?, ?, 0, 1, ?)`,
+		user.UID, repository.DefaultAccountName, repository.AccountDebitCard, user.Currency, time.Now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := seedCategories(ctx, tx, user.UID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *UserRepository) GetByUID(ctx context.Context, uid string) (repository.User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE uid = ?`, uid))
+}
+
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (repository.User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE username = ?`, username))
+}
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, uid, passwordHash string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = execOne(tx.ExecContext(ctx,
+		`UPDATE users SET password = ?, token_version = token_version + 1 WHERE uid = ?`, passwordHash, uid))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked = 1 WHERE user_uid = ?`, uid)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *UserRepository) UpdateNames(ctx context.Context, uid, firstName, secondName string) error {
+	return execOne(r.db.ExecContext(ctx, `UPDATE users SET first_name = ?, second_name = ? WHERE uid = ?`, firstName, secondName, uid))
+}
+
+func (r *UserRepository) RecordLoginFailure(ctx context.Context, uid string) (int, error) {
+	var failures int
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE users SET failed_logins = failed_logins + 1 WHERE uid = ? RETURNING failed_logins`, uid,
+	).Scan(&failures)
+	if err == sql.ErrNoRows {
+		return 0, repository.ErrNotFound
+	}
+	return failures, err
+}
+
+func (r *UserRepository) LockUntil(ctx context.Context, uid, until string) error {
+	return execOne(r.db.ExecContext(ctx, `UPDATE users SET locked_until = ? WHERE uid = ?`, until, uid))
+}
+
+func (r *UserRepository) ResetLoginFailures(ctx context.Context, uid string) error {
+	return execOne(r.db.ExecContext(ctx, `UPDATE users SET failed_logins = 0, locked_until = NULL WHERE uid = ?`, uid))
+}
+
+func scanUser(row *sql.Row) (repository.User, error) {
+	var user repository.User
+	var firstName, secondName, lockedUntil sql.NullString
+	var incomes, expenses sql.NullInt64
+	err := row.Scan(&user.ID, &user.UID, &user.Username, &user.PasswordHash, &firstName, &secondName,
+		&user.RegisteredAt, &user.Currency, &incomes, &expenses, &user.FailedLogins, &lockedUntil, &user.TokenVersion)
+	if err == sql.ErrNoRows {
+		return repository.User{}, repository.ErrNotFound
+	} else if err != nil {
+		return repository.User{}, err
+	}
+	user.FirstName = firstName.String
+	user.SecondName = secondName.String
+	user.IncomesBalance = incomes.Int64
+	user.ExpensesBalance = expenses.Int64
+	user.LockedUntil = lockedUntil.String
+	return user, nil
+}
+
+// execOne turns an update that matched no rows into ErrNotFound.
+func execOne(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
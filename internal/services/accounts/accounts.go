@@ -0,0 +1,348 @@
+package accounts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+)
+
+// Account is a wallet of the user: cash, a card or a savings account.
+type Account struct {
+	ID        int64       `json:"id"`
+	Name      string      `json:"name"`
+	Type      string      `json:"type"`
+	Balance   money.Money `json:"balance"`
+	IsDefault bool        `json:"is_default"`
+	CreatedAt string      `json:"created_at"`
+}
+
+func newAccount(account repository.Account) Account {
+	return Account{
+		ID:        account.ID,
+		Name:      account.Name,
+		Type:      account.Type,
+		Balance:   account.Balance,
+		IsDefault: account.IsDefault,
+		CreatedAt: account.CreatedAt,
+	}
+}
+
+// CreateAccountRequest is the body of the create endpoint.
+type CreateAccountRequest struct {
+	Name           string       `json:"name" example:"Tinkoff Black"`
+	Type           string       `json:"type" example:"debit_card"`
+	Currency       string       `json:"currency,omitempty" example:"RUB"`
+	InitialBalance *money.Money `json:"initial_balance,omitempty" swaggertype:"string" example:"1000.00"`
+}
+
+// PatchAccountRequest holds the fields of an account to change. Omitted fields are kept.
+// The currency and the balance cannot be changed; move money with a transfer instead.
+type PatchAccountRequest struct {
+	Name      *string `json:"name,omitempty"`
+	Type      *string `json:"type,omitempty"`
+	IsDefault *bool   `json:"is_default,omitempty" example:"true"`
+}
+
+func validType(accountType string) bool {
+	switch accountType {
+	case repository.AccountCash, repository.AccountDebitCard, repository.AccountCreditCard, repository.AccountSavings:
+		return true
+	}
+	return false
+}
+
+// validate normalizes the request and returns a user-facing message when it is invalid.
+func (req *CreateAccountRequest) validate(defaultCurrency string) string {
+	if req.Name == "" {
+		return "name is required"
+	}
+	if !validType(req.Type) {
+		return "type must be cash, debit_card, credit_card or savings"
+	}
+
+	if req.Currency == "" {
+		req.Currency = defaultCurrency
+	}
+	if !money.ValidCurrency(req.Currency) {
+		return fmt.Sprintf("Unsupported currency %q", req.Currency)
+	}
+
+	if req.InitialBalance == nil {
+		zero := money.New(0, req.Currency)
+		req.InitialBalance = &zero
+	}
+	balance, err := req.InitialBalance.OrDefault(req.Currency)
+	if err != nil || balance.Currency != req.Currency {
+		return fmt.Sprintf("initial_balance must be a %s value", req.Currency)
+	}
+	req.InitialBalance = &balance
+
+	return ""
+}
+
+// CreateAccountHandler creates an account
+// @Summary Create Account
+// @Description Creates an account (cash, debit_card, credit_card or savings) with its own currency and an optional opening balance.
+// @Tags Accounts
+// @Accept json
+// @Produce json
+// @Param account body accounts.CreateAccountRequest true "Account details"
+// @Security BearerAuth
+// @Success 201 {object} accounts.Account "Created account"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 500 {string} string "Failed to create account"
+// @Router /api/accounts [post]
+func CreateAccountHandler(users repository.UserRepository, accounts repository.AccountRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		var req CreateAccountRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for account", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		user, err := users.GetByUID(r.Context(), userUID)
+		if err != nil {
+			log.Error("failed to fetch user currency", slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if msg := req.validate(user.Currency); msg != "" {
+			log.Error("invalid account", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		account := repository.Account{
+			UserUID: userUID,
+			Name:    req.Name,
+			Type:    req.Type,
+			Balance: *req.InitialBalance,
+		}
+		if err := accounts.Create(r.Context(), &account); err != nil {
+			log.Error("failed to create account", slog.Any("error", err))
+			http.Error(w, "Failed to create account", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("account created successfully", slog.Int64("accountID", account.ID), slog.String("userUID", userUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(newAccount(account))
+	}
+}
+
+// GetAccountsHandler lists the user's accounts
+// @Summary List Accounts
+// @Description Returns all accounts of the authenticated user with their balances, the default account first.
+// @Tags Accounts
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} accounts.Account "Accounts"
+// @Failure 500 {string} string "Failed to fetch accounts"
+// @Router /api/accounts [get]
+func GetAccountsHandler(accounts repository.AccountRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		records, err := accounts.List(r.Context(), userUID)
+		if err != nil {
+			log.Error("failed to fetch accounts", slog.Any("error", err))
+			http.Error(w, "Failed to fetch accounts", http.StatusInternalServerError)
+			return
+		}
+
+		list := make([]Account, 0, len(records))
+		for _, account := range records {
+			list = append(list, newAccount(account))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// GetAccountHandler returns one account
+// @Summary Get Account
+// @Description Returns an account owned by the authenticated user.
+// @Tags Accounts
+// @Produce json
+// @Param id path int true "Account ID"
+// @Security BearerAuth
+// @Success 200 {object} accounts.Account "Account"
+// @Failure 400 {string} string "Invalid account ID"
+// @Failure 403 {string} string "Unauthorized to access this account"
+// @Failure 404 {string} string "Account not found"
+// @Failure 500 {string} string "Failed to fetch account"
+// @Router /api/accounts/{id} [get]
+func GetAccountHandler(accounts repository.AccountRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, ok := loadOwnedAccount(accounts, w, r, log)
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(newAccount(account))
+	}
+}
+
+// PatchAccountHandler renames an account, changes its type or makes it the default one
+// @Summary Patch Account
+// @Description Changes the name or type of an account, or makes it the default account for incomes and expenses without an account.
+// @Tags Accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param account body accounts.PatchAccountRequest true "Fields to change"
+// @Security BearerAuth
+// @Success 200 {object} accounts.Account "Updated account"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Unauthorized to access this account"
+// @Failure 404 {string} string "Account not found"
+// @Failure 500 {string} string "Failed to update account"
+// @Router /api/accounts/{id} [patch]
+func PatchAccountHandler(accounts repository.AccountRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, ok := loadOwnedAccount(accounts, w, r, log)
+		if !ok {
+			return
+		}
+
+		var req PatchAccountRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for account update", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		if req.Name != nil {
+			if *req.Name == "" {
+				http.Error(w, "name must not be empty", http.StatusBadRequest)
+				return
+			}
+			account.Name = *req.Name
+		}
+		if req.Type != nil {
+			if !validType(*req.Type) {
+				http.Error(w, "type must be cash, debit_card, credit_card or savings", http.StatusBadRequest)
+				return
+			}
+			account.Type = *req.Type
+		}
+		if req.IsDefault != nil && !*req.IsDefault && account.IsDefault {
+			http.Error(w, "Make another account the default one instead", http.StatusBadRequest)
+			return
+		}
+
+		if err := accounts.Update(r.Context(), account); err != nil {
+			log.Error("failed to update account", slog.Int64("accountID", account.ID), slog.Any("error", err))
+			http.Error(w, "Failed to update account", http.StatusInternalServerError)
+			return
+		}
+
+		if req.IsDefault != nil && *req.IsDefault && !account.IsDefault {
+			if err := accounts.SetDefault(r.Context(), account.UserUID, account.ID); err != nil {
+				log.Error("failed to set default account", slog.Int64("accountID", account.ID), slog.Any("error", err))
+				http.Error(w, "Failed to update account", http.StatusInternalServerError)
+				return
+			}
+			account.IsDefault = true
+		}
+
+		log.Info("account updated successfully", slog.Int64("accountID", account.ID), slog.String("userUID", account.UserUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(newAccount(account))
+	}
+}
+
+// DeleteAccountHandler deletes an account
+// @Summary Delete Account
+// @Description Deletes an account. The default account and accounts with incomes, expenses or transfers cannot be deleted.
+// @Tags Accounts
+// @Produce json
+// @Param id path int true "Account ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Success message"
+// @Failure 400 {string} string "Invalid account ID"
+// @Failure 403 {string} string "Unauthorized to access this account"
+// @Failure 404 {string} string "Account not found"
+// @Failure 409 {string} string "Account is in use"
+// @Failure 500 {string} string "Failed to delete account"
+// @Router /api/accounts/{id} [delete]
+func DeleteAccountHandler(accounts repository.AccountRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, ok := loadOwnedAccount(accounts, w, r, log)
+		if !ok {
+			return
+		}
+
+		if account.IsDefault {
+			http.Error(w, "The default account cannot be deleted", http.StatusConflict)
+			return
+		}
+
+		err := accounts.Delete(r.Context(), account.ID)
+		if errors.Is(err, repository.ErrInUse) {
+			http.Error(w, "Account has incomes, expenses or transfers", http.StatusConflict)
+			return
+		} else if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Account not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to delete account", slog.Int64("accountID", account.ID), slog.Any("error", err))
+			http.Error(w, "Failed to delete account", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("account deleted successfully", slog.Int64("accountID", account.ID), slog.String("userUID", account.UserUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Account deleted successfully"}`))
+	}
+}
+
+// loadOwnedAccount fetches the account from the URL and checks that it belongs to the user.
+// On failure the response has already been written.
+func loadOwnedAccount(accounts repository.AccountRepository, w http.ResponseWriter, r *http.Request, log *slog.Logger) (repository.Account, bool) {
+	accountID := chi.URLParam(r, "id")
+	userUID := r.Context().Value("userUID").(string)
+
+	id, err := strconv.ParseInt(accountID, 10, 64)
+	if err != nil {
+		log.Warn("invalid account ID parameter", slog.String("accountID", accountID))
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return repository.Account{}, false
+	}
+
+	account, err := accounts.Get(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Warn("account not found", slog.String("accountID", accountID))
+		http.Error(w, "Account not found", http.StatusNotFound)
+		return repository.Account{}, false
+	} else if err != nil {
+		log.Error("failed to fetch account", slog.Any("error", err))
+		http.Error(w, "Failed to fetch account", http.StatusInternalServerError)
+		return repository.Account{}, false
+	}
+
+	if account.UserUID != userUID {
+		log.Warn("unauthorized attempt to access account", slog.String("userUID", userUID), slog.String("ownerUID", account.UserUID))
+		http.Error(w, "Unauthorized to access this account", http.StatusForbidden)
+		return repository.Account{}, false
+	}
+
+	return account, true
+}
@@ -0,0 +1,270 @@
+package accounts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Transfer moves money between two accounts of the user.
+type Transfer struct {
+	ID            int64       `json:"id"`
+	FromAccountID int64       `json:"from_account_id"`
+	ToAccountID   int64       `json:"to_account_id"`
+	Amount        money.Money `json:"amount"`
+	ToAmount      money.Money `json:"to_amount"`
+	Date          string      `json:"date"`
+	Description   string      `json:"description"`
+	GoalID        int64       `json:"goal_id,omitempty"` // the savings goal the transfer contributes to
+}
+
+func newTransfer(t repository.Transfer) Transfer {
+	return Transfer{
+		ID:            t.ID,
+		FromAccountID: t.FromAccountID,
+		ToAccountID:   t.ToAccountID,
+		Amount:        t.Amount,
+		ToAmount:      t.ToAmount,
+		Date:          t.Date,
+		Description:   t.Description,
+		GoalID:        t.GoalID,
+	}
+}
+
+// TransferRequest is the body of the create transfer endpoint.
+type TransferRequest struct {
+	FromAccountID int64       `json:"from_account_id" example:"1"`
+	ToAccountID   int64       `json:"to_account_id" example:"2"`
+	Amount        money.Money `json:"amount" swaggertype:"string" example:"5000"`
+	// ToAmount is the amount credited to the destination account. It is required when the
+	// accounts have different currencies and must be omitted (or equal) otherwise.
+	ToAmount    *money.Money `json:"to_amount,omitempty" swaggertype:"string" example:"55.20"`
+	Date        string       `json:"date,omitempty" example:"2024-10-05"`
+	Description string       `json:"description,omitempty"`
+}
+
+// Validate normalizes the request against the resolved accounts and returns a user-facing
+// message when it is invalid. Goal contributions are validated by it too.
+func (req *TransferRequest) Validate(from, to repository.Account) string {
+	if from.ID == to.ID {
+		return "from_account_id and to_account_id must differ"
+	}
+
+	fromCurrency, toCurrency := from.Balance.Currency, to.Balance.Currency
+	amount, err := req.Amount.OrDefault(fromCurrency)
+	if err != nil || amount.Currency != fromCurrency || !amount.IsPositive() {
+		return fmt.Sprintf("amount must be a positive %s value", fromCurrency)
+	}
+	req.Amount = amount
+
+	if req.ToAmount == nil {
+		if fromCurrency != toCurrency {
+			return fmt.Sprintf("to_amount in %s is required for a transfer from %s", toCurrency, fromCurrency)
+		}
+		req.ToAmount = &amount
+	}
+	toAmount, err := req.ToAmount.OrDefault(toCurrency)
+	if err != nil || toAmount.Currency != toCurrency || !toAmount.IsPositive() {
+		return fmt.Sprintf("to_amount must be a positive %s value", toCurrency)
+	}
+	if fromCurrency == toCurrency && toAmount != amount {
+		return "to_amount must equal amount for accounts in the same currency"
+	}
+	req.ToAmount = &toAmount
+
+	if req.Date == "" {
+		req.Date = time.Now().Format(dateLayout)
+	}
+	if _, err := time.Parse(dateLayout, req.Date); err != nil {
+		return "Invalid date format (YYYY-MM-DD)"
+	}
+
+	return ""
+}
+
+// CreateTransferHandler moves money between two accounts
+// @Summary Create Transfer
+// @Description Moves money from one account of the user to another atomically. Transfers are neither incomes nor expenses.
+// @Description Between accounts in different currencies the credited to_amount has to be given explicitly.
+// @Tags Accounts
+// @Accept json
+// @Produce json
+// @Param transfer body accounts.TransferRequest true "Transfer details"
+// @Security BearerAuth
+// @Success 201 {object} accounts.Transfer "Created transfer"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 500 {string} string "Failed to create transfer"
+// @Router /api/transfers [post]
+func CreateTransferHandler(accounts repository.AccountRepository, transfers repository.TransferRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		var req TransferRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for transfer", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+		if req.FromAccountID == 0 || req.ToAccountID == 0 {
+			http.Error(w, "from_account_id and to_account_id are required", http.StatusBadRequest)
+			return
+		}
+
+		var resolved [2]repository.Account
+		for i, id := range []int64{req.FromAccountID, req.ToAccountID} {
+			account, err := repository.OwnedAccount(r.Context(), accounts, userUID, id)
+			if errors.Is(err, repository.ErrNotFound) {
+				log.Warn("account not found", slog.Int64("accountID", id), slog.String("userUID", userUID))
+				http.Error(w, "Account not found", http.StatusBadRequest)
+				return
+			} else if err != nil {
+				log.Error("failed to fetch account", slog.Any("error", err))
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			resolved[i] = account
+		}
+
+		if msg := req.Validate(resolved[0], resolved[1]); msg != "" {
+			log.Error("invalid transfer", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		transfer := repository.Transfer{
+			UserUID:       userUID,
+			FromAccountID: req.FromAccountID,
+			ToAccountID:   req.ToAccountID,
+			Amount:        req.Amount,
+			ToAmount:      *req.ToAmount,
+			Date:          req.Date,
+			Description:   req.Description,
+		}
+		if err := transfers.Create(r.Context(), &transfer); err != nil {
+			log.Error("failed to create transfer", slog.Any("error", err))
+			http.Error(w, "Failed to create transfer", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("transfer created successfully", slog.Int64("transferID", transfer.ID), slog.String("userUID", userUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(newTransfer(transfer))
+	}
+}
+
+// GetTransfersHandler lists transfers within a date range
+// @Summary List Transfers
+// @Description Returns the user's transfers between the given dates inclusive.
+// @Tags Accounts
+// @Produce json
+// @Param from query string true "Start date (YYYY-MM-DD)"
+// @Param to query string true "End date (YYYY-MM-DD)"
+// @Security BearerAuth
+// @Success 200 {array} accounts.Transfer "Transfers"
+// @Failure 400 {string} string "Invalid date format or missing parameters"
+// @Failure 500 {string} string "Failed to fetch transfers"
+// @Router /api/transfers [get]
+func GetTransfersHandler(transfers repository.TransferRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from := r.URL.Query().Get("from")
+		to := r.URL.Query().Get("to")
+		if from == "" || to == "" {
+			http.Error(w, "Both from and to are required", http.StatusBadRequest)
+			return
+		}
+		if _, err := time.Parse(dateLayout, from); err != nil {
+			http.Error(w, "Invalid from format (YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		if _, err := time.Parse(dateLayout, to); err != nil {
+			http.Error(w, "Invalid to format (YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+
+		userUID := r.Context().Value("userUID").(string)
+
+		records, err := transfers.List(r.Context(), userUID, from, to)
+		if err != nil {
+			log.Error("failed to fetch transfers", slog.Any("error", err))
+			http.Error(w, "Failed to fetch transfers", http.StatusInternalServerError)
+			return
+		}
+
+		list := make([]Transfer, 0, len(records))
+		for _, t := range records {
+			list = append(list, newTransfer(t))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// DeleteTransferHandler deletes a transfer and moves the money back
+// @Summary Delete Transfer
+// @Description Deletes a transfer owned by the user and restores both account balances.
+// @Tags Accounts
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Success message"
+// @Failure 400 {string} string "Invalid transfer ID"
+// @Failure 403 {string} string "Unauthorized to delete this transfer"
+// @Failure 404 {string} string "Transfer not found"
+// @Failure 500 {string} string "Failed to delete transfer"
+// @Router /api/transfers/{id} [delete]
+func DeleteTransferHandler(transfers repository.TransferRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		transferID := chi.URLParam(r, "id")
+		id, err := strconv.ParseInt(transferID, 10, 64)
+		if err != nil {
+			log.Warn("invalid transfer ID parameter", slog.String("transferID", transferID))
+			http.Error(w, "Invalid transfer ID", http.StatusBadRequest)
+			return
+		}
+
+		userUID := r.Context().Value("userUID").(string)
+
+		transfer, err := transfers.Get(r.Context(), id)
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Transfer not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to fetch transfer", slog.Any("error", err))
+			http.Error(w, "Failed to fetch transfer", http.StatusInternalServerError)
+			return
+		}
+
+		if transfer.UserUID != userUID {
+			log.Warn("unauthorized attempt to delete transfer", slog.String("userUID", userUID), slog.String("ownerUID", transfer.UserUID))
+			http.Error(w, "Unauthorized to delete this transfer", http.StatusForbidden)
+			return
+		}
+
+		err = transfers.Delete(r.Context(), id)
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Transfer not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to delete transfer", slog.String("transferID", transferID), slog.Any("error", err))
+			http.Error(w, "Failed to delete transfer", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("transfer deleted successfully", slog.String("transferID", transferID), slog.String("userUID", userUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Transfer deleted successfully"}`))
+	}
+}
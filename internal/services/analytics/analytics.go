@@ -0,0 +1,528 @@
+// Package analytics serves the aggregates the dashboard needs. All sums are computed by the
+// database; only the amounts in the requested currency are included.
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"tbank-go/internal/money"
+	"tbank-go/internal/storage"
+	"tbank-go/internal/user-service"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Grouping values of the group_by parameter.
+const (
+	GroupByDay      = "day"
+	GroupByWeek     = "week"
+	GroupByMonth    = "month"
+	GroupByCategory = "category"
+)
+
+// maxDays limits the range of a day-grouped summary so the response stays small.
+const maxDays = 366
+
+// Totals are the sums of a date range.
+type Totals struct {
+	Incomes      money.Money `json:"incomes"`
+	Expenses     money.Money `json:"expenses"`
+	Net          money.Money `json:"net"` // incomes minus expenses
+	IncomeCount  int         `json:"income_count"`
+	ExpenseCount int         `json:"expense_count"`
+}
+
+// Averages are the totals spread over the periods of the range and over the transactions.
+type Averages struct {
+	Period                string      `json:"period"` // day, week or month
+	Incomes               money.Money `json:"incomes"`
+	Expenses              money.Money `json:"expenses"`
+	Net                   money.Money `json:"net"`
+	IncomePerTransaction  money.Money `json:"income_per_transaction"`
+	ExpensePerTransaction money.Money `json:"expense_per_transaction"`
+}
+
+// Period is the totals of one day, week or month. Periods without transactions are included.
+type Period struct {
+	Start    string      `json:"start"` // first day of the period, may precede from
+	Incomes  money.Money `json:"incomes"`
+	Expenses money.Money `json:"expenses"`
+	Net      money.Money `json:"net"`
+	Count    int         `json:"count"`
+}
+
+// CategoryShare is the total of one category and its share of all incomes or expenses. Members of
+// a household have categories of their own, so a household's summary sums them by name without an ID.
+type CategoryShare struct {
+	CategoryID int64       `json:"category_id,omitempty"`
+	Category   string      `json:"category"`
+	Type       string      `json:"type"` // income or expense
+	Amount     money.Money `json:"amount"`
+	Count      int         `json:"count"`
+	Share      float64     `json:"share"` // percent of the type total
+}
+
+// TagTotal is the total of the incomes or expenses with one tag. A transaction with several tags
+// counts towards each of them, so the shares need not add up to 100. A household's summary sums the
+// members' tags by name, with a TagID of 0.
+type TagTotal struct {
+	TagID  int64       `json:"tag_id"`
+	Tag    string      `json:"tag"`
+	Type   string      `json:"type"` // income or expense
+	Amount money.Money `json:"amount"`
+	Count  int         `json:"count"`
+	Share  float64     `json:"share"` // percent of the type total
+}
+
+// Comparison is the previous period of the same length and the change against it.
+type Comparison struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Totals Totals `json:"totals"`
+	// IncomesChange and ExpensesChange are percent changes, null when the previous value is zero.
+	IncomesChange  *float64    `json:"incomes_change"`
+	ExpensesChange *float64    `json:"expenses_change"`
+	NetChange      money.Money `json:"net_change"`
+}
+
+// CurrencyCount counts transactions left out of the summary because of their currency.
+type CurrencyCount struct {
+	Currency string `json:"currency"`
+	Count    int    `json:"count"`
+}
+
+// Summary is the response of the summary endpoint.
+type Summary struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	GroupBy  string   `json:"group_by"`
+	Currency string   `json:"currency"`
+	Totals   Totals   `json:"totals"`
+	Averages Averages `json:"averages"`
+	// Periods is only filled for day, week and month grouping.
+	Periods         []Period        `json:"periods,omitempty"`
+	Categories      []CategoryShare `json:"categories"`
+	Tags            []TagTotal      `json:"tags"`
+	Previous        Comparison      `json:"previous"`
+	OtherCurrencies []CurrencyCount `json:"other_currencies"`
+}
+
+// GetSummaryHandler returns totals, averages and category shares of a date range
+// @Summary Analytics Summary
+// @Description Returns totals, net cash flow, averages and per-category shares of incomes and expenses between two dates,
+// @Description the totals per day, week (starting on Monday) or month, and a comparison with the previous period of the same length.
+// @Description Only transactions in `currency` (the user's base currency by default) are included; the others are counted in other_currencies.
+// @Description tags holds the totals per tag; a transaction with several tags counts towards each of them.
+// @Description Use /api/reports/summary for totals converted across currencies.
+// @Description With X-Household-ID the incomes and expenses shared with the household by all its members are summed instead.
+// @Tags Analytics
+// @Produce json
+// @Param X-Household-ID header int false "Household to summarize the shared incomes and expenses of"
+// @Param from query string true "Start date (YYYY-MM-DD)"
+// @Param to query string true "End date (YYYY-MM-DD)"
+// @Param group_by query string false "day, week, month (default) or category"
+// @Param currency query string false "Currency, defaults to the user's base currency"
+// @Security BearerAuth
+// @Success 200 {object} analytics.Summary "Summary"
+// @Failure 400 {string} string "Invalid parameters"
+// @Failure 500 {string} string "Failed to build summary"
+// @Router /api/analytics/summary [get]
+func GetSummaryHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		from, to := query.Get("from"), query.Get("to")
+		if from == "" || to == "" {
+			http.Error(w, "Both from and to are required", http.StatusBadRequest)
+			return
+		}
+		start, err := time.Parse(dateLayout, from)
+		if err != nil {
+			http.Error(w, "Invalid from format (YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		end, err := time.Parse(dateLayout, to)
+		if err != nil {
+			http.Error(w, "Invalid to format (YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		if end.Before(start) {
+			http.Error(w, "from must not be after to", http.StatusBadRequest)
+			return
+		}
+
+		groupBy := query.Get("group_by")
+		switch groupBy {
+		case "":
+			groupBy = GroupByMonth
+		case GroupByDay, GroupByWeek, GroupByMonth, GroupByCategory:
+		default:
+			http.Error(w, "group_by must be day, week, month or category", http.StatusBadRequest)
+			return
+		}
+		if groupBy == GroupByDay && end.Sub(start) >= maxDays*24*time.Hour {
+			http.Error(w, fmt.Sprintf("Ranges grouped by day are limited to %d days", maxDays), http.StatusBadRequest)
+			return
+		}
+
+		userUID := r.Context().Value("userUID").(string)
+		householdID, _ := r.Context().Value("householdID").(int64)
+
+		currency := query.Get("currency")
+		if currency == "" {
+			currency, err = user_service.GetUserCurrency(db, userUID)
+			if err != nil {
+				log.Error("failed to fetch user currency", slog.Any("error", err))
+				http.Error(w, "Failed to build summary", http.StatusInternalServerError)
+				return
+			}
+		} else if !money.ValidCurrency(currency) {
+			http.Error(w, "Invalid currency", http.StatusBadRequest)
+			return
+		}
+
+		summary, err := buildSummary(r.Context(), db, scope{userUID: userUID, householdID: householdID}, currency, groupBy, start, end)
+		if err != nil {
+			log.Error("failed to build analytics summary", slog.String("userUID", userUID), slog.Any("error", err))
+			http.Error(w, "Failed to build summary", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(summary)
+	}
+}
+
+// scope selects the transactions a summary covers: the user's or, with a household, those shared
+// with it by all its members, like the listings of /api/income and /api/expense.
+type scope struct {
+	userUID     string
+	householdID int64
+}
+
+// where returns the condition on the income and expenses tables and its argument.
+func (s scope) where() (string, any) {
+	if s.householdID != 0 {
+		return "household_id = ?", s.householdID
+	}
+	return "user_uid = ?", s.userUID
+}
+
+func buildSummary(ctx context.Context, db *sql.DB, s scope, currency, groupBy string, start, end time.Time) (Summary, error) {
+	from, to := start.Format(dateLayout), end.Format(dateLayout)
+	summary := Summary{
+		From:            from,
+		To:              to,
+		GroupBy:         groupBy,
+		Currency:        currency,
+		Categories:      []CategoryShare{},
+		Tags:            []TagTotal{},
+		OtherCurrencies: []CurrencyCount{},
+	}
+
+	var err error
+	summary.Totals, err = totals(ctx, db, s, currency, from, to)
+	if err != nil {
+		return summary, err
+	}
+
+	// A category summary is averaged per day.
+	days := int(end.Sub(start).Hours()/24) + 1
+	unit, n := GroupByDay, int64(days)
+	if groupBy != GroupByCategory {
+		unit = groupBy
+		starts := periodStarts(unit, start, end)
+		n = int64(len(starts))
+		summary.Periods, err = periods(ctx, db, s, currency, from, to, unit, starts)
+		if err != nil {
+			return summary, err
+		}
+	}
+
+	summary.Categories, err = categoryShares(ctx, db, s, currency, from, to)
+	if err != nil {
+		return summary, err
+	}
+
+	t := summary.Totals
+	summary.Tags, err = tagTotals(ctx, db, s, currency, from, to, t)
+	if err != nil {
+		return summary, err
+	}
+
+	summary.Averages = Averages{
+		Period:                unit,
+		Incomes:               money.New(divRound(t.Incomes.Amount, n), currency),
+		Expenses:              money.New(divRound(t.Expenses.Amount, n), currency),
+		Net:                   money.New(divRound(t.Net.Amount, n), currency),
+		IncomePerTransaction:  money.New(divRound(t.Incomes.Amount, int64(t.IncomeCount)), currency),
+		ExpensePerTransaction: money.New(divRound(t.Expenses.Amount, int64(t.ExpenseCount)), currency),
+	}
+
+	// The previous period ends the day before from and is as long as the requested one.
+	prevEnd := start.AddDate(0, 0, -1)
+	prevStart := prevEnd.AddDate(0, 0, 1-days)
+	previous, err := totals(ctx, db, s, currency, prevStart.Format(dateLayout), prevEnd.Format(dateLayout))
+	if err != nil {
+		return summary, err
+	}
+	summary.Previous = Comparison{
+		From:           prevStart.Format(dateLayout),
+		To:             prevEnd.Format(dateLayout),
+		Totals:         previous,
+		IncomesChange:  percentChange(previous.Incomes.Amount, t.Incomes.Amount),
+		ExpensesChange: percentChange(previous.Expenses.Amount, t.Expenses.Amount),
+		NetChange:      money.New(t.Net.Amount-previous.Net.Amount, currency),
+	}
+
+	summary.OtherCurrencies, err = otherCurrencies(ctx, db, s, currency, from, to)
+	if err != nil {
+		return summary, err
+	}
+
+	return summary, nil
+}
+
+// transactions returns the union of the incomes and expenses of the scope in one currency and
+// date range, and its arguments.
+func transactions(s scope, currency, from, to string) (string, []any) {
+	condition, arg := s.where()
+	query := `
+	SELECT 'income' AS type, id, category_id, category, amount, date FROM income
+	WHERE ` + condition + ` AND currency = ? AND date BETWEEN ? AND ?
+	UNION ALL
+	SELECT 'expense', id, category_id, category, amount, date FROM expenses
+	WHERE ` + condition + ` AND currency = ? AND date BETWEEN ? AND ?`
+	return query, []any{arg, currency, from, to, arg, currency, from, to}
+}
+
+func totals(ctx context.Context, db *sql.DB, s scope, currency, from, to string) (Totals, error) {
+	t := Totals{Incomes: money.New(0, currency), Expenses: money.New(0, currency)}
+	union, args := transactions(s, currency, from, to)
+	err := db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(CASE WHEN type = 'income' THEN amount ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN type = 'expense' THEN amount ELSE 0 END), 0),
+		       COUNT(CASE WHEN type = 'income' THEN 1 END),
+		       COUNT(CASE WHEN type = 'expense' THEN 1 END)
+		FROM (`+union+`) AS t`, args...,
+	).Scan(&t.Incomes.Amount, &t.Expenses.Amount, &t.IncomeCount, &t.ExpenseCount)
+	t.Net = money.New(t.Incomes.Amount-t.Expenses.Amount, currency)
+	return t, err
+}
+
+// periods returns the totals of every period starting in starts; the database groups the
+// transactions, periods without any are filled in with zeros.
+func periods(ctx context.Context, db *sql.DB, s scope, currency, from, to, unit string, starts []string) ([]Period, error) {
+	union, args := transactions(s, currency, from, to)
+	rows, err := db.QueryContext(ctx, `
+		SELECT period, SUM(CASE WHEN type = 'income' THEN amount ELSE 0 END),
+		       SUM(CASE WHEN type = 'expense' THEN amount ELSE 0 END), COUNT(*)
+		FROM (SELECT `+periodStart(storage.DialectOf(db), unit)+` AS period, type, amount FROM (`+union+`) AS t) AS p
+		GROUP BY period ORDER BY period`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byStart := make(map[string]Period, len(starts))
+	for rows.Next() {
+		p := Period{Incomes: money.New(0, currency), Expenses: money.New(0, currency)}
+		if err := rows.Scan(&p.Start, &p.Incomes.Amount, &p.Expenses.Amount, &p.Count); err != nil {
+			return nil, err
+		}
+		p.Net = money.New(p.Incomes.Amount-p.Expenses.Amount, currency)
+		byStart[p.Start] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	list := make([]Period, 0, len(starts))
+	for _, start := range starts {
+		p, ok := byStart[start]
+		if !ok {
+			p = Period{Start: start, Incomes: money.New(0, currency), Expenses: money.New(0, currency), Net: money.New(0, currency)}
+		}
+		list = append(list, p)
+	}
+	return list, nil
+}
+
+// periodStart returns the SQL expression for the first day (YYYY-MM-DD) of the day, week or
+// month of the date column. Weeks start on Monday.
+func periodStart(dialect storage.Dialect, unit string) string {
+	switch unit {
+	case GroupByWeek:
+		if dialect == storage.Postgres {
+			return `to_char(date_trunc('week', date::date), 'YYYY-MM-DD')`
+		}
+		// 'weekday 0' moves forward to Sunday (or stays on it), six days back is Monday.
+		return `date(date, 'weekday 0', '-6 days')`
+	case GroupByMonth:
+		return `SUBSTR(date, 1, 7) || '-01'`
+	default:
+		return `date`
+	}
+}
+
+// periodStarts lists the first days of the periods the range touches, matching periodStart.
+func periodStarts(unit string, start, end time.Time) []string {
+	switch unit {
+	case GroupByWeek:
+		start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	case GroupByMonth:
+		start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	var starts []string
+	for day := start; !day.After(end); {
+		starts = append(starts, day.Format(dateLayout))
+		switch unit {
+		case GroupByWeek:
+			day = day.AddDate(0, 0, 7)
+		case GroupByMonth:
+			day = day.AddDate(0, 1, 0)
+		default:
+			day = day.AddDate(0, 0, 1)
+		}
+	}
+	return starts
+}
+
+// categoryShares returns the total of every category, largest first within each type. The
+// share of the type total is computed by a window function.
+func categoryShares(ctx context.Context, db *sql.DB, s scope, currency, from, to string) ([]CategoryShare, error) {
+	id, group := "COALESCE(category_id, 0)", "COALESCE(category_id, 0), CASE WHEN category_id IS NULL THEN category ELSE '' END"
+	if s.householdID != 0 {
+		id, group = "0", "category"
+	}
+	union, args := transactions(s, currency, from, to)
+	rows, err := db.QueryContext(ctx, `
+		SELECT type, `+id+`, MIN(category), SUM(amount), COUNT(*),
+		       SUM(amount) * 100.0 / NULLIF(SUM(SUM(amount)) OVER (PARTITION BY type), 0)
+		FROM (`+union+`) AS t
+		GROUP BY type, `+group+`
+		ORDER BY type DESC, SUM(amount) DESC, MIN(category)`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []CategoryShare{}
+	for rows.Next() {
+		c := CategoryShare{Amount: money.New(0, currency)}
+		var share sql.NullFloat64
+		if err := rows.Scan(&c.Type, &c.CategoryID, &c.Category, &c.Amount.Amount, &c.Count, &share); err != nil {
+			return nil, err
+		}
+		c.Share = math.Round(share.Float64*100) / 100
+		shares = append(shares, c)
+	}
+	return shares, rows.Err()
+}
+
+// tagTotals returns the total of every tag, largest first within each type. Shares are taken of
+// the type totals, which also count the untagged transactions.
+func tagTotals(ctx context.Context, db *sql.DB, s scope, currency, from, to string, all Totals) ([]TagTotal, error) {
+	id, group := "tags.id", "tags.id"
+	if s.householdID != 0 {
+		id, group = "0", "tags.name_key"
+	}
+	union, args := transactions(s, currency, from, to)
+	rows, err := db.QueryContext(ctx, `
+		SELECT t.type, `+id+`, MIN(tags.name), SUM(t.amount), COUNT(*)
+		FROM (`+union+`) AS t
+		JOIN transaction_tags tt ON tt.kind = t.type AND tt.transaction_id = t.id
+		JOIN tags ON tags.id = tt.tag_id
+		GROUP BY t.type, `+group+`
+		ORDER BY t.type DESC, SUM(t.amount) DESC, MIN(tags.name)`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []TagTotal{}
+	for rows.Next() {
+		tag := TagTotal{Amount: money.New(0, currency)}
+		if err := rows.Scan(&tag.Type, &tag.TagID, &tag.Tag, &tag.Amount.Amount, &tag.Count); err != nil {
+			return nil, err
+		}
+		total := all.Incomes.Amount
+		if tag.Type == "expense" {
+			total = all.Expenses.Amount
+		}
+		if total != 0 {
+			tag.Share = math.Round(float64(tag.Amount.Amount)*10000/float64(total)) / 100
+		}
+		list = append(list, tag)
+	}
+	return list, rows.Err()
+}
+
+func otherCurrencies(ctx context.Context, db *sql.DB, s scope, currency, from, to string) ([]CurrencyCount, error) {
+	condition, arg := s.where()
+	rows, err := db.QueryContext(ctx, `
+		SELECT currency, COUNT(*) FROM (
+			SELECT currency FROM income WHERE `+condition+` AND currency <> ? AND date BETWEEN ? AND ?
+			UNION ALL
+			SELECT currency FROM expenses WHERE `+condition+` AND currency <> ? AND date BETWEEN ? AND ?
+		) AS t GROUP BY currency ORDER BY currency`,
+		arg, currency, from, to, arg, currency, from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []CurrencyCount{}
+	for rows.Next() {
+		var c CurrencyCount
+		if err := rows.Scan(&c.Currency, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// percentChange returns the change from previous to current in percent rounded to two
+// decimals, or nil when previous is zero.
+func percentChange(previous, current int64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := math.Round(float64(current-previous)/math.Abs(float64(previous))*10000) / 100
+	return &change
+}
+
+// divRound divides a by b rounding half away from zero; it returns 0 when b is 0.
+func divRound(a, b int64) int64 {
+	if b == 0 {
+		return 0
+	}
+	q, r := a/b, a%b
+	if 2*abs(r) >= abs(b) {
+		if (a < 0) != (b < 0) {
+			q--
+		} else {
+			q++
+		}
+	}
+	return q
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
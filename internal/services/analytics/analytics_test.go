@@ -0,0 +1,314 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/repository/sqlstore"
+	"tbank-go/internal/services/servicetest"
+	"tbank-go/internal/storage/storagetest"
+	"testing"
+)
+
+// seed records alice's transactions around March 1-14, 2024 and one of bob's.
+func seed(t *testing.T, repos repository.Repositories) {
+	t.Helper()
+	ctx := context.Background()
+	for _, uid := range []string{"alice", "bob"} {
+		servicetest.CreateUser(t, repos, uid)
+	}
+	income := repository.Income{UserUID: "alice", Category: "Salary", Amount: money.New(10000000, "RUB"), Date: "2024-03-01"}
+	if err := repos.Incomes.Create(ctx, &income); err != nil {
+		t.Fatal(err)
+	}
+	for _, expense := range []repository.Expense{
+		{UserUID: "alice", Category: "Food", Amount: money.New(40000, "RUB"), Date: "2024-02-20"}, // previous period
+		{UserUID: "alice", Category: "Food", Amount: money.New(30000, "RUB"), Date: "2024-03-04"},
+		{UserUID: "alice", Category: "Food", Amount: money.New(20000, "RUB"), Date: "2024-03-10"},
+		{UserUID: "alice", Category: "Transport", Amount: money.New(50000, "RUB"), Date: "2024-03-11"},
+		{UserUID: "alice", Category: "Food", Amount: money.New(1000, "USD"), Date: "2024-03-05"},
+		{UserUID: "alice", Category: "Food", Amount: money.New(99900, "RUB"), Date: "2024-03-15"}, // after the range
+		{UserUID: "bob", Category: "Food", Amount: money.New(77700, "RUB"), Date: "2024-03-04"},
+	} {
+		category, err := repos.Categories.FindByName(ctx, expense.UserUID, repository.CategoryExpense, expense.Category)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expense.CategoryID = category.ID
+		if err := repos.Expenses.Create(ctx, &expense); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func getSummary(t *testing.T, db *sql.DB, query string, status int) Summary {
+	t.Helper()
+	w := httptest.NewRecorder()
+	GetSummaryHandler(db, servicetest.Discard)(w, servicetest.NewRequest(http.MethodGet, "/api/analytics/summary?"+query, "", "alice"))
+	if status != http.StatusOK {
+		if w.Code != status {
+			t.Errorf("%s: status %d, want %d", query, w.Code, status)
+		}
+		return Summary{}
+	}
+	return servicetest.Decode[Summary](t, w, status)
+}
+
+func rub(amount int64) money.Money {
+	return money.New(amount, "RUB")
+}
+
+func TestSummaryGroupBy(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		seed(t, sqlstore.New(db))
+
+		type period struct {
+			start             string
+			incomes, expenses int64
+			count             int
+		}
+		tests := []struct {
+			groupBy  string
+			periods  []period
+			unit     string
+			averages [3]int64 // incomes, expenses and net per unit
+		}{
+			{groupBy: "week", unit: "week", averages: [3]int64{3333333, 33333, 3300000},
+				periods: []period{ // 2024-03-01 is a Friday, weeks start on Monday
+					{start: "2024-02-26", incomes: 10000000, count: 1},
+					{start: "2024-03-04", expenses: 50000, count: 2},
+					{start: "2024-03-11", expenses: 50000, count: 1},
+				}},
+			{groupBy: "month", unit: "month", averages: [3]int64{10000000, 100000, 9900000},
+				periods: []period{{start: "2024-03-01", incomes: 10000000, expenses: 100000, count: 4}}},
+			{groupBy: "", unit: "month", averages: [3]int64{10000000, 100000, 9900000},
+				periods: []period{{start: "2024-03-01", incomes: 10000000, expenses: 100000, count: 4}}},
+			{groupBy: "category", unit: "day", averages: [3]int64{714286, 7143, 707143}},
+		}
+		for _, tt := range tests {
+			summary := getSummary(t, db, "from=2024-03-01&to=2024-03-14&group_by="+tt.groupBy, http.StatusOK)
+			if len(summary.Periods) != len(tt.periods) {
+				t.Errorf("group_by=%s: periods %+v", tt.groupBy, summary.Periods)
+				continue
+			}
+			for i, want := range tt.periods {
+				got := summary.Periods[i]
+				if got.Start != want.start || got.Incomes != rub(want.incomes) || got.Expenses != rub(want.expenses) ||
+					got.Net != rub(want.incomes-want.expenses) || got.Count != want.count {
+					t.Errorf("group_by=%s: period %d = %+v, want %+v", tt.groupBy, i, got, want)
+				}
+			}
+			averages := summary.Averages
+			if averages.Period != tt.unit || averages.Incomes != rub(tt.averages[0]) || averages.Expenses != rub(tt.averages[1]) ||
+				averages.Net != rub(tt.averages[2]) || averages.ExpensePerTransaction != rub(33333) {
+				t.Errorf("group_by=%s: averages %+v", tt.groupBy, averages)
+			}
+		}
+
+		// Days without transactions are listed with zeros.
+		days := getSummary(t, db, "from=2024-03-01&to=2024-03-14&group_by=day", http.StatusOK).Periods
+		if len(days) != 14 || days[0].Incomes != rub(10000000) || days[2].Count != 0 || days[3].Expenses != rub(30000) ||
+			days[9].Expenses != rub(20000) || days[13].Start != "2024-03-14" {
+			t.Errorf("days = %+v", days)
+		}
+	})
+}
+
+func TestSummaryTotals(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		seed(t, sqlstore.New(db))
+
+		summary := getSummary(t, db, "from=2024-03-01&to=2024-03-14&group_by=category", http.StatusOK)
+		want := Totals{Incomes: rub(10000000), Expenses: rub(100000), Net: rub(9900000), IncomeCount: 1, ExpenseCount: 3}
+		if summary.Currency != "RUB" || summary.Totals != want {
+			t.Errorf("totals = %s %+v, want %+v", summary.Currency, summary.Totals, want)
+		}
+
+		wantCategories := []CategoryShare{
+			{Category: "Salary", Type: "income", Amount: rub(10000000), Count: 1, Share: 100},
+			{Category: "Food", Type: "expense", Amount: rub(50000), Count: 2, Share: 50},
+			{Category: "Transport", Type: "expense", Amount: rub(50000), Count: 1, Share: 50},
+		}
+		if len(summary.Categories) != len(wantCategories) {
+			t.Fatalf("categories = %+v", summary.Categories)
+		}
+		for i, want := range wantCategories {
+			got := summary.Categories[i]
+			got.CategoryID = 0
+			if got != want {
+				t.Errorf("category %d = %+v, want %+v", i, got, want)
+			}
+		}
+		if summary.Categories[1].CategoryID == 0 {
+			t.Error("expense category has no ID")
+		}
+
+		previous := summary.Previous
+		if previous.From != "2024-02-16" || previous.To != "2024-02-29" || previous.Totals.Expenses != rub(40000) ||
+			previous.IncomesChange != nil || previous.ExpensesChange == nil || *previous.ExpensesChange != 150 ||
+			previous.NetChange != rub(9940000) {
+			t.Errorf("previous = %+v", previous)
+		}
+
+		if len(summary.OtherCurrencies) != 1 || summary.OtherCurrencies[0] != (CurrencyCount{Currency: "USD", Count: 1}) {
+			t.Errorf("other currencies = %+v", summary.OtherCurrencies)
+		}
+
+		usd := getSummary(t, db, "from=2024-03-01&to=2024-03-14&currency=USD", http.StatusOK)
+		if usd.Totals.Expenses != money.New(1000, "USD") || usd.Totals.ExpenseCount != 1 || len(usd.OtherCurrencies) != 1 ||
+			usd.OtherCurrencies[0] != (CurrencyCount{Currency: "RUB", Count: 4}) {
+			t.Errorf("USD summary = %+v, other currencies %+v", usd.Totals, usd.OtherCurrencies)
+		}
+
+		for _, query := range []string{
+			"from=2024-03-01",
+			"from=2024-03-14&to=2024-03-01",
+			"from=2024-03-01&to=2024-03-14&group_by=year",
+			"from=2023-01-01&to=2024-01-02&group_by=day", // 367 days
+			"from=2024-03-01&to=2024-03-14&currency=rub",
+		} {
+			getSummary(t, db, query, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestSummaryTags(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		ctx := context.Background()
+		servicetest.CreateUser(t, repos, "alice")
+		tags := func(names ...string) []repository.Tag {
+			t.Helper()
+			list, err := repository.ResolveTags(ctx, repos.Tags, "alice", names)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return list
+		}
+
+		income := repository.Income{UserUID: "alice", Category: "Salary", Amount: rub(100000), Date: "2024-03-01", Tags: tags("work")}
+		if err := repos.Incomes.Create(ctx, &income); err != nil {
+			t.Fatal(err)
+		}
+		for _, expense := range []repository.Expense{
+			{Category: "Taxi", Amount: rub(30000), Date: "2024-03-02", Tags: tags("work", "travel")},
+			{Category: "Food", Amount: rub(10000), Date: "2024-03-03", Tags: tags("work")},
+			{Category: "Food", Amount: rub(60000), Date: "2024-03-04"},
+			// Another currency and a date after the range.
+			{Category: "Food", Amount: money.New(1000, "USD"), Date: "2024-03-05", Tags: tags("work")},
+			{Category: "Taxi", Amount: rub(5000), Date: "2024-04-01", Tags: tags("travel")},
+		} {
+			expense.UserUID = "alice"
+			if err := repos.Expenses.Create(ctx, &expense); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tags("unused")
+
+		// The untagged expense counts towards the expense total the shares are taken of.
+		summary := getSummary(t, db, "from=2024-03-01&to=2024-03-31", http.StatusOK)
+		want := []TagTotal{
+			{Tag: "work", Type: "income", Amount: rub(100000), Count: 1, Share: 100},
+			{Tag: "work", Type: "expense", Amount: rub(40000), Count: 2, Share: 40},
+			{Tag: "travel", Type: "expense", Amount: rub(30000), Count: 1, Share: 30},
+		}
+		if len(summary.Tags) != len(want) {
+			t.Fatalf("tags = %+v", summary.Tags)
+		}
+		for i := range want {
+			got := summary.Tags[i]
+			if got.TagID == 0 {
+				t.Errorf("tag %d has no ID", i)
+			}
+			got.TagID = 0
+			if got != want[i] {
+				t.Errorf("tag %d = %+v, want %+v", i, got, want[i])
+			}
+		}
+
+		usd := getSummary(t, db, "from=2024-03-01&to=2024-03-31&currency=USD", http.StatusOK)
+		if len(usd.Tags) != 1 || usd.Tags[0].Tag != "work" || usd.Tags[0].Amount != money.New(1000, "USD") || usd.Tags[0].Share != 100 {
+			t.Errorf("USD tags = %+v", usd.Tags)
+		}
+		if empty := getSummary(t, db, "from=2024-05-01&to=2024-05-31", http.StatusOK); empty.Tags == nil || len(empty.Tags) != 0 {
+			t.Errorf("tags without transactions = %#v, want an empty list", empty.Tags)
+		}
+	})
+}
+
+func TestHouseholdSummary(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		ctx := context.Background()
+		for _, uid := range []string{"alice", "bob"} {
+			servicetest.CreateUser(t, repos, uid)
+		}
+		household := servicetest.CreateHousehold(t, repos, "alice", "bob")
+
+		income := repository.Income{UserUID: "bob", Category: "Salary", Amount: rub(100000), Date: "2024-03-01", HouseholdID: household}
+		if err := repos.Incomes.Create(ctx, &income); err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range []struct {
+			userUID, category string
+			amount            int64
+			date              string
+			householdID       int64
+			tags              []string
+		}{
+			{userUID: "alice", category: "Food", amount: 10000, date: "2024-03-02", householdID: household, tags: []string{"groceries"}},
+			{userUID: "bob", category: "Food", amount: 20000, date: "2024-03-03", householdID: household, tags: []string{"Groceries"}},
+			{userUID: "alice", category: "Taxi", amount: 5000, date: "2024-03-04"},
+		} {
+			category, err := repos.Categories.FindByName(ctx, e.userUID, repository.CategoryExpense, e.category)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tags, err := repository.ResolveTags(ctx, repos.Tags, e.userUID, e.tags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expense := repository.Expense{UserUID: e.userUID, CategoryID: category.ID, Category: category.Name, Amount: rub(e.amount),
+				Date: e.date, HouseholdID: e.householdID, Tags: tags}
+			if err := repos.Expenses.Create(ctx, &expense); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		summarize := func(householdID int64) Summary {
+			t.Helper()
+			w := httptest.NewRecorder()
+			r := servicetest.NewRequest(http.MethodGet, "/api/analytics/summary?from=2024-03-01&to=2024-03-31&group_by=category", "", "alice")
+			if householdID != 0 {
+				r = servicetest.WithHousehold(r, householdID, repository.RoleOwner)
+			}
+			GetSummaryHandler(db, servicetest.Discard)(w, r)
+			return servicetest.Decode[Summary](t, w, http.StatusOK)
+		}
+
+		// The members' Food categories and groceries tags are summed by name.
+		summary := summarize(household)
+		if want := (Totals{Incomes: rub(100000), Expenses: rub(30000), Net: rub(70000), IncomeCount: 1, ExpenseCount: 2}); summary.Totals != want {
+			t.Errorf("household totals = %+v, want %+v", summary.Totals, want)
+		}
+		wantCategories := []CategoryShare{
+			{Category: "Salary", Type: "income", Amount: rub(100000), Count: 1, Share: 100},
+			{Category: "Food", Type: "expense", Amount: rub(30000), Count: 2, Share: 100},
+		}
+		if len(summary.Categories) != len(wantCategories) || summary.Categories[0] != wantCategories[0] || summary.Categories[1] != wantCategories[1] {
+			t.Errorf("household categories = %+v, want %+v", summary.Categories, wantCategories)
+		}
+		if want := (TagTotal{Tag: "Groceries", Type: "expense", Amount: rub(30000), Count: 2, Share: 100}); len(summary.Tags) != 1 || summary.Tags[0] != want {
+			t.Errorf("household tags = %+v, want %+v", summary.Tags, want)
+		}
+
+		// Alice's own summary has her shared and personal expenses but not Bob's.
+		personal := summarize(0)
+		if want := (Totals{Incomes: rub(0), Expenses: rub(15000), Net: rub(-15000), ExpenseCount: 2}); personal.Totals != want {
+			t.Errorf("personal totals = %+v, want %+v", personal.Totals, want)
+		}
+	})
+}
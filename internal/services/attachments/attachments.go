@@ -0,0 +1,590 @@
+// Package attachments lets users keep receipts and other documents with their incomes and expenses.
+package attachments
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"tbank-go/internal/blob"
+	"tbank-go/internal/repository"
+	"unicode"
+)
+
+const (
+	// maxPerTransaction limits the attachments of one income or expense.
+	maxPerTransaction = 20
+	// maxFilenameLength is the longest kept file name in characters.
+	maxFilenameLength = 128
+)
+
+// contentTypes maps the accepted types, detected from the content of the file, to the extension
+// of their blobs.
+var contentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// Attachment is a file attached to an income or an expense.
+type Attachment struct {
+	ID            int64  `json:"id"`
+	Type          string `json:"type"` // income or expense
+	TransactionID int64  `json:"transaction_id"`
+	Filename      string `json:"filename"`
+	ContentType   string `json:"content_type"`
+	Size          int64  `json:"size"` // bytes
+	URL           string `json:"url"`
+	// ThumbnailURL is set for JPEG, PNG and GIF images.
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	UserUID      string `json:"user_uid"` // the uploader
+	CreatedAt    string `json:"created_at"`
+}
+
+func newAttachment(a repository.Attachment) Attachment {
+	attachment := Attachment{
+		ID:            a.ID,
+		Type:          a.Kind,
+		TransactionID: a.TransactionID,
+		Filename:      a.Filename,
+		ContentType:   a.ContentType,
+		Size:          a.Size,
+		URL:           fmt.Sprintf("/api/attachments/%d/file", a.ID),
+		UserUID:       a.UserUID,
+		CreatedAt:     a.CreatedAt,
+	}
+	if a.ThumbnailKey != "" {
+		attachment.ThumbnailURL = fmt.Sprintf("/api/attachments/%d/thumbnail", a.ID)
+	}
+	return attachment
+}
+
+// transactionGetter reads an income or an expense.
+type transactionGetter func(ctx context.Context, id int64) (repository.Transaction, error)
+
+// UploadExpenseAttachmentHandler attaches a file to an expense
+// @Summary Upload Expense Attachment
+// @Description Attaches a receipt or another document to an expense. JPEG, PNG, GIF and WebP images and PDF documents are accepted;
+// @Description the type is detected from the content. A thumbnail is made of JPEG, PNG and GIF images.
+// @Description The author of the expense and the owners and editors of the household it is shared with may upload. At most 20 files per expense.
+// @Tags Attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Expense ID"
+// @Param file formData file true "Image or PDF"
+// @Security BearerAuth
+// @Success 201 {object} attachments.Attachment "Stored attachment"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Unauthorized to update this expense"
+// @Failure 404 {string} string "Expense not found"
+// @Failure 413 {string} string "File too large"
+// @Failure 415 {string} string "Unsupported file type"
+// @Failure 500 {string} string "Failed to store attachment"
+// @Router /api/expense/{id}/attachments [post]
+func UploadExpenseAttachmentHandler(expenses repository.ExpenseRepository, households repository.HouseholdRepository,
+	attachments repository.AttachmentRepository, blobs blob.Store, maxSize int64, log *slog.Logger) http.HandlerFunc {
+	return uploadHandler(repository.EntryExpense, expenses.Get, households, attachments, blobs, maxSize, log)
+}
+
+// UploadIncomeAttachmentHandler attaches a file to an income
+// @Summary Upload Income Attachment
+// @Description Attaches a document to an income, see the expense endpoint.
+// @Tags Attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Income ID"
+// @Param file formData file true "Image or PDF"
+// @Security BearerAuth
+// @Success 201 {object} attachments.Attachment "Stored attachment"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Unauthorized to update this income"
+// @Failure 404 {string} string "Income not found"
+// @Failure 413 {string} string "File too large"
+// @Failure 415 {string} string "Unsupported file type"
+// @Failure 500 {string} string "Failed to store attachment"
+// @Router /api/income/{id}/attachments [post]
+func UploadIncomeAttachmentHandler(incomes repository.IncomeRepository, households repository.HouseholdRepository,
+	attachments repository.AttachmentRepository, blobs blob.Store, maxSize int64, log *slog.Logger) http.HandlerFunc {
+	return uploadHandler(repository.EntryIncome, incomes.Get, households, attachments, blobs, maxSize, log)
+}
+
+func uploadHandler(kind string, get transactionGetter, households repository.HouseholdRepository,
+	attachments repository.AttachmentRepository, blobs blob.Store, maxSize int64, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		t, ok := loadTransaction(kind, get, households, w, r, log, true)
+		if !ok {
+			return
+		}
+
+		existing, err := attachments.List(r.Context(), kind, t.ID)
+		if err != nil {
+			log.Error("failed to fetch attachments", slog.Any("error", err))
+			http.Error(w, "Failed to store attachment", http.StatusInternalServerError)
+			return
+		}
+		if len(existing) >= maxPerTransaction {
+			http.Error(w, fmt.Sprintf("At most %d files can be attached to one %s", maxPerTransaction, kind), http.StatusBadRequest)
+			return
+		}
+
+		filename, data, status, msg := readFile(w, r, maxSize)
+		if msg != "" {
+			log.Warn("invalid attachment upload", slog.String("reason", msg))
+			http.Error(w, msg, status)
+			return
+		}
+
+		contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+		ext, ok := contentTypes[contentType]
+		if !ok {
+			log.Warn("unsupported attachment type", slog.String("contentType", contentType))
+			http.Error(w, "Only JPEG, PNG, GIF and WebP images and PDF documents can be attached", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		name, err := randomName()
+		if err != nil {
+			log.Error("failed to generate attachment key", slog.Any("error", err))
+			http.Error(w, "Failed to store attachment", http.StatusInternalServerError)
+			return
+		}
+		attachment := repository.Attachment{
+			UserUID:       userUID,
+			Kind:          kind,
+			TransactionID: t.ID,
+			Filename:      cleanFilename(filename, ext),
+			ContentType:   contentType,
+			Size:          int64(len(data)),
+			Key:           fmt.Sprintf("%s/%d/%s%s", kind, t.ID, name, ext),
+		}
+
+		if err := blobs.Put(r.Context(), attachment.Key, contentType, data); err != nil {
+			log.Error("failed to store attachment file", slog.String("key", attachment.Key), slog.Any("error", err))
+			http.Error(w, "Failed to store attachment", http.StatusInternalServerError)
+			return
+		}
+
+		// An image without a thumbnail is still attached.
+		thumb, err := thumbnail(data, contentType)
+		if err != nil {
+			log.Warn("failed to make thumbnail", slog.String("key", attachment.Key), slog.Any("error", err))
+		} else if thumb != nil {
+			thumbnailKey := fmt.Sprintf("%s/%d/%s.thumb.jpg", kind, t.ID, name)
+			if err := blobs.Put(r.Context(), thumbnailKey, "image/jpeg", thumb); err != nil {
+				log.Warn("failed to store thumbnail", slog.String("key", thumbnailKey), slog.Any("error", err))
+			} else {
+				attachment.ThumbnailKey = thumbnailKey
+			}
+		}
+
+		if err := attachments.Create(r.Context(), &attachment); err != nil {
+			log.Error("failed to insert attachment", slog.Any("error", err))
+			if err := blob.Remove(r.Context(), blobs, attachment.Key, attachment.ThumbnailKey); err != nil {
+				log.Warn("failed to remove attachment files", slog.String("key", attachment.Key), slog.Any("error", err))
+			}
+			http.Error(w, "Failed to store attachment", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("attachment uploaded successfully", slog.Int64("attachmentID", attachment.ID), slog.String("kind", kind),
+			slog.Int64("transactionID", t.ID), slog.String("userUID", userUID), slog.Int64("size", attachment.Size))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(newAttachment(attachment))
+	}
+}
+
+// GetExpenseAttachmentsHandler lists the attachments of an expense
+// @Summary List Expense Attachments
+// @Description Returns the files attached to an expense in upload order. Every member of the household the expense is shared with may list them.
+// @Tags Attachments
+// @Produce json
+// @Param id path int true "Expense ID"
+// @Security BearerAuth
+// @Success 200 {array} attachments.Attachment "Attachments"
+// @Failure 403 {string} string "Unauthorized to access this expense"
+// @Failure 404 {string} string "Expense not found"
+// @Failure 500 {string} string "Failed to fetch attachments"
+// @Router /api/expense/{id}/attachments [get]
+func GetExpenseAttachmentsHandler(expenses repository.ExpenseRepository, households repository.HouseholdRepository,
+	attachments repository.AttachmentRepository, log *slog.Logger) http.HandlerFunc {
+	return listHandler(repository.EntryExpense, expenses.Get, households, attachments, log)
+}
+
+// GetIncomeAttachmentsHandler lists the attachments of an income
+// @Summary List Income Attachments
+// @Description Returns the files attached to an income in upload order.
+// @Tags Attachments
+// @Produce json
+// @Param id path int true "Income ID"
+// @Security BearerAuth
+// @Success 200 {array} attachments.Attachment "Attachments"
+// @Failure 403 {string} string "Unauthorized to access this income"
+// @Failure 404 {string} string "Income not found"
+// @Failure 500 {string} string "Failed to fetch attachments"
+// @Router /api/income/{id}/attachments [get]
+func GetIncomeAttachmentsHandler(incomes repository.IncomeRepository, households repository.HouseholdRepository,
+	attachments repository.AttachmentRepository, log *slog.Logger) http.HandlerFunc {
+	return listHandler(repository.EntryIncome, incomes.Get, households, attachments, log)
+}
+
+func listHandler(kind string, get transactionGetter, households repository.HouseholdRepository,
+	attachments repository.AttachmentRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t, ok := loadTransaction(kind, get, households, w, r, log, false)
+		if !ok {
+			return
+		}
+
+		records, err := attachments.List(r.Context(), kind, t.ID)
+		if err != nil {
+			log.Error("failed to fetch attachments", slog.Any("error", err))
+			http.Error(w, "Failed to fetch attachments", http.StatusInternalServerError)
+			return
+		}
+
+		list := make([]Attachment, 0, len(records))
+		for _, a := range records {
+			list = append(list, newAttachment(a))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// GetAttachmentHandler returns the details of an attachment
+// @Summary Get Attachment
+// @Description Returns the details of a file attached to an income or an expense the user may see.
+// @Tags Attachments
+// @Produce json
+// @Param id path int true "Attachment ID"
+// @Security BearerAuth
+// @Success 200 {object} attachments.Attachment "Attachment"
+// @Failure 403 {string} string "Unauthorized to access this attachment"
+// @Failure 404 {string} string "Attachment not found"
+// @Router /api/attachments/{id} [get]
+func GetAttachmentHandler(incomes repository.IncomeRepository, expenses repository.ExpenseRepository, households repository.HouseholdRepository,
+	attachments repository.AttachmentRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		attachment, ok := loadAttachment(incomes, expenses, households, attachments, w, r, log, false)
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(newAttachment(attachment))
+	}
+}
+
+// GetAttachmentFileHandler downloads an attached file
+// @Summary Download Attachment
+// @Description Returns the attached file with its content type and original name.
+// @Tags Attachments
+// @Produce octet-stream
+// @Param id path int true "Attachment ID"
+// @Security BearerAuth
+// @Success 200 {file} file "File"
+// @Failure 403 {string} string "Unauthorized to access this attachment"
+// @Failure 404 {string} string "Attachment not found"
+// @Failure 500 {string} string "Failed to fetch attachment"
+// @Router /api/attachments/{id}/file [get]
+func GetAttachmentFileHandler(incomes repository.IncomeRepository, expenses repository.ExpenseRepository, households repository.HouseholdRepository,
+	attachments repository.AttachmentRepository, blobs blob.Store, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		attachment, ok := loadAttachment(incomes, expenses, households, attachments, w, r, log, false)
+		if !ok {
+			return
+		}
+		serveBlob(w, r, blobs, attachment.Key, attachment.ContentType, attachment.Filename, log)
+	}
+}
+
+// GetAttachmentThumbnailHandler returns the thumbnail of an attached image
+// @Summary Attachment Thumbnail
+// @Description Returns a JPEG thumbnail of at most 256 pixels on the longest side. Only JPEG, PNG and GIF images have one.
+// @Tags Attachments
+// @Produce jpeg
+// @Param id path int true "Attachment ID"
+// @Security BearerAuth
+// @Success 200 {file} file "Thumbnail"
+// @Failure 403 {string} string "Unauthorized to access this attachment"
+// @Failure 404 {string} string "Attachment has no thumbnail"
+// @Failure 500 {string} string "Failed to fetch attachment"
+// @Router /api/attachments/{id}/thumbnail [get]
+func GetAttachmentThumbnailHandler(incomes repository.IncomeRepository, expenses repository.ExpenseRepository, households repository.HouseholdRepository,
+	attachments repository.AttachmentRepository, blobs blob.Store, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		attachment, ok := loadAttachment(incomes, expenses, households, attachments, w, r, log, false)
+		if !ok {
+			return
+		}
+		if attachment.ThumbnailKey == "" {
+			http.Error(w, "Attachment has no thumbnail", http.StatusNotFound)
+			return
+		}
+		serveBlob(w, r, blobs, attachment.ThumbnailKey, "image/jpeg", "", log)
+	}
+}
+
+// DeleteAttachmentHandler deletes an attachment
+// @Summary Delete Attachment
+// @Description Deletes an attached file. The author of the income or expense and the owners and editors of the household it is shared with may delete it.
+// @Tags Attachments
+// @Produce json
+// @Param id path int true "Attachment ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Success message"
+// @Failure 403 {string} string "Unauthorized to delete this attachment"
+// @Failure 404 {string} string "Attachment not found"
+// @Failure 500 {string} string "Failed to delete attachment"
+// @Router /api/attachments/{id} [delete]
+func DeleteAttachmentHandler(incomes repository.IncomeRepository, expenses repository.ExpenseRepository, households repository.HouseholdRepository,
+	attachments repository.AttachmentRepository, blobs blob.Store, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		attachment, ok := loadAttachment(incomes, expenses, households, attachments, w, r, log, true)
+		if !ok {
+			return
+		}
+
+		err := attachments.Delete(r.Context(), attachment.ID)
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Attachment not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to delete attachment", slog.Int64("attachmentID", attachment.ID), slog.Any("error", err))
+			http.Error(w, "Failed to delete attachment", http.StatusInternalServerError)
+			return
+		}
+		// The record is gone, a file left behind is only logged.
+		if err := blob.Remove(r.Context(), blobs, attachment.Key, attachment.ThumbnailKey); err != nil {
+			log.Warn("failed to remove attachment files", slog.String("key", attachment.Key), slog.Any("error", err))
+		}
+
+		log.Info("attachment deleted successfully", slog.Int64("attachmentID", attachment.ID), slog.String("userUID", r.Context().Value("userUID").(string)))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Attachment deleted successfully"}`))
+	}
+}
+
+// readFile reads the "file" part of a multipart request. On failure it returns the status and
+// the user-facing message of the response.
+func readFile(w http.ResponseWriter, r *http.Request, maxSize int64) (string, []byte, int, string) {
+	// The other parts and the multipart framing get a megabyte on top of the file.
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+1<<20)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return "", nil, http.StatusBadRequest, "Invalid input, expected multipart/form-data"
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return "", nil, http.StatusBadRequest, "file is required"
+		} else if err != nil {
+			status, msg := readStatus(err)
+			return "", nil, status, msg
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(part, maxSize+1))
+		part.Close()
+		if err != nil {
+			status, msg := readStatus(err)
+			return "", nil, status, msg
+		}
+		if int64(len(data)) > maxSize {
+			return "", nil, http.StatusRequestEntityTooLarge, fmt.Sprintf("File too large, at most %d bytes", maxSize)
+		}
+		if len(data) == 0 {
+			return "", nil, http.StatusBadRequest, "file is empty"
+		}
+		return part.FileName(), data, 0, ""
+	}
+}
+
+func readStatus(err error) (int, string) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge, "File too large"
+	}
+	return http.StatusBadRequest, "Invalid input"
+}
+
+// cleanFilename keeps the base name of an uploaded file without control characters, shortened to
+// maxFilenameLength characters. Files without a usable name are called "attachment" with ext.
+func cleanFilename(name, ext string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name))
+	if name == "" || name == "." || name == "/" {
+		return "attachment" + ext
+	}
+	if runes := []rune(name); len(runes) > maxFilenameLength {
+		name = string(runes[:maxFilenameLength])
+	}
+	return name
+}
+
+func randomName() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// serveBlob writes the blob as the response. A non-empty filename is sent in Content-Disposition.
+func serveBlob(w http.ResponseWriter, r *http.Request, blobs blob.Store, key, contentType, filename string, log *slog.Logger) {
+	body, err := blobs.Get(r.Context(), key)
+	if errors.Is(err, blob.ErrNotFound) {
+		log.Error("attachment file is missing", slog.String("key", key))
+		http.Error(w, "Attachment file not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Error("failed to fetch attachment file", slog.String("key", key), slog.Any("error", err))
+		http.Error(w, "Failed to fetch attachment", http.StatusInternalServerError)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	if filename != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filename}))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, body); err != nil {
+		log.Warn("failed to send attachment file", slog.String("key", key), slog.Any("error", err))
+	}
+}
+
+// loadTransaction fetches the income or expense from the {id} URL parameter and checks that the
+// caller may see it, or change it when modify is set. It writes the error response itself and
+// reports whether the handler may continue.
+func loadTransaction(kind string, get transactionGetter, households repository.HouseholdRepository,
+	w http.ResponseWriter, r *http.Request, log *slog.Logger, modify bool) (repository.Transaction, bool) {
+	transactionID := chi.URLParam(r, "id")
+	userUID := r.Context().Value("userUID").(string)
+	label := strings.ToUpper(kind[:1]) + kind[1:]
+
+	id, err := strconv.ParseInt(transactionID, 10, 64)
+	if err != nil {
+		log.Warn("invalid transaction ID parameter", slog.String("kind", kind), slog.String("id", transactionID))
+		http.Error(w, fmt.Sprintf("Invalid %s ID", kind), http.StatusBadRequest)
+		return repository.Transaction{}, false
+	}
+
+	t, err := get(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, label+" not found", http.StatusNotFound)
+		return repository.Transaction{}, false
+	} else if err != nil {
+		log.Error("failed to fetch transaction", slog.String("kind", kind), slog.Int64("id", id), slog.Any("error", err))
+		http.Error(w, fmt.Sprintf("Failed to fetch %s details", kind), http.StatusInternalServerError)
+		return repository.Transaction{}, false
+	}
+
+	check, action := repository.CanView, "access"
+	if modify {
+		check, action = repository.CanModify, "update"
+	}
+	allowed, err := check(r.Context(), households, userUID, t)
+	if err != nil {
+		log.Error("failed to check household role", slog.Any("error", err))
+		http.Error(w, fmt.Sprintf("Failed to fetch %s details", kind), http.StatusInternalServerError)
+		return repository.Transaction{}, false
+	}
+	if !allowed {
+		log.Warn("unauthorized attempt to "+action+" "+kind, slog.String("userUID", userUID), slog.String("ownerUID", t.UserUID))
+		http.Error(w, fmt.Sprintf("Unauthorized to %s this %s", action, kind), http.StatusForbidden)
+		return repository.Transaction{}, false
+	}
+
+	return t, true
+}
+
+// loadAttachment fetches the attachment from the {id} URL parameter and checks through its
+// transaction that the caller may see it, or delete it when modify is set. It writes the error
+// response itself and reports whether the handler may continue.
+func loadAttachment(incomes repository.IncomeRepository, expenses repository.ExpenseRepository, households repository.HouseholdRepository,
+	attachments repository.AttachmentRepository, w http.ResponseWriter, r *http.Request, log *slog.Logger, modify bool) (repository.Attachment, bool) {
+	attachmentID := chi.URLParam(r, "id")
+	userUID := r.Context().Value("userUID").(string)
+
+	id, err := strconv.ParseInt(attachmentID, 10, 64)
+	if err != nil {
+		log.Warn("invalid attachment ID parameter", slog.String("attachmentID", attachmentID))
+		http.Error(w, "Invalid attachment ID", http.StatusBadRequest)
+		return repository.Attachment{}, false
+	}
+
+	attachment, err := attachments.Get(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Warn("attachment not found", slog.String("attachmentID", attachmentID))
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return repository.Attachment{}, false
+	} else if err != nil {
+		log.Error("failed to fetch attachment", slog.Any("error", err))
+		http.Error(w, "Failed to fetch attachment", http.StatusInternalServerError)
+		return repository.Attachment{}, false
+	}
+
+	get := incomes.Get
+	if attachment.Kind == repository.EntryExpense {
+		get = expenses.Get
+	}
+	t, err := get(r.Context(), attachment.TransactionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Warn("transaction of attachment not found", slog.String("attachmentID", attachmentID))
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return repository.Attachment{}, false
+	} else if err != nil {
+		log.Error("failed to fetch transaction of attachment", slog.Any("error", err))
+		http.Error(w, "Failed to fetch attachment", http.StatusInternalServerError)
+		return repository.Attachment{}, false
+	}
+
+	check, action := repository.CanView, "access"
+	if modify {
+		check, action = repository.CanModify, "delete"
+	}
+	allowed, err := check(r.Context(), households, userUID, t)
+	if err != nil {
+		log.Error("failed to check household role", slog.Any("error", err))
+		http.Error(w, "Failed to fetch attachment", http.StatusInternalServerError)
+		return repository.Attachment{}, false
+	}
+	if !allowed {
+		log.Warn("unauthorized attempt to "+action+" attachment", slog.String("userUID", userUID), slog.String("ownerUID", t.UserUID))
+		http.Error(w, "Unauthorized to "+action+" this attachment", http.StatusForbidden)
+		return repository.Attachment{}, false
+	}
+
+	return attachment, true
+}
@@ -0,0 +1,144 @@
+package attachments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"tbank-go/internal/blob"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/repository/memory"
+	"tbank-go/internal/services/servicetest"
+	"testing"
+)
+
+// uploadRequest builds a multipart request with a part per entry of fields. The "file" part is
+// sent as a file named filename.
+func uploadRequest(t *testing.T, userUID string, id int64, filename string, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, content := range fields {
+		var part io.Writer
+		var err error
+		if name == "file" {
+			part, err = writer.CreateFormFile(name, filename)
+		} else {
+			part, err = writer.CreateFormField(name)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(content))
+	}
+	writer.Close()
+
+	r := servicetest.NewRequest(http.MethodPost, "/api/expense/1/attachments", body.String(), userUID, "id", servicetest.ID(id))
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	return r
+}
+
+func TestUploadValidation(t *testing.T) {
+	repos := memory.New()
+	ctx := context.Background()
+	servicetest.CreateUser(t, repos, "alice")
+	account := servicetest.DefaultAccount(t, repos, "alice")
+	expense := repository.Expense{UserUID: "alice", AccountID: account.ID, Category: "Food", Amount: money.New(1000, "RUB"), Date: "2024-03-01"}
+	if err := repos.Expenses.Create(ctx, &expense); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	blobs, err := blob.NewLocalStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const maxSize = 4096
+	upload := UploadExpenseAttachmentHandler(repos.Expenses, repos.Households, repos.Attachments, blobs, maxSize, servicetest.Discard)
+
+	pngData := encodePNG(t, image.NewRGBA(image.Rect(0, 0, 40, 20)))
+	tests := []struct {
+		name      string
+		filename  string
+		fields    map[string]string
+		status    int
+		message   string // expected error message
+		wantType  string // expected content type of a stored attachment
+		wantThumb bool
+	}{
+		{name: "png", filename: "receipt.png", fields: map[string]string{"file": string(pngData)},
+			status: http.StatusCreated, wantType: "image/png", wantThumb: true},
+		{name: "pdf named like an image", filename: "scan.jpg", fields: map[string]string{"file": "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"},
+			status: http.StatusCreated, wantType: "application/pdf"},
+		{name: "other fields are skipped", filename: "r.png", fields: map[string]string{"note": "x", "file": string(pngData)},
+			status: http.StatusCreated, wantType: "image/png", wantThumb: true},
+		{name: "text", filename: "notes.pdf", fields: map[string]string{"file": "just some text"},
+			status: http.StatusUnsupportedMediaType, message: "Only JPEG, PNG, GIF and WebP images and PDF documents can be attached"},
+		{name: "html", filename: "page.png", fields: map[string]string{"file": "<html><script>alert(1)</script></html>"},
+			status: http.StatusUnsupportedMediaType, message: "Only JPEG, PNG, GIF and WebP images and PDF documents can be attached"},
+		{name: "too large", filename: "big.pdf", fields: map[string]string{"file": "%PDF-" + strings.Repeat("x", maxSize)},
+			status: http.StatusRequestEntityTooLarge, message: "File too large, at most 4096 bytes"},
+		{name: "empty", filename: "empty.pdf", fields: map[string]string{"file": ""},
+			status: http.StatusBadRequest, message: "file is empty"},
+		{name: "no file", fields: map[string]string{"note": "x"},
+			status: http.StatusBadRequest, message: "file is required"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		upload(w, uploadRequest(t, "alice", expense.ID, tt.filename, tt.fields))
+		if w.Code != tt.status {
+			t.Errorf("%s: status %d %s, want %d", tt.name, w.Code, w.Body, tt.status)
+			continue
+		}
+		if tt.status != http.StatusCreated {
+			if got := strings.TrimSpace(w.Body.String()); got != tt.message {
+				t.Errorf("%s: message %q, want %q", tt.name, got, tt.message)
+			}
+			continue
+		}
+
+		var attachment Attachment
+		if err := json.Unmarshal(w.Body.Bytes(), &attachment); err != nil {
+			t.Fatal(err)
+		}
+		if attachment.ContentType != tt.wantType || attachment.Filename != tt.filename || (attachment.ThumbnailURL != "") != tt.wantThumb {
+			t.Errorf("%s: attachment = %+v", tt.name, attachment)
+		}
+		stored, err := repos.Attachments.Get(ctx, attachment.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Keys start with the kind, the store directory is the only attachments prefix.
+		prefix := "expense/" + servicetest.ID(expense.ID) + "/"
+		for _, key := range []string{stored.Key, stored.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+			if !strings.HasPrefix(key, prefix) {
+				t.Errorf("%s: key %q, want prefix %q", tt.name, key, prefix)
+			}
+			if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(key))); err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+		}
+	}
+
+	w := httptest.NewRecorder()
+	upload(w, servicetest.NewRequest(http.MethodPost, "/api/expense/1/attachments", `{"file": "x"}`, "alice", "id", servicetest.ID(expense.ID)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("json body: status %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	upload(w, uploadRequest(t, "bob", expense.ID, "r.png", map[string]string{"file": string(pngData)}))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("upload by another user: status %d", w.Code)
+	}
+}
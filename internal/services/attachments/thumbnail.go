@@ -0,0 +1,77 @@
+package attachments
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+const (
+	// thumbnailSize is the longest side of a thumbnail in pixels.
+	thumbnailSize = 256
+	// maxSourcePixels bounds the images decoded for a thumbnail; a small compressed file can
+	// claim huge dimensions.
+	maxSourcePixels = 50_000_000
+)
+
+// thumbnail returns a JPEG of the image scaled with the nearest-neighbour method to at most
+// thumbnailSize pixels on the longest side. It returns nil for types the standard library cannot
+// decode, such as WebP. EXIF orientation is not applied.
+func thumbnail(data []byte, contentType string) ([]byte, error) {
+	var decode func(io.Reader) (image.Image, error)
+	var decodeConfig func(io.Reader) (image.Config, error)
+	switch contentType {
+	case "image/jpeg":
+		decode, decodeConfig = jpeg.Decode, jpeg.DecodeConfig
+	case "image/png":
+		decode, decodeConfig = png.Decode, png.DecodeConfig
+	case "image/gif":
+		decode, decodeConfig = gif.Decode, gif.DecodeConfig
+	default:
+		return nil, nil
+	}
+
+	cfg, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > maxSourcePixels {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large for a thumbnail", cfg.Width, cfg.Height)
+	}
+	src, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	tw, th := w, h
+	if w >= h && w > thumbnailSize {
+		tw, th = thumbnailSize, max(1, h*thumbnailSize/w)
+	} else if h > w && h > thumbnailSize {
+		tw, th = max(1, w*thumbnailSize/h), thumbnailSize
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		sy := bounds.Min.Y + (2*y+1)*h/(2*th)
+		for x := 0; x < tw; x++ {
+			sx := bounds.Min.X + (2*x+1)*w/(2*tw)
+			r, g, b, a := src.At(sx, sy).RGBA()
+			// JPEG has no alpha channel, transparent pixels are put on white.
+			white := 0xffff - a
+			dst.SetRGBA(x, y, color.RGBA{R: uint8((r + white) >> 8), G: uint8((g + white) >> 8), B: uint8((b + white) >> 8), A: 0xff})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
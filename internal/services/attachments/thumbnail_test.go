@@ -0,0 +1,96 @@
+package attachments
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestThumbnailScaling(t *testing.T) {
+	tests := []struct {
+		width, height int
+		wantW, wantH  int
+	}{
+		{width: 1000, height: 500, wantW: 256, wantH: 128},
+		{width: 300, height: 900, wantW: 85, wantH: 256},
+		{width: 512, height: 512, wantW: 256, wantH: 256},
+		{width: 100, height: 50, wantW: 100, wantH: 50}, // small images keep their size
+		{width: 2000, height: 1, wantW: 256, wantH: 1},
+	}
+	for _, tt := range tests {
+		data := encodePNG(t, image.NewRGBA(image.Rect(0, 0, tt.width, tt.height)))
+		thumb, err := thumbnail(data, "image/png")
+		if err != nil {
+			t.Fatalf("%dx%d: %v", tt.width, tt.height, err)
+		}
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(thumb))
+		if err != nil {
+			t.Fatalf("%dx%d: thumbnail is not a JPEG: %v", tt.width, tt.height, err)
+		}
+		if cfg.Width != tt.wantW || cfg.Height != tt.wantH {
+			t.Errorf("%dx%d: thumbnail is %dx%d, want %dx%d", tt.width, tt.height, cfg.Width, cfg.Height, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestThumbnailPixels(t *testing.T) {
+	// Left half red, right half transparent.
+	src := image.NewNRGBA(image.Rect(0, 0, 512, 512))
+	for y := 0; y < 512; y++ {
+		for x := 0; x < 256; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: 0xff, A: 0xff})
+		}
+	}
+	thumb, err := thumbnail(encodePNG(t, src), "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	near := func(got uint32, want uint8) bool { v := int(got >> 8); return v > int(want)-24 && v < int(want)+24 }
+	if r, g, b, _ := img.At(64, 128).RGBA(); !near(r, 0xff) || !near(g, 0) || !near(b, 0) {
+		t.Errorf("left pixel = %d,%d,%d, want red", r>>8, g>>8, b>>8)
+	}
+	if r, g, b, _ := img.At(192, 128).RGBA(); !near(r, 0xff) || !near(g, 0xff) || !near(b, 0xff) {
+		t.Errorf("transparent pixel = %d,%d,%d, want white", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestThumbnailRejects(t *testing.T) {
+	// A tiny GIF whose screen descriptor claims 20000x20000 pixels.
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.Black, color.White}), nil); err != nil {
+		t.Fatal(err)
+	}
+	huge := buf.Bytes()
+	binary.LittleEndian.PutUint16(huge[6:], 20000)
+	binary.LittleEndian.PutUint16(huge[8:], 20000)
+
+	if _, err := thumbnail(huge, "image/gif"); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("20000x20000 GIF: %v, want a too large error", err)
+	}
+	if _, err := thumbnail([]byte("\x89PNG\r\n\x1a\nbroken"), "image/png"); err == nil {
+		t.Error("broken PNG: no error")
+	}
+	if thumb, err := thumbnail([]byte("RIFF....WEBPVP8 "), "image/webp"); thumb != nil || err != nil {
+		t.Errorf("WebP: %v, %v, want no thumbnail", thumb, err)
+	}
+}
@@ -3,9 +3,11 @@ package auth
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"github.com/google/uuid"
 	"log/slog"
 	"net/http"
+	"tbank-go/internal/repository"
 	"tbank-go/internal/user-service"
 	"tbank-go/internal/utils"
 	"time"
@@ -21,7 +23,6 @@ type AuthRequest struct {
 // ChangePasswordRequest defines the request body for the Change Password endpoint.
 // @Description Request body for changing the user's password.
 type ChangePasswordRequest struct {
-	Username    string `json:"username" example:"johndoe"`
 	OldPassword string `json:"old_password" example:"oldpassword123"`
 	NewPassword string `json:"new_password" example:"newpassword123"`
 }
@@ -33,10 +34,12 @@ type ChangePasswordRequest struct {
 // @Produce json
 // @Param user body AuthRequest true "User Information"
 // @Success 201 {object} map[string]string
-// @Failure 400 {string} string "Invalid input"
+// @Failure 400 {string} string "Invalid input or password rejected by the policy"
+// @Failure 409 {string} string "Username already taken"
+// @Failure 429 {string} string "Too many requests"
 // @Failure 500 {string} string "Error registering user"
 // @Router /register [post]
-func Register(db *sql.DB, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+func Register(users repository.UserRepository, throttle *Throttle, policy *PasswordPolicy, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
 	var requestBody AuthRequest
 	err := json.NewDecoder(r.Body).Decode(&requestBody)
 	if err != nil {
@@ -45,12 +48,23 @@ func Register(db *sql.DB, w http.ResponseWriter, r *http.Request, log *slog.Logg
 		return
 	}
 
+	if !throttle.allowUsername(w, requestBody.Username, log) {
+		return
+	}
+
 	log.Info("registering user", slog.String("username", requestBody.Username))
 
+	if msg := policy.check(requestBody.Password, requestBody.Username); msg != "" {
+		log.Warn("password rejected by policy", slog.String("username", requestBody.Username), slog.String("reason", msg))
+		http.Error(w, msg, http.StatusBadRequest) // 400 Bad Request
+		return
+	}
+
 	user := user_service.User{
-		UID:      uuid.NewString(),
-		Username: requestBody.Username,
-		Password: requestBody.Password,
+		UID:          uuid.NewString(),
+		Username:     requestBody.Username,
+		Password:     requestBody.Password,
+		RegisteredAt: time.Now().Format(time.RFC3339),
 	}
 
 	log.Info("generated UID for user", slog.String("uid", user.UID))
@@ -62,12 +76,22 @@ func Register(db *sql.DB, w http.ResponseWriter, r *http.Request, log *slog.Logg
 		return
 	}
 
-	err = user.Create(db, log)
-	if err != nil {
+	err = users.Create(r.Context(), &repository.User{
+		UID:          user.UID,
+		Username:     user.Username,
+		PasswordHash: user.Password,
+		RegisteredAt: user.RegisteredAt,
+	})
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		log.Warn("username already taken", slog.String("username", user.Username))
+		http.Error(w, "Username already taken", http.StatusConflict) // 409 Conflict
+		return
+	} else if err != nil {
 		log.Error("error registering user", slog.String("username", user.Username), slog.Any("error", err))
 		http.Error(w, "Error registering user", http.StatusInternalServerError) // 500 Internal Server Error
 		return
 	}
+	log.Info("user added successfully", slog.String("username", user.Username))
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated) // 201 Created
@@ -78,18 +102,20 @@ func Register(db *sql.DB, w http.ResponseWriter, r *http.Request, log *slog.Logg
 }
 
 // @Summary Login a user
-// @Description Authenticate user and return a JWT
+// @Description Authenticate user and return a JWT access token with a refresh token.
+// @Description With 2FA enabled an MFAChallenge is returned instead; exchange it at /login/2fa.
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param credentials body AuthRequest true "Login Credentials"
-// @Success 200 {object} map[string]string
+// @Success 200 {object} TokenPair
+// @Success 200 {object} MFAChallenge
 // @Failure 400 {string} string "Invalid input"
-// @Failure 404 {string} string "User not found"
 // @Failure 401 {string} string "Invalid credentials"
+// @Failure 429 {string} string "Too many requests"
 // @Failure 500 {string} string "Error generating token"
 // @Router /login [post]
-func Login(db *sql.DB, w http.ResponseWriter, r *http.Request, log *slog.Logger, jwtSecret string, jwtLifetime time.Duration) {
+func Login(db *sql.DB, users repository.UserRepository, throttle *Throttle, w http.ResponseWriter, r *http.Request, log *slog.Logger, jwtSecret string, jwtLifetime, refreshLifetime time.Duration) {
 	var requestBody AuthRequest
 	err := json.NewDecoder(r.Body).Decode(&requestBody)
 	if err != nil {
@@ -98,23 +124,60 @@ func Login(db *sql.DB, w http.ResponseWriter, r *http.Request, log *slog.Logger,
 		return
 	}
 
+	if !throttle.allowUsername(w, requestBody.Username, log) {
+		return
+	}
+
 	log.Info("logging in user", slog.String("username", requestBody.Username))
 
-	user, err := user_service.GetUserByUsername(db, requestBody.Username, log)
-	if err != nil {
-		log.Error("user not found during login", slog.String("username", requestBody.Username), slog.Any("error", err))
-		http.Error(w, "User not found", http.StatusNotFound) // 404 Not Found
+	// Unknown usernames get the same answer as wrong passwords, after the same delay.
+	user, err := users.GetByUsername(r.Context(), requestBody.Username)
+	if errors.Is(err, repository.ErrNotFound) {
+		burnPasswordCheck(requestBody.Password)
+		log.Error("user not found during login", slog.String("username", requestBody.Username))
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized) // 401 Unauthorized
+		return
+	} else if err != nil {
+		log.Error("failed to get user by username", slog.String("username", requestBody.Username), slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError) // 500 Internal Server Error
+		return
+	}
+
+	if throttle.checkLocked(w, user, requestBody.Password, log) {
 		return
 	}
 
-	err = utils.CheckPassword(user.Password, requestBody.Password)
+	err = utils.CheckPassword(user.PasswordHash, requestBody.Password)
 	if err != nil {
+		throttle.recordFailure(r.Context(), users, user, log)
 		log.Error("invalid credentials during login", slog.String("username", requestBody.Username))
 		http.Error(w, "Invalid credentials", http.StatusUnauthorized) // 401 Unauthorized
 		return
 	}
+	throttle.recordSuccess(r.Context(), users, user, log)
 
-	tokenString, err := utils.GenerateJWT(user.UID, jwtSecret, jwtLifetime)
+	state, err := loadTwoFactorState(db, user.UID)
+	if err != nil {
+		log.Error("failed to load 2fa state", slog.String("username", requestBody.Username), slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError) // 500 Internal Server Error
+		return
+	}
+	if state.enabled {
+		challenge, err := createChallenge(db, user.UID)
+		if err != nil {
+			log.Error("error creating 2fa challenge during login", slog.String("username", requestBody.Username), slog.Any("error", err))
+			http.Error(w, "Error generating token", http.StatusInternalServerError) // 500 Internal Server Error
+			return
+		}
+
+		log.Info("password accepted, second factor required", slog.String("username", requestBody.Username))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK) // 200 OK
+		json.NewEncoder(w).Encode(challenge)
+		return
+	}
+
+	pair, err := issueTokenPair(db, user.UID, jwtSecret, jwtLifetime, refreshLifetime)
 	if err != nil {
 		log.Error("error generating token during login", slog.String("username", requestBody.Username), slog.Any("error", err))
 		http.Error(w, "Error generating token", http.StatusInternalServerError) // 500 Internal Server Error
@@ -124,25 +187,26 @@ func Login(db *sql.DB, w http.ResponseWriter, r *http.Request, log *slog.Logger,
 	log.Info("user logged in successfully", slog.String("username", requestBody.Username))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK) // 200 OK
-	json.NewEncoder(w).Encode(map[string]string{
-		"token": tokenString,
-		"uid":   user.UID,
-	})
+	json.NewEncoder(w).Encode(pair)
 }
 
 // @Summary Change user password
-// @Description Update the user's password after verifying the old password
+// @Description Update the authenticated user's password after verifying the old one. The new password must
+// @Description satisfy the password policy. Every session of the user, including the current one, is ended.
 // @Tags Auth
 // @Accept json
 // @Produce plain
 // @Param changePassword body ChangePasswordRequest true "Change Password Information"
+// @Security BearerAuth
 // @Success 200 {string} string "Password updated successfully"
 // @Failure 400 {string} string "Invalid input"
-// @Failure 404 {string} string "User not found"
-// @Failure 401 {string} string "Invalid old password"
+// @Failure 401 {string} string "Invalid credentials"
+// @Failure 429 {string} string "Too many requests"
 // @Failure 500 {string} string "Error updating password"
 // @Router /change-password [post]
-func ChangePassword(db *sql.DB, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+func ChangePassword(users repository.UserRepository, throttle *Throttle, policy *PasswordPolicy, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+	userUID := r.Context().Value("userUID").(string)
+
 	var requestBody ChangePasswordRequest
 	err := json.NewDecoder(r.Body).Decode(&requestBody)
 	if err != nil {
@@ -150,38 +214,50 @@ func ChangePassword(db *sql.DB, w http.ResponseWriter, r *http.Request, log *slo
 		http.Error(w, "Invalid input", http.StatusBadRequest) // 400 Bad Request
 		return
 	}
-	log.Info("changing password for user", slog.String("username", requestBody.Username))
+	log.Info("changing password for user", slog.String("userUID", userUID))
 
-	user, err := user_service.GetUserByUsername(db, requestBody.Username, log)
+	stored, err := users.GetByUID(r.Context(), userUID)
 	if err != nil {
-		log.Error("user not found during password change", slog.String("username", requestBody.Username), slog.Any("error", err))
-		http.Error(w, "User not found", http.StatusNotFound) // 404 Not Found
+		log.Error("failed to get user by uid", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError) // 500 Internal Server Error
+		return
+	}
+
+	if !throttle.allowUsername(w, stored.Username, log) || throttle.checkLocked(w, stored, requestBody.OldPassword, log) {
 		return
 	}
 
-	err = utils.CheckPassword(user.Password, requestBody.OldPassword)
+	err = utils.CheckPassword(stored.PasswordHash, requestBody.OldPassword)
 	if err != nil {
-		log.Error("invalid old password during password change", slog.String("username", requestBody.Username))
-		http.Error(w, "Invalid old password", http.StatusUnauthorized) // 401 Unauthorized
+		throttle.recordFailure(r.Context(), users, stored, log)
+		log.Error("invalid old password during password change", slog.String("userUID", userUID))
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized) // 401 Unauthorized
+		return
+	}
+	throttle.recordSuccess(r.Context(), users, stored, log)
+
+	if msg := policy.check(requestBody.NewPassword, stored.Username); msg != "" {
+		log.Warn("new password rejected by policy", slog.String("userUID", userUID), slog.String("reason", msg))
+		http.Error(w, msg, http.StatusBadRequest) // 400 Bad Request
 		return
 	}
 
-	user.Password = requestBody.NewPassword
+	user := user_service.User{UID: stored.UID, Username: stored.Username, Password: requestBody.NewPassword}
 	err = user.HashPassword(log)
 	if err != nil {
-		log.Error("error hashing new password during password change", slog.String("username", requestBody.Username), slog.Any("error", err))
+		log.Error("error hashing new password during password change", slog.String("userUID", userUID), slog.Any("error", err))
 		http.Error(w, "Error hashing new password", http.StatusInternalServerError) // 500 Internal Server Error
 		return
 	}
 
-	err = user_service.UpdatePassword(db, log, &user)
+	err = users.UpdatePassword(r.Context(), user.UID, user.Password)
 	if err != nil {
-		log.Error("error updating password", slog.String("username", user.Username), slog.Any("error", err))
+		log.Error("error updating password", slog.String("userUID", userUID), slog.Any("error", err))
 		http.Error(w, "Error updating password", http.StatusInternalServerError) // 500 Internal Server Error
 		return
 	}
 
-	log.Info("password changed successfully", slog.String("username", requestBody.Username))
+	log.Info("password changed successfully, sessions ended", slog.String("userUID", userUID))
 	w.WriteHeader(http.StatusOK) // 200 OK
 	w.Write([]byte("Password updated successfully"))
 }
@@ -2,13 +2,19 @@ package auth
 
 import (
 	"context"
+	"database/sql"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 	"tbank-go/internal/utils"
 )
 
-func AuthMiddleware(jwtSecret string, log *slog.Logger) func(http.Handler) http.Handler {
+// HouseholdHeader selects the household a request acts on. AuthMiddleware stores its ID and
+// the caller's role in the context as "householdID" (int64) and "householdRole" (string).
+const HouseholdHeader = "X-Household-ID"
+
+func AuthMiddleware(db *sql.DB, jwtSecret string, log *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -21,17 +27,77 @@ func AuthMiddleware(jwtSecret string, log *slog.Logger) func(http.Handler) http.
 			token := strings.TrimPrefix(authHeader, "Bearer ")
 
 			// Parse the JWT token to extract the UID
-			uid, err := utils.ParseJWT(token, jwtSecret)
+			claims, err := utils.ParseJWTClaims(token, jwtSecret)
 			if err != nil {
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
+			uid := claims.UID
+
+			// Reject tokens revoked by logout
+			if claims.ID != "" {
+				var revoked int
+				err = db.QueryRow(`SELECT COUNT(*) FROM revoked_tokens WHERE jti = ?`, claims.ID).Scan(&revoked)
+				if err != nil {
+					log.Error("failed to check token revocation", slog.Any("error", err))
+					http.Error(w, "Internal server error", http.StatusInternalServerError)
+					return
+				}
+				if revoked > 0 {
+					log.Warn("revoked token presented", slog.String("userUID", uid))
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+					return
+				}
+			}
+
+			// Reject tokens issued before the last password change
+			var tokenVersion int
+			err = db.QueryRow(`SELECT token_version FROM users WHERE uid = ?`, uid).Scan(&tokenVersion)
+			if err == sql.ErrNoRows {
+				log.Warn("token of unknown user presented", slog.String("userUID", uid))
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			} else if err != nil {
+				log.Error("failed to check token version", slog.Any("error", err))
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if claims.TokenVersion != tokenVersion {
+				log.Warn("outdated token presented", slog.String("userUID", uid))
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
 
 			// Log UID for debugging
 			log.Info("user authenticated", slog.String("userUID", uid))
 
 			// Attach the UID to the request context
 			ctx := context.WithValue(r.Context(), "userUID", uid)
+			ctx = context.WithValue(ctx, "tokenClaims", claims)
+
+			// Requests on behalf of a household carry its ID; the caller has to be a member
+			if value := r.Header.Get(HouseholdHeader); value != "" {
+				householdID, err := strconv.ParseInt(value, 10, 64)
+				if err != nil || householdID <= 0 {
+					http.Error(w, "Invalid "+HouseholdHeader+" header", http.StatusBadRequest)
+					return
+				}
+				var role string
+				err = db.QueryRow(`SELECT role FROM household_members WHERE household_id = ? AND user_uid = ?`,
+					householdID, uid).Scan(&role)
+				if err == sql.ErrNoRows {
+					log.Warn("household accessed by non-member", slog.String("userUID", uid), slog.Int64("householdID", householdID))
+					http.Error(w, "Not a member of this household", http.StatusForbidden)
+					return
+				} else if err != nil {
+					log.Error("failed to check household membership", slog.Any("error", err))
+					http.Error(w, "Internal server error", http.StatusInternalServerError)
+					return
+				}
+				ctx = context.WithValue(ctx, "householdID", householdID)
+				ctx = context.WithValue(ctx, "householdRole", role)
+			}
+
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"tbank-go/internal/config"
+	"unicode/utf8"
+)
+
+// PasswordPolicy decides whether a new password is acceptable.
+type PasswordPolicy struct {
+	MinLength int
+	breached  map[string]struct{} // lowercased
+}
+
+// LoadPasswordPolicy builds the policy from the config, reading the breached password list if one is set.
+func LoadPasswordPolicy(cfg config.Password) (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{MinLength: cfg.MinLength, breached: make(map[string]struct{})}
+	if cfg.BreachedList == "" {
+		return policy, nil
+	}
+
+	file, err := os.Open(cfg.BreachedList)
+	if err != nil {
+		return nil, fmt.Errorf("open breached password list: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			policy.breached[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read breached password list: %w", err)
+	}
+	return policy, nil
+}
+
+// BreachedCount returns the number of passwords in the breached list.
+func (p *PasswordPolicy) BreachedCount() int {
+	return len(p.breached)
+}
+
+// check returns a message for the user if the password violates the policy, or "" if it is acceptable.
+func (p *PasswordPolicy) check(password, username string) string {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Sprintf("Password must be at least %d characters long", p.MinLength)
+	}
+	if username != "" && strings.EqualFold(password, username) {
+		return "Password must not be the same as the username"
+	}
+	if _, ok := p.breached[strings.ToLower(password)]; ok {
+		return "Password is too common or appeared in a data breach"
+	}
+	return ""
+}
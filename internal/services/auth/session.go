@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"tbank-go/internal/utils"
+	"time"
+)
+
+// RefreshRequest defines the request body for the Refresh and Logout endpoints.
+// @Description Request body carrying a refresh token.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" example:"b2YtY291cnNlLWl0LWlzLW9wYXF1ZQ"`
+}
+
+// TokenPair is returned by Login and Refresh.
+// @Description Access and refresh token pair.
+type TokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	UID          string `json:"uid"`
+}
+
+// issueTokenPair generates a new access token and stores a new refresh token for the user.
+func issueTokenPair(db *sql.DB, uid string, jwtSecret string, jwtLifetime, refreshLifetime time.Duration) (TokenPair, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	pair, err := issueTokenPairTx(tx, uid, jwtSecret, jwtLifetime, refreshLifetime)
+	if err != nil {
+		tx.Rollback()
+		return TokenPair{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return TokenPair{}, err
+	}
+	return pair, nil
+}
+
+func issueTokenPairTx(tx *sql.Tx, uid string, jwtSecret string, jwtLifetime, refreshLifetime time.Duration) (TokenPair, error) {
+	var tokenVersion int
+	err := tx.QueryRow(`SELECT token_version FROM users WHERE uid = ?`, uid).Scan(&tokenVersion)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	accessToken, err := utils.GenerateJWT(uid, tokenVersion, jwtSecret, jwtLifetime)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	refreshToken, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	now := time.Now().UTC()
+	_, err = tx.Exec(
+		`INSERT INTO refresh_tokens (user_uid, token_hash, expires_at, created_at) VALUES (?, ?, ?, ?)`,
+		uid, utils.HashToken(refreshToken), now.Add(refreshLifetime).Format(time.RFC3339), now.Format(time.RFC3339),
+	)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{Token: accessToken, RefreshToken: refreshToken, UID: uid}, nil
+}
+
+// Refresh @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access/refresh token pair. The presented refresh token is revoked.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body RefreshRequest true "Refresh token"
+// @Success 200 {object} TokenPair
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Invalid refresh token"
+// @Failure 500 {string} string "Error refreshing token"
+// @Router /token/refresh [post]
+func Refresh(db *sql.DB, w http.ResponseWriter, r *http.Request, log *slog.Logger, jwtSecret string, jwtLifetime, refreshLifetime time.Duration) {
+	var requestBody RefreshRequest
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil || requestBody.RefreshToken == "" {
+		log.Error("invalid input during token refresh", slog.Any("error", err))
+		http.Error(w, "Invalid input", http.StatusBadRequest) // 400 Bad Request
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Error("failed to start transaction", slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var (
+		tokenID   int64
+		userUID   string
+		expiresAt string
+		revoked   bool
+	)
+	err = tx.QueryRow(
+		`SELECT id, user_uid, expires_at, revoked FROM refresh_tokens WHERE token_hash = ?`,
+		utils.HashToken(requestBody.RefreshToken),
+	).Scan(&tokenID, &userUID, &expiresAt, &revoked)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		log.Warn("unknown refresh token presented")
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized) // 401 Unauthorized
+		return
+	} else if err != nil {
+		tx.Rollback()
+		log.Error("failed to fetch refresh token", slog.Any("error", err))
+		http.Error(w, "Error refreshing token", http.StatusInternalServerError)
+		return
+	}
+
+	if revoked {
+		// A rotated token was used again: assume it was stolen and end every session of the user.
+		_, err = tx.Exec(`UPDATE refresh_tokens SET revoked = 1 WHERE user_uid = ?`, userUID)
+		if err != nil {
+			tx.Rollback()
+			log.Error("failed to revoke refresh tokens", slog.String("userUID", userUID), slog.Any("error", err))
+			http.Error(w, "Error refreshing token", http.StatusInternalServerError)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			log.Error("failed to commit transaction", slog.Any("error", err))
+		}
+		log.Warn("revoked refresh token reused, all sessions revoked", slog.String("userUID", userUID))
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	if expiresAt <= time.Now().UTC().Format(time.RFC3339) {
+		tx.Rollback()
+		log.Warn("expired refresh token presented", slog.String("userUID", userUID))
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	_, err = tx.Exec(`UPDATE refresh_tokens SET revoked = 1 WHERE id = ?`, tokenID)
+	if err != nil {
+		tx.Rollback()
+		log.Error("failed to revoke refresh token", slog.Any("error", err))
+		http.Error(w, "Error refreshing token", http.StatusInternalServerError)
+		return
+	}
+
+	pair, err := issueTokenPairTx(tx, userUID, jwtSecret, jwtLifetime, refreshLifetime)
+	if err != nil {
+		tx.Rollback()
+		log.Error("error generating tokens during refresh", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Error refreshing token", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit transaction", slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Info("tokens refreshed successfully", slog.String("userUID", userUID))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(pair)
+}
+
+// Logout @Summary Logout
+// @Description Revoke the given refresh token and the access token used for this request.
+// @Tags Auth
+// @Accept json
+// @Produce plain
+// @Param body body RefreshRequest false "Refresh token to revoke"
+// @Security BearerAuth
+// @Success 200 {string} string "Logged out successfully"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Error logging out"
+// @Router /logout [post]
+func Logout(db *sql.DB, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+	userUID := r.Context().Value("userUID").(string)
+	claims := r.Context().Value("tokenClaims").(*utils.Claims)
+
+	// The body is optional: without it only the access token is revoked.
+	var requestBody RefreshRequest
+	json.NewDecoder(r.Body).Decode(&requestBody)
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Error("failed to start transaction", slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if requestBody.RefreshToken != "" {
+		_, err = tx.Exec(
+			`UPDATE refresh_tokens SET revoked = 1 WHERE token_hash = ? AND user_uid = ?`,
+			utils.HashToken(requestBody.RefreshToken), userUID,
+		)
+		if err != nil {
+			tx.Rollback()
+			log.Error("failed to revoke refresh token", slog.String("userUID", userUID), slog.Any("error", err))
+			http.Error(w, "Error logging out", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if claims.ID != "" {
+		now := time.Now().UTC().Format(time.RFC3339)
+		// Housekeeping: entries past their expiry can no longer be presented anyway.
+		_, err = tx.Exec(`DELETE FROM revoked_tokens WHERE expires_at <= ?`, now)
+		if err != nil {
+			tx.Rollback()
+			log.Error("failed to clean up revoked tokens", slog.Any("error", err))
+			http.Error(w, "Error logging out", http.StatusInternalServerError)
+			return
+		}
+
+		expiresAt := now
+		if claims.ExpiresAt != nil {
+			expiresAt = claims.ExpiresAt.UTC().Format(time.RFC3339)
+		}
+		_, err = tx.Exec(
+			`INSERT INTO revoked_tokens (jti, expires_at) VALUES (?, ?) ON CONFLICT(jti) DO NOTHING`,
+			claims.ID, expiresAt,
+		)
+		if err != nil {
+			tx.Rollback()
+			log.Error("failed to revoke access token", slog.String("userUID", userUID), slog.Any("error", err))
+			http.Error(w, "Error logging out", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit transaction", slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Info("user logged out successfully", slog.String("userUID", userUID))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Logged out successfully"))
+}
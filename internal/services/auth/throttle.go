@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"tbank-go/internal/config"
+	"tbank-go/internal/ratelimit"
+	"tbank-go/internal/repository"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Throttle protects the endpoints that check a password against brute force. The router
+// limits requests per client IP; Throttle adds a limit per username, so that spreading
+// guesses over many addresses does not help, and a progressive lockout of the account.
+// Both answer unknown and existing usernames alike.
+type Throttle struct {
+	Usernames   ratelimit.Limiter
+	MaxFailures int           // wrong passwords in a row before the account is locked, 0 disables lockout
+	Lockout     time.Duration // first lockout, doubled for every further failure
+	MaxLockout  time.Duration
+}
+
+// NewThrottle returns a Throttle with an in-memory username limiter.
+func NewThrottle(cfg config.RateLimit) *Throttle {
+	return &Throttle{
+		Usernames:   ratelimit.NewMemory(cfg.UsernameInterval, cfg.UsernameBurst),
+		MaxFailures: cfg.MaxFailures,
+		Lockout:     cfg.Lockout,
+		MaxLockout:  cfg.MaxLockout,
+	}
+}
+
+// allowUsername takes a token for the username and answers 429 when there is none.
+func (t *Throttle) allowUsername(w http.ResponseWriter, username string, log *slog.Logger) bool {
+	ok, retryAfter := t.Usernames.Allow(strings.ToLower(username))
+	if !ok {
+		log.Warn("rate limit exceeded", slog.String("username", username))
+		ratelimit.TooManyRequests(w, retryAfter)
+	}
+	return ok
+}
+
+// checkLocked answers a login attempt on a locked account with the same 401 as a wrong
+// password, after the same delay, without checking the password. A 429 with Retry-After would
+// only ever be seen for existing usernames and so tell which ones exist.
+func (t *Throttle) checkLocked(w http.ResponseWriter, user repository.User, password string, log *slog.Logger) bool {
+	if user.LockedUntil == "" {
+		return false
+	}
+	lockedUntil, err := time.Parse(time.RFC3339, user.LockedUntil)
+	if err != nil || !time.Now().Before(lockedUntil) {
+		return false
+	}
+	burnPasswordCheck(password)
+	log.Warn("login attempt on locked account", slog.String("userUID", user.UID), slog.String("lockedUntil", user.LockedUntil))
+	http.Error(w, "Invalid credentials", http.StatusUnauthorized) // 401 Unauthorized
+	return true
+}
+
+// recordFailure counts a wrong password and locks the account once MaxFailures is reached.
+func (t *Throttle) recordFailure(ctx context.Context, users repository.UserRepository, user repository.User, log *slog.Logger) {
+	failures, err := users.RecordLoginFailure(ctx, user.UID)
+	if err != nil {
+		log.Error("failed to record login failure", slog.String("userUID", user.UID), slog.Any("error", err))
+		return
+	}
+	if t.MaxFailures <= 0 || failures < t.MaxFailures {
+		return
+	}
+
+	lockout := t.Lockout
+	for i := t.MaxFailures; i < failures && lockout < t.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if t.MaxLockout > 0 && lockout > t.MaxLockout {
+		lockout = t.MaxLockout
+	}
+
+	until := time.Now().UTC().Add(lockout).Format(time.RFC3339)
+	if err := users.LockUntil(ctx, user.UID, until); err != nil {
+		log.Error("failed to lock account", slog.String("userUID", user.UID), slog.Any("error", err))
+		return
+	}
+	log.Warn("account locked after failed logins", slog.String("userUID", user.UID), slog.Int("failures", failures), slog.String("lockedUntil", until))
+}
+
+// recordSuccess clears the failure counter after a correct password.
+func (t *Throttle) recordSuccess(ctx context.Context, users repository.UserRepository, user repository.User, log *slog.Logger) {
+	if user.FailedLogins == 0 && user.LockedUntil == "" {
+		return
+	}
+	if err := users.ResetLoginFailures(ctx, user.UID); err != nil {
+		log.Error("failed to reset login failures", slog.String("userUID", user.UID), slog.Any("error", err))
+	}
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// burnPasswordCheck spends as much time as a real password check. It is used for unknown
+// usernames so that response times do not reveal which usernames exist.
+func burnPasswordCheck(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+	})
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
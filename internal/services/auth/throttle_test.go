@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"tbank-go/internal/ratelimit"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/repository/memory"
+	"tbank-go/internal/services/servicetest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func login(repos repository.Repositories, throttle *Throttle, username, password string) *httptest.ResponseRecorder {
+	body := `{"username": "` + username + `", "password": "` + password + `"}`
+	w := httptest.NewRecorder()
+	Login(nil, repos.Users, throttle, w, httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body)), servicetest.Discard, "secret", time.Minute, time.Hour)
+	return w
+}
+
+// A locked account must be indistinguishable from an unknown username and a wrong password.
+func TestLoginLockedAccount(t *testing.T) {
+	repos := memory.New()
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Users.Create(context.Background(), &repository.User{UID: "alice-uid", Username: "alice", PasswordHash: string(hash)}); err != nil {
+		t.Fatal(err)
+	}
+	throttle := &Throttle{Usernames: ratelimit.NewMemory(time.Nanosecond, 100), MaxFailures: 3, Lockout: time.Hour, MaxLockout: time.Hour}
+
+	unknown := login(repos, throttle, "mallory", "guess")
+	for i := 0; i < 3; i++ {
+		if w := login(repos, throttle, "alice", "guess"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("wrong password %d: status %d", i+1, w.Code)
+		}
+	}
+	user, _ := repos.Users.GetByUID(context.Background(), "alice-uid")
+	if user.LockedUntil == "" {
+		t.Fatal("account is not locked")
+	}
+
+	for _, password := range []string{"guess", "correct horse"} {
+		locked := login(repos, throttle, "alice", password)
+		if locked.Code != unknown.Code || locked.Body.String() != unknown.Body.String() {
+			t.Errorf("locked account with %q: %d %q, unknown user: %d %q", password,
+				locked.Code, locked.Body, unknown.Code, unknown.Body)
+		}
+		if locked.Header().Get("Retry-After") != "" {
+			t.Errorf("locked account with %q: Retry-After is set", password)
+		}
+	}
+}
@@ -0,0 +1,602 @@
+package auth
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/user-service"
+	"tbank-go/internal/utils"
+	"time"
+)
+
+const (
+	totpIssuer = "TBank"
+	// recoveryCodeCount is the number of recovery codes issued at once; issuing a new set invalidates the old one.
+	recoveryCodeCount = 10
+	// challengeLifetime bounds the time between the password step and the code step of a login.
+	challengeLifetime = 5 * time.Minute
+	// maxChallengeAttempts is the number of wrong codes after which a challenge is discarded.
+	maxChallengeAttempts = 5
+)
+
+// TwoFactorCodeRequest carries a code from the authenticator app or, where accepted, a recovery code.
+// @Description Request body carrying a second factor.
+type TwoFactorCodeRequest struct {
+	Code         string `json:"code" example:"123456"`
+	RecoveryCode string `json:"recovery_code,omitempty" example:"k3f9-2hxq-7pdm"`
+}
+
+// DisableTwoFactorRequest defines the request body for the Disable 2FA endpoint.
+// @Description Password and a second factor required to turn 2FA off.
+type DisableTwoFactorRequest struct {
+	Password     string `json:"password" example:"password123"`
+	Code         string `json:"code" example:"123456"`
+	RecoveryCode string `json:"recovery_code,omitempty" example:"k3f9-2hxq-7pdm"`
+}
+
+// TwoFactorLoginRequest defines the request body for the second login step.
+// @Description Challenge token from Login together with a TOTP or recovery code.
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" example:"Y2hhbGxlbmdlLXRva2Vu"`
+	Code           string `json:"code" example:"123456"`
+	RecoveryCode   string `json:"recovery_code,omitempty" example:"k3f9-2hxq-7pdm"`
+}
+
+// EnrollmentResponse is returned by EnrollTwoFactor.
+// @Description Secret to be added to an authenticator app.
+type EnrollmentResponse struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	OTPAuthURI string `json:"otpauth_uri" example:"otpauth://totp/TBank:johndoe?secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP&issuer=TBank"`
+}
+
+// RecoveryCodesResponse carries freshly issued recovery codes. They are shown only once.
+// @Description One-time recovery codes.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAChallenge is returned by Login instead of a TokenPair when the user has 2FA enabled.
+// @Description Second login step required.
+type MFAChallenge struct {
+	MFARequired    bool   `json:"mfa_required" example:"true"`
+	ChallengeToken string `json:"challenge_token" example:"Y2hhbGxlbmdlLXRva2Vu"`
+	ExpiresAt      string `json:"expires_at" example:"2024-10-05T12:05:00Z"`
+}
+
+// twoFactorState is the 2FA part of a users row.
+type twoFactorState struct {
+	secret   sql.NullString
+	enabled  bool
+	lastStep int64
+}
+
+func loadTwoFactorState(q user_service.Querier, userUID string) (twoFactorState, error) {
+	var state twoFactorState
+	err := q.QueryRow(
+		`SELECT totp_secret, totp_enabled, totp_last_step FROM users WHERE uid = ?`, userUID,
+	).Scan(&state.secret, &state.enabled, &state.lastStep)
+	return state, err
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code and consumes it:
+// the TOTP step is remembered so the same code cannot be replayed, a recovery code is marked used.
+func verifySecondFactor(tx *sql.Tx, userUID string, state twoFactorState, code, recoveryCode string) (bool, error) {
+	now := time.Now().UTC()
+
+	if recoveryCode != "" {
+		res, err := tx.Exec(
+			`UPDATE recovery_codes SET used_at = ? WHERE user_uid = ? AND code_hash = ? AND used_at IS NULL`,
+			now.Format(time.RFC3339), userUID, utils.HashRecoveryCode(recoveryCode),
+		)
+		if err != nil {
+			return false, err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+		return affected > 0, nil
+	}
+
+	step, ok := utils.ValidateTOTP(state.secret.String, code, now, state.lastStep)
+	if !ok {
+		return false, nil
+	}
+	_, err := tx.Exec(`UPDATE users SET totp_last_step = ? WHERE uid = ?`, step, userUID)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// replaceRecoveryCodes drops every recovery code of the user and stores hashes of a new set.
+func replaceRecoveryCodes(tx *sql.Tx, userUID string) ([]string, error) {
+	_, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_uid = ?`, userUID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := utils.GenerateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(
+			`INSERT INTO recovery_codes (user_uid, code_hash, created_at) VALUES (?, ?, ?)`,
+			userUID, utils.HashRecoveryCode(code), now,
+		)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// createChallenge stores a new login challenge for the user and returns it.
+func createChallenge(db *sql.DB, userUID string) (MFAChallenge, error) {
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return MFAChallenge{}, err
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(challengeLifetime).Format(time.RFC3339)
+
+	// Housekeeping: expired challenges can no longer be exchanged anyway.
+	_, err = db.Exec(`DELETE FROM mfa_challenges WHERE expires_at <= ?`, now.Format(time.RFC3339))
+	if err != nil {
+		return MFAChallenge{}, err
+	}
+
+	_, err = db.Exec(
+		`INSERT INTO mfa_challenges (user_uid, token_hash, expires_at, created_at) VALUES (?, ?, ?, ?)`,
+		userUID, utils.HashToken(token), expiresAt, now.Format(time.RFC3339),
+	)
+	if err != nil {
+		return MFAChallenge{}, err
+	}
+
+	return MFAChallenge{MFARequired: true, ChallengeToken: token, ExpiresAt: expiresAt}, nil
+}
+
+// EnrollTwoFactor @Summary Start 2FA enrollment
+// @Description Generate a new TOTP secret. 2FA stays off until the secret is confirmed with a code via /2fa/confirm.
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} EnrollmentResponse
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "User not found"
+// @Failure 409 {string} string "Two-factor authentication is already enabled"
+// @Failure 500 {string} string "Error enrolling two-factor authentication"
+// @Router /2fa/enroll [post]
+func EnrollTwoFactor(db *sql.DB, users repository.UserRepository, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+	userUID := r.Context().Value("userUID").(string)
+
+	user, err := users.GetByUID(r.Context(), userUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Error("user not found during 2fa enrollment", slog.String("userUID", userUID))
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Error("failed to get user", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		log.Error("failed to generate totp secret", slog.Any("error", err))
+		http.Error(w, "Error enrolling two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	// A pending (unconfirmed) secret is simply replaced, an active one is left alone.
+	res, err := db.Exec(`UPDATE users SET totp_secret = ? WHERE uid = ? AND totp_enabled = 0`, secret, userUID)
+	if err != nil {
+		log.Error("failed to store totp secret", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Error enrolling two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		log.Warn("2fa already enabled", slog.String("userUID", userUID))
+		http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+
+	log.Info("2fa enrollment started", slog.String("userUID", userUID))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(EnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(totpIssuer, user.Username, secret),
+	})
+}
+
+// ConfirmTwoFactor @Summary Confirm 2FA enrollment
+// @Description Enable 2FA by proving possession of the secret with a first code. Returns one-time recovery codes.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body TwoFactorCodeRequest true "Code from the authenticator app"
+// @Security BearerAuth
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Invalid code"
+// @Failure 409 {string} string "Two-factor authentication is already enabled"
+// @Failure 500 {string} string "Error enabling two-factor authentication"
+// @Router /2fa/confirm [post]
+func ConfirmTwoFactor(db *sql.DB, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+	userUID := r.Context().Value("userUID").(string)
+
+	var requestBody TwoFactorCodeRequest
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil || requestBody.Code == "" {
+		log.Error("invalid input during 2fa confirmation", slog.Any("error", err))
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Error("failed to start transaction", slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	state, err := loadTwoFactorState(tx, userUID)
+	if err != nil {
+		tx.Rollback()
+		log.Error("failed to load 2fa state", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Error enabling two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+	if state.enabled {
+		tx.Rollback()
+		http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+	if !state.secret.Valid {
+		tx.Rollback()
+		http.Error(w, "Two-factor enrollment has not been started", http.StatusBadRequest)
+		return
+	}
+
+	ok, err := verifySecondFactor(tx, userUID, state, requestBody.Code, "")
+	if err != nil {
+		tx.Rollback()
+		log.Error("failed to verify totp code", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Error enabling two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		tx.Rollback()
+		log.Warn("invalid totp code during 2fa confirmation", slog.String("userUID", userUID))
+		http.Error(w, "Invalid code", http.StatusUnauthorized)
+		return
+	}
+
+	_, err = tx.Exec(`UPDATE users SET totp_enabled = 1 WHERE uid = ?`, userUID)
+	if err != nil {
+		tx.Rollback()
+		log.Error("failed to enable 2fa", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Error enabling two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	codes, err := replaceRecoveryCodes(tx, userUID)
+	if err != nil {
+		tx.Rollback()
+		log.Error("failed to issue recovery codes", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Error enabling two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit transaction", slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Info("2fa enabled", slog.String("userUID", userUID))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateRecoveryCodes @Summary Regenerate recovery codes
+// @Description Replace all recovery codes with a new set. Requires a current TOTP code.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body TwoFactorCodeRequest true "Code from the authenticator app"
+// @Security BearerAuth
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Invalid code"
+// @Failure 409 {string} string "Two-factor authentication is not enabled"
+// @Failure 500 {string} string "Error issuing recovery codes"
+// @Router /2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(db *sql.DB, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+	userUID := r.Context().Value("userUID").(string)
+
+	var requestBody TwoFactorCodeRequest
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil || requestBody.Code == "" {
+		log.Error("invalid input during recovery code regeneration", slog.Any("error", err))
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Error("failed to start transaction", slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	state, err := loadTwoFactorState(tx, userUID)
+	if err != nil {
+		tx.Rollback()
+		log.Error("failed to load 2fa state", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Error issuing recovery codes", http.StatusInternalServerError)
+		return
+	}
+	if !state.enabled {
+		tx.Rollback()
+		http.Error(w, "Two-factor authentication is not enabled", http.StatusConflict)
+		return
+	}
+
+	// Only a TOTP code is accepted here: a leaked recovery code must not be enough to mint new ones.
+	ok, err := verifySecondFactor(tx, userUID, state, requestBody.Code, "")
+	if err != nil {
+		tx.Rollback()
+		log.Error("failed to verify totp code", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Error issuing recovery codes", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		tx.Rollback()
+		log.Warn("invalid totp code during recovery code regeneration", slog.String("userUID", userUID))
+		http.Error(w, "Invalid code", http.StatusUnauthorized)
+		return
+	}
+
+	codes, err := replaceRecoveryCodes(tx, userUID)
+	if err != nil {
+		tx.Rollback()
+		log.Error("failed to issue recovery codes", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Error issuing recovery codes", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit transaction", slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Info("recovery codes regenerated", slog.String("userUID", userUID))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor @Summary Disable 2FA
+// @Description Turn 2FA off. Requires the password and a TOTP or recovery code; all recovery codes are deleted.
+// @Tags Auth
+// @Accept json
+// @Produce plain
+// @Param body body DisableTwoFactorRequest true "Password and second factor"
+// @Security BearerAuth
+// @Success 200 {string} string "Two-factor authentication disabled"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Invalid credentials"
+// @Failure 409 {string} string "Two-factor authentication is not enabled"
+// @Failure 500 {string} string "Error disabling two-factor authentication"
+// @Router /2fa/disable [post]
+func DisableTwoFactor(db *sql.DB, users repository.UserRepository, w http.ResponseWriter, r *http.Request, log *slog.Logger) {
+	userUID := r.Context().Value("userUID").(string)
+
+	var requestBody DisableTwoFactorRequest
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil || requestBody.Password == "" || (requestBody.Code == "" && requestBody.RecoveryCode == "") {
+		log.Error("invalid input during 2fa disable", slog.Any("error", err))
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	user, err := users.GetByUID(r.Context(), userUID)
+	if err != nil {
+		log.Error("failed to get user", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := utils.CheckPassword(user.PasswordHash, requestBody.Password); err != nil {
+		log.Warn("invalid password during 2fa disable", slog.String("userUID", userUID))
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Error("failed to start transaction", slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	state, err := loadTwoFactorState(tx, userUID)
+	if err != nil {
+		tx.Rollback()
+		log.Error("failed to load 2fa state", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Error disabling two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+	if !state.enabled {
+		tx.Rollback()
+		http.Error(w, "Two-factor authentication is not enabled", http.StatusConflict)
+		return
+	}
+
+	ok, err := verifySecondFactor(tx, userUID, state, requestBody.Code, requestBody.RecoveryCode)
+	if err != nil {
+		tx.Rollback()
+		log.Error("failed to verify second factor", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Error disabling two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		tx.Rollback()
+		log.Warn("invalid second factor during 2fa disable", slog.String("userUID", userUID))
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	_, err = tx.Exec(`UPDATE users SET totp_secret = NULL, totp_enabled = 0, totp_last_step = 0 WHERE uid = ?`, userUID)
+	if err == nil {
+		_, err = tx.Exec(`DELETE FROM recovery_codes WHERE user_uid = ?`, userUID)
+	}
+	if err == nil {
+		_, err = tx.Exec(`DELETE FROM mfa_challenges WHERE user_uid = ?`, userUID)
+	}
+	if err != nil {
+		tx.Rollback()
+		log.Error("failed to disable 2fa", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Error disabling two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit transaction", slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Info("2fa disabled", slog.String("userUID", userUID))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Two-factor authentication disabled"))
+}
+
+// VerifyTwoFactorLogin @Summary Complete a two-step login
+// @Description Exchange the challenge token returned by Login and a TOTP or recovery code for a token pair.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body TwoFactorLoginRequest true "Challenge and second factor"
+// @Success 200 {object} TokenPair
+// @Failure 400 {string} string "Invalid input"
+// @Failure 401 {string} string "Invalid code"
+// @Failure 500 {string} string "Error generating token"
+// @Router /login/2fa [post]
+func VerifyTwoFactorLogin(db *sql.DB, w http.ResponseWriter, r *http.Request, log *slog.Logger, jwtSecret string, jwtLifetime, refreshLifetime time.Duration) {
+	var requestBody TwoFactorLoginRequest
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil || requestBody.ChallengeToken == "" || (requestBody.Code == "" && requestBody.RecoveryCode == "") {
+		log.Error("invalid input during 2fa login", slog.Any("error", err))
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Error("failed to start transaction", slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var (
+		challengeID int64
+		userUID     string
+		attempts    int
+		expiresAt   string
+	)
+	err = tx.QueryRow(
+		`SELECT id, user_uid, attempts, expires_at FROM mfa_challenges WHERE token_hash = ?`,
+		utils.HashToken(requestBody.ChallengeToken),
+	).Scan(&challengeID, &userUID, &attempts, &expiresAt)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		log.Warn("unknown 2fa challenge presented")
+		http.Error(w, "Invalid or expired challenge", http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		tx.Rollback()
+		log.Error("failed to fetch 2fa challenge", slog.Any("error", err))
+		http.Error(w, "Error generating token", http.StatusInternalServerError)
+		return
+	}
+
+	if expiresAt <= time.Now().UTC().Format(time.RFC3339) || attempts >= maxChallengeAttempts {
+		tx.Rollback()
+		log.Warn("expired or exhausted 2fa challenge presented", slog.String("userUID", userUID))
+		http.Error(w, "Invalid or expired challenge", http.StatusUnauthorized)
+		return
+	}
+
+	state, err := loadTwoFactorState(tx, userUID)
+	if err != nil {
+		tx.Rollback()
+		log.Error("failed to load 2fa state", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Error generating token", http.StatusInternalServerError)
+		return
+	}
+
+	ok, err := verifySecondFactor(tx, userUID, state, requestBody.Code, requestBody.RecoveryCode)
+	if err != nil {
+		tx.Rollback()
+		log.Error("failed to verify second factor", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Error generating token", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		// The failed attempt is counted even though nothing else changes.
+		_, err = tx.Exec(`UPDATE mfa_challenges SET attempts = attempts + 1 WHERE id = ?`, challengeID)
+		if err != nil {
+			tx.Rollback()
+			log.Error("failed to count 2fa attempt", slog.Any("error", err))
+			http.Error(w, "Error generating token", http.StatusInternalServerError)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			log.Error("failed to commit transaction", slog.Any("error", err))
+		}
+		log.Warn("invalid second factor during login", slog.String("userUID", userUID))
+		http.Error(w, "Invalid code", http.StatusUnauthorized)
+		return
+	}
+
+	// Challenges are single-use.
+	_, err = tx.Exec(`DELETE FROM mfa_challenges WHERE id = ?`, challengeID)
+	if err != nil {
+		tx.Rollback()
+		log.Error("failed to delete 2fa challenge", slog.Any("error", err))
+		http.Error(w, "Error generating token", http.StatusInternalServerError)
+		return
+	}
+
+	pair, err := issueTokenPairTx(tx, userUID, jwtSecret, jwtLifetime, refreshLifetime)
+	if err != nil {
+		tx.Rollback()
+		log.Error("error generating tokens during 2fa login", slog.String("userUID", userUID), slog.Any("error", err))
+		http.Error(w, "Error generating token", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit transaction", slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Info("user logged in with second factor", slog.String("userUID", userUID))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(pair)
+}
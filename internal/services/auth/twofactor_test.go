@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"tbank-go/internal/repository/sqlstore"
+	"tbank-go/internal/services/servicetest"
+	"tbank-go/internal/storage/storagetest"
+	"tbank-go/internal/utils"
+	"testing"
+	"time"
+)
+
+func TestTwoFactorLogin(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		servicetest.CreateUser(t, repos, "alice")
+
+		w := httptest.NewRecorder()
+		EnrollTwoFactor(db, repos.Users, w, servicetest.NewRequest(http.MethodPost, "/2fa/enroll", "", "alice"), servicetest.Discard)
+		enrollment := servicetest.Decode[EnrollmentResponse](t, w, http.StatusOK)
+
+		code := func(step int64) string {
+			t.Helper()
+			c, err := utils.TOTPCode(enrollment.Secret, step)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return c
+		}
+		step := utils.TOTPStep(time.Now())
+		confirmCode := code(step)
+
+		w = httptest.NewRecorder()
+		ConfirmTwoFactor(db, w, servicetest.NewRequest(http.MethodPost, "/2fa/confirm", `{"code": "`+confirmCode+`"}`, "alice"), servicetest.Discard)
+		recovery := servicetest.Decode[RecoveryCodesResponse](t, w, http.StatusOK).RecoveryCodes
+		if len(recovery) != recoveryCodeCount {
+			t.Fatalf("got %d recovery codes, want %d", len(recovery), recoveryCodeCount)
+		}
+
+		newChallenge := func() string {
+			t.Helper()
+			challenge, err := createChallenge(db, "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			return challenge.ChallengeToken
+		}
+		verify := func(challenge, code, recoveryCode string) *httptest.ResponseRecorder {
+			body := `{"challenge_token": "` + challenge + `", "code": "` + code + `", "recovery_code": "` + recoveryCode + `"}`
+			w := httptest.NewRecorder()
+			VerifyTwoFactorLogin(db, w, httptest.NewRequest(http.MethodPost, "/login/2fa", strings.NewReader(body)), servicetest.Discard,
+				"secret", time.Minute, time.Hour)
+			return w
+		}
+		assertRejected := func(name string, w *httptest.ResponseRecorder, message string) {
+			t.Helper()
+			if w.Code != http.StatusUnauthorized || strings.TrimSpace(w.Body.String()) != message {
+				t.Errorf("%s: %d %q, want 401 %q", name, w.Code, strings.TrimSpace(w.Body.String()), message)
+			}
+		}
+
+		// The code that confirmed the enrollment cannot be replayed, the next step's code logs in once.
+		challenge := newChallenge()
+		assertRejected("replayed code", verify(challenge, confirmCode, ""), "Invalid code")
+		servicetest.Decode[TokenPair](t, verify(challenge, code(step+1), ""), http.StatusOK)
+		assertRejected("used challenge", verify(challenge, code(step+1), ""), "Invalid or expired challenge")
+		assertRejected("code of a used step", verify(newChallenge(), code(step+1), ""), "Invalid code")
+
+		// An expired challenge is refused even with a valid second factor, which stays unused.
+		expired := newChallenge()
+		past := time.Now().UTC().Add(-time.Second).Format(time.RFC3339)
+		if _, err := db.Exec(`UPDATE mfa_challenges SET expires_at = ? WHERE token_hash = ?`, past, utils.HashToken(expired)); err != nil {
+			t.Fatal(err)
+		}
+		assertRejected("expired challenge", verify(expired, "", recovery[0]), "Invalid or expired challenge")
+
+		// After maxChallengeAttempts wrong codes the challenge is dead.
+		exhausted := newChallenge()
+		for i := 0; i < maxChallengeAttempts; i++ {
+			assertRejected("wrong code", verify(exhausted, "000000", ""), "Invalid code")
+		}
+		assertRejected("exhausted challenge", verify(exhausted, "", recovery[0]), "Invalid or expired challenge")
+
+		// A recovery code works exactly once, however it is typed.
+		servicetest.Decode[TokenPair](t, verify(newChallenge(), "", recovery[0]), http.StatusOK)
+		assertRejected("reused recovery code", verify(newChallenge(), "", recovery[0]), "Invalid code")
+		servicetest.Decode[TokenPair](t, verify(newChallenge(), "", strings.ToUpper(recovery[1])), http.StatusOK)
+		assertRejected("reused recovery code without dashes", verify(newChallenge(), "", strings.ReplaceAll(recovery[1], "-", "")), "Invalid code")
+	})
+}
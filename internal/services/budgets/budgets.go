@@ -0,0 +1,481 @@
+package budgets
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/user-service"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Budget periods.
+const (
+	PeriodMonthly = "monthly"
+	PeriodWeekly  = "weekly"
+)
+
+// Carry-over rules applied when a period ends.
+const (
+	CarryOverNone   = "none"   // every period starts from the plain limit
+	CarryOverUnused = "unused" // unspent money is added to the next period, overspending is forgotten
+	CarryOverAll    = "all"    // both unspent money and overspending move to the next period
+)
+
+// Budget is a spending limit for one expense category and its subcategories. A household
+// budget limits the expenses shared with the household by all its members in their categories
+// of the same name.
+type Budget struct {
+	ID          int         `json:"id"`
+	CategoryID  int64       `json:"category_id"`
+	Category    string      `json:"category"`
+	Period      string      `json:"period"`
+	Limit       money.Money `json:"limit"`
+	CarryOver   string      `json:"carry_over"`
+	StartDate   string      `json:"start_date"`
+	HouseholdID int64       `json:"household_id,omitempty"`
+}
+
+// scope selects the budgets a request acts on: the user's personal budgets or, when the
+// X-Household-ID header is sent, the budgets of that household.
+type scope struct {
+	userUID     string
+	householdID int64
+	role        string // the user's role in the household
+}
+
+func scopeOf(r *http.Request) scope {
+	s := scope{userUID: r.Context().Value("userUID").(string)}
+	if householdID, ok := r.Context().Value("householdID").(int64); ok {
+		s.householdID = householdID
+		s.role = r.Context().Value("householdRole").(string)
+	}
+	return s
+}
+
+// where returns the condition selecting the budgets of the scope and its argument.
+func (s scope) where() (string, any) {
+	if s.householdID != 0 {
+		return "household_id = ?", s.householdID
+	}
+	return "user_uid = ? AND household_id IS NULL", s.userUID
+}
+
+// canEdit reports whether the user may change the budgets of the scope.
+func (s scope) canEdit() bool {
+	return s.householdID == 0 || repository.CanEdit(s.role)
+}
+
+// BudgetRequest is the body of the create and update endpoints.
+type BudgetRequest struct {
+	CategoryID int64       `json:"category_id,omitempty"` // takes precedence over category
+	Category   string      `json:"category" example:"Food"`
+	Period     string      `json:"period" example:"monthly"`
+	Limit      money.Money `json:"limit" swaggertype:"string" example:"15000"`
+	CarryOver  string      `json:"carry_over,omitempty" example:"unused"`
+	StartDate  string      `json:"start_date,omitempty" example:"2024-01-01"`
+}
+
+// validate normalizes the request and returns a user-facing message when it is invalid.
+func (req *BudgetRequest) validate(currency string) string {
+	if req.CategoryID == 0 && req.Category == "" {
+		return "category is required"
+	}
+	if req.Period != PeriodMonthly && req.Period != PeriodWeekly {
+		return "period must be monthly or weekly"
+	}
+
+	if req.CarryOver == "" {
+		req.CarryOver = CarryOverNone
+	}
+	if req.CarryOver != CarryOverNone && req.CarryOver != CarryOverUnused && req.CarryOver != CarryOverAll {
+		return "carry_over must be none, unused or all"
+	}
+
+	if req.StartDate == "" {
+		req.StartDate = time.Now().Format(dateLayout)
+	}
+	if _, err := time.Parse(dateLayout, req.StartDate); err != nil {
+		return "Invalid start_date format (YYYY-MM-DD)"
+	}
+
+	limit, err := req.Limit.OrDefault(currency)
+	if err != nil || limit.Currency != currency || !limit.IsPositive() {
+		return fmt.Sprintf("limit must be a positive %s value", currency)
+	}
+	req.Limit = limit
+
+	return ""
+}
+
+// CreateBudgetHandler creates a budget for a category
+// @Summary Create Budget
+// @Description Creates a monthly or weekly spending limit for one of the user's expense categories, given by category_id
+// @Description or by name; expenses of its subcategories count toward it. With X-Household-ID the budget belongs to the
+// @Description household and limits the expenses shared with it; viewers may not create it.
+// @Tags Budgets
+// @Accept json
+// @Produce json
+// @Param X-Household-ID header int false "Household to create the budget for"
+// @Param budget body budgets.BudgetRequest true "Budget details"
+// @Security BearerAuth
+// @Success 201 {object} budgets.Budget "Created budget"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Viewers cannot change household budgets"
+// @Failure 409 {string} string "Budget already exists"
+// @Failure 500 {string} string "Failed to create budget"
+// @Router /api/budgets [post]
+func CreateBudgetHandler(db *sql.DB, categories repository.CategoryRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+		scope := scopeOf(r)
+
+		var req BudgetRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for budget", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		if !scope.canEdit() {
+			http.Error(w, "Viewers cannot change household budgets", http.StatusForbidden)
+			return
+		}
+
+		currency, err := user_service.GetUserCurrency(db, userUID)
+		if err != nil {
+			log.Error("failed to fetch user currency", slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if msg := req.validate(currency); msg != "" {
+			log.Error("invalid budget", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		category, ok := resolveCategory(categories, w, r, log, req)
+		if !ok {
+			return
+		}
+
+		var exists int
+		condition, arg := scope.where()
+		err = db.QueryRow(`SELECT COUNT(*) FROM budgets WHERE `+condition+` AND category_id = ? AND period = ?`,
+			arg, category.ID, req.Period).Scan(&exists)
+		if err != nil {
+			log.Error("failed to check existing budgets", slog.Any("error", err))
+			http.Error(w, "Failed to create budget", http.StatusInternalServerError)
+			return
+		}
+		if exists > 0 {
+			http.Error(w, "Budget for this category and period already exists", http.StatusConflict)
+			return
+		}
+
+		budget := Budget{
+			CategoryID:  category.ID,
+			Category:    category.Name,
+			Period:      req.Period,
+			Limit:       req.Limit,
+			CarryOver:   req.CarryOver,
+			StartDate:   req.StartDate,
+			HouseholdID: scope.householdID,
+		}
+
+		query := `INSERT INTO budgets (user_uid, household_id, category_id, category, period, amount, currency, carry_over, start_date, created_at)
+		          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`
+		household := sql.NullInt64{Int64: budget.HouseholdID, Valid: budget.HouseholdID != 0}
+		err = db.QueryRow(query, userUID, household, budget.CategoryID, budget.Category, budget.Period, budget.Limit.Amount, budget.Limit.Currency,
+			budget.CarryOver, budget.StartDate, time.Now().UTC().Format(time.RFC3339)).Scan(&budget.ID)
+		if err != nil {
+			log.Error("failed to create budget", slog.Any("error", err))
+			http.Error(w, "Failed to create budget", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("budget created successfully", slog.Int("budgetID", budget.ID), slog.String("userUID", userUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(budget)
+	}
+}
+
+// GetBudgetsHandler lists the user's budgets
+// @Summary List Budgets
+// @Description Returns all personal budgets of the authenticated user, or the budgets of the household given in X-Household-ID.
+// @Tags Budgets
+// @Produce json
+// @Param X-Household-ID header int false "Household to list the budgets of"
+// @Security BearerAuth
+// @Success 200 {array} budgets.Budget "Budgets"
+// @Failure 500 {string} string "Failed to fetch budgets"
+// @Router /api/budgets [get]
+func GetBudgetsHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		budgets, err := listBudgets(db, scopeOf(r))
+		if err != nil {
+			log.Error("failed to fetch budgets", slog.Any("error", err))
+			http.Error(w, "Failed to fetch budgets", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(budgets)
+	}
+}
+
+// GetBudgetHandler returns one budget
+// @Summary Get Budget
+// @Description Returns a budget owned by the authenticated user or by a household the user belongs to.
+// @Tags Budgets
+// @Produce json
+// @Param id path int true "Budget ID"
+// @Security BearerAuth
+// @Success 200 {object} budgets.Budget "Budget"
+// @Failure 403 {string} string "Unauthorized to access this budget"
+// @Failure 404 {string} string "Budget not found"
+// @Failure 500 {string} string "Failed to fetch budget"
+// @Router /api/budgets/{id} [get]
+func GetBudgetHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		budget, ok := loadOwnedBudget(db, w, r, log, false)
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(budget)
+	}
+}
+
+// UpdateBudgetHandler replaces a budget
+// @Summary Update Budget
+// @Description Replaces the category, period, limit and carry-over rule of a budget. Viewers of a household may not
+// @Description change its budgets.
+// @Tags Budgets
+// @Accept json
+// @Produce json
+// @Param id path int true "Budget ID"
+// @Param budget body budgets.BudgetRequest true "Budget details"
+// @Security BearerAuth
+// @Success 200 {object} budgets.Budget "Updated budget"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Unauthorized to change this budget"
+// @Failure 404 {string} string "Budget not found"
+// @Failure 409 {string} string "Budget already exists"
+// @Failure 500 {string} string "Failed to update budget"
+// @Router /api/budgets/{id} [put]
+func UpdateBudgetHandler(db *sql.DB, categories repository.CategoryRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		var req BudgetRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for budget", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		budget, ok := loadOwnedBudget(db, w, r, log, true)
+		if !ok {
+			return
+		}
+
+		if req.StartDate == "" {
+			req.StartDate = budget.StartDate
+		}
+		if msg := req.validate(budget.Limit.Currency); msg != "" {
+			log.Error("invalid budget", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		category, ok := resolveCategory(categories, w, r, log, req)
+		if !ok {
+			return
+		}
+
+		var exists int
+		condition, arg := scope{userUID: userUID, householdID: budget.HouseholdID}.where()
+		err := db.QueryRow(`SELECT COUNT(*) FROM budgets WHERE `+condition+` AND category_id = ? AND period = ? AND id != ?`,
+			arg, category.ID, req.Period, budget.ID).Scan(&exists)
+		if err != nil {
+			log.Error("failed to check existing budgets", slog.Any("error", err))
+			http.Error(w, "Failed to update budget", http.StatusInternalServerError)
+			return
+		}
+		if exists > 0 {
+			http.Error(w, "Budget for this category and period already exists", http.StatusConflict)
+			return
+		}
+
+		budget.CategoryID = category.ID
+		budget.Category = category.Name
+		budget.Period = req.Period
+		budget.Limit = req.Limit
+		budget.CarryOver = req.CarryOver
+		budget.StartDate = req.StartDate
+
+		query := `UPDATE budgets SET category_id = ?, category = ?, period = ?, amount = ?, currency = ?, carry_over = ?, start_date = ? WHERE id = ?`
+		_, err = db.Exec(query, budget.CategoryID, budget.Category, budget.Period, budget.Limit.Amount, budget.Limit.Currency,
+			budget.CarryOver, budget.StartDate, budget.ID)
+		if err != nil {
+			log.Error("failed to update budget", slog.Int("budgetID", budget.ID), slog.Any("error", err))
+			http.Error(w, "Failed to update budget", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("budget updated successfully", slog.Int("budgetID", budget.ID), slog.String("userUID", userUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(budget)
+	}
+}
+
+// DeleteBudgetHandler deletes a budget
+// @Summary Delete Budget
+// @Description Deletes a budget owned by the authenticated user or, unless the user is a viewer, by the user's household.
+// @Description Expenses are not affected.
+// @Tags Budgets
+// @Produce json
+// @Param id path int true "Budget ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Success message"
+// @Failure 403 {string} string "Unauthorized to change this budget"
+// @Failure 404 {string} string "Budget not found"
+// @Failure 500 {string} string "Failed to delete budget"
+// @Router /api/budgets/{id} [delete]
+func DeleteBudgetHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		budget, ok := loadOwnedBudget(db, w, r, log, true)
+		if !ok {
+			return
+		}
+
+		if _, err := db.Exec(`DELETE FROM budgets WHERE id = ?`, budget.ID); err != nil {
+			log.Error("failed to delete budget", slog.Int("budgetID", budget.ID), slog.Any("error", err))
+			http.Error(w, "Failed to delete budget", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("budget deleted successfully", slog.Int("budgetID", budget.ID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Budget deleted successfully"}`))
+	}
+}
+
+// resolveCategory returns the user's expense category the request names.
+// It writes the error response itself and reports whether the handler may continue.
+func resolveCategory(categories repository.CategoryRepository, w http.ResponseWriter, r *http.Request, log *slog.Logger, req BudgetRequest) (repository.Category, bool) {
+	userUID := r.Context().Value("userUID").(string)
+	category, err := repository.ResolveCategory(r.Context(), categories, userUID, repository.CategoryExpense, req.CategoryID, req.Category)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Warn("category not found", slog.Int64("categoryID", req.CategoryID), slog.String("category", req.Category))
+		http.Error(w, "Unknown category", http.StatusBadRequest)
+		return repository.Category{}, false
+	} else if err != nil {
+		log.Error("failed to fetch category", slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return repository.Category{}, false
+	}
+	return category, true
+}
+
+// loadOwnedBudget fetches the budget from the {id} URL parameter and checks that the caller owns it
+// or is a member of its household; with edit set, household viewers are refused.
+// It writes the error response itself and reports whether the handler may continue.
+func loadOwnedBudget(db *sql.DB, w http.ResponseWriter, r *http.Request, log *slog.Logger, edit bool) (Budget, bool) {
+	budgetID := chi.URLParam(r, "id")
+	userUID := r.Context().Value("userUID").(string)
+
+	id, err := strconv.ParseInt(budgetID, 10, 64)
+	if err != nil {
+		log.Warn("invalid budget ID parameter", slog.String("budgetID", budgetID))
+		http.Error(w, "Invalid budget ID", http.StatusBadRequest)
+		return Budget{}, false
+	}
+
+	var budget Budget
+	var ownerUID string
+	var household, categoryID sql.NullInt64
+	query := `SELECT id, user_uid, household_id, category_id, category, period, amount, currency, carry_over, start_date FROM budgets WHERE id = ?`
+	err = db.QueryRow(query, id).Scan(&budget.ID, &ownerUID, &household, &categoryID, &budget.Category, &budget.Period,
+		&budget.Limit.Amount, &budget.Limit.Currency, &budget.CarryOver, &budget.StartDate)
+	if err == sql.ErrNoRows {
+		log.Warn("budget not found", slog.String("budgetID", budgetID))
+		http.Error(w, "Budget not found", http.StatusNotFound)
+		return Budget{}, false
+	} else if err != nil {
+		log.Error("failed to fetch budget", slog.Any("error", err))
+		http.Error(w, "Failed to fetch budget", http.StatusInternalServerError)
+		return Budget{}, false
+	}
+	budget.CategoryID = categoryID.Int64
+
+	if !household.Valid {
+		if ownerUID != userUID {
+			log.Warn("unauthorized attempt to access budget", slog.String("userUID", userUID), slog.String("ownerUID", ownerUID))
+			http.Error(w, "Unauthorized to access this budget", http.StatusForbidden)
+			return Budget{}, false
+		}
+		return budget, true
+	}
+	budget.HouseholdID = household.Int64
+
+	var role string
+	err = db.QueryRow(`SELECT role FROM household_members WHERE household_id = ? AND user_uid = ?`,
+		budget.HouseholdID, userUID).Scan(&role)
+	if err == sql.ErrNoRows {
+		log.Warn("unauthorized attempt to access household budget", slog.String("userUID", userUID), slog.Int64("householdID", budget.HouseholdID))
+		http.Error(w, "Unauthorized to access this budget", http.StatusForbidden)
+		return Budget{}, false
+	} else if err != nil {
+		log.Error("failed to fetch household role", slog.Any("error", err))
+		http.Error(w, "Failed to fetch budget", http.StatusInternalServerError)
+		return Budget{}, false
+	}
+	if edit && !repository.CanEdit(role) {
+		http.Error(w, "Viewers cannot change household budgets", http.StatusForbidden)
+		return Budget{}, false
+	}
+
+	return budget, true
+}
+
+func listBudgets(db *sql.DB, scope scope) ([]Budget, error) {
+	condition, arg := scope.where()
+	query := `SELECT id, category_id, category, period, amount, currency, carry_over, start_date FROM budgets WHERE ` + condition + ` ORDER BY category, period`
+	rows, err := db.Query(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	budgets := []Budget{}
+	for rows.Next() {
+		var budget Budget
+		var categoryID sql.NullInt64
+		if err := rows.Scan(&budget.ID, &categoryID, &budget.Category, &budget.Period, &budget.Limit.Amount,
+			&budget.Limit.Currency, &budget.CarryOver, &budget.StartDate); err != nil {
+			return nil, err
+		}
+		budget.CategoryID = categoryID.Int64
+		budget.HouseholdID = scope.householdID
+		budgets = append(budgets, budget)
+	}
+
+	return budgets, rows.Err()
+}
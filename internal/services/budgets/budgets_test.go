@@ -0,0 +1,63 @@
+package budgets
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"tbank-go/internal/repository/sqlstore"
+	"tbank-go/internal/services/servicetest"
+	"tbank-go/internal/storage/storagetest"
+	"testing"
+)
+
+func TestDuplicateBudgets(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		f := fixture{t: t, db: db, repos: sqlstore.New(db)}
+		servicetest.CreateUser(t, f.repos, "alice")
+		servicetest.CreateUser(t, f.repos, "bob")
+
+		transport := f.createBudget("alice", `{"category": "transport", "period": "monthly", "limit": "100"}`, 0)
+		f.createBudget("alice", `{"category": "Transport", "period": "weekly", "limit": "30"}`, 0)
+		f.createBudget("bob", `{"category": "Transport", "period": "monthly", "limit": "100"}`, 0)
+		food := f.createBudget("alice", `{"category": "Food", "period": "monthly", "limit": "300"}`, 0)
+
+		create := func(body string) int {
+			w := httptest.NewRecorder()
+			CreateBudgetHandler(db, f.repos.Categories, servicetest.Discard)(w, newRequest(http.MethodPost, body, "alice", 0))
+			return w.Code
+		}
+		update := func(id int, body string) int {
+			w := httptest.NewRecorder()
+			r := servicetest.NewRequest(http.MethodPut, "/api/budgets/"+servicetest.ID(int64(id)), body, "alice", "id", servicetest.ID(int64(id)))
+			UpdateBudgetHandler(db, f.repos.Categories, servicetest.Discard)(w, r)
+			return w.Code
+		}
+
+		transportID := servicetest.ID(transport.CategoryID)
+		tests := []struct {
+			name    string
+			request func() int
+			want    int
+		}{
+			{name: "same category by ID", want: http.StatusConflict,
+				request: func() int { return create(`{"category_id": ` + transportID + `, "period": "monthly", "limit": "50"}`) }},
+			{name: "same category by name", want: http.StatusConflict,
+				request: func() int { return create(`{"category": " TRANSPORT ", "period": "monthly", "limit": "50"}`) }},
+			{name: "subcategory", want: http.StatusCreated,
+				request: func() int { return create(`{"category": "Taxi", "period": "monthly", "limit": "50"}`) }},
+			{name: "update onto another budget", want: http.StatusConflict,
+				request: func() int { return update(food.ID, `{"category": "Transport", "period": "weekly", "limit": "30"}`) }},
+			{name: "update keeping the category", want: http.StatusOK,
+				request: func() int {
+					return update(transport.ID, `{"category_id": `+transportID+`, "period": "monthly", "limit": "150"}`)
+				}},
+			{name: "update to a category without a budget", want: http.StatusOK,
+				request: func() int { return update(food.ID, `{"category": "Fuel", "period": "monthly", "limit": "80"}`) }},
+		}
+		for _, tt := range tests {
+			if got := tt.request(); got != tt.want {
+				t.Errorf("%s: status %d, want %d", tt.name, got, tt.want)
+			}
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package budgets
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"tbank-go/internal/money"
+	"time"
+)
+
+// BudgetStatus shows how much of a budget is used in the period containing the requested date.
+type BudgetStatus struct {
+	BudgetID    int         `json:"budget_id"`
+	CategoryID  int64       `json:"category_id"`
+	Category    string      `json:"category"`
+	Period      string      `json:"period"`
+	PeriodStart string      `json:"period_start"`
+	PeriodEnd   string      `json:"period_end"`
+	Limit       money.Money `json:"limit"`
+	CarriedOver money.Money `json:"carried_over"`
+	Available   money.Money `json:"available"`
+	Spent       money.Money `json:"spent"`
+	Remaining   money.Money `json:"remaining"`
+	Overspent   bool        `json:"overspent"`
+}
+
+// GetBudgetStatusHandler reports spent vs. limit for every budget
+// @Summary Budget Status
+// @Description Returns limit, carried-over amount, spent and remaining money for each budget in the current period (or the period containing `date`).
+// @Description Expenses of the budget's category and its subcategories count toward it. With X-Household-ID the household
+// @Description budgets are reported, counting the expenses shared with the household by all members in their categories of that name.
+// @Description Only expenses in the currency of the limit dated on or after start_date are counted.
+// @Tags Budgets
+// @Produce json
+// @Param X-Household-ID header int false "Household to report the budgets of"
+// @Param date query string false "Date inside the period to report (YYYY-MM-DD), defaults to today"
+// @Security BearerAuth
+// @Success 200 {array} budgets.BudgetStatus "Budget status"
+// @Failure 400 {string} string "Invalid date format"
+// @Failure 500 {string} string "Failed to compute budget status"
+// @Router /api/budgets/status [get]
+func GetBudgetStatusHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		at := time.Now()
+		if date := r.URL.Query().Get("date"); date != "" {
+			parsed, err := time.Parse(dateLayout, date)
+			if err != nil {
+				log.Error("invalid date format", slog.Any("error", err))
+				http.Error(w, "Invalid date format (YYYY-MM-DD)", http.StatusBadRequest)
+				return
+			}
+			at = parsed
+		}
+
+		budgets, err := listBudgets(db, scopeOf(r))
+		if err != nil {
+			log.Error("failed to fetch budgets", slog.Any("error", err))
+			http.Error(w, "Failed to compute budget status", http.StatusInternalServerError)
+			return
+		}
+
+		statuses := make([]BudgetStatus, 0, len(budgets))
+		for _, budget := range budgets {
+			status, err := computeStatus(db, userUID, budget, at)
+			if err != nil {
+				log.Error("failed to compute budget status", slog.Int("budgetID", budget.ID), slog.Any("error", err))
+				http.Error(w, "Failed to compute budget status", http.StatusInternalServerError)
+				return
+			}
+			statuses = append(statuses, status)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(statuses)
+	}
+}
+
+// computeStatus walks every period from the budget start up to the one containing at,
+// applying the carry-over rule at each period boundary.
+func computeStatus(db *sql.DB, userUID string, budget Budget, at time.Time) (BudgetStatus, error) {
+	startDate, err := time.Parse(dateLayout, budget.StartDate)
+	if err != nil {
+		return BudgetStatus{}, err
+	}
+
+	currentStart, currentEnd := periodBounds(budget.Period, at)
+	firstStart, _ := periodBounds(budget.Period, startDate)
+	if budget.CarryOver == CarryOverNone || firstStart.After(currentStart) {
+		firstStart = currentStart
+	}
+
+	// Expenses before the start date do not count, even when they fall into its period.
+	from := firstStart
+	if startDate.After(from) {
+		from = startDate
+	}
+	spentByPeriod, err := spentPerPeriod(db, userUID, budget, from, currentEnd)
+	if err != nil {
+		return BudgetStatus{}, err
+	}
+
+	var carried int64
+	for start := firstStart; start.Before(currentStart); {
+		key := start.Format(dateLayout)
+		left := budget.Limit.Amount + carried - spentByPeriod[key]
+		switch {
+		case budget.CarryOver == CarryOverAll:
+			carried = left
+		case left > 0:
+			carried = left
+		default:
+			carried = 0
+		}
+		_, end := periodBounds(budget.Period, start)
+		start = end.AddDate(0, 0, 1)
+	}
+
+	currency := budget.Limit.Currency
+	available := budget.Limit.Amount + carried
+	spent := spentByPeriod[currentStart.Format(dateLayout)]
+
+	return BudgetStatus{
+		BudgetID:    budget.ID,
+		CategoryID:  budget.CategoryID,
+		Category:    budget.Category,
+		Period:      budget.Period,
+		PeriodStart: currentStart.Format(dateLayout),
+		PeriodEnd:   currentEnd.Format(dateLayout),
+		Limit:       budget.Limit,
+		CarriedOver: money.New(carried, currency),
+		Available:   money.New(available, currency),
+		Spent:       money.New(spent, currency),
+		Remaining:   money.New(available-spent, currency),
+		Overspent:   spent > available,
+	}, nil
+}
+
+// spentPerPeriod sums the expenses of the budget's category and its subcategories in the currency
+// of the limit per period, keyed by the period start date. A household budget counts the expenses
+// shared with the household instead of the user's, in the categories of all members named like
+// the budget's one and their subcategories.
+func spentPerPeriod(db *sql.DB, userUID string, budget Budget, from, to time.Time) (map[string]int64, error) {
+	condition, owner := "user_uid = ?", any(userUID)
+	categories, categoryArgs := "?", []any{budget.CategoryID}
+	if budget.HouseholdID != 0 {
+		condition, owner = "household_id = ?", budget.HouseholdID
+		categories = `
+			SELECT c.id FROM categories c
+			JOIN household_members m ON m.user_uid = c.user_uid AND m.household_id = ?
+			WHERE c.type = 'expense' AND c.name_key = (SELECT name_key FROM categories WHERE id = ?)`
+		categoryArgs = []any{budget.HouseholdID, budget.CategoryID}
+	}
+	query := `
+		SELECT date, SUM(amount)
+		FROM expenses
+		WHERE ` + condition + ` AND currency = ? AND date BETWEEN ? AND ?
+		  AND category_id IN (SELECT id FROM categories WHERE id IN (` + categories + `) OR parent_id IN (` + categories + `))
+		GROUP BY date`
+	args := []any{owner, budget.Limit.Currency, from.Format(dateLayout), to.Format(dateLayout)}
+	args = append(append(args, categoryArgs...), categoryArgs...)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	spent := make(map[string]int64)
+	for rows.Next() {
+		var date string
+		var amount int64
+		if err := rows.Scan(&date, &amount); err != nil {
+			return nil, err
+		}
+		day, err := time.Parse(dateLayout, date)
+		if err != nil {
+			continue
+		}
+		start, _ := periodBounds(budget.Period, day)
+		spent[start.Format(dateLayout)] += amount
+	}
+
+	return spent, rows.Err()
+}
+
+// periodBounds returns the first and last day of the period containing t.
+// Weeks start on Monday.
+func periodBounds(period string, t time.Time) (time.Time, time.Time) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if period == PeriodWeekly {
+		offset := (int(day.Weekday()) + 6) % 7
+		start := day.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 6)
+	}
+	start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, -1)
+}
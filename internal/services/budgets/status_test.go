@@ -0,0 +1,139 @@
+package budgets
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/repository/sqlstore"
+	"tbank-go/internal/services/servicetest"
+	"tbank-go/internal/storage/storagetest"
+	"testing"
+)
+
+// newRequest builds a request of uid, scoped to the household with an editor's role when
+// householdID is set.
+func newRequest(method, body, uid string, householdID int64) *http.Request {
+	r := servicetest.NewRequest(method, "/api/budgets", body, uid)
+	if householdID != 0 {
+		r = servicetest.WithHousehold(r, householdID, repository.RoleEditor)
+	}
+	return r
+}
+
+type fixture struct {
+	t     *testing.T
+	db    *sql.DB
+	repos repository.Repositories
+}
+
+func (f fixture) addExpense(uid, category string, amount int64, currency, date string, householdID int64) {
+	f.t.Helper()
+	ctx := context.Background()
+	c, err := f.repos.Categories.FindByName(ctx, uid, repository.CategoryExpense, category)
+	if err != nil {
+		f.t.Fatalf("category %s: %v", category, err)
+	}
+	account := servicetest.DefaultAccount(f.t, f.repos, uid)
+	err = f.repos.Expenses.Create(ctx, &repository.Expense{UserUID: uid, AccountID: account.ID, CategoryID: c.ID,
+		Category: c.Name, Amount: money.New(amount, currency), Date: date, HouseholdID: householdID})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func (f fixture) createBudget(uid, body string, householdID int64) Budget {
+	f.t.Helper()
+	w := httptest.NewRecorder()
+	CreateBudgetHandler(f.db, f.repos.Categories, servicetest.Discard)(w, newRequest(http.MethodPost, body, uid, householdID))
+	return servicetest.Decode[Budget](f.t, w, http.StatusCreated)
+}
+
+func (f fixture) status(uid string, householdID int64) []BudgetStatus {
+	f.t.Helper()
+	r := newRequest(http.MethodGet, "", uid, householdID)
+	r.URL.RawQuery = "date=2024-03-15"
+	w := httptest.NewRecorder()
+	GetBudgetStatusHandler(f.db, servicetest.Discard)(w, r)
+	return servicetest.Decode[[]BudgetStatus](f.t, w, http.StatusOK)
+}
+
+func TestBudgetStatusCountsSubcategories(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		f := fixture{t: t, db: db, repos: sqlstore.New(db)}
+		servicetest.CreateUser(t, f.repos, "alice")
+		budget := f.createBudget("alice", `{"category": "transport", "period": "monthly", "limit": "100", "start_date": "2024-03-05"}`, 0)
+		if budget.Category != "Transport" || budget.CategoryID == 0 {
+			t.Fatalf("budget = %+v", budget)
+		}
+
+		f.addExpense("alice", "Taxi", 3000, "RUB", "2024-03-10", 0)
+		f.addExpense("alice", "Transport", 2000, "RUB", "2024-03-11", 0)
+		f.addExpense("alice", "Food", 100000, "RUB", "2024-03-10", 0)           // another category
+		f.addExpense("alice", "Taxi", 500, "USD", "2024-03-10", 0)              // another currency
+		f.addExpense("alice", "Fuel", 4000, "RUB", "2024-03-01", 0)             // before start_date
+		f.addExpense("alice", "Public transport", 7000, "RUB", "2024-04-01", 0) // next period
+
+		statuses := f.status("alice", 0)
+		if len(statuses) != 1 {
+			t.Fatalf("statuses = %+v", statuses)
+		}
+		if got := statuses[0]; got.CategoryID != budget.CategoryID || got.Spent != money.New(5000, "RUB") || got.Remaining != money.New(5000, "RUB") {
+			t.Errorf("status = %+v, want 50.00 spent", got)
+		}
+
+		// The budget follows a renamed category and keeps it from being deleted.
+		ctx := context.Background()
+		category, _ := f.repos.Categories.Get(ctx, budget.CategoryID)
+		category.Name = "Commute"
+		if err := f.repos.Categories.Update(ctx, category); err != nil {
+			t.Fatal(err)
+		}
+		if statuses := f.status("alice", 0); statuses[0].Category != "Commute" || statuses[0].Spent.Amount != 5000 {
+			t.Errorf("status after rename = %+v", statuses[0])
+		}
+		if err := f.repos.Categories.Delete(ctx, budget.CategoryID); !errors.Is(err, repository.ErrInUse) {
+			t.Errorf("delete of a budgeted category: %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		CreateBudgetHandler(db, f.repos.Categories, servicetest.Discard)(w,
+			newRequest(http.MethodPost, `{"category": "Salary", "period": "monthly", "limit": "100"}`, "alice", 0))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("budget for an income category: status %d", w.Code)
+		}
+	})
+}
+
+func TestHouseholdBudgetStatus(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		f := fixture{t: t, db: db, repos: sqlstore.New(db)}
+		ctx := context.Background()
+		servicetest.CreateUser(t, f.repos, "alice")
+		servicetest.CreateUser(t, f.repos, "bob")
+		household := repository.Household{Name: "Home"}
+		if err := f.repos.Households.Create(ctx, &household, "alice"); err != nil {
+			t.Fatal(err)
+		}
+		invite := repository.HouseholdInvite{HouseholdID: household.ID, InviteeUID: "bob", InviterUID: "alice", Role: repository.RoleEditor}
+		if err := f.repos.Households.CreateInvite(ctx, &invite); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.repos.Households.AcceptInvite(ctx, invite.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		f.createBudget("alice", `{"category": "Transport", "period": "monthly", "limit": "100", "start_date": "2024-01-01"}`, household.ID)
+		f.addExpense("alice", "Transport", 1000, "RUB", "2024-03-10", household.ID)
+		f.addExpense("bob", "Taxi", 2000, "RUB", "2024-03-11", household.ID)
+		f.addExpense("bob", "Taxi", 4000, "RUB", "2024-03-12", 0) // not shared
+
+		statuses := f.status("bob", household.ID)
+		if len(statuses) != 1 || statuses[0].Spent != money.New(3000, "RUB") {
+			t.Errorf("household status = %+v, want 30.00 spent", statuses)
+		}
+	})
+}
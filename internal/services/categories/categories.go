@@ -0,0 +1,485 @@
+package categories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"tbank-go/internal/repository"
+	"unicode/utf8"
+)
+
+const maxNameLength = 64
+
+var (
+	colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+	iconPattern  = regexp.MustCompile(`^[a-z0-9-]{1,32}$`)
+)
+
+// Category is an income or expense category. Subcategories have a parent_id.
+type Category struct {
+	ID        int64  `json:"id"`
+	ParentID  int64  `json:"parent_id,omitempty"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Icon      string `json:"icon"`
+	Color     string `json:"color"`
+	CreatedAt string `json:"created_at"`
+}
+
+func newCategory(category repository.Category) Category {
+	return Category{
+		ID:        category.ID,
+		ParentID:  category.ParentID,
+		Name:      category.Name,
+		Type:      category.Type,
+		Icon:      category.Icon,
+		Color:     category.Color,
+		CreatedAt: category.CreatedAt,
+	}
+}
+
+// CreateCategoryRequest is the body of the create endpoint.
+type CreateCategoryRequest struct {
+	Name     string `json:"name" example:"Coffee"`
+	Type     string `json:"type" example:"expense"`
+	ParentID int64  `json:"parent_id,omitempty" example:"1"`
+	Icon     string `json:"icon,omitempty" example:"coffee"`
+	Color    string `json:"color,omitempty" example:"#795548"`
+}
+
+// PatchCategoryRequest holds the fields of a category to change. Omitted fields are kept,
+// parent_id 0 turns a subcategory into a top-level one. The type cannot be changed.
+type PatchCategoryRequest struct {
+	Name     *string `json:"name,omitempty"`
+	ParentID *int64  `json:"parent_id,omitempty"`
+	Icon     *string `json:"icon,omitempty"`
+	Color    *string `json:"color,omitempty"`
+}
+
+// MergeCategoryRequest is the body of the merge endpoint.
+type MergeCategoryRequest struct {
+	TargetID int64 `json:"target_id" example:"3"`
+}
+
+// validateFields checks the fields shared by create and update and returns a user-facing
+// message when one is invalid.
+func validateFields(category *repository.Category) string {
+	category.Name = strings.Join(strings.Fields(category.Name), " ")
+	if category.Name == "" {
+		return "name is required"
+	}
+	if utf8.RuneCountInString(category.Name) > maxNameLength {
+		return "name is too long"
+	}
+	if category.Icon != "" && !iconPattern.MatchString(category.Icon) {
+		return "icon must be up to 32 lowercase letters, digits and dashes"
+	}
+	if category.Color != "" {
+		if !colorPattern.MatchString(category.Color) {
+			return "color must be #RRGGBB"
+		}
+		category.Color = strings.ToUpper(category.Color)
+	}
+	return ""
+}
+
+// checkParent verifies that parentID is a top-level category of the user with the same type
+// and that category may become its child. It returns a user-facing message or "".
+func checkParent(ctx context.Context, categories repository.CategoryRepository, category repository.Category) (string, error) {
+	if category.ParentID == 0 {
+		return "", nil
+	}
+	if category.ParentID == category.ID {
+		return "A category cannot be its own parent", nil
+	}
+
+	parent, err := repository.ResolveCategory(ctx, categories, category.UserUID, category.Type, category.ParentID, "")
+	if errors.Is(err, repository.ErrNotFound) {
+		return "Parent category not found", nil
+	} else if err != nil {
+		return "", err
+	}
+	if parent.ParentID != 0 {
+		return "Subcategories cannot have subcategories", nil
+	}
+
+	if category.ID != 0 {
+		list, err := categories.List(ctx, category.UserUID)
+		if err != nil {
+			return "", err
+		}
+		for _, c := range list {
+			if c.ParentID == category.ID {
+				return "A category with subcategories cannot become a subcategory", nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// CreateCategoryHandler creates a category
+// @Summary Create Category
+// @Description Creates an income or expense category, optionally as a subcategory of a top-level category of the same type.
+// @Description Names are unique per type regardless of case and extra spaces.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param category body categories.CreateCategoryRequest true "Category details"
+// @Security BearerAuth
+// @Success 201 {object} categories.Category "Created category"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 409 {string} string "Category already exists"
+// @Failure 500 {string} string "Failed to create category"
+// @Router /api/categories [post]
+func CreateCategoryHandler(categories repository.CategoryRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		var req CreateCategoryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for category", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		if req.Type != repository.CategoryIncome && req.Type != repository.CategoryExpense {
+			http.Error(w, "type must be income or expense", http.StatusBadRequest)
+			return
+		}
+
+		category := repository.Category{
+			UserUID:  userUID,
+			ParentID: req.ParentID,
+			Name:     req.Name,
+			Type:     req.Type,
+			Icon:     req.Icon,
+			Color:    req.Color,
+		}
+		if msg := validateFields(&category); msg != "" {
+			log.Error("invalid category", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+		msg, err := checkParent(r.Context(), categories, category)
+		if err != nil {
+			log.Error("failed to fetch parent category", slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		err = categories.Create(r.Context(), &category)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			http.Error(w, "Category already exists", http.StatusConflict)
+			return
+		} else if err != nil {
+			log.Error("failed to create category", slog.Any("error", err))
+			http.Error(w, "Failed to create category", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("category created successfully", slog.Int64("categoryID", category.ID), slog.String("userUID", userUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(newCategory(category))
+	}
+}
+
+// GetCategoriesHandler lists the user's categories
+// @Summary List Categories
+// @Description Returns the categories of the authenticated user ordered by type and name. Subcategories carry the ID of their parent.
+// @Tags Categories
+// @Produce json
+// @Param type query string false "income or expense"
+// @Security BearerAuth
+// @Success 200 {array} categories.Category "Categories"
+// @Failure 400 {string} string "Invalid type"
+// @Failure 500 {string} string "Failed to fetch categories"
+// @Router /api/categories [get]
+func GetCategoriesHandler(categories repository.CategoryRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		categoryType := r.URL.Query().Get("type")
+		if categoryType != "" && categoryType != repository.CategoryIncome && categoryType != repository.CategoryExpense {
+			http.Error(w, "type must be income or expense", http.StatusBadRequest)
+			return
+		}
+
+		records, err := categories.List(r.Context(), userUID)
+		if err != nil {
+			log.Error("failed to fetch categories", slog.Any("error", err))
+			http.Error(w, "Failed to fetch categories", http.StatusInternalServerError)
+			return
+		}
+
+		list := make([]Category, 0, len(records))
+		for _, category := range records {
+			if categoryType == "" || category.Type == categoryType {
+				list = append(list, newCategory(category))
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// GetCategoryHandler returns one category
+// @Summary Get Category
+// @Description Returns a category owned by the authenticated user.
+// @Tags Categories
+// @Produce json
+// @Param id path int true "Category ID"
+// @Security BearerAuth
+// @Success 200 {object} categories.Category "Category"
+// @Failure 400 {string} string "Invalid category ID"
+// @Failure 403 {string} string "Unauthorized to access this category"
+// @Failure 404 {string} string "Category not found"
+// @Failure 500 {string} string "Failed to fetch category"
+// @Router /api/categories/{id} [get]
+func GetCategoryHandler(categories repository.CategoryRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		category, ok := loadOwnedCategory(categories, w, r, log)
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(newCategory(category))
+	}
+}
+
+// PatchCategoryHandler renames, moves or restyles a category
+// @Summary Patch Category
+// @Description Changes the name, parent, icon or color of a category. A new name is also applied to the category's incomes, expenses, budgets and recurring rules.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param category body categories.PatchCategoryRequest true "Fields to change"
+// @Security BearerAuth
+// @Success 200 {object} categories.Category "Updated category"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Unauthorized to access this category"
+// @Failure 404 {string} string "Category not found"
+// @Failure 409 {string} string "Category already exists"
+// @Failure 500 {string} string "Failed to update category"
+// @Router /api/categories/{id} [patch]
+func PatchCategoryHandler(categories repository.CategoryRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		category, ok := loadOwnedCategory(categories, w, r, log)
+		if !ok {
+			return
+		}
+
+		var req PatchCategoryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for category update", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		if req.Name != nil {
+			category.Name = *req.Name
+		}
+		if req.ParentID != nil {
+			category.ParentID = *req.ParentID
+		}
+		if req.Icon != nil {
+			category.Icon = *req.Icon
+		}
+		if req.Color != nil {
+			category.Color = *req.Color
+		}
+		if msg := validateFields(&category); msg != "" {
+			log.Error("invalid category", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+		if req.ParentID != nil {
+			msg, err := checkParent(r.Context(), categories, category)
+			if err != nil {
+				log.Error("failed to fetch parent category", slog.Any("error", err))
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if msg != "" {
+				http.Error(w, msg, http.StatusBadRequest)
+				return
+			}
+		}
+
+		err := categories.Update(r.Context(), category)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			http.Error(w, "Category already exists", http.StatusConflict)
+			return
+		} else if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Category not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to update category", slog.Int64("categoryID", category.ID), slog.Any("error", err))
+			http.Error(w, "Failed to update category", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("category updated successfully", slog.Int64("categoryID", category.ID), slog.String("userUID", category.UserUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(newCategory(category))
+	}
+}
+
+// DeleteCategoryHandler deletes a category
+// @Summary Delete Category
+// @Description Deletes a category without incomes, expenses, budgets or subcategories. Use merge to get rid of a category that is in use.
+// @Tags Categories
+// @Produce json
+// @Param id path int true "Category ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Success message"
+// @Failure 400 {string} string "Invalid category ID"
+// @Failure 403 {string} string "Unauthorized to access this category"
+// @Failure 404 {string} string "Category not found"
+// @Failure 409 {string} string "Category is in use"
+// @Failure 500 {string} string "Failed to delete category"
+// @Router /api/categories/{id} [delete]
+func DeleteCategoryHandler(categories repository.CategoryRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		category, ok := loadOwnedCategory(categories, w, r, log)
+		if !ok {
+			return
+		}
+
+		err := categories.Delete(r.Context(), category.ID)
+		if errors.Is(err, repository.ErrInUse) {
+			http.Error(w, "Category has incomes, expenses, budgets or subcategories, merge it into another one instead", http.StatusConflict)
+			return
+		} else if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Category not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to delete category", slog.Int64("categoryID", category.ID), slog.Any("error", err))
+			http.Error(w, "Failed to delete category", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("category deleted successfully", slog.Int64("categoryID", category.ID), slog.String("userUID", category.UserUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Category deleted successfully"}`))
+	}
+}
+
+// MergeCategoryHandler merges a category into another one
+// @Summary Merge Categories
+// @Description Moves all incomes or expenses, budgets, recurring rules and subcategories of the category to the target category of the same type and deletes it.
+// @Description A budget of the merged category is dropped when the target already has one for the same period.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param id path int true "ID of the category to merge and delete"
+// @Param merge body categories.MergeCategoryRequest true "Target category"
+// @Security BearerAuth
+// @Success 200 {object} categories.Category "Target category"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Unauthorized to access this category"
+// @Failure 404 {string} string "Category not found"
+// @Failure 500 {string} string "Failed to merge categories"
+// @Router /api/categories/{id}/merge [post]
+func MergeCategoryHandler(categories repository.CategoryRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		source, ok := loadOwnedCategory(categories, w, r, log)
+		if !ok {
+			return
+		}
+
+		var req MergeCategoryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for category merge", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+		if req.TargetID == 0 || req.TargetID == source.ID {
+			http.Error(w, "target_id must be another category", http.StatusBadRequest)
+			return
+		}
+
+		target, err := repository.ResolveCategory(r.Context(), categories, source.UserUID, source.Type, req.TargetID, "")
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Target category not found", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Error("failed to fetch target category", slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		err = categories.Merge(r.Context(), source.ID, target.ID)
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Category not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to merge categories", slog.Int64("sourceID", source.ID), slog.Int64("targetID", target.ID), slog.Any("error", err))
+			http.Error(w, "Failed to merge categories", http.StatusInternalServerError)
+			return
+		}
+
+		target, err = categories.Get(r.Context(), target.ID)
+		if err != nil {
+			log.Error("failed to fetch target category", slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("categories merged successfully", slog.Int64("sourceID", source.ID), slog.Int64("targetID", target.ID),
+			slog.String("userUID", source.UserUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(newCategory(target))
+	}
+}
+
+// loadOwnedCategory fetches the category from the URL and checks that it belongs to the user.
+// On failure the response has already been written.
+func loadOwnedCategory(categories repository.CategoryRepository, w http.ResponseWriter, r *http.Request, log *slog.Logger) (repository.Category, bool) {
+	categoryID := chi.URLParam(r, "id")
+	userUID := r.Context().Value("userUID").(string)
+
+	id, err := strconv.ParseInt(categoryID, 10, 64)
+	if err != nil {
+		log.Warn("invalid category ID parameter", slog.String("categoryID", categoryID))
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return repository.Category{}, false
+	}
+
+	category, err := categories.Get(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Warn("category not found", slog.String("categoryID", categoryID))
+		http.Error(w, "Category not found", http.StatusNotFound)
+		return repository.Category{}, false
+	} else if err != nil {
+		log.Error("failed to fetch category", slog.Any("error", err))
+		http.Error(w, "Failed to fetch category", http.StatusInternalServerError)
+		return repository.Category{}, false
+	}
+
+	if category.UserUID != userUID {
+		log.Warn("unauthorized attempt to access category", slog.String("userUID", userUID), slog.String("ownerUID", category.UserUID))
+		http.Error(w, "Unauthorized to access this category", http.StatusForbidden)
+		return repository.Category{}, false
+	}
+
+	return category, true
+}
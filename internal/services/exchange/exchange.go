@@ -0,0 +1,142 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"tbank-go/internal/rates"
+	"time"
+)
+
+const (
+	dateLayout   = "2006-01-02"
+	maxRatesSize = 1 << 20 // 1 MB, a daily file is about 10 KB
+)
+
+// Rate is the price in rubles of Nominal units of Currency.
+type Rate struct {
+	Date     string `json:"date"`
+	Currency string `json:"currency"`
+	Nominal  int64  `json:"nominal"`
+	Value    string `json:"value" example:"91.6618"`
+}
+
+// ImportResult is the response of the rates import endpoint.
+type ImportResult struct {
+	Files    int      `json:"files"`
+	Imported int      `json:"imported"`
+	Dates    []string `json:"dates"`
+}
+
+func newRate(rate rates.Rate) Rate {
+	return Rate{Date: rate.Date, Currency: rate.Currency, Nominal: rate.Nominal, Value: rate.Decimal()}
+}
+
+// ImportRatesHandler loads exchange rates from CBR daily XML files
+// @Summary Import Exchange Rates
+// @Description Imports one or more Central Bank of Russia daily rate files (XML_daily.asp, windows-1251).
+// @Description Rates already stored for the same date and currency are replaced. Available only when rates.allow_upload is enabled.
+// @Tags Rates
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CBR XML file, may be repeated"
+// @Security BearerAuth
+// @Success 200 {object} exchange.ImportResult "Import result"
+// @Failure 400 {string} string "Invalid rates file"
+// @Failure 413 {string} string "File too large"
+// @Failure 500 {string} string "Failed to save rates"
+// @Router /api/rates/import [post]
+func ImportRatesHandler(store *rates.Store, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxRatesSize)
+		if err := r.ParseMultipartForm(maxRatesSize); err != nil {
+			log.Error("failed to parse rates upload", slog.Any("error", err))
+			if strings.Contains(err.Error(), "too large") {
+				http.Error(w, "File too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		headers := r.MultipartForm.File["file"]
+		if len(headers) == 0 {
+			http.Error(w, "file is required", http.StatusBadRequest)
+			return
+		}
+
+		result := ImportResult{Files: len(headers), Dates: make([]string, 0, len(headers))}
+		var all []rates.Rate
+		for _, header := range headers {
+			file, err := header.Open()
+			if err != nil {
+				log.Error("failed to open uploaded rates file", slog.Any("error", err))
+				http.Error(w, "Invalid input", http.StatusBadRequest)
+				return
+			}
+			parsed, err := rates.ParseCBR(file)
+			file.Close()
+			if err != nil {
+				log.Error("failed to parse rates file", slog.String("file", header.Filename), slog.Any("error", err))
+				http.Error(w, fmt.Sprintf("Invalid rates file %s: %v", header.Filename, err), http.StatusBadRequest)
+				return
+			}
+			all = append(all, parsed...)
+			result.Dates = append(result.Dates, parsed[0].Date)
+		}
+
+		if err := store.Save(r.Context(), all); err != nil {
+			log.Error("failed to save rates", slog.Any("error", err))
+			http.Error(w, "Failed to save rates", http.StatusInternalServerError)
+			return
+		}
+		result.Imported = len(all)
+
+		log.Info("exchange rates imported", slog.String("userUID", r.Context().Value("userUID").(string)),
+			slog.Int("rates", result.Imported), slog.Any("dates", result.Dates))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// GetRatesHandler lists the exchange rates in effect on a date
+// @Summary List Exchange Rates
+// @Description Returns for every known currency the latest rate published on or before the date (today by default).
+// @Tags Rates
+// @Produce json
+// @Param date query string false "Date (YYYY-MM-DD)"
+// @Security BearerAuth
+// @Success 200 {array} exchange.Rate "Rates"
+// @Failure 400 {string} string "Invalid date format"
+// @Failure 500 {string} string "Failed to fetch rates"
+// @Router /api/rates [get]
+func GetRatesHandler(store *rates.Store, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		date := r.URL.Query().Get("date")
+		if date == "" {
+			date = time.Now().Format(dateLayout)
+		} else if _, err := time.Parse(dateLayout, date); err != nil {
+			http.Error(w, "Invalid date format (YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+
+		stored, err := store.List(r.Context(), date)
+		if err != nil {
+			log.Error("failed to fetch rates", slog.Any("error", err))
+			http.Error(w, "Failed to fetch rates", http.StatusInternalServerError)
+			return
+		}
+
+		list := make([]Rate, 0, len(stored))
+		for _, rate := range stored {
+			list = append(list, newRate(rate))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(list)
+	}
+}
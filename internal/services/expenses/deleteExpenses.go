@@ -1,15 +1,20 @@
 package expenses
 
 import (
-	"database/sql"
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"tbank-go/internal/blob"
+	"tbank-go/internal/repository"
 )
 
 // DeleteExpenseHandler deletes an expense by its ID and adjusts the user's expense balance
 // @Summary Delete Expense
-// @Description Deletes a specific expense record and updates the user's expense balance.
+// @Description Deletes a specific expense record and updates the author's expense balance. Owners and editors
+// @Description of the household the expense is shared with may delete it too.
+// @Description The files attached to the expense are deleted with it.
 // @Tags Expenses
 // @Accept json
 // @Produce json
@@ -17,25 +22,24 @@ import (
 // @Security BearerAuth
 // @Success 200 {object} map[string]string "Success message"
 // @Failure 400 {object} map[string]string "Invalid ID parameter"
+// @Failure 403 {object} map[string]string "Unauthorized to delete this expense"
 // @Failure 404 {object} map[string]string "Expense not found"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /api/expense/{id} [delete]
-func DeleteExpenseHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+func DeleteExpenseHandler(expenses repository.ExpenseRepository, households repository.HouseholdRepository, blobs blob.Store, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		expenseID := chi.URLParam(r, "id")
-		if expenseID == "" {
-			log.Error("missing expense ID parameter")
-			http.Error(w, "Expense ID is required", http.StatusBadRequest)
+		id, err := strconv.ParseInt(expenseID, 10, 64)
+		if err != nil {
+			log.Error("invalid expense ID parameter", slog.String("expenseID", expenseID))
+			http.Error(w, "Invalid expense ID", http.StatusBadRequest)
 			return
 		}
 
 		userUID := r.Context().Value("userUID").(string)
 
-		var ownerUID string
-		var expenseAmount float64
-		query := `SELECT user_uid, amount FROM expenses WHERE id = ?`
-		err := db.QueryRow(query, expenseID).Scan(&ownerUID, &expenseAmount)
-		if err == sql.ErrNoRows {
+		expense, err := expenses.Get(r.Context(), id)
+		if errors.Is(err, repository.ErrNotFound) {
 			log.Warn("expense not found", slog.String("expenseID", expenseID))
 			http.Error(w, "Expense not found", http.StatusNotFound)
 			return
@@ -45,49 +49,32 @@ func DeleteExpenseHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		if ownerUID != userUID {
-			log.Warn("unauthorized attempt to delete expense", slog.String("userUID", userUID), slog.String("ownerUID", ownerUID))
-			http.Error(w, "Unauthorized to delete this expense", http.StatusForbidden)
-			return
-		}
-
-		tx, err := db.Begin()
+		allowed, err := repository.CanModify(r.Context(), households, userUID, expense)
 		if err != nil {
-			log.Error("failed to start transaction", slog.Any("error", err))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			log.Error("failed to check household role", slog.Any("error", err))
+			http.Error(w, "Failed to fetch expense details", http.StatusInternalServerError)
 			return
 		}
-
-		deleteQuery := `DELETE FROM expenses WHERE id = ?`
-		result, err := tx.Exec(deleteQuery, expenseID)
-		if err != nil {
-			tx.Rollback()
-			log.Error("failed to delete expense", slog.String("expenseID", expenseID), slog.Any("error", err))
-			http.Error(w, "Failed to delete expense", http.StatusInternalServerError)
+		if !allowed {
+			log.Warn("unauthorized attempt to delete expense", slog.String("userUID", userUID), slog.String("ownerUID", expense.UserUID))
+			http.Error(w, "Unauthorized to delete this expense", http.StatusForbidden)
 			return
 		}
 
-		rowsAffected, err := result.RowsAffected()
-		if err != nil || rowsAffected == 0 {
-			tx.Rollback()
+		// The records of the attachments go with the expense, their files are removed afterwards.
+		keys, err := expenses.Delete(r.Context(), id)
+		if errors.Is(err, repository.ErrNotFound) {
 			log.Warn("expense not found during deletion", slog.String("expenseID", expenseID))
 			http.Error(w, "Expense not found", http.StatusNotFound)
 			return
-		}
-
-		updateBalanceQuery := `UPDATE users SET expenses_balance = expenses_balance - ? WHERE uid = ?`
-		_, err = tx.Exec(updateBalanceQuery, expenseAmount, userUID)
-		if err != nil {
-			tx.Rollback()
-			log.Error("failed to adjust expense balance", slog.Any("error", err))
-			http.Error(w, "Failed to adjust expense balance", http.StatusInternalServerError)
+		} else if err != nil {
+			log.Error("failed to delete expense", slog.String("expenseID", expenseID), slog.Any("error", err))
+			http.Error(w, "Failed to delete expense", http.StatusInternalServerError)
 			return
 		}
 
-		if err := tx.Commit(); err != nil {
-			log.Error("failed to commit transaction", slog.Any("error", err))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
+		if err := blob.Remove(r.Context(), blobs, keys...); err != nil {
+			log.Warn("failed to remove attachment files", slog.String("expenseID", expenseID), slog.Any("error", err))
 		}
 
 		log.Info("expense deleted successfully", slog.String("expenseID", expenseID), slog.String("userUID", userUID))
@@ -0,0 +1,319 @@
+package expenses
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"tbank-go/internal/blob"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/repository/memory"
+	"tbank-go/internal/repository/sqlstore"
+	"tbank-go/internal/services/listing"
+	"tbank-go/internal/services/servicetest"
+	"tbank-go/internal/storage/storagetest"
+	"testing"
+)
+
+// balances returns the user's expense counter and the balance of the default account.
+func balances(t *testing.T, repos repository.Repositories, uid string) (int64, int64) {
+	t.Helper()
+	user, err := repos.Users.GetByUID(context.Background(), uid)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	account := servicetest.DefaultAccount(t, repos, uid)
+	return user.ExpensesBalance, account.Balance.Amount
+}
+
+func addExpense(t *testing.T, repos repository.Repositories, uid, body string) int64 {
+	t.Helper()
+	w := httptest.NewRecorder()
+	AddExpenseHandler(repos.Accounts, repos.Categories, repos.Expenses, repos.Tags, servicetest.Discard)(w, servicetest.NewRequest(http.MethodPost, "/api/expense", body, uid))
+	if w.Code != http.StatusOK {
+		t.Fatalf("add expense: %d %s", w.Code, w.Body)
+	}
+	list, err := repos.Expenses.List(context.Background(), uid, "", "9999-12-31")
+	if err != nil || len(list) == 0 {
+		t.Fatalf("list expenses: %v", err)
+	}
+	return list[len(list)-1].ID
+}
+
+func TestAddExpense(t *testing.T) {
+	repos := memory.New()
+	servicetest.CreateUser(t, repos, "alice")
+
+	id := addExpense(t, repos, "alice", `{"category": "Taxi", "amount": "99.99", "date": "2024-03-01", "description": "Airport"}`)
+
+	expense, err := repos.Expenses.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("get expense: %v", err)
+	}
+	if expense.Category != "Taxi" || expense.Amount.Amount != 9999 || expense.Description != "Airport" {
+		t.Errorf("expense = %+v", expense)
+	}
+	if counter, account := balances(t, repos, "alice"); counter != 9999 || account != -9999 {
+		t.Errorf("balances = %d, %d, want 9999 and -9999", counter, account)
+	}
+
+	w := httptest.NewRecorder()
+	AddExpenseHandler(repos.Accounts, repos.Categories, repos.Expenses, repos.Tags, servicetest.Discard)(w,
+		servicetest.NewRequest(http.MethodPost, "/api/expense", `{"category": "Salary", "amount": "1", "date": "2024-03-01"}`, "alice"))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expense in an income category: status %d", w.Code)
+	}
+}
+
+func TestUpdateExpense(t *testing.T) {
+	repos := memory.New()
+	servicetest.CreateUser(t, repos, "alice")
+	id := addExpense(t, repos, "alice", `{"category": "Food", "amount": "500", "date": "2024-03-01"}`)
+
+	w := httptest.NewRecorder()
+	UpdateExpenseHandler(repos.Accounts, repos.Categories, repos.Expenses, repos.Households, servicetest.Discard)(w,
+		servicetest.NewRequest(http.MethodPut, "/api/expense", `{"category": "Fuel", "amount": "750", "date": "2024-03-05"}`, "alice", "id", servicetest.ID(id)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", w.Code, w.Body)
+	}
+	if counter, account := balances(t, repos, "alice"); counter != 75000 || account != -75000 {
+		t.Errorf("balances after update = %d, %d, want 75000 and -75000", counter, account)
+	}
+
+	w = httptest.NewRecorder()
+	PatchExpenseHandler(repos.Accounts, repos.Categories, repos.Expenses, repos.Households, servicetest.Discard)(w,
+		servicetest.NewRequest(http.MethodPatch, "/api/expense", `{"amount": "100"}`, "alice", "id", servicetest.ID(id)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("patch: %d %s", w.Code, w.Body)
+	}
+	expense, _ := repos.Expenses.Get(context.Background(), id)
+	if expense.Category != "Fuel" || expense.Date != "2024-03-05" || expense.Amount.Amount != 10000 {
+		t.Errorf("expense = %+v", expense)
+	}
+	if counter, account := balances(t, repos, "alice"); counter != 10000 || account != -10000 {
+		t.Errorf("balances after patch = %d, %d, want 10000 and -10000", counter, account)
+	}
+}
+
+func TestDeleteExpense(t *testing.T) {
+	repos := memory.New()
+	servicetest.CreateUser(t, repos, "alice")
+	servicetest.CreateUser(t, repos, "bob")
+	keep := addExpense(t, repos, "alice", `{"category": "Food", "amount": "120", "date": "2024-03-01"}`)
+	id := addExpense(t, repos, "alice", `{"category": "Food", "amount": "80", "date": "2024-03-02"}`)
+
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, key := range []string{"expense/receipt.jpg", "expense/receipt_thumb.jpg"} {
+		if err := blobs.Put(ctx, key, "image/jpeg", []byte("receipt")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	attachment := repository.Attachment{UserUID: "alice", Kind: repository.EntryExpense, TransactionID: id,
+		Key: "expense/receipt.jpg", ThumbnailKey: "expense/receipt_thumb.jpg"}
+	if err := repos.Attachments.Create(ctx, &attachment); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := DeleteExpenseHandler(repos.Expenses, repos.Households, blobs, servicetest.Discard)
+
+	tests := []struct {
+		name    string
+		userUID string
+		id      int64
+		want    int
+	}{
+		{name: "another user", userUID: "bob", id: id, want: http.StatusForbidden},
+		{name: "missing expense", userUID: "alice", id: id + 100, want: http.StatusNotFound},
+		{name: "owner", userUID: "alice", id: id, want: http.StatusOK},
+		{name: "already deleted", userUID: "alice", id: id, want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler(w, servicetest.NewRequest(http.MethodDelete, "/api/expense", "", tt.userUID, "id", servicetest.ID(tt.id)))
+		if w.Code != tt.want {
+			t.Fatalf("%s: status %d (%s), want %d", tt.name, w.Code, strings.TrimSpace(w.Body.String()), tt.want)
+		}
+		if tt.want == http.StatusForbidden {
+			if _, err := repos.Expenses.Get(ctx, id); err != nil {
+				t.Fatalf("%s: expense deleted: %v", tt.name, err)
+			}
+		}
+	}
+
+	if _, err := repos.Expenses.Get(ctx, keep); err != nil {
+		t.Errorf("other expense deleted: %v", err)
+	}
+	if counter, account := balances(t, repos, "alice"); counter != 12000 || account != -12000 {
+		t.Errorf("balances after delete = %d, %d, want 12000 and -12000", counter, account)
+	}
+	if _, err := repos.Attachments.Get(ctx, attachment.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("attachment record kept: %v", err)
+	}
+	for _, key := range []string{attachment.Key, attachment.ThumbnailKey} {
+		if _, err := blobs.Get(ctx, key); !errors.Is(err, blob.ErrNotFound) {
+			t.Errorf("attachment file %s kept: %v", key, err)
+		}
+	}
+}
+
+func TestDeleteExpenseInvalidID(t *testing.T) {
+	repos := memory.New()
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	DeleteExpenseHandler(repos.Expenses, repos.Households, blobs, servicetest.Discard)(w,
+		servicetest.NewRequest(http.MethodDelete, "/api/expense/abc", "", "alice", "id", "abc"))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
+
+func TestGetExpensesPages(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		servicetest.CreateUser(t, repos, "alice")
+		servicetest.CreateUser(t, repos, "bob")
+		for _, body := range []string{
+			`{"category": "Food", "amount": "100", "date": "2024-03-01", "description": "Lunch"}`,
+			`{"category": "Food", "amount": "300", "date": "2024-03-02", "description": "Dinner"}`,
+			`{"category": "Taxi", "amount": "250", "date": "2024-03-03", "description": "Airport"}`,
+			`{"category": "Fuel", "amount": "300", "date": "2024-03-04", "description": "Gas station"}`,
+			`{"category": "Food", "amount": "50", "date": "2024-03-05", "description": "Coffee"}`,
+		} {
+			addExpense(t, repos, "alice", body)
+		}
+		addExpense(t, repos, "bob", `{"category": "Food", "amount": "999", "date": "2024-03-03"}`)
+
+		handler := GetExpensesHandler(repos.Accounts, repos.Categories, repos.Expenses, repos.Tags, repos.Households, servicetest.Discard)
+		get := func(query string, status int) listing.Page[Expense] {
+			t.Helper()
+			w := httptest.NewRecorder()
+			handler(w, servicetest.NewRequest(http.MethodGet, "/api/expense?"+query, "", "alice"))
+			if status != http.StatusOK {
+				if w.Code != status {
+					t.Errorf("%s: status %d, want %d", query, w.Code, status)
+				}
+				return listing.Page[Expense]{}
+			}
+			return servicetest.Decode[listing.Page[Expense]](t, w, status)
+		}
+		descriptions := func(page listing.Page[Expense]) string {
+			var names []string
+			for _, expense := range page.Items {
+				names = append(names, expense.Description)
+			}
+			return strings.Join(names, ",")
+		}
+
+		// Equal amounts are ordered by ID in the same direction.
+		const query = "sort=amount&order=desc&limit=2"
+		var pages []string
+		cursor := ""
+		for i := 0; i < 5; i++ {
+			page := get(query+"&cursor="+url.QueryEscape(cursor), http.StatusOK)
+			if page.TotalCount != 5 {
+				t.Errorf("page %d: total_count %d, want 5", i, page.TotalCount)
+			}
+			pages = append(pages, descriptions(page))
+			if cursor = page.NextCursor; cursor == "" {
+				break
+			}
+		}
+		if got, want := strings.Join(pages, "|"), "Gas station,Dinner|Airport,Lunch|Coffee"; got != want {
+			t.Errorf("pages = %s, want %s", got, want)
+		}
+
+		first := get(query, http.StatusOK)
+		get("sort=date&order=desc&limit=2&cursor="+url.QueryEscape(first.NextCursor), http.StatusBadRequest)
+		get("sort=amount&order=asc&limit=2&cursor="+url.QueryEscape(first.NextCursor), http.StatusBadRequest)
+		get("cursor=garbage", http.StatusBadRequest)
+		get("limit=501", http.StatusBadRequest)
+
+		tests := []struct {
+			query string
+			want  string
+			total int
+			more  bool // whether a next_cursor is expected
+		}{
+			{query: "", want: "Lunch,Dinner,Airport,Gas station,Coffee", total: 5},
+			{query: "order=desc&limit=1", want: "Coffee", total: 5, more: true},
+			{query: "from=2024-03-02&to=2024-03-04", want: "Dinner,Airport,Gas station", total: 3},
+			{query: "category=Transport", want: "Airport,Gas station", total: 2},
+			{query: "category=Taxi&category=Food&sort=amount", want: "Coffee,Lunch,Airport,Dinner", total: 4},
+			{query: "min_amount=100&max_amount=250", want: "Lunch,Airport", total: 2},
+			{query: "description=STATION", want: "Gas station", total: 1},
+			{query: "currency=USD", want: "", total: 0},
+		}
+		for _, tt := range tests {
+			page := get(tt.query, http.StatusOK)
+			if got := descriptions(page); got != tt.want || page.TotalCount != tt.total || (page.NextCursor != "") != tt.more {
+				t.Errorf("%q: %s, total_count %d, next_cursor %q, want %s and %d", tt.query, got, page.TotalCount, page.NextCursor, tt.want, tt.total)
+			}
+		}
+		get("category=Salary", http.StatusBadRequest)
+	})
+}
+
+func TestGetExpensesByTags(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		servicetest.CreateUser(t, repos, "alice")
+		servicetest.CreateUser(t, repos, "bob")
+		for _, body := range []string{
+			`{"category": "Taxi", "amount": "300", "date": "2024-03-01", "description": "Airport", "tags": ["Work", "travel"]}`,
+			`{"category": "Food", "amount": "100", "date": "2024-03-02", "description": "Lunch", "tags": ["work"]}`,
+			`{"category": "Food", "amount": "600", "date": "2024-03-03", "description": "Groceries"}`,
+			`{"category": "Food", "amount": "50", "date": "2024-03-04", "description": "Coffee", "tags": ["coffee"]}`,
+		} {
+			addExpense(t, repos, "alice", body)
+		}
+		addExpense(t, repos, "bob", `{"category": "Food", "amount": "999", "date": "2024-03-02", "tags": ["holiday"]}`)
+
+		handler := GetExpensesHandler(repos.Accounts, repos.Categories, repos.Expenses, repos.Tags, repos.Households, servicetest.Discard)
+		tests := []struct {
+			query  string
+			status int
+			want   string
+		}{
+			// Tags are matched and ordered ignoring case, the first spelling is kept.
+			{query: "tags=work", status: http.StatusOK, want: "Airport [travel Work],Lunch [Work]"},
+			{query: "tags=TRAVEL", status: http.StatusOK, want: "Airport [travel Work]"},
+			// Several tags match transactions with any of them, each listed once.
+			{query: "tags=travel,coffee", status: http.StatusOK, want: "Airport [travel Work],Coffee [coffee]"},
+			{query: "tags=travel&tags=work", status: http.StatusOK, want: "Airport [travel Work],Lunch [Work]"},
+			{query: "tags=work&category=Food", status: http.StatusOK, want: "Lunch [Work]"},
+			{query: "", status: http.StatusOK, want: "Airport [travel Work],Lunch [Work],Groceries [],Coffee [coffee]"},
+			{query: "tags=holiday", status: http.StatusBadRequest},
+			{query: "tags=work,", status: http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			w := httptest.NewRecorder()
+			handler(w, servicetest.NewRequest(http.MethodGet, "/api/expense?"+tt.query, "", "alice"))
+			if tt.status != http.StatusOK {
+				if w.Code != tt.status {
+					t.Errorf("%q: status %d, want %d", tt.query, w.Code, tt.status)
+				}
+				continue
+			}
+			page := servicetest.Decode[listing.Page[Expense]](t, w, http.StatusOK)
+			var got []string
+			for _, expense := range page.Items {
+				got = append(got, fmt.Sprintf("%s %v", expense.Description, expense.Tags))
+			}
+			if strings.Join(got, ",") != tt.want || page.TotalCount != len(got) {
+				t.Errorf("%q: %s (total_count %d), want %s", tt.query, strings.Join(got, ","), page.TotalCount, tt.want)
+			}
+		}
+	})
+}
@@ -1,91 +1,121 @@
 package expenses
 
 import (
-	"database/sql"
 	"encoding/json"
 	"log/slog"
 	"net/http"
-	"time"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/services/listing"
 )
 
 type Expense struct {
-	ID          int     `json:"id"`
-	Category    string  `json:"category"`
-	Amount      float64 `json:"amount"`
-	Date        string  `json:"date"`        // Format: YYYY-MM-DD
-	Description string  `json:"description"` // Description of the expense
+	ID          int64       `json:"id"`
+	AccountID   int64       `json:"account_id"`
+	CategoryID  int64       `json:"category_id,omitempty"`
+	Category    string      `json:"category"`
+	Amount      money.Money `json:"amount"`
+	Date        string      `json:"date"`        // Format: YYYY-MM-DD
+	Description string      `json:"description"` // Description of the expense
+	// HouseholdID is set when the expense is shared with a household, UserUID then names its author.
+	HouseholdID int64  `json:"household_id,omitempty"`
+	UserUID     string `json:"user_uid,omitempty"`
+	// Tags are the names of the expense's tags.
+	Tags []string `json:"tags"`
 }
 
-// GetExpensesHandler @Summary Get expenses for a user in a given date range
-// @Description Fetches all expenses for the authenticated user within the specified date range (from YYYY-MM-DD to YYYY-MM-DD)
+func newExpense(record repository.Expense) Expense {
+	expense := Expense{
+		ID:          record.ID,
+		AccountID:   record.AccountID,
+		CategoryID:  record.CategoryID,
+		Category:    record.Category,
+		Amount:      record.Amount,
+		Date:        record.Date,
+		Description: record.Description,
+		Tags:        []string{},
+	}
+	for _, tag := range record.Tags {
+		expense.Tags = append(expense.Tags, tag.Name)
+	}
+	if record.HouseholdID != 0 {
+		expense.HouseholdID = record.HouseholdID
+		expense.UserUID = record.UserUID
+	}
+	return expense
+}
+
+// GetExpensesHandler retrieves a page of the user's expenses
+// @Summary List Expenses
+// @Description Returns a page of the user's expenses with the total number of matching expenses. All filters are optional.
+// @Description A category also matches its subcategories; min_amount and max_amount are in `currency`, the default account's currency when omitted.
+// @Description Pass next_cursor of a page as `cursor` together with the same parameters to get the next page.
+// @Description With X-Household-ID the expenses shared with the household by all its members are listed instead.
 // @Tags Expenses
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer token"
-// @Param from query string true "Start date (YYYY-MM-DD)"
-// @Param to query string true "End date (YYYY-MM-DD)"
-// @Success 200 {array} expenses.Expense "List of expenses"
-// @Failure 400 {string} string "Invalid date format"
+// @Param X-Household-ID header int false "Household to list the shared expenses of"
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD)"
+// @Param category query []string false "Category names" collectionFormat(multi)
+// @Param category_id query []int false "Category IDs" collectionFormat(multi)
+// @Param tags query []string false "Tag names; expenses with any of them" collectionFormat(multi)
+// @Param currency query string false "Only expenses in this currency"
+// @Param min_amount query string false "Minimum amount"
+// @Param max_amount query string false "Maximum amount"
+// @Param description query string false "Substring of the description"
+// @Param sort query string false "date (default) or amount"
+// @Param order query string false "asc (default) or desc"
+// @Param limit query int false "Page size, 50 by default, at most 500"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} listing.Page[expenses.Expense] "Page of expenses"
+// @Failure 400 {string} string "Invalid parameters"
 // @Failure 401 {string} string "Unauthorized"
 // @Failure 500 {string} string "Failed to fetch expenses"
 // @Router /api/expense [get]
-func GetExpensesHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+func GetExpensesHandler(accounts repository.AccountRepository, categories repository.CategoryRepository, expenses repository.ExpenseRepository, tags repository.TagRepository, households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userUID := r.Context().Value("userUID").(string)
 
-		from := r.URL.Query().Get("from")
-		to := r.URL.Query().Get("to")
-
-		if _, err := time.Parse("2006-01-02", from); err != nil {
-			log.Error("invalid from date format", slog.Any("error", err))
-			http.Error(w, "Invalid from date format (YYYY-MM-DD)", http.StatusBadRequest)
+		var filter repository.TransactionFilter
+		var msg string
+		var err error
+		if householdID, _ := r.Context().Value("householdID").(int64); householdID != 0 {
+			filter, msg, err = listing.ParseHouseholdFilter(r.Context(), r.URL.Query(), userUID, householdID, repository.CategoryExpense, accounts, categories, tags, households)
+		} else {
+			filter, msg, err = listing.ParseTransactionFilter(r.Context(), r.URL.Query(), userUID, repository.CategoryExpense, accounts, categories, tags)
+		}
+		if err != nil {
+			log.Error("failed to parse expense filter", slog.Any("error", err))
+			http.Error(w, "Failed to fetch expenses", http.StatusInternalServerError)
 			return
 		}
-
-		if _, err := time.Parse("2006-01-02", to); err != nil {
-			log.Error("invalid to date format", slog.Any("error", err))
-			http.Error(w, "Invalid to date format (YYYY-MM-DD)", http.StatusBadRequest)
+		if msg != "" {
+			log.Warn("invalid expense filter", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
 			return
 		}
 
-		query := `
-		SELECT id, category, amount, date, description
-		FROM expenses
-		WHERE user_uid = ? AND date BETWEEN ? AND ?;`
-
-		rows, err := db.Query(query, userUID, from, to)
+		page, err := expenses.Find(r.Context(), filter)
 		if err != nil {
 			log.Error("failed to fetch expenses", slog.Any("error", err))
 			http.Error(w, "Failed to fetch expenses", http.StatusInternalServerError)
 			return
 		}
-		defer rows.Close()
 
-		var expenses []Expense
-		for rows.Next() {
-			var expense Expense
-			if err := rows.Scan(&expense.ID, &expense.Category, &expense.Amount, &expense.Date, &expense.Description); err != nil {
-				log.Error("failed to scan expense", slog.Any("error", err))
-				http.Error(w, "Error processing expenses", http.StatusInternalServerError)
-				return
-			}
-			expenses = append(expenses, expense)
-		}
-
-		if err := rows.Err(); err != nil {
-			log.Error("error iterating over expenses", slog.Any("error", err))
-			http.Error(w, "Error processing expenses", http.StatusInternalServerError)
-			return
+		list := make([]Expense, 0, len(page.Transactions))
+		for _, record := range page.Transactions {
+			list = append(list, newExpense(record))
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(expenses); err != nil {
+		if err := json.NewEncoder(w).Encode(listing.NewPage(list, page.TotalCount, filter, page.Next)); err != nil {
 			log.Error("failed to encode expenses", slog.Any("error", err))
-			http.Error(w, "Failed to encode expenses", http.StatusInternalServerError)
 			return
 		}
 
-		log.Info("expenses fetched successfully", slog.String("userUID", userUID), slog.String("from", from), slog.String("to", to))
+		log.Info("expenses fetched successfully", slog.String("userUID", userUID), slog.Int("count", len(list)))
 	}
 }
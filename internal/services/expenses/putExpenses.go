@@ -1,33 +1,45 @@
 package expenses
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
 )
 
 type UpdateExpenseRequest struct {
-	Category    string  `json:"category"`
-	Amount      float64 `json:"amount"`
-	Date        string  `json:"date"`
-	Description string  `json:"description,omitempty"`
+	AccountID   int64       `json:"account_id,omitempty"`  // the default account when omitted
+	CategoryID  int64       `json:"category_id,omitempty"` // takes precedence over category
+	Category    string      `json:"category"`
+	Amount      money.Money `json:"amount" swaggertype:"string" example:"99.99"`
+	Date        string      `json:"date"`
+	Description string      `json:"description,omitempty"`
+	Tags        []string    `json:"tags,omitempty" example:"trip"` // tag names, created when missing
 }
 
 // AddExpenseHandler adds an expense and adjusts the user's balance
 // @Summary Add Expense
-// @Description Adds a new expense record and adjusts the user's expense balance.
+// @Description Adds a new expense record and adjusts the user's expense balance. The amount is debited from the
+// @Description given account (the default account when account_id is omitted) and must be in its currency.
+// @Description The category is one of the user's expense categories, given by category_id or by name.
+// @Description Tags are given by name; the ones the user does not have yet are created.
+// @Description With X-Household-ID the expense is shared with the household; viewers of the household may not add expenses.
 // @Tags Expenses
 // @Accept json
 // @Produce plain
 // @Param Authorization header string true "Bearer token"
+// @Param X-Household-ID header int false "Household to share the expense with"
 // @Param expense body expenses.UpdateExpenseRequest true "New expense details"
 // @Success 200 {string} string "Expense added successfully"
 // @Failure 400 {string} string "Invalid input"
 // @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Viewers cannot add expenses to the household"
 // @Failure 500 {string} string "Failed to add expense"
 // @Router /api/expense [post]
-func AddExpenseHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+func AddExpenseHandler(accounts repository.AccountRepository, categories repository.CategoryRepository, expenses repository.ExpenseRepository, tags repository.TagRepository, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req UpdateExpenseRequest
 		userUID := r.Context().Value("userUID").(string)
@@ -36,40 +48,71 @@ func AddExpenseHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		var expenseID string
-		query := `INSERT INTO expenses (user_uid, category, amount, date, description) 
-		          VALUES (?, ?, ?, ?, ?) RETURNING id`
-		err := db.QueryRow(query, userUID, req.Category, req.Amount, req.Date, req.Description).
-			Scan(&expenseID)
-		if err != nil {
-			log.Error("failed to insert expense", slog.Any("error", err))
-			http.Error(w, "Failed to insert expense", http.StatusInternalServerError)
+		householdID, _ := r.Context().Value("householdID").(int64)
+		if householdID != 0 && !repository.CanEdit(r.Context().Value("householdRole").(string)) {
+			log.Warn("household viewer attempted to add expense", slog.String("userUID", userUID), slog.Int64("householdID", householdID))
+			http.Error(w, "Viewers cannot add expenses to the household", http.StatusForbidden)
+			return
+		}
+
+		category, err := repository.ResolveCategory(r.Context(), categories, userUID, repository.CategoryExpense, req.CategoryID, req.Category)
+		if errors.Is(err, repository.ErrNotFound) {
+			log.Warn("category not found", slog.Int64("categoryID", req.CategoryID), slog.String("category", req.Category))
+			http.Error(w, "Unknown category", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Error("failed to fetch category", slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
 
-		tx, err := db.Begin()
-		if err != nil {
-			log.Error("failed to start transaction", slog.Any("error", err))
+		account, err := repository.OwnedAccount(r.Context(), accounts, userUID, req.AccountID)
+		if errors.Is(err, repository.ErrNotFound) {
+			log.Warn("account not found", slog.Int64("accountID", req.AccountID), slog.String("userUID", userUID))
+			http.Error(w, "Account not found", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Error("failed to fetch account", slog.Any("error", err))
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
+		currency := account.Balance.Currency
 
-		adjustBalanceQuery := `UPDATE users SET expenses_balance = expenses_balance + ? WHERE uid = ?`
-		_, err = tx.Exec(adjustBalanceQuery, req.Amount, userUID)
-		if err != nil {
-			tx.Rollback()
-			log.Error("failed to adjust expense balance", slog.Any("error", err))
-			http.Error(w, "Failed to adjust expense balance", http.StatusInternalServerError)
+		req.Amount, err = req.Amount.OrDefault(currency)
+		if err != nil || req.Amount.Currency != currency || !req.Amount.IsPositive() {
+			log.Error("invalid expense amount", slog.String("amount", req.Amount.String()), slog.Any("error", err))
+			http.Error(w, fmt.Sprintf("Amount must be a positive %s value", currency), http.StatusBadRequest)
 			return
 		}
 
-		if err := tx.Commit(); err != nil {
-			log.Error("failed to commit transaction", slog.Any("error", err))
+		expenseTags, err := repository.ResolveTags(r.Context(), tags, userUID, req.Tags)
+		if errors.Is(err, repository.ErrInvalidTagName) {
+			http.Error(w, fmt.Sprintf("Tag names must be 1 to %d characters long", repository.MaxTagNameLength), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Error("failed to resolve tags", slog.Any("error", err))
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
 
-		log.Info("expense added successfully", slog.String("expenseID", expenseID), slog.String("userUID", userUID), slog.Float64("amount", req.Amount))
+		expense := repository.Expense{
+			UserUID:     userUID,
+			AccountID:   account.ID,
+			CategoryID:  category.ID,
+			Category:    category.Name,
+			Amount:      req.Amount,
+			Date:        req.Date,
+			Description: req.Description,
+			HouseholdID: householdID,
+			Tags:        expenseTags,
+		}
+		if err := expenses.Create(r.Context(), &expense); err != nil {
+			log.Error("failed to insert expense", slog.Any("error", err))
+			http.Error(w, "Failed to insert expense", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("expense added successfully", slog.Int64("expenseID", expense.ID), slog.String("userUID", userUID), slog.String("amount", req.Amount.String()))
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("Expense added successfully"))
 	}
@@ -0,0 +1,197 @@
+package expenses
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"time"
+)
+
+// PatchExpenseRequest holds the fields of an expense to change. Omitted fields are kept.
+type PatchExpenseRequest struct {
+	AccountID   *int64       `json:"account_id,omitempty"`
+	CategoryID  *int64       `json:"category_id,omitempty"`
+	Category    *string      `json:"category,omitempty"`
+	Amount      *money.Money `json:"amount,omitempty" swaggertype:"string" example:"99.99"`
+	Date        *string      `json:"date,omitempty"`
+	Description *string      `json:"description,omitempty"`
+}
+
+// UpdateExpenseHandler replaces an expense and adjusts the user's expense balance by the difference
+// @Summary Update Expense
+// @Description Replaces all fields of an expense owned by the user and adjusts the expense balance by the amount delta.
+// @Description Owners and editors of the household the expense is shared with may update it too; the category and
+// @Description the account are then looked up among the author's.
+// @Tags Expenses
+// @Accept json
+// @Produce json
+// @Param id path string true "Expense ID"
+// @Param expense body expenses.PatchExpenseRequest true "New expense details; category or category_id, amount and date are required"
+// @Security BearerAuth
+// @Success 200 {object} expenses.Expense "Updated expense"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Unauthorized to update this expense"
+// @Failure 404 {string} string "Expense not found"
+// @Failure 500 {string} string "Failed to update expense"
+// @Router /api/expense/{id} [put]
+func UpdateExpenseHandler(accounts repository.AccountRepository, categories repository.CategoryRepository, expenses repository.ExpenseRepository, households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return updateExpenseHandler(accounts, categories, expenses, households, log, false)
+}
+
+// PatchExpenseHandler changes selected fields of an expense and adjusts the user's expense balance by the difference
+// @Summary Patch Expense
+// @Description Changes only the provided fields of an expense owned by the user and adjusts the expense balance by the amount delta.
+// @Description Owners and editors of the household the expense is shared with may patch it too.
+// @Tags Expenses
+// @Accept json
+// @Produce json
+// @Param id path string true "Expense ID"
+// @Param expense body expenses.PatchExpenseRequest true "Fields to change"
+// @Security BearerAuth
+// @Success 200 {object} expenses.Expense "Updated expense"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Unauthorized to update this expense"
+// @Failure 404 {string} string "Expense not found"
+// @Failure 500 {string} string "Failed to update expense"
+// @Router /api/expense/{id} [patch]
+func PatchExpenseHandler(accounts repository.AccountRepository, categories repository.CategoryRepository, expenses repository.ExpenseRepository, households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return updateExpenseHandler(accounts, categories, expenses, households, log, true)
+}
+
+func updateExpenseHandler(accounts repository.AccountRepository, categories repository.CategoryRepository, expenses repository.ExpenseRepository, households repository.HouseholdRepository, log *slog.Logger, partial bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		expenseID := chi.URLParam(r, "id")
+		id, err := strconv.ParseInt(expenseID, 10, 64)
+		if err != nil {
+			log.Error("invalid expense ID parameter", slog.String("expenseID", expenseID))
+			http.Error(w, "Invalid expense ID", http.StatusBadRequest)
+			return
+		}
+
+		userUID := r.Context().Value("userUID").(string)
+
+		var req PatchExpenseRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for expense update", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		if !partial && ((req.Category == nil && req.CategoryID == nil) || req.Amount == nil || req.Date == nil) {
+			log.Error("missing fields in expense update", slog.String("expenseID", expenseID))
+			http.Error(w, "category, amount and date are required", http.StatusBadRequest)
+			return
+		}
+
+		expense, err := expenses.Get(r.Context(), id)
+		if errors.Is(err, repository.ErrNotFound) {
+			log.Warn("expense not found", slog.String("expenseID", expenseID))
+			http.Error(w, "Expense not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to fetch expense details", slog.Any("error", err))
+			http.Error(w, "Failed to fetch expense details", http.StatusInternalServerError)
+			return
+		}
+
+		allowed, err := repository.CanModify(r.Context(), households, userUID, expense)
+		if err != nil {
+			log.Error("failed to check household role", slog.Any("error", err))
+			http.Error(w, "Failed to fetch expense details", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			log.Warn("unauthorized attempt to update expense", slog.String("userUID", userUID), slog.String("ownerUID", expense.UserUID))
+			http.Error(w, "Unauthorized to update this expense", http.StatusForbidden)
+			return
+		}
+
+		if req.Category != nil || req.CategoryID != nil {
+			var categoryID int64
+			var name string
+			if req.CategoryID != nil {
+				categoryID = *req.CategoryID
+			}
+			if req.Category != nil {
+				name = *req.Category
+			}
+			category, err := repository.ResolveCategory(r.Context(), categories, expense.UserUID, repository.CategoryExpense, categoryID, name)
+			if errors.Is(err, repository.ErrNotFound) {
+				log.Warn("category not found", slog.Int64("categoryID", categoryID), slog.String("category", name))
+				http.Error(w, "Unknown category", http.StatusBadRequest)
+				return
+			} else if err != nil {
+				log.Error("failed to fetch category", slog.Any("error", err))
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			expense.CategoryID = category.ID
+			expense.Category = category.Name
+		}
+		if req.Date != nil {
+			if _, err := time.Parse("2006-01-02", *req.Date); err != nil {
+				log.Error("invalid date format", slog.Any("error", err))
+				http.Error(w, "Invalid date format (YYYY-MM-DD)", http.StatusBadRequest)
+				return
+			}
+			expense.Date = *req.Date
+		}
+		if req.Description != nil {
+			expense.Description = *req.Description
+		} else if !partial {
+			expense.Description = ""
+		}
+		if req.AccountID != nil {
+			expense.AccountID = *req.AccountID
+		} else if !partial {
+			expense.AccountID = 0
+		}
+		if req.Amount != nil || expense.AccountID == 0 || req.AccountID != nil {
+			account, err := repository.OwnedAccount(r.Context(), accounts, expense.UserUID, expense.AccountID)
+			if errors.Is(err, repository.ErrNotFound) {
+				log.Warn("account not found", slog.Int64("accountID", expense.AccountID), slog.String("userUID", expense.UserUID))
+				http.Error(w, "Account not found", http.StatusBadRequest)
+				return
+			} else if err != nil {
+				log.Error("failed to fetch account", slog.Any("error", err))
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			expense.AccountID = account.ID
+			currency := account.Balance.Currency
+
+			amount := expense.Amount
+			if req.Amount != nil {
+				amount, err = req.Amount.OrDefault(currency)
+			}
+			if err != nil || amount.Currency != currency || !amount.IsPositive() {
+				log.Error("invalid expense amount", slog.String("amount", amount.String()), slog.Any("error", err))
+				http.Error(w, fmt.Sprintf("Amount must be a positive %s value", currency), http.StatusBadRequest)
+				return
+			}
+			expense.Amount = amount
+		}
+
+		err = expenses.Update(r.Context(), expense)
+		if errors.Is(err, repository.ErrNotFound) {
+			log.Warn("expense not found during update", slog.String("expenseID", expenseID))
+			http.Error(w, "Expense not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to update expense", slog.String("expenseID", expenseID), slog.Any("error", err))
+			http.Error(w, "Failed to update expense", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("expense updated successfully", slog.String("expenseID", expenseID), slog.String("userUID", userUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(newExpense(expense))
+	}
+}
@@ -0,0 +1,181 @@
+// Package export streams the user's incomes and expenses as CSV, XLSX or JSON.
+package export
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"tbank-go/internal/money"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatJSON = "json"
+)
+
+// Row is one exported transaction.
+type Row struct {
+	Date           string      `json:"date"`
+	Type           string      `json:"type"` // income or expense
+	Category       string      `json:"category"`
+	Description    string      `json:"description"`
+	Amount         money.Money `json:"amount"`
+	RunningBalance money.Money `json:"running_balance"`
+}
+
+// rowWriter encodes rows one by one straight into the response.
+type rowWriter interface {
+	WriteRow(row Row) error
+	Close() error
+}
+
+// ExportHandler streams incomes and expenses in the requested format
+// @Summary Export Transactions
+// @Description Streams incomes and expenses ordered by date with a running balance (incomes minus expenses, including everything before `from`).
+// @Description With X-Household-ID the incomes and expenses shared with the household by all its members are exported instead.
+// @Tags Export
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce json
+// @Param X-Household-ID header int false "Household to export the shared incomes and expenses of"
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD)"
+// @Param format query string false "csv (default), xlsx or json"
+// @Security BearerAuth
+// @Success 200 {file} file "Exported transactions"
+// @Failure 400 {string} string "Invalid parameters"
+// @Failure 500 {string} string "Failed to export transactions"
+// @Router /api/export [get]
+func ExportHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		from := r.URL.Query().Get("from")
+		to := r.URL.Query().Get("to")
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = FormatCSV
+		}
+		if format != FormatCSV && format != FormatXLSX && format != FormatJSON {
+			http.Error(w, "format must be csv, xlsx or json", http.StatusBadRequest)
+			return
+		}
+
+		if from == "" {
+			from = "0001-01-01"
+		} else if _, err := time.Parse(dateLayout, from); err != nil {
+			log.Error("invalid from date format", slog.Any("error", err))
+			http.Error(w, "Invalid from date format (YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		if to == "" {
+			to = "9999-12-31"
+		} else if _, err := time.Parse(dateLayout, to); err != nil {
+			log.Error("invalid to date format", slog.Any("error", err))
+			http.Error(w, "Invalid to date format (YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+
+		// The user's incomes and expenses or, like in /api/income and /api/expense, those shared
+		// with the household by all its members.
+		condition, owner := "user_uid = ?", any(userUID)
+		if householdID, _ := r.Context().Value("householdID").(int64); householdID != 0 {
+			condition, owner = "household_id = ?", householdID
+		}
+
+		var currency string
+		var opening int64
+		err := db.QueryRow(`
+			SELECT currency,
+				COALESCE((SELECT SUM(amount) FROM income WHERE `+condition+` AND date < ?), 0) -
+				COALESCE((SELECT SUM(amount) FROM expenses WHERE `+condition+` AND date < ?), 0)
+			FROM users WHERE uid = ?`,
+			owner, from, owner, from, userUID).Scan(&currency, &opening)
+		if err != nil {
+			log.Error("failed to compute opening balance", slog.Any("error", err))
+			http.Error(w, "Failed to export transactions", http.StatusInternalServerError)
+			return
+		}
+
+		query := `
+			SELECT 'income' AS type, id, date, category, amount, currency, description
+			FROM income WHERE ` + condition + ` AND date BETWEEN ? AND ?
+			UNION ALL
+			SELECT 'expense' AS type, id, date, category, amount, currency, description
+			FROM expenses WHERE ` + condition + ` AND date BETWEEN ? AND ?
+			ORDER BY date, type DESC, id`
+		rows, err := db.QueryContext(r.Context(), query, owner, from, to, owner, from, to)
+		if err != nil {
+			log.Error("failed to fetch transactions", slog.Any("error", err))
+			http.Error(w, "Failed to export transactions", http.StatusInternalServerError)
+			return
+		}
+		defer rows.Close()
+
+		filename := "transactions." + format
+		if r.URL.Query().Get("from") != "" || r.URL.Query().Get("to") != "" {
+			filename = fmt.Sprintf("transactions_%s_%s.%s", r.URL.Query().Get("from"), r.URL.Query().Get("to"), format)
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+		var writer rowWriter
+		switch format {
+		case FormatCSV:
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			writer, err = newCSVWriter(w)
+		case FormatXLSX:
+			w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+			writer, err = newXLSXWriter(w)
+		case FormatJSON:
+			w.Header().Set("Content-Type", "application/json")
+			writer, err = newJSONWriter(w)
+		}
+		if err != nil {
+			log.Error("failed to start export", slog.Any("error", err))
+			return
+		}
+
+		// From here on the status line is sent, so errors can only be logged.
+		balance := opening
+		count := 0
+		for rows.Next() {
+			var id int
+			var row Row
+			var description sql.NullString
+			if err := rows.Scan(&row.Type, &id, &row.Date, &row.Category, &row.Amount.Amount, &row.Amount.Currency, &description); err != nil {
+				log.Error("failed to scan transaction", slog.Any("error", err))
+				return
+			}
+			row.Description = description.String
+
+			if row.Type == "income" {
+				balance += row.Amount.Amount
+			} else {
+				balance -= row.Amount.Amount
+			}
+			row.RunningBalance = money.New(balance, currency)
+
+			if err := writer.WriteRow(row); err != nil {
+				log.Error("failed to write export row", slog.Any("error", err))
+				return
+			}
+			count++
+		}
+		if err := rows.Err(); err != nil {
+			log.Error("failed to iterate over transactions", slog.Any("error", err))
+			return
+		}
+
+		if err := writer.Close(); err != nil {
+			log.Error("failed to finish export", slog.Any("error", err))
+			return
+		}
+
+		log.Info("transactions exported", slog.String("userUID", userUID), slog.String("format", format), slog.Int("rows", count))
+	}
+}
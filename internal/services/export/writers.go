@@ -0,0 +1,104 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// flushEvery controls how often buffered rows are pushed to the client.
+const flushEvery = 500
+
+var columns = []string{"date", "type", "category", "description", "amount", "currency", "running_balance"}
+
+func flush(w io.Writer) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+type csvWriter struct {
+	out  io.Writer
+	csv  *csv.Writer
+	rows int
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	writer := &csvWriter{out: w, csv: csv.NewWriter(w)}
+	// UTF-8 BOM so that Excel detects the encoding of Cyrillic text.
+	if _, err := w.Write([]byte("\xef\xbb\xbf")); err != nil {
+		return nil, err
+	}
+	return writer, writer.csv.Write(columns)
+}
+
+func (c *csvWriter) WriteRow(row Row) error {
+	err := c.csv.Write([]string{
+		row.Date,
+		row.Type,
+		row.Category,
+		row.Description,
+		row.Amount.Decimal(),
+		row.Amount.Currency,
+		row.RunningBalance.Decimal(),
+	})
+	if err != nil {
+		return err
+	}
+
+	c.rows++
+	if c.rows%flushEvery == 0 {
+		c.csv.Flush()
+		flush(c.out)
+	}
+	return c.csv.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.csv.Flush()
+	return c.csv.Error()
+}
+
+type jsonWriter struct {
+	out  io.Writer
+	buf  *bufio.Writer
+	enc  *json.Encoder
+	rows int
+}
+
+func newJSONWriter(w io.Writer) (*jsonWriter, error) {
+	buf := bufio.NewWriter(w)
+	if _, err := buf.WriteString("["); err != nil {
+		return nil, err
+	}
+	return &jsonWriter{out: w, buf: buf, enc: json.NewEncoder(buf)}, nil
+}
+
+func (j *jsonWriter) WriteRow(row Row) error {
+	if j.rows > 0 {
+		if _, err := j.buf.WriteString(","); err != nil {
+			return err
+		}
+	}
+	if err := j.enc.Encode(row); err != nil {
+		return err
+	}
+
+	j.rows++
+	if j.rows%flushEvery == 0 {
+		if err := j.buf.Flush(); err != nil {
+			return err
+		}
+		flush(j.out)
+	}
+	return nil
+}
+
+func (j *jsonWriter) Close() error {
+	if _, err := j.buf.WriteString("]\n"); err != nil {
+		return err
+	}
+	return j.buf.Flush()
+}
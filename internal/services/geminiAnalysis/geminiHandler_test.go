@@ -0,0 +1,65 @@
+package geminiAnalysis
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"tbank-go/internal/advice"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/repository/sqlstore"
+	"tbank-go/internal/services/servicetest"
+	"tbank-go/internal/storage/storagetest"
+	"testing"
+)
+
+func TestGenerateFinancialAdvice(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		ctx := context.Background()
+		servicetest.CreateUser(t, repos, "alice")
+		servicetest.CreateUser(t, repos, "bob")
+		for _, expense := range []repository.Expense{
+			{UserUID: "alice", Category: "Taxi", Amount: money.New(45050, "RUB"), Date: "2024-03-01", Description: "Airport"},
+			{UserUID: "alice", Category: "Food", Amount: money.New(1200, "USD"), Date: "2024-03-02", Description: "Lunch"},
+			{UserUID: "bob", Category: "Travel", Amount: money.New(99900, "RUB"), Date: "2024-03-02"},
+		} {
+			expense.AccountID = servicetest.DefaultAccount(t, repos, expense.UserUID).ID
+			if err := repos.Expenses.Create(ctx, &expense); err != nil {
+				t.Fatal(err)
+			}
+		}
+		provider := advice.NewFakeProvider()
+		handler := GenerateFinancialAdviceHandler(db, provider, servicetest.Discard)
+
+		w := httptest.NewRecorder()
+		handler(w, servicetest.NewRequest(http.MethodGet, "/api/financial-advice", "", "alice"))
+		got := servicetest.Decode[FinancialAdviceResponse](t, w, http.StatusOK)
+
+		// The fake answer fingerprints the prompt, so it matches only when the prompt lists
+		// exactly alice's expenses.
+		prompt := constructPrompt([]Expense{
+			{Category: "Taxi", Amount: money.New(45050, "RUB"), Description: "Airport"},
+			{Category: "Food", Amount: money.New(1200, "USD"), Description: "Lunch"},
+		})
+		if !strings.Contains(prompt, "Taxi: 450.50 RUB (Airport)\nFood: 12.00 USD (Lunch)\n") {
+			t.Errorf("prompt = %q", prompt)
+		}
+		want, _ := provider.GenerateAdvice(ctx, prompt)
+		if got.Advice != want {
+			t.Errorf("advice = %q, want %q", got.Advice, want)
+		}
+
+		// A provider failure is a 500 without the provider's error.
+		r := servicetest.NewRequest(http.MethodGet, "/api/financial-advice", "", "alice")
+		canceled, cancel := context.WithCancel(r.Context())
+		cancel()
+		w = httptest.NewRecorder()
+		handler(w, r.WithContext(canceled))
+		if w.Code != http.StatusInternalServerError || strings.TrimSpace(w.Body.String()) != "Failed to generate financial advice" {
+			t.Errorf("canceled request: %d %q", w.Code, w.Body)
+		}
+	})
+}
@@ -0,0 +1,609 @@
+package goals
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/services/accounts"
+	"time"
+	"unicode/utf8"
+)
+
+const dateLayout = "2006-01-02"
+
+// Goal is an amount the user wants to save, optionally by a deadline.
+type Goal struct {
+	ID       int64       `json:"id"`
+	Name     string      `json:"name"`
+	Target   money.Money `json:"target"`
+	Saved    money.Money `json:"saved"` // sum of the contributions
+	Deadline string      `json:"deadline,omitempty"`
+	// AccountID is the account contributions go to. Without it every contribution names its own destination.
+	AccountID int64  `json:"account_id,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+func newGoal(g repository.Goal) Goal {
+	return Goal{
+		ID:        g.ID,
+		Name:      g.Name,
+		Target:    g.Target,
+		Saved:     g.Saved,
+		Deadline:  g.Deadline,
+		AccountID: g.AccountID,
+		CreatedAt: g.CreatedAt,
+	}
+}
+
+// GoalRequest is the body of the create and update endpoints.
+type GoalRequest struct {
+	Name string `json:"name" example:"Vacation"`
+	// Target is in the currency of the linked account, or in the user's currency when the
+	// goal has no account and the currency is not given explicitly.
+	Target    money.Money `json:"target" swaggertype:"string" example:"150000"`
+	Deadline  string      `json:"deadline,omitempty" example:"2025-06-01"`
+	AccountID int64       `json:"account_id,omitempty" example:"2"`
+}
+
+// validate normalizes the request and returns a user-facing message when it is invalid.
+// currency is the currency of the linked account, or the default one when there is no account.
+func (req *GoalRequest) validate(currency string, linked bool) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return "name is required"
+	}
+	if utf8.RuneCountInString(req.Name) > 100 {
+		return "name must be at most 100 characters"
+	}
+
+	target, err := req.Target.OrDefault(currency)
+	if err != nil || !target.IsPositive() {
+		return fmt.Sprintf("target must be a positive %s value", currency)
+	}
+	if linked && target.Currency != currency {
+		return fmt.Sprintf("target must be in %s, the currency of the account", currency)
+	}
+	req.Target = target
+
+	if req.Deadline != "" {
+		if _, err := time.Parse(dateLayout, req.Deadline); err != nil {
+			return "Invalid deadline format (YYYY-MM-DD)"
+		}
+	}
+
+	return ""
+}
+
+// Contribution is a transfer that puts money towards a goal. Like a transfer, Amount is
+// debited from the source account and ToAmount is credited to the goal.
+type Contribution struct {
+	ID            int64       `json:"id"` // ID of the transfer
+	FromAccountID int64       `json:"from_account_id"`
+	ToAccountID   int64       `json:"to_account_id"`
+	Amount        money.Money `json:"amount"`
+	ToAmount      money.Money `json:"to_amount"`
+	Date          string      `json:"date"`
+	Description   string      `json:"description"`
+}
+
+func newContribution(t repository.Transfer) Contribution {
+	return Contribution{
+		ID:            t.ID,
+		FromAccountID: t.FromAccountID,
+		ToAccountID:   t.ToAccountID,
+		Amount:        t.Amount,
+		ToAmount:      t.ToAmount,
+		Date:          t.Date,
+		Description:   t.Description,
+	}
+}
+
+// ContributionRequest is the body of the create contribution endpoint. Its fields mean the same
+// as those of accounts.TransferRequest.
+type ContributionRequest struct {
+	FromAccountID int64 `json:"from_account_id" example:"1"`
+	// ToAccountID is where the money goes. It is required for goals without a linked account
+	// and must be omitted (or equal) otherwise.
+	ToAccountID int64 `json:"to_account_id,omitempty" example:"2"`
+	// Amount is debited from the source account, in its currency.
+	Amount money.Money `json:"amount" swaggertype:"string" example:"5000"`
+	// ToAmount is the amount credited to the goal. It is required when the source account is in
+	// another currency than the goal and must be omitted (or equal) otherwise.
+	ToAmount    *money.Money `json:"to_amount,omitempty" swaggertype:"string" example:"55.20"`
+	Date        string       `json:"date,omitempty" example:"2024-10-05"`
+	Description string       `json:"description,omitempty"`
+}
+
+// validate normalizes the request against the goal and the resolved accounts and returns a
+// user-facing message when it is invalid.
+func (req *ContributionRequest) validate(goal Goal, from, to repository.Account) string {
+	currency := goal.Target.Currency
+	if to.Balance.Currency != currency {
+		return fmt.Sprintf("the destination account must be in %s, the currency of the goal", currency)
+	}
+
+	transfer := accounts.TransferRequest(*req)
+	if msg := transfer.Validate(from, to); msg != "" {
+		return msg
+	}
+	*req = ContributionRequest(transfer)
+
+	if req.Description == "" {
+		req.Description = "Contribution to " + goal.Name
+	}
+	return ""
+}
+
+// CreateGoalHandler creates a savings goal
+// @Summary Create Goal
+// @Description Creates a savings goal. When account_id is given, contributions go to that account and the goal takes its currency.
+// @Tags Goals
+// @Accept json
+// @Produce json
+// @Param goal body goals.GoalRequest true "Goal details"
+// @Security BearerAuth
+// @Success 201 {object} goals.Goal "Created goal"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 500 {string} string "Failed to create goal"
+// @Router /api/goals [post]
+func CreateGoalHandler(users repository.UserRepository, accounts repository.AccountRepository, goals repository.GoalRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		var req GoalRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for goal", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		currency, ok := goalCurrency(users, accounts, w, r, req.AccountID, log)
+		if !ok {
+			return
+		}
+		if msg := req.validate(currency, req.AccountID != 0); msg != "" {
+			log.Error("invalid goal", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		goal := repository.Goal{
+			UserUID:   userUID,
+			Name:      req.Name,
+			Target:    req.Target,
+			Deadline:  req.Deadline,
+			AccountID: req.AccountID,
+		}
+		if err := goals.Create(r.Context(), &goal); err != nil {
+			log.Error("failed to create goal", slog.Any("error", err))
+			http.Error(w, "Failed to create goal", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("goal created successfully", slog.Int64("goalID", goal.ID), slog.String("userUID", userUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(newGoal(goal))
+	}
+}
+
+// GetGoalsHandler lists the user's goals
+// @Summary List Goals
+// @Description Returns all savings goals of the authenticated user with the amount saved so far.
+// @Tags Goals
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} goals.Goal "Goals"
+// @Failure 500 {string} string "Failed to fetch goals"
+// @Router /api/goals [get]
+func GetGoalsHandler(goals repository.GoalRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		records, err := goals.List(r.Context(), userUID)
+		if err != nil {
+			log.Error("failed to fetch goals", slog.Any("error", err))
+			http.Error(w, "Failed to fetch goals", http.StatusInternalServerError)
+			return
+		}
+
+		list := make([]Goal, 0, len(records))
+		for _, goal := range records {
+			list = append(list, newGoal(goal))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// GetGoalHandler returns one goal
+// @Summary Get Goal
+// @Description Returns a savings goal owned by the authenticated user.
+// @Tags Goals
+// @Produce json
+// @Param id path int true "Goal ID"
+// @Security BearerAuth
+// @Success 200 {object} goals.Goal "Goal"
+// @Failure 403 {string} string "Unauthorized to access this goal"
+// @Failure 404 {string} string "Goal not found"
+// @Failure 500 {string} string "Failed to fetch goal"
+// @Router /api/goals/{id} [get]
+func GetGoalHandler(goals repository.GoalRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		goal, ok := loadOwnedGoal(goals, w, r, log)
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(goal)
+	}
+}
+
+// UpdateGoalHandler replaces a goal
+// @Summary Update Goal
+// @Description Replaces the name, target, deadline and account of a goal. The currency of a goal cannot change once it has contributions.
+// @Tags Goals
+// @Accept json
+// @Produce json
+// @Param id path int true "Goal ID"
+// @Param goal body goals.GoalRequest true "Goal details"
+// @Security BearerAuth
+// @Success 200 {object} goals.Goal "Updated goal"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Unauthorized to access this goal"
+// @Failure 404 {string} string "Goal not found"
+// @Failure 500 {string} string "Failed to update goal"
+// @Router /api/goals/{id} [put]
+func UpdateGoalHandler(users repository.UserRepository, accounts repository.AccountRepository, goals repository.GoalRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		var req GoalRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for goal", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		goal, ok := loadOwnedGoal(goals, w, r, log)
+		if !ok {
+			return
+		}
+
+		currency := goal.Target.Currency
+		if req.AccountID != 0 {
+			currency, ok = goalCurrency(users, accounts, w, r, req.AccountID, log)
+			if !ok {
+				return
+			}
+		}
+		if msg := req.validate(currency, req.AccountID != 0); msg != "" {
+			log.Error("invalid goal", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		contributions, err := goals.Contributions(r.Context(), goal.ID)
+		if err != nil {
+			log.Error("failed to fetch contributions", slog.Any("error", err))
+			http.Error(w, "Failed to update goal", http.StatusInternalServerError)
+			return
+		}
+		if len(contributions) > 0 && req.Target.Currency != goal.Target.Currency {
+			http.Error(w, fmt.Sprintf("The goal has contributions in %s, its currency cannot change", goal.Target.Currency), http.StatusBadRequest)
+			return
+		}
+
+		goal.Name = req.Name
+		goal.Target = req.Target
+		goal.Deadline = req.Deadline
+		goal.AccountID = req.AccountID
+		goal.Saved.Currency = req.Target.Currency
+
+		err = goals.Update(r.Context(), repository.Goal{ID: goal.ID, Name: goal.Name, Target: goal.Target,
+			Deadline: goal.Deadline, AccountID: goal.AccountID})
+		if err != nil {
+			log.Error("failed to update goal", slog.Int64("goalID", goal.ID), slog.Any("error", err))
+			http.Error(w, "Failed to update goal", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("goal updated successfully", slog.Int64("goalID", goal.ID), slog.String("userUID", userUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(goal)
+	}
+}
+
+// DeleteGoalHandler deletes a goal
+// @Summary Delete Goal
+// @Description Deletes a savings goal. Its contributions stay as plain transfers.
+// @Tags Goals
+// @Produce json
+// @Param id path int true "Goal ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Success message"
+// @Failure 403 {string} string "Unauthorized to access this goal"
+// @Failure 404 {string} string "Goal not found"
+// @Failure 500 {string} string "Failed to delete goal"
+// @Router /api/goals/{id} [delete]
+func DeleteGoalHandler(goals repository.GoalRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		goal, ok := loadOwnedGoal(goals, w, r, log)
+		if !ok {
+			return
+		}
+
+		err := goals.Delete(r.Context(), goal.ID)
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Goal not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to delete goal", slog.Int64("goalID", goal.ID), slog.Any("error", err))
+			http.Error(w, "Failed to delete goal", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("goal deleted successfully", slog.Int64("goalID", goal.ID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Goal deleted successfully"}`))
+	}
+}
+
+// CreateContributionHandler puts money towards a goal
+// @Summary Contribute to Goal
+// @Description Records a contribution as a transfer from one of the user's accounts to the goal account.
+// @Description For a goal without a linked account the destination is given as to_account_id. The destination has to be in the goal currency.
+// @Description As for a transfer, amount is debited from the source account; from an account in another currency the credited to_amount
+// @Description has to be given explicitly.
+// @Tags Goals
+// @Accept json
+// @Produce json
+// @Param id path int true "Goal ID"
+// @Param contribution body goals.ContributionRequest true "Contribution details"
+// @Security BearerAuth
+// @Success 201 {object} goals.Contribution "Created contribution"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Unauthorized to access this goal"
+// @Failure 404 {string} string "Goal not found"
+// @Failure 500 {string} string "Failed to create contribution"
+// @Router /api/goals/{id}/contributions [post]
+func CreateContributionHandler(accounts repository.AccountRepository, goals repository.GoalRepository, transfers repository.TransferRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		var req ContributionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for contribution", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		goal, ok := loadOwnedGoal(goals, w, r, log)
+		if !ok {
+			return
+		}
+
+		if goal.AccountID != 0 {
+			if req.ToAccountID != 0 && req.ToAccountID != goal.AccountID {
+				http.Error(w, "to_account_id must be omitted, contributions to this goal go to its account", http.StatusBadRequest)
+				return
+			}
+			req.ToAccountID = goal.AccountID
+		}
+		if req.FromAccountID == 0 || req.ToAccountID == 0 {
+			http.Error(w, "from_account_id and to_account_id are required", http.StatusBadRequest)
+			return
+		}
+
+		var resolved [2]repository.Account
+		for i, id := range []int64{req.FromAccountID, req.ToAccountID} {
+			account, err := repository.OwnedAccount(r.Context(), accounts, userUID, id)
+			if errors.Is(err, repository.ErrNotFound) {
+				log.Warn("account not found", slog.Int64("accountID", id), slog.String("userUID", userUID))
+				http.Error(w, "Account not found", http.StatusBadRequest)
+				return
+			} else if err != nil {
+				log.Error("failed to fetch account", slog.Any("error", err))
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			resolved[i] = account
+		}
+
+		if msg := req.validate(goal, resolved[0], resolved[1]); msg != "" {
+			log.Error("invalid contribution", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		transfer := repository.Transfer{
+			UserUID:       userUID,
+			FromAccountID: req.FromAccountID,
+			ToAccountID:   req.ToAccountID,
+			Amount:        req.Amount,
+			ToAmount:      *req.ToAmount,
+			Date:          req.Date,
+			Description:   req.Description,
+			GoalID:        goal.ID,
+		}
+		if err := transfers.Create(r.Context(), &transfer); err != nil {
+			log.Error("failed to create contribution", slog.Any("error", err))
+			http.Error(w, "Failed to create contribution", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("contribution created successfully", slog.Int64("goalID", goal.ID), slog.Int64("transferID", transfer.ID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(newContribution(transfer))
+	}
+}
+
+// GetContributionsHandler lists the contributions to a goal
+// @Summary List Goal Contributions
+// @Description Returns the contributions to a goal in date order.
+// @Tags Goals
+// @Produce json
+// @Param id path int true "Goal ID"
+// @Security BearerAuth
+// @Success 200 {array} goals.Contribution "Contributions"
+// @Failure 403 {string} string "Unauthorized to access this goal"
+// @Failure 404 {string} string "Goal not found"
+// @Failure 500 {string} string "Failed to fetch contributions"
+// @Router /api/goals/{id}/contributions [get]
+func GetContributionsHandler(goals repository.GoalRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		goal, ok := loadOwnedGoal(goals, w, r, log)
+		if !ok {
+			return
+		}
+
+		transfers, err := goals.Contributions(r.Context(), goal.ID)
+		if err != nil {
+			log.Error("failed to fetch contributions", slog.Any("error", err))
+			http.Error(w, "Failed to fetch contributions", http.StatusInternalServerError)
+			return
+		}
+
+		contributions := make([]Contribution, 0, len(transfers))
+		for _, t := range transfers {
+			contributions = append(contributions, newContribution(t))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(contributions)
+	}
+}
+
+// DeleteContributionHandler removes a contribution from a goal
+// @Summary Delete Goal Contribution
+// @Description Deletes the transfer of a contribution and moves the money back to the source account.
+// @Tags Goals
+// @Produce json
+// @Param id path int true "Goal ID"
+// @Param contributionID path int true "Contribution ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Success message"
+// @Failure 400 {string} string "Invalid contribution ID"
+// @Failure 403 {string} string "Unauthorized to access this goal"
+// @Failure 404 {string} string "Goal or contribution not found"
+// @Failure 500 {string} string "Failed to delete contribution"
+// @Router /api/goals/{id}/contributions/{contributionID} [delete]
+func DeleteContributionHandler(goals repository.GoalRepository, transfers repository.TransferRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		goal, ok := loadOwnedGoal(goals, w, r, log)
+		if !ok {
+			return
+		}
+
+		contributionID := chi.URLParam(r, "contributionID")
+		id, err := strconv.ParseInt(contributionID, 10, 64)
+		if err != nil {
+			log.Warn("invalid contribution ID parameter", slog.String("contributionID", contributionID))
+			http.Error(w, "Invalid contribution ID", http.StatusBadRequest)
+			return
+		}
+
+		transfer, err := transfers.Get(r.Context(), id)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && transfer.GoalID != goal.ID) {
+			http.Error(w, "Contribution not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to fetch contribution", slog.Any("error", err))
+			http.Error(w, "Failed to fetch contribution", http.StatusInternalServerError)
+			return
+		}
+
+		err = transfers.Delete(r.Context(), id)
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Contribution not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to delete contribution", slog.String("contributionID", contributionID), slog.Any("error", err))
+			http.Error(w, "Failed to delete contribution", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("contribution deleted successfully", slog.Int64("goalID", goal.ID), slog.String("contributionID", contributionID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Contribution deleted successfully"}`))
+	}
+}
+
+// loadOwnedGoal fetches the goal from the {id} URL parameter and checks that the caller owns it.
+// It writes the error response itself and reports whether the handler may continue.
+func loadOwnedGoal(goals repository.GoalRepository, w http.ResponseWriter, r *http.Request, log *slog.Logger) (Goal, bool) {
+	goalID := chi.URLParam(r, "id")
+	userUID := r.Context().Value("userUID").(string)
+
+	id, err := strconv.ParseInt(goalID, 10, 64)
+	if err != nil {
+		log.Warn("invalid goal ID parameter", slog.String("goalID", goalID))
+		http.Error(w, "Invalid goal ID", http.StatusBadRequest)
+		return Goal{}, false
+	}
+
+	goal, err := goals.Get(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Warn("goal not found", slog.String("goalID", goalID))
+		http.Error(w, "Goal not found", http.StatusNotFound)
+		return Goal{}, false
+	} else if err != nil {
+		log.Error("failed to fetch goal", slog.Any("error", err))
+		http.Error(w, "Failed to fetch goal", http.StatusInternalServerError)
+		return Goal{}, false
+	}
+
+	if goal.UserUID != userUID {
+		log.Warn("unauthorized attempt to access goal", slog.String("userUID", userUID), slog.String("ownerUID", goal.UserUID))
+		http.Error(w, "Unauthorized to access this goal", http.StatusForbidden)
+		return Goal{}, false
+	}
+
+	return newGoal(goal), true
+}
+
+// goalCurrency returns the currency of the account a goal is linked to, or the user's
+// currency when accountID is 0. It writes the error response itself and reports whether
+// the handler may continue.
+func goalCurrency(users repository.UserRepository, accounts repository.AccountRepository, w http.ResponseWriter, r *http.Request,
+	accountID int64, log *slog.Logger) (string, bool) {
+	userUID := r.Context().Value("userUID").(string)
+
+	if accountID == 0 {
+		user, err := users.GetByUID(r.Context(), userUID)
+		if err != nil {
+			log.Error("failed to fetch user currency", slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return "", false
+		}
+		return user.Currency, true
+	}
+
+	account, err := repository.OwnedAccount(r.Context(), accounts, userUID, accountID)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Warn("account not found", slog.Int64("accountID", accountID), slog.String("userUID", userUID))
+		http.Error(w, "Account not found", http.StatusBadRequest)
+		return "", false
+	} else if err != nil {
+		log.Error("failed to fetch account", slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return "", false
+	}
+	return account.Balance.Currency, true
+}
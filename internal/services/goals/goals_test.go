@@ -0,0 +1,113 @@
+package goals
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/repository/memory"
+	"tbank-go/internal/services/servicetest"
+	"testing"
+)
+
+// setup creates alice with her default RUB account and a USD savings account.
+func setup(t *testing.T) (repository.Repositories, repository.Account, repository.Account) {
+	t.Helper()
+	repos := memory.New()
+	servicetest.CreateUser(t, repos, "alice")
+	main := servicetest.DefaultAccount(t, repos, "alice")
+	savings := repository.Account{UserUID: "alice", Name: "Savings", Type: repository.AccountSavings, Balance: money.New(0, "USD")}
+	if err := repos.Accounts.Create(context.Background(), &savings); err != nil {
+		t.Fatal(err)
+	}
+	return repos, main, savings
+}
+
+func createGoal(t *testing.T, repos repository.Repositories, body string) Goal {
+	t.Helper()
+	w := httptest.NewRecorder()
+	CreateGoalHandler(repos.Users, repos.Accounts, repos.Goals, servicetest.Discard)(w, servicetest.NewRequest(http.MethodPost, "/api/goals", body, "alice"))
+	return servicetest.Decode[Goal](t, w, http.StatusCreated)
+}
+
+func TestCreateContribution(t *testing.T) {
+	repos, main, savings := setup(t)
+	goal := createGoal(t, repos, `{"name": "Vacation", "target": "1000", "account_id": `+servicetest.ID(savings.ID)+`}`)
+	if goal.Target != money.New(100000, "USD") || goal.Saved != money.New(0, "USD") {
+		t.Fatalf("goal = %+v", goal)
+	}
+	handler := CreateContributionHandler(repos.Accounts, repos.Goals, repos.Transfers, servicetest.Discard)
+	from := servicetest.ID(main.ID)
+
+	tests := []struct {
+		name string
+		body string
+		want string // error message, empty for success
+	}{
+		{name: "to_amount missing across currencies", body: `{"from_account_id": ` + from + `, "amount": "9000"}`,
+			want: "to_amount in USD is required for a transfer from RUB"},
+		{name: "to_amount in another currency", body: `{"from_account_id": ` + from + `, "amount": "9000", "to_amount": {"value": "100", "currency": "EUR"}}`,
+			want: "to_amount must be a positive USD value"},
+		{name: "another destination", body: `{"from_account_id": ` + from + `, "to_account_id": ` + from + `, "amount": "9000"}`,
+			want: "to_account_id must be omitted, contributions to this goal go to its account"},
+		{name: "valid", body: `{"from_account_id": ` + from + `, "amount": "9000", "to_amount": "100", "date": "2024-03-01"}`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler(w, servicetest.NewRequest(http.MethodPost, "/api/goals", tt.body, "alice", "id", servicetest.ID(goal.ID)))
+		if tt.want != "" {
+			if w.Code != http.StatusBadRequest || strings.TrimSpace(w.Body.String()) != tt.want {
+				t.Errorf("%s: %d %q, want 400 %q", tt.name, w.Code, strings.TrimSpace(w.Body.String()), tt.want)
+			}
+			continue
+		}
+		c := servicetest.Decode[Contribution](t, w, http.StatusCreated)
+		if c.Amount != money.New(900000, "RUB") || c.ToAmount != money.New(10000, "USD") || c.Description != "Contribution to Vacation" {
+			t.Errorf("%s: contribution = %+v", tt.name, c)
+		}
+	}
+
+	stored, err := repos.Goals.Get(context.Background(), goal.ID)
+	if err != nil || stored.Saved != money.New(10000, "USD") {
+		t.Errorf("saved = %v, %v, want 100.00 USD", stored.Saved, err)
+	}
+	account, _ := repos.Accounts.Get(context.Background(), main.ID)
+	if account.Balance.Amount != -900000 {
+		t.Errorf("source balance = %d, want -900000", account.Balance.Amount)
+	}
+}
+
+func TestDeleteGoalKeepsTransfers(t *testing.T) {
+	repos, main, savings := setup(t)
+	goal := createGoal(t, repos, `{"name": "Car", "target": {"value": "5000", "currency": "USD"}}`)
+	ctx := context.Background()
+	transfer := repository.Transfer{UserUID: "alice", FromAccountID: main.ID, ToAccountID: savings.ID,
+		Amount: money.New(90000, "RUB"), ToAmount: money.New(1000, "USD"), Date: "2024-03-01", GoalID: goal.ID}
+	if err := repos.Transfers.Create(ctx, &transfer); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	DeleteGoalHandler(repos.Goals, servicetest.Discard)(w, servicetest.NewRequest(http.MethodDelete, "/api/goals", "", "bob", "id", servicetest.ID(goal.ID)))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("delete by another user: status %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	DeleteGoalHandler(repos.Goals, servicetest.Discard)(w, servicetest.NewRequest(http.MethodDelete, "/api/goals", "", "alice", "id", servicetest.ID(goal.ID)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", w.Code, w.Body)
+	}
+	stored, err := repos.Transfers.Get(ctx, transfer.ID)
+	if err != nil || stored.GoalID != 0 {
+		t.Errorf("transfer after goal delete = %+v, %v", stored, err)
+	}
+
+	w = httptest.NewRecorder()
+	GetGoalHandler(repos.Goals, servicetest.Discard)(w, servicetest.NewRequest(http.MethodGet, "/api/goals", "", "alice", "id", servicetest.ID(goal.ID)))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("get deleted goal: status %d", w.Code)
+	}
+}
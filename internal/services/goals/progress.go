@@ -0,0 +1,135 @@
+package goals
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"time"
+)
+
+const (
+	// rateWindowMonths is how far back contributions count towards the recent contribution rate.
+	rateWindowMonths = 3
+	// maxProjectionDays is the horizon beyond which no completion date is projected.
+	maxProjectionDays = 100 * 365
+)
+
+// Progress shows how far a goal is and when it will be reached at the current pace.
+type Progress struct {
+	GoalID    int64       `json:"goal_id"`
+	Target    money.Money `json:"target"`
+	Saved     money.Money `json:"saved"`
+	Remaining money.Money `json:"remaining"` // zero once the goal is reached
+	Percent   float64     `json:"percent"`   // saved share of the target, may exceed 100
+	Completed bool        `json:"completed"`
+	// MonthlyRate is the average contributed per month over the last rateWindowMonths months.
+	MonthlyRate money.Money `json:"monthly_rate"`
+	// ProjectedCompletion is the date the goal is reached at MonthlyRate. It is null when the goal
+	// is already reached, nothing was contributed recently or the date is a century away.
+	ProjectedCompletion *string `json:"projected_completion"`
+	// RequiredPerMonth is what has to be contributed every month, including the current one, to
+	// reach the goal by the deadline. It is null for goals without a deadline.
+	RequiredPerMonth *money.Money `json:"required_per_month"`
+	// OnTrack reports whether the projected completion is not later than the deadline. It is null
+	// for goals without a deadline.
+	OnTrack *bool `json:"on_track"`
+}
+
+// GetProgressHandler reports the progress of a goal
+// @Summary Goal Progress
+// @Description Returns the amount saved and remaining, the average monthly contribution over the last 3 months,
+// @Description the completion date projected from it and the monthly contribution needed to meet the deadline.
+// @Tags Goals
+// @Produce json
+// @Param id path int true "Goal ID"
+// @Security BearerAuth
+// @Success 200 {object} goals.Progress "Goal progress"
+// @Failure 403 {string} string "Unauthorized to access this goal"
+// @Failure 404 {string} string "Goal not found"
+// @Failure 500 {string} string "Failed to fetch goal progress"
+// @Router /api/goals/{id}/progress [get]
+func GetProgressHandler(goals repository.GoalRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		goal, ok := loadOwnedGoal(goals, w, r, log)
+		if !ok {
+			return
+		}
+
+		today, _ := time.Parse(dateLayout, time.Now().Format(dateLayout))
+		windowStart := today.AddDate(0, -rateWindowMonths, 0)
+
+		recent, err := goals.Contributed(r.Context(), goal.ID, windowStart.Format(dateLayout), today.Format(dateLayout))
+		if err != nil {
+			log.Error("failed to fetch recent contributions", slog.Int64("goalID", goal.ID), slog.Any("error", err))
+			http.Error(w, "Failed to fetch goal progress", http.StatusInternalServerError)
+			return
+		}
+
+		progress := newProgress(goal, recent, today, windowStart)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(progress)
+	}
+}
+
+// newProgress computes the progress of goal from the sum of its contributions after
+// windowStart up to today.
+func newProgress(goal Goal, recent int64, today, windowStart time.Time) Progress {
+	currency := goal.Target.Currency
+	remaining := max(goal.Target.Amount-goal.Saved.Amount, 0)
+
+	progress := Progress{
+		GoalID:      goal.ID,
+		Target:      goal.Target,
+		Saved:       goal.Saved,
+		Remaining:   money.New(remaining, currency),
+		Percent:     math.Round(float64(goal.Saved.Amount)/float64(goal.Target.Amount)*1000) / 10,
+		Completed:   remaining == 0,
+		MonthlyRate: money.New(divRound(recent, rateWindowMonths), currency),
+	}
+
+	if remaining > 0 && recent > 0 {
+		// At recent per window, the remaining amount takes remaining/recent windows.
+		windowDays := int64(today.Sub(windowStart).Hours() / 24)
+		days := (remaining*windowDays + recent - 1) / recent
+		if days <= maxProjectionDays {
+			date := today.AddDate(0, 0, int(days)).Format(dateLayout)
+			progress.ProjectedCompletion = &date
+		}
+	}
+
+	if goal.Deadline != "" {
+		deadline, _ := time.Parse(dateLayout, goal.Deadline)
+		months := int64(monthsUntil(today, deadline))
+		required := money.New((remaining+months-1)/months, currency)
+		progress.RequiredPerMonth = &required
+
+		onTrack := remaining == 0 || (progress.ProjectedCompletion != nil && *progress.ProjectedCompletion <= goal.Deadline)
+		progress.OnTrack = &onTrack
+	}
+
+	return progress
+}
+
+// monthsUntil returns the number of monthly contributions left before deadline, counting the
+// current month and a started last month. It is at least 1, also for a passed deadline.
+func monthsUntil(today, deadline time.Time) int {
+	months := (deadline.Year()-today.Year())*12 + int(deadline.Month()) - int(today.Month())
+	if deadline.Day() >= today.Day() {
+		months++
+	}
+	return max(months, 1)
+}
+
+// divRound divides a by b rounding half away from zero.
+func divRound(a, b int64) int64 {
+	q, r := a/b, a%b
+	if 2*r >= b {
+		q++
+	}
+	return q
+}
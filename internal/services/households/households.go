@@ -0,0 +1,416 @@
+package households
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"tbank-go/internal/repository"
+	"unicode/utf8"
+)
+
+// Household is a group of users sharing incomes, expenses and budgets. Send its ID in the
+// X-Household-ID header to list, add and budget the shared records.
+type Household struct {
+	ID        int64    `json:"id"`
+	Name      string   `json:"name"`
+	Role      string   `json:"role"` // the caller's role: owner, editor or viewer
+	CreatedAt string   `json:"created_at"`
+	Members   []Member `json:"members,omitempty"` // only when a single household is fetched
+}
+
+// Member is a user in a household.
+type Member struct {
+	UserUID  string `json:"user_uid"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	JoinedAt string `json:"joined_at"`
+}
+
+func newMember(m repository.HouseholdMember) Member {
+	return Member{UserUID: m.UserUID, Username: m.Username, Role: m.Role, JoinedAt: m.JoinedAt}
+}
+
+// HouseholdRequest is the body of the create and rename endpoints.
+type HouseholdRequest struct {
+	Name string `json:"name" example:"Family"`
+}
+
+// validate normalizes the request and returns a user-facing message when it is invalid.
+func (req *HouseholdRequest) validate() string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return "name is required"
+	}
+	if utf8.RuneCountInString(req.Name) > 64 {
+		return "name must be at most 64 characters"
+	}
+	return ""
+}
+
+// RoleRequest is the body of the change member role endpoint.
+type RoleRequest struct {
+	Role string `json:"role" example:"editor"`
+}
+
+// CreateHouseholdHandler creates a household
+// @Summary Create Household
+// @Description Creates a household with the authenticated user as its owner.
+// @Tags Households
+// @Accept json
+// @Produce json
+// @Param household body households.HouseholdRequest true "Household details"
+// @Security BearerAuth
+// @Success 201 {object} households.Household "Created household"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 500 {string} string "Failed to create household"
+// @Router /api/households [post]
+func CreateHouseholdHandler(households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		var req HouseholdRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for household", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+		if msg := req.validate(); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		household := repository.Household{Name: req.Name}
+		if err := households.Create(r.Context(), &household, userUID); err != nil {
+			log.Error("failed to create household", slog.Any("error", err))
+			http.Error(w, "Failed to create household", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("household created successfully", slog.Int64("householdID", household.ID), slog.String("userUID", userUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(Household{ID: household.ID, Name: household.Name, Role: repository.RoleOwner, CreatedAt: household.CreatedAt})
+	}
+}
+
+// GetHouseholdsHandler lists the user's households
+// @Summary List Households
+// @Description Returns the households the authenticated user belongs to with the user's role in each.
+// @Tags Households
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} households.Household "Households"
+// @Failure 500 {string} string "Failed to fetch households"
+// @Router /api/households [get]
+func GetHouseholdsHandler(households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		memberships, err := households.ListByMember(r.Context(), userUID)
+		if err != nil {
+			log.Error("failed to fetch households", slog.Any("error", err))
+			http.Error(w, "Failed to fetch households", http.StatusInternalServerError)
+			return
+		}
+
+		list := make([]Household, 0, len(memberships))
+		for _, m := range memberships {
+			list = append(list, Household{ID: m.Household.ID, Name: m.Household.Name, Role: m.Role, CreatedAt: m.Household.CreatedAt})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// GetHouseholdHandler returns a household with its members
+// @Summary Get Household
+// @Description Returns a household the authenticated user belongs to together with its members.
+// @Tags Households
+// @Produce json
+// @Param id path int true "Household ID"
+// @Security BearerAuth
+// @Success 200 {object} households.Household "Household"
+// @Failure 403 {string} string "Unauthorized to access this household"
+// @Failure 404 {string} string "Household not found"
+// @Failure 500 {string} string "Failed to fetch household"
+// @Router /api/households/{id} [get]
+func GetHouseholdHandler(households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		household, role, ok := loadMembership(households, w, r, log)
+		if !ok {
+			return
+		}
+
+		members, err := households.Members(r.Context(), household.ID)
+		if err != nil {
+			log.Error("failed to fetch household members", slog.Any("error", err))
+			http.Error(w, "Failed to fetch household", http.StatusInternalServerError)
+			return
+		}
+
+		result := Household{ID: household.ID, Name: household.Name, Role: role, CreatedAt: household.CreatedAt}
+		for _, m := range members {
+			result.Members = append(result.Members, newMember(m))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// RenameHouseholdHandler renames a household
+// @Summary Rename Household
+// @Description Changes the name of a household. Only the owner may do this.
+// @Tags Households
+// @Accept json
+// @Produce json
+// @Param id path int true "Household ID"
+// @Param household body households.HouseholdRequest true "New name"
+// @Security BearerAuth
+// @Success 200 {object} households.Household "Renamed household"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Only the owner can rename the household"
+// @Failure 404 {string} string "Household not found"
+// @Failure 500 {string} string "Failed to rename household"
+// @Router /api/households/{id} [patch]
+func RenameHouseholdHandler(households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req HouseholdRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for household", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+		if msg := req.validate(); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		household, role, ok := loadMembership(households, w, r, log)
+		if !ok {
+			return
+		}
+		if role != repository.RoleOwner {
+			http.Error(w, "Only the owner can rename the household", http.StatusForbidden)
+			return
+		}
+
+		if err := households.Rename(r.Context(), household.ID, req.Name); err != nil {
+			log.Error("failed to rename household", slog.Int64("householdID", household.ID), slog.Any("error", err))
+			http.Error(w, "Failed to rename household", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("household renamed successfully", slog.Int64("householdID", household.ID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Household{ID: household.ID, Name: req.Name, Role: role, CreatedAt: household.CreatedAt})
+	}
+}
+
+// DeleteHouseholdHandler deletes a household
+// @Summary Delete Household
+// @Description Deletes a household with its budgets and invites. Only the owner may do this.
+// @Description The shared incomes and expenses stay with their authors as personal records.
+// @Tags Households
+// @Produce json
+// @Param id path int true "Household ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Success message"
+// @Failure 403 {string} string "Only the owner can delete the household"
+// @Failure 404 {string} string "Household not found"
+// @Failure 500 {string} string "Failed to delete household"
+// @Router /api/households/{id} [delete]
+func DeleteHouseholdHandler(households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		household, role, ok := loadMembership(households, w, r, log)
+		if !ok {
+			return
+		}
+		if role != repository.RoleOwner {
+			http.Error(w, "Only the owner can delete the household", http.StatusForbidden)
+			return
+		}
+
+		if err := households.Delete(r.Context(), household.ID); err != nil {
+			log.Error("failed to delete household", slog.Int64("householdID", household.ID), slog.Any("error", err))
+			http.Error(w, "Failed to delete household", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("household deleted successfully", slog.Int64("householdID", household.ID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Household deleted successfully"}`))
+	}
+}
+
+// UpdateMemberHandler changes the role of a member
+// @Summary Change Member Role
+// @Description Makes a member an editor or a viewer. Only the owner may do this. Giving a member the owner role
+// @Description hands the household over: the current owner becomes an editor.
+// @Tags Households
+// @Accept json
+// @Produce json
+// @Param id path int true "Household ID"
+// @Param uid path string true "Member UID"
+// @Param role body households.RoleRequest true "New role"
+// @Security BearerAuth
+// @Success 200 {array} households.Member "Members of the household"
+// @Failure 400 {string} string "Invalid role"
+// @Failure 403 {string} string "Only the owner can change roles"
+// @Failure 404 {string} string "Member not found"
+// @Failure 500 {string} string "Failed to change role"
+// @Router /api/households/{id}/members/{uid} [patch]
+func UpdateMemberHandler(households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+		memberUID := chi.URLParam(r, "uid")
+
+		var req RoleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for member role", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+		if req.Role != repository.RoleOwner && req.Role != repository.RoleEditor && req.Role != repository.RoleViewer {
+			http.Error(w, "role must be owner, editor or viewer", http.StatusBadRequest)
+			return
+		}
+
+		household, role, ok := loadMembership(households, w, r, log)
+		if !ok {
+			return
+		}
+		if role != repository.RoleOwner {
+			http.Error(w, "Only the owner can change roles", http.StatusForbidden)
+			return
+		}
+		if memberUID == userUID {
+			http.Error(w, "The owner keeps the owner role until handing it to another member", http.StatusBadRequest)
+			return
+		}
+
+		err := households.SetRole(r.Context(), household.ID, memberUID, req.Role)
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Member not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to change member role", slog.Int64("householdID", household.ID), slog.Any("error", err))
+			http.Error(w, "Failed to change role", http.StatusInternalServerError)
+			return
+		}
+
+		members, err := households.Members(r.Context(), household.ID)
+		if err != nil {
+			log.Error("failed to fetch household members", slog.Any("error", err))
+			http.Error(w, "Failed to fetch household", http.StatusInternalServerError)
+			return
+		}
+		list := make([]Member, 0, len(members))
+		for _, m := range members {
+			list = append(list, newMember(m))
+		}
+
+		log.Info("member role changed", slog.Int64("householdID", household.ID), slog.String("memberUID", memberUID),
+			slog.String("role", req.Role))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// RemoveMemberHandler removes a member from a household
+// @Summary Remove Member
+// @Description Removes a member from a household. The owner may remove anyone else, other members may only leave themselves.
+// @Description The incomes and expenses the member shared become personal records of the member again.
+// @Tags Households
+// @Produce json
+// @Param id path int true "Household ID"
+// @Param uid path string true "Member UID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Success message"
+// @Failure 403 {string} string "Only the owner can remove other members"
+// @Failure 404 {string} string "Member not found"
+// @Failure 409 {string} string "The owner cannot leave the household"
+// @Failure 500 {string} string "Failed to remove member"
+// @Router /api/households/{id}/members/{uid} [delete]
+func RemoveMemberHandler(households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+		memberUID := chi.URLParam(r, "uid")
+
+		household, role, ok := loadMembership(households, w, r, log)
+		if !ok {
+			return
+		}
+		if memberUID == userUID && role == repository.RoleOwner {
+			http.Error(w, "The owner cannot leave the household, hand it to another member or delete it", http.StatusConflict)
+			return
+		}
+		if memberUID != userUID && role != repository.RoleOwner {
+			http.Error(w, "Only the owner can remove other members", http.StatusForbidden)
+			return
+		}
+
+		err := households.RemoveMember(r.Context(), household.ID, memberUID)
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Member not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to remove member", slog.Int64("householdID", household.ID), slog.Any("error", err))
+			http.Error(w, "Failed to remove member", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("member removed from household", slog.Int64("householdID", household.ID), slog.String("memberUID", memberUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Member removed successfully"}`))
+	}
+}
+
+// loadMembership fetches the household from the {id} URL parameter and the caller's role in it.
+// It writes the error response itself and reports whether the handler may continue.
+func loadMembership(households repository.HouseholdRepository, w http.ResponseWriter, r *http.Request, log *slog.Logger) (repository.Household, string, bool) {
+	householdID := chi.URLParam(r, "id")
+	userUID := r.Context().Value("userUID").(string)
+
+	id, err := strconv.ParseInt(householdID, 10, 64)
+	if err != nil {
+		log.Warn("invalid household ID parameter", slog.String("householdID", householdID))
+		http.Error(w, "Invalid household ID", http.StatusBadRequest)
+		return repository.Household{}, "", false
+	}
+
+	household, err := households.Get(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Warn("household not found", slog.String("householdID", householdID))
+		http.Error(w, "Household not found", http.StatusNotFound)
+		return repository.Household{}, "", false
+	} else if err != nil {
+		log.Error("failed to fetch household", slog.Any("error", err))
+		http.Error(w, "Failed to fetch household", http.StatusInternalServerError)
+		return repository.Household{}, "", false
+	}
+
+	role, err := households.Role(r.Context(), id, userUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Warn("unauthorized attempt to access household", slog.String("userUID", userUID), slog.Int64("householdID", id))
+		http.Error(w, "Unauthorized to access this household", http.StatusForbidden)
+		return repository.Household{}, "", false
+	} else if err != nil {
+		log.Error("failed to fetch household role", slog.Any("error", err))
+		http.Error(w, "Failed to fetch household", http.StatusInternalServerError)
+		return repository.Household{}, "", false
+	}
+
+	return household, role, true
+}
@@ -0,0 +1,348 @@
+package households
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"tbank-go/internal/repository"
+)
+
+// Invite asks a registered user to join a household.
+type Invite struct {
+	ID            int64  `json:"id"`
+	HouseholdID   int64  `json:"household_id"`
+	HouseholdName string `json:"household_name"`
+	Username      string `json:"username"`   // the invited user
+	InvitedBy     string `json:"invited_by"` // username of the member who sent the invite
+	Role          string `json:"role"`
+	CreatedAt     string `json:"created_at"`
+}
+
+func newInvite(i repository.HouseholdInvite) Invite {
+	return Invite{
+		ID:            i.ID,
+		HouseholdID:   i.HouseholdID,
+		HouseholdName: i.HouseholdName,
+		Username:      i.InviteeUsername,
+		InvitedBy:     i.InviterUsername,
+		Role:          i.Role,
+		CreatedAt:     i.CreatedAt,
+	}
+}
+
+// InviteRequest is the body of the create invite endpoint.
+type InviteRequest struct {
+	Username string `json:"username" example:"bob"`
+	Role     string `json:"role,omitempty" example:"editor"` // editor or viewer, viewer when omitted
+}
+
+// CreateInviteHandler invites a user to a household
+// @Summary Invite to Household
+// @Description Invites a registered user as an editor or a viewer. Only the owner may invite. The user joins after accepting.
+// @Tags Households
+// @Accept json
+// @Produce json
+// @Param id path int true "Household ID"
+// @Param invite body households.InviteRequest true "Invite details"
+// @Security BearerAuth
+// @Success 201 {object} households.Invite "Created invite"
+// @Failure 400 {string} string "Invalid input or unknown user"
+// @Failure 403 {string} string "Only the owner can invite members"
+// @Failure 404 {string} string "Household not found"
+// @Failure 409 {string} string "User is already a member or invited"
+// @Failure 500 {string} string "Failed to create invite"
+// @Router /api/households/{id}/invites [post]
+func CreateInviteHandler(users repository.UserRepository, households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		var req InviteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for invite", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+		req.Username = strings.TrimSpace(req.Username)
+		if req.Username == "" {
+			http.Error(w, "username is required", http.StatusBadRequest)
+			return
+		}
+		if req.Role == "" {
+			req.Role = repository.RoleViewer
+		}
+		if req.Role != repository.RoleEditor && req.Role != repository.RoleViewer {
+			http.Error(w, "role must be editor or viewer", http.StatusBadRequest)
+			return
+		}
+
+		household, role, ok := loadMembership(households, w, r, log)
+		if !ok {
+			return
+		}
+		if role != repository.RoleOwner {
+			http.Error(w, "Only the owner can invite members", http.StatusForbidden)
+			return
+		}
+
+		invitee, err := users.GetByUsername(r.Context(), req.Username)
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "User not found", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Error("failed to fetch user", slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		invite := repository.HouseholdInvite{HouseholdID: household.ID, InviteeUID: invitee.UID, InviterUID: userUID, Role: req.Role}
+		err = households.CreateInvite(r.Context(), &invite)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			http.Error(w, "User is already a member of the household or invited to it", http.StatusConflict)
+			return
+		} else if err != nil {
+			log.Error("failed to create invite", slog.Any("error", err))
+			http.Error(w, "Failed to create invite", http.StatusInternalServerError)
+			return
+		}
+
+		invite, err = households.GetInvite(r.Context(), invite.ID)
+		if err != nil {
+			log.Error("failed to fetch invite", slog.Any("error", err))
+			http.Error(w, "Failed to create invite", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("household invite created", slog.Int64("householdID", household.ID), slog.String("inviteeUID", invitee.UID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(newInvite(invite))
+	}
+}
+
+// GetHouseholdInvitesHandler lists the pending invites of a household
+// @Summary List Household Invites
+// @Description Returns the invites of a household that were neither accepted nor declined yet. Only the owner may see them.
+// @Tags Households
+// @Produce json
+// @Param id path int true "Household ID"
+// @Security BearerAuth
+// @Success 200 {array} households.Invite "Pending invites"
+// @Failure 403 {string} string "Only the owner can see the invites"
+// @Failure 404 {string} string "Household not found"
+// @Failure 500 {string} string "Failed to fetch invites"
+// @Router /api/households/{id}/invites [get]
+func GetHouseholdInvitesHandler(households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		household, role, ok := loadMembership(households, w, r, log)
+		if !ok {
+			return
+		}
+		if role != repository.RoleOwner {
+			http.Error(w, "Only the owner can see the invites", http.StatusForbidden)
+			return
+		}
+
+		invites, err := households.Invites(r.Context(), household.ID)
+		if err != nil {
+			log.Error("failed to fetch invites", slog.Any("error", err))
+			http.Error(w, "Failed to fetch invites", http.StatusInternalServerError)
+			return
+		}
+		writeInvites(w, invites)
+	}
+}
+
+// RevokeInviteHandler withdraws a pending invite
+// @Summary Revoke Household Invite
+// @Description Withdraws an invite that was not accepted yet. Only the owner may do this.
+// @Tags Households
+// @Produce json
+// @Param id path int true "Household ID"
+// @Param inviteID path int true "Invite ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Success message"
+// @Failure 403 {string} string "Only the owner can revoke invites"
+// @Failure 404 {string} string "Invite not found"
+// @Failure 500 {string} string "Failed to revoke invite"
+// @Router /api/households/{id}/invites/{inviteID} [delete]
+func RevokeInviteHandler(households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		household, role, ok := loadMembership(households, w, r, log)
+		if !ok {
+			return
+		}
+		if role != repository.RoleOwner {
+			http.Error(w, "Only the owner can revoke invites", http.StatusForbidden)
+			return
+		}
+
+		invite, ok := loadInvite(households, w, r, log)
+		if !ok {
+			return
+		}
+		if invite.HouseholdID != household.ID {
+			http.Error(w, "Invite not found", http.StatusNotFound)
+			return
+		}
+
+		if err := households.DeleteInvite(r.Context(), invite.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			log.Error("failed to revoke invite", slog.Int64("inviteID", invite.ID), slog.Any("error", err))
+			http.Error(w, "Failed to revoke invite", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("household invite revoked", slog.Int64("inviteID", invite.ID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Invite revoked successfully"}`))
+	}
+}
+
+// GetMyInvitesHandler lists the invites the user received
+// @Summary List Received Invites
+// @Description Returns the household invites of the authenticated user that wait for an answer.
+// @Tags Households
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} households.Invite "Pending invites"
+// @Failure 500 {string} string "Failed to fetch invites"
+// @Router /api/households/invites [get]
+func GetMyInvitesHandler(households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		invites, err := households.InvitesFor(r.Context(), userUID)
+		if err != nil {
+			log.Error("failed to fetch invites", slog.Any("error", err))
+			http.Error(w, "Failed to fetch invites", http.StatusInternalServerError)
+			return
+		}
+		writeInvites(w, invites)
+	}
+}
+
+// AcceptInviteHandler joins a household
+// @Summary Accept Household Invite
+// @Description Accepts an invite of the authenticated user and joins the household with the invited role.
+// @Tags Households
+// @Produce json
+// @Param inviteID path int true "Invite ID"
+// @Security BearerAuth
+// @Success 200 {object} households.Household "Joined household"
+// @Failure 404 {string} string "Invite not found"
+// @Failure 500 {string} string "Failed to accept invite"
+// @Router /api/households/invites/{inviteID}/accept [post]
+func AcceptInviteHandler(households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		invite, ok := loadReceivedInvite(households, w, r, log)
+		if !ok {
+			return
+		}
+
+		err := households.AcceptInvite(r.Context(), invite.ID)
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Invite not found", http.StatusNotFound)
+			return
+		} else if err != nil && !errors.Is(err, repository.ErrAlreadyExists) {
+			log.Error("failed to accept invite", slog.Int64("inviteID", invite.ID), slog.Any("error", err))
+			http.Error(w, "Failed to accept invite", http.StatusInternalServerError)
+			return
+		}
+
+		household, err := households.Get(r.Context(), invite.HouseholdID)
+		if err != nil {
+			log.Error("failed to fetch household", slog.Any("error", err))
+			http.Error(w, "Failed to accept invite", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("household invite accepted", slog.Int64("householdID", household.ID), slog.String("userUID", invite.InviteeUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Household{ID: household.ID, Name: household.Name, Role: invite.Role, CreatedAt: household.CreatedAt})
+	}
+}
+
+// DeclineInviteHandler turns down an invite
+// @Summary Decline Household Invite
+// @Description Declines an invite of the authenticated user.
+// @Tags Households
+// @Produce json
+// @Param inviteID path int true "Invite ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Success message"
+// @Failure 404 {string} string "Invite not found"
+// @Failure 500 {string} string "Failed to decline invite"
+// @Router /api/households/invites/{inviteID}/decline [post]
+func DeclineInviteHandler(households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		invite, ok := loadReceivedInvite(households, w, r, log)
+		if !ok {
+			return
+		}
+
+		if err := households.DeleteInvite(r.Context(), invite.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			log.Error("failed to decline invite", slog.Int64("inviteID", invite.ID), slog.Any("error", err))
+			http.Error(w, "Failed to decline invite", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("household invite declined", slog.Int64("inviteID", invite.ID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Invite declined successfully"}`))
+	}
+}
+
+func writeInvites(w http.ResponseWriter, invites []repository.HouseholdInvite) {
+	list := make([]Invite, 0, len(invites))
+	for _, i := range invites {
+		list = append(list, newInvite(i))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(list)
+}
+
+// loadInvite fetches the invite from the {inviteID} URL parameter.
+// It writes the error response itself and reports whether the handler may continue.
+func loadInvite(households repository.HouseholdRepository, w http.ResponseWriter, r *http.Request, log *slog.Logger) (repository.HouseholdInvite, bool) {
+	inviteID := chi.URLParam(r, "inviteID")
+	id, err := strconv.ParseInt(inviteID, 10, 64)
+	if err != nil {
+		log.Warn("invalid invite ID parameter", slog.String("inviteID", inviteID))
+		http.Error(w, "Invalid invite ID", http.StatusBadRequest)
+		return repository.HouseholdInvite{}, false
+	}
+
+	invite, err := households.GetInvite(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "Invite not found", http.StatusNotFound)
+		return repository.HouseholdInvite{}, false
+	} else if err != nil {
+		log.Error("failed to fetch invite", slog.Any("error", err))
+		http.Error(w, "Failed to fetch invite", http.StatusInternalServerError)
+		return repository.HouseholdInvite{}, false
+	}
+	return invite, true
+}
+
+// loadReceivedInvite is loadInvite for invites addressed to the caller. Invites of other
+// users are reported as not found.
+func loadReceivedInvite(households repository.HouseholdRepository, w http.ResponseWriter, r *http.Request, log *slog.Logger) (repository.HouseholdInvite, bool) {
+	invite, ok := loadInvite(households, w, r, log)
+	if !ok {
+		return repository.HouseholdInvite{}, false
+	}
+	if userUID := r.Context().Value("userUID").(string); invite.InviteeUID != userUID {
+		log.Warn("attempt to answer another user's invite", slog.String("userUID", userUID), slog.Int64("inviteID", invite.ID))
+		http.Error(w, "Invite not found", http.StatusNotFound)
+		return repository.HouseholdInvite{}, false
+	}
+	return invite, true
+}
@@ -1,15 +1,20 @@
 package incomes
 
 import (
-	"database/sql"
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"tbank-go/internal/blob"
+	"tbank-go/internal/repository"
 )
 
 // DeleteIncomeHandler deletes an income record by its ID and adjusts the user's income balance
 // @Summary Delete Income by ID
-// @Description Deletes a specific income record by its unique ID and updates the user's income balance.
+// @Description Deletes a specific income record by its unique ID and updates the author's income balance. Owners and
+// @Description editors of the household the income is shared with may delete it too.
+// @Description The files attached to the income are deleted with it.
 // @Tags Incomes
 // @Accept json
 // @Produce json
@@ -17,25 +22,24 @@ import (
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{} "Success message"
 // @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 403 {object} map[string]interface{} "Unauthorized to delete this income"
 // @Failure 404 {object} map[string]interface{} "Income not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /api/income/{id} [delete]
-func DeleteIncomeHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+func DeleteIncomeHandler(incomes repository.IncomeRepository, households repository.HouseholdRepository, blobs blob.Store, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		incomeID := chi.URLParam(r, "id")
-		if incomeID == "" {
-			log.Error("missing income ID parameter")
-			http.Error(w, "Income ID is required", http.StatusBadRequest)
+		id, err := strconv.ParseInt(incomeID, 10, 64)
+		if err != nil {
+			log.Error("invalid income ID parameter", slog.String("incomeID", incomeID))
+			http.Error(w, "Invalid income ID", http.StatusBadRequest)
 			return
 		}
 
 		userUID := r.Context().Value("userUID").(string)
 
-		var ownerUID string
-		var incomeAmount float64
-		query := `SELECT user_uid, amount FROM income WHERE id = ?`
-		err := db.QueryRow(query, incomeID).Scan(&ownerUID, &incomeAmount)
-		if err == sql.ErrNoRows {
+		income, err := incomes.Get(r.Context(), id)
+		if errors.Is(err, repository.ErrNotFound) {
 			log.Warn("income not found", slog.String("incomeID", incomeID))
 			http.Error(w, "Income not found", http.StatusNotFound)
 			return
@@ -45,49 +49,32 @@ func DeleteIncomeHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		if ownerUID != userUID {
-			log.Warn("unauthorized attempt to delete income", slog.String("userUID", userUID), slog.String("ownerUID", ownerUID))
-			http.Error(w, "Unauthorized to delete this income", http.StatusForbidden)
-			return
-		}
-
-		tx, err := db.Begin()
+		allowed, err := repository.CanModify(r.Context(), households, userUID, income)
 		if err != nil {
-			log.Error("failed to start transaction", slog.Any("error", err))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			log.Error("failed to check household role", slog.Any("error", err))
+			http.Error(w, "Failed to fetch income details", http.StatusInternalServerError)
 			return
 		}
-
-		deleteQuery := `DELETE FROM income WHERE id = ?`
-		result, err := tx.Exec(deleteQuery, incomeID)
-		if err != nil {
-			tx.Rollback()
-			log.Error("failed to delete income", slog.String("incomeID", incomeID), slog.Any("error", err))
-			http.Error(w, "Failed to delete income", http.StatusInternalServerError)
+		if !allowed {
+			log.Warn("unauthorized attempt to delete income", slog.String("userUID", userUID), slog.String("ownerUID", income.UserUID))
+			http.Error(w, "Unauthorized to delete this income", http.StatusForbidden)
 			return
 		}
 
-		rowsAffected, err := result.RowsAffected()
-		if err != nil || rowsAffected == 0 {
-			tx.Rollback()
+		// The records of the attachments go with the income, their files are removed afterwards.
+		keys, err := incomes.Delete(r.Context(), id)
+		if errors.Is(err, repository.ErrNotFound) {
 			log.Warn("income not found during deletion", slog.String("incomeID", incomeID))
 			http.Error(w, "Income not found", http.StatusNotFound)
 			return
-		}
-
-		updateBalanceQuery := `UPDATE users SET incomes_balance = incomes_balance - ? WHERE uid = ?`
-		_, err = tx.Exec(updateBalanceQuery, incomeAmount, userUID)
-		if err != nil {
-			tx.Rollback()
-			log.Error("failed to update income balance", slog.Any("error", err))
-			http.Error(w, "Failed to update income balance", http.StatusInternalServerError)
+		} else if err != nil {
+			log.Error("failed to delete income", slog.String("incomeID", incomeID), slog.Any("error", err))
+			http.Error(w, "Failed to delete income", http.StatusInternalServerError)
 			return
 		}
 
-		if err := tx.Commit(); err != nil {
-			log.Error("failed to commit transaction", slog.Any("error", err))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
+		if err := blob.Remove(r.Context(), blobs, keys...); err != nil {
+			log.Warn("failed to remove attachment files", slog.String("incomeID", incomeID), slog.Any("error", err))
 		}
 
 		log.Info("income deleted successfully", slog.String("incomeID", incomeID), slog.String("userUID", userUID))
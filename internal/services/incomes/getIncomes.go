@@ -1,91 +1,78 @@
 package incomes
 
 import (
-	"database/sql"
 	"encoding/json"
 	"log/slog"
 	"net/http"
-	"time"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/services/listing"
 )
 
-// GetIncomesHandler retrieves incomes for a user within the specified date range
-// @Summary Get Incomes within a date range
-// @Description Retrieves all income records for a user within the specified date range.
+// GetIncomesHandler retrieves a page of the user's incomes
+// @Summary List Incomes
+// @Description Returns a page of the user's incomes with the total number of matching incomes. All filters are optional.
+// @Description A category also matches its subcategories; min_amount and max_amount are in `currency`, the default account's currency when omitted.
+// @Description Pass next_cursor of a page as `cursor` together with the same parameters to get the next page.
+// @Description With X-Household-ID the incomes shared with the household by all its members are listed instead.
 // @Tags Incomes
 // @Accept json
 // @Produce json
-// @Param from query string true "Start date (YYYY-MM-DD)"
-// @Param to query string true "End date (YYYY-MM-DD)"
+// @Param X-Household-ID header int false "Household to list the shared incomes of"
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD)"
+// @Param category query []string false "Category names" collectionFormat(multi)
+// @Param category_id query []int false "Category IDs" collectionFormat(multi)
+// @Param tags query []string false "Tag names; incomes with any of them" collectionFormat(multi)
+// @Param currency query string false "Only incomes in this currency"
+// @Param min_amount query string false "Minimum amount"
+// @Param max_amount query string false "Maximum amount"
+// @Param description query string false "Substring of the description"
+// @Param sort query string false "date (default) or amount"
+// @Param order query string false "asc (default) or desc"
+// @Param limit query int false "Page size, 50 by default, at most 500"
+// @Param cursor query string false "next_cursor of the previous page"
 // @Security BearerAuth
-// @Success 200 {array} map[string]interface{} "Incomes list"
-// @Failure 400 {object} map[string]interface{} "Invalid date format or missing parameters"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Success 200 {object} listing.Page[incomes.IncomeRecord] "Page of incomes"
+// @Failure 400 {string} string "Invalid parameters"
+// @Failure 500 {string} string "Failed to fetch incomes"
 // @Router /api/income [get]
-func GetIncomesHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+func GetIncomesHandler(accounts repository.AccountRepository, categories repository.CategoryRepository, incomes repository.IncomeRepository, tags repository.TagRepository, households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		startDate := r.URL.Query().Get("from")
-		endDate := r.URL.Query().Get("to")
+		userUID := r.Context().Value("userUID").(string)
 
-		if startDate == "" || endDate == "" {
-			log.Error("missing date parameter", slog.String("start_date", startDate), slog.String("end_date", endDate))
-			http.Error(w, "Both start_date and end_date are required", http.StatusBadRequest)
-			return
+		var filter repository.TransactionFilter
+		var msg string
+		var err error
+		if householdID, _ := r.Context().Value("householdID").(int64); householdID != 0 {
+			filter, msg, err = listing.ParseHouseholdFilter(r.Context(), r.URL.Query(), userUID, householdID, repository.CategoryIncome, accounts, categories, tags, households)
+		} else {
+			filter, msg, err = listing.ParseTransactionFilter(r.Context(), r.URL.Query(), userUID, repository.CategoryIncome, accounts, categories, tags)
 		}
-
-		if _, err := time.Parse("2006-01-02", startDate); err != nil {
-			log.Error("invalid start_date format", slog.Any("error", err))
-			http.Error(w, "Invalid start_date format (YYYY-MM-DD)", http.StatusBadRequest)
+		if err != nil {
+			log.Error("failed to parse income filter", slog.Any("error", err))
+			http.Error(w, "Failed to fetch incomes", http.StatusInternalServerError)
 			return
 		}
-		if _, err := time.Parse("2006-01-02", endDate); err != nil {
-			log.Error("invalid end_date format", slog.Any("error", err))
-			http.Error(w, "Invalid end_date format (YYYY-MM-DD)", http.StatusBadRequest)
+		if msg != "" {
+			log.Warn("invalid income filter", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
 			return
 		}
 
-		userUID := r.Context().Value("userUID").(string)
-
-		query := `
-			SELECT id, category, amount, date, description 
-			FROM income 
-			WHERE user_uid = ? AND date BETWEEN ? AND ?
-		`
-
-		rows, err := db.Query(query, userUID, startDate, endDate)
+		page, err := incomes.Find(r.Context(), filter)
 		if err != nil {
 			log.Error("failed to fetch incomes", slog.Any("error", err))
 			http.Error(w, "Failed to fetch incomes", http.StatusInternalServerError)
 			return
 		}
-		defer rows.Close()
 
-		var incomes []map[string]interface{}
-		for rows.Next() {
-			var id int
-			var category, date, description string
-			var amount float64
-			if err := rows.Scan(&id, &category, &amount, &date, &description); err != nil {
-				log.Error("failed to scan row", slog.Any("error", err))
-				http.Error(w, "Failed to scan income data", http.StatusInternalServerError)
-				return
-			}
-			incomes = append(incomes, map[string]interface{}{
-				"id":          id,
-				"category":    category,
-				"amount":      amount,
-				"date":        date,
-				"description": description,
-			})
-		}
-
-		if err := rows.Err(); err != nil {
-			log.Error("failed to iterate over rows", slog.Any("error", err))
-			http.Error(w, "Failed to iterate over income data", http.StatusInternalServerError)
-			return
+		list := make([]IncomeRecord, 0, len(page.Transactions))
+		for _, income := range page.Transactions {
+			list = append(list, newIncomeRecord(income))
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(incomes)
+		json.NewEncoder(w).Encode(listing.NewPage(list, page.TotalCount, filter, page.Next))
 	}
 }
@@ -0,0 +1,241 @@
+package incomes
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"tbank-go/internal/blob"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/repository/memory"
+	"tbank-go/internal/repository/sqlstore"
+	"tbank-go/internal/services/listing"
+	"tbank-go/internal/services/servicetest"
+	"tbank-go/internal/storage/storagetest"
+	"testing"
+)
+
+// balances returns the user's income counter and the balance of the default account.
+func balances(t *testing.T, repos repository.Repositories, uid string) (int64, int64) {
+	t.Helper()
+	user, err := repos.Users.GetByUID(context.Background(), uid)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	account := servicetest.DefaultAccount(t, repos, uid)
+	return user.IncomesBalance, account.Balance.Amount
+}
+
+func addIncome(t *testing.T, repos repository.Repositories, uid, body string) int64 {
+	t.Helper()
+	w := httptest.NewRecorder()
+	AddIncomeHandler(repos.Accounts, repos.Categories, repos.Incomes, repos.Tags, servicetest.Discard)(w, servicetest.NewRequest(http.MethodPost, "/api/income", body, uid))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("add income: %d %s", w.Code, w.Body)
+	}
+	list, err := repos.Incomes.List(context.Background(), uid, "", "9999-12-31")
+	if err != nil || len(list) == 0 {
+		t.Fatalf("list incomes: %v", err)
+	}
+	return list[len(list)-1].ID
+}
+
+func TestAddIncome(t *testing.T) {
+	repos := memory.New()
+	servicetest.CreateUser(t, repos, "alice")
+
+	id := addIncome(t, repos, "alice", `{"category": "salary", "amount": "1500.50", "date": "2024-03-01", "tags": ["work"]}`)
+
+	income, err := repos.Incomes.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("get income: %v", err)
+	}
+	if income.Category != "Salary" || income.CategoryID == 0 {
+		t.Errorf("category = %q (%d), want the Salary category", income.Category, income.CategoryID)
+	}
+	if income.Amount != money.New(150050, "RUB") {
+		t.Errorf("amount = %v", income.Amount)
+	}
+	if len(income.Tags) != 1 || income.Tags[0].Name != "work" {
+		t.Errorf("tags = %+v", income.Tags)
+	}
+	if counter, account := balances(t, repos, "alice"); counter != 150050 || account != 150050 {
+		t.Errorf("balances = %d, %d, want 150050 each", counter, account)
+	}
+}
+
+func TestAddIncomeInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{name: "malformed JSON", body: `{`, want: http.StatusBadRequest},
+		{name: "invalid date", body: `{"category": "Salary", "amount": "1", "date": "01.03.2024"}`, want: http.StatusBadRequest},
+		{name: "unknown category", body: `{"category": "Lottery", "amount": "1", "date": "2024-03-01"}`, want: http.StatusBadRequest},
+		{name: "expense category", body: `{"category": "Food", "amount": "1", "date": "2024-03-01"}`, want: http.StatusBadRequest},
+		{name: "zero amount", body: `{"category": "Salary", "amount": "0", "date": "2024-03-01"}`, want: http.StatusBadRequest},
+		{name: "other currency", body: `{"category": "Salary", "amount": {"value": "10", "currency": "USD"}, "date": "2024-03-01"}`, want: http.StatusBadRequest},
+		{name: "unknown account", body: `{"account_id": 999, "category": "Salary", "amount": "1", "date": "2024-03-01"}`, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := memory.New()
+			servicetest.CreateUser(t, repos, "alice")
+
+			w := httptest.NewRecorder()
+			AddIncomeHandler(repos.Accounts, repos.Categories, repos.Incomes, repos.Tags, servicetest.Discard)(w, servicetest.NewRequest(http.MethodPost, "/api/income", tt.body, "alice"))
+			if w.Code != tt.want {
+				t.Errorf("status = %d (%s), want %d", w.Code, strings.TrimSpace(w.Body.String()), tt.want)
+			}
+			if counter, account := balances(t, repos, "alice"); counter != 0 || account != 0 {
+				t.Errorf("balances = %d, %d after a rejected income", counter, account)
+			}
+		})
+	}
+}
+
+func TestUpdateIncome(t *testing.T) {
+	repos := memory.New()
+	servicetest.CreateUser(t, repos, "alice")
+	id := addIncome(t, repos, "alice", `{"category": "Salary", "amount": "1000", "date": "2024-03-01", "description": "March"}`)
+	handler := UpdateIncomeHandler(repos.Accounts, repos.Categories, repos.Incomes, repos.Households, servicetest.Discard)
+
+	w := httptest.NewRecorder()
+	handler(w, servicetest.NewRequest(http.MethodPut, "/api/income", `{"category": "Freelance", "amount": "1250", "date": "2024-03-02"}`, "alice", "id", "1"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("update of a missing income: status %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, servicetest.NewRequest(http.MethodPut, "/api/income", `{"category": "Freelance", "amount": "1250", "date": "2024-03-02"}`, "alice", "id", servicetest.ID(id)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", w.Code, w.Body)
+	}
+	income, _ := repos.Incomes.Get(context.Background(), id)
+	if income.Category != "Freelance" || income.Date != "2024-03-02" || income.Description != "" {
+		t.Errorf("income = %+v", income)
+	}
+	if counter, account := balances(t, repos, "alice"); counter != 125000 || account != 125000 {
+		t.Errorf("balances after update = %d, %d, want 125000 each", counter, account)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, servicetest.NewRequest(http.MethodPut, "/api/income", `{"amount": "1"}`, "alice", "id", servicetest.ID(id)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("update without category and date: status %d", w.Code)
+	}
+}
+
+func TestPatchIncome(t *testing.T) {
+	repos := memory.New()
+	servicetest.CreateUser(t, repos, "alice")
+	servicetest.CreateUser(t, repos, "bob")
+	id := addIncome(t, repos, "alice", `{"category": "Salary", "amount": "1000", "date": "2024-03-01", "description": "March"}`)
+	handler := PatchIncomeHandler(repos.Accounts, repos.Categories, repos.Incomes, repos.Households, servicetest.Discard)
+
+	w := httptest.NewRecorder()
+	handler(w, servicetest.NewRequest(http.MethodPatch, "/api/income", `{"amount": "400.25"}`, "alice", "id", servicetest.ID(id)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("patch: %d %s", w.Code, w.Body)
+	}
+	income, _ := repos.Incomes.Get(context.Background(), id)
+	if income.Description != "March" || income.Category != "Salary" || income.Amount.Amount != 40025 {
+		t.Errorf("income = %+v", income)
+	}
+	if counter, account := balances(t, repos, "alice"); counter != 40025 || account != 40025 {
+		t.Errorf("balances after patch = %d, %d, want 40025 each", counter, account)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, servicetest.NewRequest(http.MethodPatch, "/api/income", `{"amount": "1"}`, "bob", "id", servicetest.ID(id)))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("patch by another user: status %d", w.Code)
+	}
+	if counter, _ := balances(t, repos, "alice"); counter != 40025 {
+		t.Errorf("balance changed by a forbidden patch: %d", counter)
+	}
+}
+
+func TestDeleteIncome(t *testing.T) {
+	repos := memory.New()
+	servicetest.CreateUser(t, repos, "alice")
+	servicetest.CreateUser(t, repos, "bob")
+	keep := addIncome(t, repos, "alice", `{"category": "Salary", "amount": "300", "date": "2024-03-01"}`)
+	id := addIncome(t, repos, "alice", `{"category": "Gifts", "amount": "200", "date": "2024-03-02"}`)
+
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := DeleteIncomeHandler(repos.Incomes, repos.Households, blobs, servicetest.Discard)
+
+	w := httptest.NewRecorder()
+	handler(w, servicetest.NewRequest(http.MethodDelete, "/api/income", "", "bob", "id", servicetest.ID(id)))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("delete by another user: status %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, servicetest.NewRequest(http.MethodDelete, "/api/income", "", "alice", "id", servicetest.ID(id)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", w.Code, w.Body)
+	}
+	if _, err := repos.Incomes.Get(context.Background(), id); err != repository.ErrNotFound {
+		t.Errorf("income still stored: %v", err)
+	}
+	if _, err := repos.Incomes.Get(context.Background(), keep); err != nil {
+		t.Errorf("other income deleted: %v", err)
+	}
+	if counter, account := balances(t, repos, "alice"); counter != 30000 || account != 30000 {
+		t.Errorf("balances after delete = %d, %d, want 30000 each", counter, account)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, servicetest.NewRequest(http.MethodDelete, "/api/income", "", "alice", "id", servicetest.ID(id)))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("second delete: status %d", w.Code)
+	}
+}
+
+func TestGetIncomesPages(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		servicetest.CreateUser(t, repos, "alice")
+		servicetest.CreateUser(t, repos, "bob")
+		for _, date := range []string{"2024-01-10", "2024-02-10", "2024-03-10"} {
+			addIncome(t, repos, "alice", `{"category": "Salary", "amount": "1000", "date": "`+date+`"}`)
+		}
+		addIncome(t, repos, "bob", `{"category": "Salary", "amount": "1000", "date": "2024-02-15"}`)
+
+		handler := GetIncomesHandler(repos.Accounts, repos.Categories, repos.Incomes, repos.Tags, repos.Households, servicetest.Discard)
+		get := func(query string) listing.Page[IncomeRecord] {
+			t.Helper()
+			w := httptest.NewRecorder()
+			handler(w, servicetest.NewRequest(http.MethodGet, "/api/income?"+query, "", "alice"))
+			return servicetest.Decode[listing.Page[IncomeRecord]](t, w, http.StatusOK)
+		}
+
+		var dates []string
+		page := get("order=desc&limit=2")
+		for {
+			if page.TotalCount != 3 {
+				t.Errorf("total_count = %d, want 3", page.TotalCount)
+			}
+			for _, income := range page.Items {
+				dates = append(dates, income.Date)
+			}
+			if page.NextCursor == "" || len(dates) > 3 {
+				break
+			}
+			page = get("order=desc&limit=2&cursor=" + url.QueryEscape(page.NextCursor))
+		}
+		if got := strings.Join(dates, ","); got != "2024-03-10,2024-02-10,2024-01-10" {
+			t.Errorf("dates = %s", got)
+		}
+	})
+}
@@ -1,33 +1,62 @@
 package incomes
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
 	"time"
 )
 
 type Income struct {
-	Category    string  `json:"category"`
-	Amount      float64 `json:"amount"`
-	Date        string  `json:"date"`
-	Description string  `json:"description"`
+	AccountID   int64       `json:"account_id,omitempty"`  // the default account when omitted
+	CategoryID  int64       `json:"category_id,omitempty"` // takes precedence over category
+	Category    string      `json:"category"`
+	Amount      money.Money `json:"amount" swaggertype:"string" example:"1500.50"`
+	Date        string      `json:"date"`
+	Description string      `json:"description"`
+	// Tags names the tags of the income. Missing ones are created on add; updates leave the tags
+	// alone, they are changed through /api/tags/bulk.
+	Tags []string `json:"tags" example:"salary"`
+}
+
+// record converts the request body into a repository income owned by userUID and shared with
+// the household, 0 for a personal income.
+func (income Income) record(userUID string, householdID int64) repository.Income {
+	return repository.Income{
+		UserUID:     userUID,
+		HouseholdID: householdID,
+		AccountID:   income.AccountID,
+		CategoryID:  income.CategoryID,
+		Category:    income.Category,
+		Amount:      income.Amount,
+		Date:        income.Date,
+		Description: income.Description,
+	}
 }
 
 // AddIncomeHandler @Summary Add a new income
-// @Description Add a new income record for the authenticated user
+// @Description Add a new income record for the authenticated user. The amount is credited to the given
+// @Description account (the default account when account_id is omitted) and must be in its currency.
+// @Description The category is one of the user's income categories, given by category_id or by name.
+// @Description Tags are given by name; the ones the user does not have yet are created.
+// @Description With X-Household-ID the income is shared with the household; viewers of the household may not add incomes.
 // @Tags Incomes
 // @Accept json
 // @Produce plain
 // @Param Authorization header string true "Bearer token"
+// @Param X-Household-ID header int false "Household to share the income with"
 // @Param income body incomes.Income true "Income details"
 // @Success 201 {string} string "Income added successfully"
 // @Failure 400 {string} string "Invalid input"
 // @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Viewers cannot add incomes to the household"
 // @Failure 500 {string} string "Failed to add income"
 // @Router /api/income [post]
-func AddIncomeHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+func AddIncomeHandler(accounts repository.AccountRepository, categories repository.CategoryRepository, incomes repository.IncomeRepository, tags repository.TagRepository, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var income Income
 		userUID := r.Context().Value("userUID").(string)
@@ -38,44 +67,69 @@ func AddIncomeHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
 			return
 		}
 
+		householdID, _ := r.Context().Value("householdID").(int64)
+		if householdID != 0 && !repository.CanEdit(r.Context().Value("householdRole").(string)) {
+			log.Warn("household viewer attempted to add income", slog.String("userUID", userUID), slog.Int64("householdID", householdID))
+			http.Error(w, "Viewers cannot add incomes to the household", http.StatusForbidden)
+			return
+		}
+
 		if _, err := time.Parse("2006-01-02", income.Date); err != nil {
 			log.Error("invalid date format", slog.Any("error", err))
 			http.Error(w, "Invalid date format (YYYY-MM-DD)", http.StatusBadRequest)
 			return
 		}
 
-		tx, err := db.Begin()
-		if err != nil {
-			log.Error("failed to start transaction", slog.Any("error", err))
+		category, err := repository.ResolveCategory(r.Context(), categories, userUID, repository.CategoryIncome, income.CategoryID, income.Category)
+		if errors.Is(err, repository.ErrNotFound) {
+			log.Warn("category not found", slog.Int64("categoryID", income.CategoryID), slog.String("category", income.Category))
+			http.Error(w, "Unknown category", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Error("failed to fetch category", slog.Any("error", err))
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
+		income.CategoryID = category.ID
+		income.Category = category.Name
 
-		query := `INSERT INTO income (user_uid, category, amount, date, description) VALUES (?, ?, ?, ?, ?)`
-		_, err = tx.Exec(query, userUID, income.Category, income.Amount, income.Date, income.Description)
-		if err != nil {
-			tx.Rollback()
-			log.Error("failed to add income", slog.Any("error", err))
-			http.Error(w, "Failed to add income", http.StatusInternalServerError)
+		account, err := repository.OwnedAccount(r.Context(), accounts, userUID, income.AccountID)
+		if errors.Is(err, repository.ErrNotFound) {
+			log.Warn("account not found", slog.Int64("accountID", income.AccountID), slog.String("userUID", userUID))
+			http.Error(w, "Account not found", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Error("failed to fetch account", slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
+		income.AccountID = account.ID
+		currency := account.Balance.Currency
 
-		balanceUpdateQuery := `UPDATE users SET incomes_balance = incomes_balance + ? WHERE uid = ?`
-		_, err = tx.Exec(balanceUpdateQuery, income.Amount, userUID)
-		if err != nil {
-			tx.Rollback()
-			log.Error("failed to update income balance", slog.Any("error", err))
-			http.Error(w, "Failed to update income balance", http.StatusInternalServerError)
+		income.Amount, err = income.Amount.OrDefault(currency)
+		if err != nil || income.Amount.Currency != currency || !income.Amount.IsPositive() {
+			log.Error("invalid income amount", slog.String("amount", income.Amount.String()), slog.Any("error", err))
+			http.Error(w, fmt.Sprintf("Amount must be a positive %s value", currency), http.StatusBadRequest)
 			return
 		}
 
-		if err := tx.Commit(); err != nil {
-			log.Error("failed to commit transaction", slog.Any("error", err))
+		record := income.record(userUID, householdID)
+		record.Tags, err = repository.ResolveTags(r.Context(), tags, userUID, income.Tags)
+		if errors.Is(err, repository.ErrInvalidTagName) {
+			http.Error(w, fmt.Sprintf("Tag names must be 1 to %d characters long", repository.MaxTagNameLength), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Error("failed to resolve tags", slog.Any("error", err))
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
+		if err := incomes.Create(r.Context(), &record); err != nil {
+			log.Error("failed to add income", slog.Any("error", err))
+			http.Error(w, "Failed to add income", http.StatusInternalServerError)
+			return
+		}
 
-		log.Info("income added successfully", slog.String("userUID", userUID))
+		log.Info("income added successfully", slog.Int64("incomeID", record.ID), slog.String("userUID", userUID))
 		w.WriteHeader(http.StatusCreated)
 		w.Write([]byte("Income added successfully"))
 	}
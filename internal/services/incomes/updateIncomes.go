@@ -0,0 +1,229 @@
+package incomes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"time"
+)
+
+// IncomeRecord is a stored income together with its ID.
+type IncomeRecord struct {
+	ID int64 `json:"id"`
+	Income
+	// HouseholdID is set when the income is shared with a household, UserUID then names its author.
+	HouseholdID int64  `json:"household_id,omitempty"`
+	UserUID     string `json:"user_uid,omitempty"`
+}
+
+// PatchIncomeRequest holds the fields of an income to change. Omitted fields are kept.
+type PatchIncomeRequest struct {
+	AccountID   *int64       `json:"account_id,omitempty"`
+	CategoryID  *int64       `json:"category_id,omitempty"`
+	Category    *string      `json:"category,omitempty"`
+	Amount      *money.Money `json:"amount,omitempty" swaggertype:"string" example:"1500.50"`
+	Date        *string      `json:"date,omitempty"`
+	Description *string      `json:"description,omitempty"`
+}
+
+// UpdateIncomeHandler replaces an income and adjusts the user's income balance by the difference
+// @Summary Update Income
+// @Description Replaces all fields of an income owned by the user and adjusts the income balance by the amount delta.
+// @Description Owners and editors of the household the income is shared with may update it too; the category and
+// @Description the account are then looked up among the author's.
+// @Tags Incomes
+// @Accept json
+// @Produce json
+// @Param id path string true "Income ID"
+// @Param income body incomes.PatchIncomeRequest true "New income details; category or category_id, amount and date are required"
+// @Security BearerAuth
+// @Success 200 {object} incomes.IncomeRecord "Updated income"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Unauthorized to update this income"
+// @Failure 404 {string} string "Income not found"
+// @Failure 500 {string} string "Failed to update income"
+// @Router /api/income/{id} [put]
+func UpdateIncomeHandler(accounts repository.AccountRepository, categories repository.CategoryRepository, incomes repository.IncomeRepository, households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return updateIncomeHandler(accounts, categories, incomes, households, log, false)
+}
+
+// PatchIncomeHandler changes selected fields of an income and adjusts the user's income balance by the difference
+// @Summary Patch Income
+// @Description Changes only the provided fields of an income owned by the user and adjusts the income balance by the amount delta.
+// @Description Owners and editors of the household the income is shared with may patch it too.
+// @Tags Incomes
+// @Accept json
+// @Produce json
+// @Param id path string true "Income ID"
+// @Param income body incomes.PatchIncomeRequest true "Fields to change"
+// @Security BearerAuth
+// @Success 200 {object} incomes.IncomeRecord "Updated income"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Unauthorized to update this income"
+// @Failure 404 {string} string "Income not found"
+// @Failure 500 {string} string "Failed to update income"
+// @Router /api/income/{id} [patch]
+func PatchIncomeHandler(accounts repository.AccountRepository, categories repository.CategoryRepository, incomes repository.IncomeRepository, households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return updateIncomeHandler(accounts, categories, incomes, households, log, true)
+}
+
+func updateIncomeHandler(accounts repository.AccountRepository, categories repository.CategoryRepository, incomes repository.IncomeRepository, households repository.HouseholdRepository, log *slog.Logger, partial bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		incomeID := chi.URLParam(r, "id")
+		id, err := strconv.ParseInt(incomeID, 10, 64)
+		if err != nil {
+			log.Error("invalid income ID parameter", slog.String("incomeID", incomeID))
+			http.Error(w, "Invalid income ID", http.StatusBadRequest)
+			return
+		}
+
+		userUID := r.Context().Value("userUID").(string)
+
+		var req PatchIncomeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for income update", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		if !partial && ((req.Category == nil && req.CategoryID == nil) || req.Amount == nil || req.Date == nil) {
+			log.Error("missing fields in income update", slog.String("incomeID", incomeID))
+			http.Error(w, "category, amount and date are required", http.StatusBadRequest)
+			return
+		}
+
+		income, err := incomes.Get(r.Context(), id)
+		if errors.Is(err, repository.ErrNotFound) {
+			log.Warn("income not found", slog.String("incomeID", incomeID))
+			http.Error(w, "Income not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to fetch income details", slog.Any("error", err))
+			http.Error(w, "Failed to fetch income details", http.StatusInternalServerError)
+			return
+		}
+
+		allowed, err := repository.CanModify(r.Context(), households, userUID, income)
+		if err != nil {
+			log.Error("failed to check household role", slog.Any("error", err))
+			http.Error(w, "Failed to fetch income details", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			log.Warn("unauthorized attempt to update income", slog.String("userUID", userUID), slog.String("ownerUID", income.UserUID))
+			http.Error(w, "Unauthorized to update this income", http.StatusForbidden)
+			return
+		}
+
+		if req.Category != nil || req.CategoryID != nil {
+			var categoryID int64
+			var name string
+			if req.CategoryID != nil {
+				categoryID = *req.CategoryID
+			}
+			if req.Category != nil {
+				name = *req.Category
+			}
+			category, err := repository.ResolveCategory(r.Context(), categories, income.UserUID, repository.CategoryIncome, categoryID, name)
+			if errors.Is(err, repository.ErrNotFound) {
+				log.Warn("category not found", slog.Int64("categoryID", categoryID), slog.String("category", name))
+				http.Error(w, "Unknown category", http.StatusBadRequest)
+				return
+			} else if err != nil {
+				log.Error("failed to fetch category", slog.Any("error", err))
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			income.CategoryID = category.ID
+			income.Category = category.Name
+		}
+		if req.Date != nil {
+			if _, err := time.Parse("2006-01-02", *req.Date); err != nil {
+				log.Error("invalid date format", slog.Any("error", err))
+				http.Error(w, "Invalid date format (YYYY-MM-DD)", http.StatusBadRequest)
+				return
+			}
+			income.Date = *req.Date
+		}
+		if req.Description != nil {
+			income.Description = *req.Description
+		} else if !partial {
+			income.Description = ""
+		}
+		if req.AccountID != nil {
+			income.AccountID = *req.AccountID
+		} else if !partial {
+			income.AccountID = 0
+		}
+		if req.Amount != nil || income.AccountID == 0 || req.AccountID != nil {
+			account, err := repository.OwnedAccount(r.Context(), accounts, income.UserUID, income.AccountID)
+			if errors.Is(err, repository.ErrNotFound) {
+				log.Warn("account not found", slog.Int64("accountID", income.AccountID), slog.String("userUID", income.UserUID))
+				http.Error(w, "Account not found", http.StatusBadRequest)
+				return
+			} else if err != nil {
+				log.Error("failed to fetch account", slog.Any("error", err))
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			income.AccountID = account.ID
+			currency := account.Balance.Currency
+
+			amount := income.Amount
+			if req.Amount != nil {
+				amount, err = req.Amount.OrDefault(currency)
+			}
+			if err != nil || amount.Currency != currency || !amount.IsPositive() {
+				log.Error("invalid income amount", slog.String("amount", amount.String()), slog.Any("error", err))
+				http.Error(w, fmt.Sprintf("Amount must be a positive %s value", currency), http.StatusBadRequest)
+				return
+			}
+			income.Amount = amount
+		}
+
+		err = incomes.Update(r.Context(), income)
+		if errors.Is(err, repository.ErrNotFound) {
+			log.Warn("income not found during update", slog.String("incomeID", incomeID))
+			http.Error(w, "Income not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to update income", slog.String("incomeID", incomeID), slog.Any("error", err))
+			http.Error(w, "Failed to update income", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("income updated successfully", slog.String("incomeID", incomeID), slog.String("userUID", userUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(newIncomeRecord(income))
+	}
+}
+
+func newIncomeRecord(income repository.Income) IncomeRecord {
+	record := IncomeRecord{
+		ID: income.ID,
+		Income: Income{
+			AccountID:   income.AccountID,
+			CategoryID:  income.CategoryID,
+			Category:    income.Category,
+			Amount:      income.Amount,
+			Date:        income.Date,
+			Description: income.Description,
+			Tags:        []string{},
+		},
+	}
+	for _, tag := range income.Tags {
+		record.Tags = append(record.Tags, tag.Name)
+	}
+	if income.HouseholdID != 0 {
+		record.HouseholdID = income.HouseholdID
+		record.UserUID = income.UserUID
+	}
+	return record
+}
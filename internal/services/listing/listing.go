@@ -0,0 +1,329 @@
+// Package listing parses the pagination, sorting and filter parameters shared by the
+// transaction listings and builds the paged response envelope.
+package listing
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"time"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	// DefaultLimit is the page size when limit is not given.
+	DefaultLimit = 50
+	// MaxLimit is the largest accepted page size.
+	MaxLimit = 500
+)
+
+// Page is the envelope of a paged listing.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	TotalCount int    `json:"total_count"`           // items matching the filters on all pages
+	NextCursor string `json:"next_cursor,omitempty"` // pass as cursor to get the next page, absent on the last one
+}
+
+// NewPage wraps the items of a page. next is the cursor from the repository, nil on the last page.
+func NewPage[T any](items []T, totalCount int, filter repository.TransactionFilter, next *repository.Cursor) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return Page[T]{Items: items, TotalCount: totalCount, NextCursor: EncodeCursor(filter, next)}
+}
+
+// cursor is the JSON inside an encoded cursor. The sort is included so that a cursor
+// cannot be replayed against a listing in another order.
+type cursor struct {
+	Sort   string `json:"s"`
+	Desc   bool   `json:"r,omitempty"`
+	Date   string `json:"d,omitempty"`
+	Amount int64  `json:"a,omitempty"`
+	ID     int64  `json:"i"`
+}
+
+// EncodeCursor returns the opaque next_cursor value for a position, or "" for nil.
+func EncodeCursor(filter repository.TransactionFilter, c *repository.Cursor) string {
+	if c == nil {
+		return ""
+	}
+	data, _ := json.Marshal(cursor{Sort: filter.SortBy, Desc: filter.Descending, Date: c.Date, Amount: c.Amount, ID: c.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string, filter repository.TransactionFilter) (*repository.Cursor, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, false
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != filter.SortBy || c.Desc != filter.Descending || c.ID <= 0 {
+		return nil, false
+	}
+	return &repository.Cursor{Date: c.Date, Amount: c.Amount, ID: c.ID}, true
+}
+
+// ParseTransactionFilter reads the listing parameters of the income, expense or feed endpoint:
+//
+//	from, to            date range, both optional
+//	account_id          only transactions of this account
+//	category            category name, repeatable
+//	category_id         category ID, repeatable or comma-separated
+//	tags                tag name, repeatable or comma-separated; transactions with any of them
+//	currency            only transactions in this currency
+//	min_amount          lower amount bound, in currency (the default account's currency when omitted)
+//	max_amount          upper amount bound
+//	description         substring of the description
+//	sort                date (default) or amount
+//	order               asc (default) or desc
+//	limit               page size, DefaultLimit by default, at most MaxLimit
+//	cursor              next_cursor of the previous page
+//
+// Categories are looked up among the categories of categoryType, or of both types when it is
+// empty. A category also matches its subcategories. The returned message is meant for the user and
+// is set when a parameter is invalid; err is set when a repository fails.
+func ParseTransactionFilter(ctx context.Context, q url.Values, userUID, categoryType string,
+	accounts repository.AccountRepository, categories repository.CategoryRepository, tags repository.TagRepository) (repository.TransactionFilter, string, error) {
+	return parseFilter(ctx, q, userUID, []string{userUID}, categoryType, accounts, categories, tags)
+}
+
+// ParseHouseholdFilter is ParseTransactionFilter for the records shared with a household: the
+// filter matches the records of every member, and categories are looked up among the categories
+// of every member, so that "Food" selects the Food category of each of them; tags likewise.
+// account_id still has to be an account of the user.
+func ParseHouseholdFilter(ctx context.Context, q url.Values, userUID string, householdID int64, categoryType string,
+	accounts repository.AccountRepository, categories repository.CategoryRepository, tags repository.TagRepository,
+	households repository.HouseholdRepository) (repository.TransactionFilter, string, error) {
+	members, err := households.Members(ctx, householdID)
+	if err != nil {
+		return repository.TransactionFilter{}, "", err
+	}
+	owners := make([]string, 0, len(members))
+	for _, m := range members {
+		owners = append(owners, m.UserUID)
+	}
+
+	filter, msg, err := parseFilter(ctx, q, userUID, owners, categoryType, accounts, categories, tags)
+	filter.HouseholdID = householdID
+	return filter, msg, err
+}
+
+// parseFilter parses the listing parameters for userUID, looking categories and tags up among
+// those of owners.
+func parseFilter(ctx context.Context, q url.Values, userUID string, owners []string, categoryType string,
+	accounts repository.AccountRepository, categories repository.CategoryRepository, tags repository.TagRepository) (repository.TransactionFilter, string, error) {
+	filter := repository.TransactionFilter{
+		UserUID:     userUID,
+		From:        q.Get("from"),
+		To:          q.Get("to"),
+		Currency:    q.Get("currency"),
+		Description: strings.TrimSpace(q.Get("description")),
+		SortBy:      repository.SortByDate,
+		Limit:       DefaultLimit,
+	}
+
+	if filter.From != "" {
+		if _, err := time.Parse(dateLayout, filter.From); err != nil {
+			return filter, "Invalid from format (YYYY-MM-DD)", nil
+		}
+	}
+	if filter.To != "" {
+		if _, err := time.Parse(dateLayout, filter.To); err != nil {
+			return filter, "Invalid to format (YYYY-MM-DD)", nil
+		}
+	}
+	if filter.Currency != "" && !money.ValidCurrency(filter.Currency) {
+		return filter, "Invalid currency", nil
+	}
+
+	switch sortBy := q.Get("sort"); sortBy {
+	case "", repository.SortByDate:
+	case repository.SortByAmount:
+		filter.SortBy = sortBy
+	default:
+		return filter, "sort must be date or amount", nil
+	}
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		return filter, "order must be asc or desc", nil
+	}
+
+	if value := q.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return filter, fmt.Sprintf("limit must be between 1 and %d", MaxLimit), nil
+		}
+		filter.Limit = limit
+	}
+	if value := q.Get("cursor"); value != "" {
+		after, ok := decodeCursor(value, filter)
+		if !ok {
+			return filter, "Invalid cursor or cursor of another sort order", nil
+		}
+		filter.After = after
+	}
+
+	if value := q.Get("account_id"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id <= 0 {
+			return filter, "Invalid account_id", nil
+		}
+		_, err = repository.OwnedAccount(ctx, accounts, userUID, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			return filter, "Account not found", nil
+		} else if err != nil {
+			return filter, "", err
+		}
+		filter.AccountID = id
+	}
+
+	minAmount, maxAmount := q.Get("min_amount"), q.Get("max_amount")
+	if minAmount != "" || maxAmount != "" {
+		currency := filter.Currency
+		if currency == "" {
+			account, err := accounts.GetDefault(ctx, userUID)
+			if err != nil {
+				return filter, "", err
+			}
+			currency = account.Balance.Currency
+			filter.Currency = currency
+		}
+		if minAmount != "" {
+			amount, err := money.Parse(minAmount, currency)
+			if err != nil {
+				return filter, fmt.Sprintf("Invalid min_amount for %s", currency), nil
+			}
+			filter.MinAmount = &amount.Amount
+		}
+		if maxAmount != "" {
+			amount, err := money.Parse(maxAmount, currency)
+			if err != nil {
+				return filter, fmt.Sprintf("Invalid max_amount for %s", currency), nil
+			}
+			filter.MaxAmount = &amount.Amount
+		}
+	}
+
+	msg, err := parseCategories(ctx, q, owners, categoryType, categories, &filter)
+	if msg != "" || err != nil {
+		return filter, msg, err
+	}
+	msg, err = parseTags(ctx, q, owners, tags, &filter)
+	return filter, msg, err
+}
+
+// parseCategories resolves the category and category_id parameters into filter.CategoryIDs,
+// adding the subcategories of every selected category. Categories of all owners are candidates.
+func parseCategories(ctx context.Context, q url.Values, owners []string, categoryType string,
+	categories repository.CategoryRepository, filter *repository.TransactionFilter) (string, error) {
+	var ids []int64
+	for _, value := range q["category_id"] {
+		for _, part := range strings.Split(value, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+			if err != nil || id <= 0 {
+				return "Invalid category_id", nil
+			}
+			ids = append(ids, id)
+		}
+	}
+	names := q["category"]
+	if len(ids) == 0 && len(names) == 0 {
+		return "", nil
+	}
+
+	var all []repository.Category
+	for _, owner := range owners {
+		list, err := categories.List(ctx, owner)
+		if err != nil {
+			return "", err
+		}
+		all = append(all, list...)
+	}
+	selectable := func(category repository.Category) bool {
+		return categoryType == "" || category.Type == categoryType
+	}
+
+	for _, id := range ids {
+		if !slices.ContainsFunc(all, func(c repository.Category) bool { return c.ID == id && selectable(c) }) {
+			return fmt.Sprintf("Unknown category_id %d", id), nil
+		}
+	}
+	for _, name := range names {
+		found := false
+		for _, category := range all {
+			if selectable(category) && repository.CategoryKey(category.Name) == repository.CategoryKey(name) {
+				ids = append(ids, category.ID)
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Sprintf("Unknown category %q", name), nil
+		}
+	}
+
+	for _, category := range all {
+		if category.ParentID != 0 && slices.Contains(ids, category.ParentID) {
+			ids = append(ids, category.ID)
+		}
+	}
+
+	slices.Sort(ids)
+	filter.CategoryIDs = slices.Compact(ids)
+	return "", nil
+}
+
+// parseTags resolves the tags parameter into filter.TagIDs. Tags of all owners are candidates.
+func parseTags(ctx context.Context, q url.Values, owners []string, tags repository.TagRepository,
+	filter *repository.TransactionFilter) (string, error) {
+	var names []string
+	for _, value := range q["tags"] {
+		for _, name := range strings.Split(value, ",") {
+			if repository.TagKey(name) == "" {
+				return "Invalid tags", nil
+			}
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+
+	var all []repository.Tag
+	for _, owner := range owners {
+		list, err := tags.List(ctx, owner)
+		if err != nil {
+			return "", err
+		}
+		all = append(all, list...)
+	}
+
+	var ids []int64
+	for _, name := range names {
+		found := false
+		for _, tag := range all {
+			if repository.TagKey(tag.Name) == repository.TagKey(name) {
+				ids = append(ids, tag.ID)
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Sprintf("Unknown tag %q", strings.TrimSpace(name)), nil
+		}
+	}
+
+	slices.Sort(ids)
+	filter.TagIDs = slices.Compact(ids)
+	return "", nil
+}
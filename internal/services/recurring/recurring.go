@@ -0,0 +1,432 @@
+package recurring
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository/sqlstore"
+	"tbank-go/internal/user-service"
+	"time"
+)
+
+const (
+	defaultPreviewDays = 30
+	maxPreviewDays     = 366
+)
+
+const ruleColumns = `id, type, category, amount, currency, description, freq, freq_interval, by_month_day, by_weekday,
+	start_date, until_date, max_count, materialized_through`
+
+// Occurrence is one upcoming transaction produced by a rule.
+type Occurrence struct {
+	RuleID      int         `json:"rule_id"`
+	Date        string      `json:"date"`
+	Type        string      `json:"type"`
+	Category    string      `json:"category"`
+	Amount      money.Money `json:"amount"`
+	Description string      `json:"description"`
+}
+
+// CreateRuleHandler creates a recurring income or expense
+// @Summary Create Recurring Rule
+// @Description Creates a daily, weekly or monthly rule. Occurrences from start_date up to today are recorded immediately, later ones by the scheduler.
+// @Description start_date may be at most a year in the past.
+// @Tags Recurring
+// @Accept json
+// @Produce json
+// @Param rule body recurring.RuleRequest true "Rule details"
+// @Security BearerAuth
+// @Success 201 {object} recurring.Rule "Created rule"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 500 {string} string "Failed to create recurring rule"
+// @Router /api/recurring [post]
+func CreateRuleHandler(db *sql.DB, incomes *sqlstore.IncomeRepository, expenses *sqlstore.ExpenseRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		var req RuleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for recurring rule", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		currency, err := user_service.GetUserCurrency(db, userUID)
+		if err != nil {
+			log.Error("failed to fetch user currency", slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if msg := req.validate(currency, ""); msg != "" {
+			log.Error("invalid recurring rule", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		rule := req.rule()
+		query := `INSERT INTO recurring_rules (user_uid, type, category, amount, currency, description, freq, freq_interval,
+		          by_month_day, by_weekday, start_date, until_date, max_count, created_at)
+		          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`
+		err = db.QueryRow(query, userUID, rule.Type, rule.Category, rule.Amount.Amount, rule.Amount.Currency, rule.Description,
+			rule.Freq, rule.Interval, rule.ByMonthDay, rule.ByWeekday, rule.StartDate, nullString(rule.Until), rule.Count,
+			time.Now().UTC().Format(time.RFC3339)).Scan(&rule.ID)
+		if err != nil {
+			log.Error("failed to create recurring rule", slog.Any("error", err))
+			http.Error(w, "Failed to create recurring rule", http.StatusInternalServerError)
+			return
+		}
+
+		created, err := materializeRule(r.Context(), db, incomes, expenses, userUID, rule, today(time.Now()))
+		if err != nil {
+			// The scheduler retries on its next run, so the rule itself is still created.
+			log.Error("failed to materialize recurring rule", slog.Int("ruleID", rule.ID), slog.Any("error", err))
+		} else {
+			rule.MaterializedThrough = today(time.Now()).Format(dateLayout)
+		}
+
+		log.Info("recurring rule created successfully", slog.Int("ruleID", rule.ID), slog.String("userUID", userUID),
+			slog.Int("materialized", created))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(rule)
+	}
+}
+
+// GetRulesHandler lists the user's recurring rules
+// @Summary List Recurring Rules
+// @Description Returns all recurring rules of the authenticated user.
+// @Tags Recurring
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} recurring.Rule "Recurring rules"
+// @Failure 500 {string} string "Failed to fetch recurring rules"
+// @Router /api/recurring [get]
+func GetRulesHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		rules, err := listRules(db, userUID)
+		if err != nil {
+			log.Error("failed to fetch recurring rules", slog.Any("error", err))
+			http.Error(w, "Failed to fetch recurring rules", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(rules)
+	}
+}
+
+// GetRuleHandler returns one recurring rule
+// @Summary Get Recurring Rule
+// @Description Returns a recurring rule owned by the authenticated user.
+// @Tags Recurring
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Security BearerAuth
+// @Success 200 {object} recurring.Rule "Recurring rule"
+// @Failure 403 {string} string "Unauthorized to access this recurring rule"
+// @Failure 404 {string} string "Recurring rule not found"
+// @Failure 500 {string} string "Failed to fetch recurring rule"
+// @Router /api/recurring/{id} [get]
+func GetRuleHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule, ok := loadOwnedRule(db, w, r, log)
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(rule)
+	}
+}
+
+// UpdateRuleHandler replaces a recurring rule
+// @Summary Update Recurring Rule
+// @Description Replaces a recurring rule. Already recorded transactions are kept; the change applies to occurrences after materialized_through.
+// @Tags Recurring
+// @Accept json
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Param rule body recurring.RuleRequest true "Rule details"
+// @Security BearerAuth
+// @Success 200 {object} recurring.Rule "Updated rule"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Unauthorized to access this recurring rule"
+// @Failure 404 {string} string "Recurring rule not found"
+// @Failure 500 {string} string "Failed to update recurring rule"
+// @Router /api/recurring/{id} [put]
+func UpdateRuleHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		var req RuleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for recurring rule", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		existing, ok := loadOwnedRule(db, w, r, log)
+		if !ok {
+			return
+		}
+
+		if req.StartDate == "" {
+			req.StartDate = existing.StartDate
+		}
+		if msg := req.validate(existing.Amount.Currency, existing.StartDate); msg != "" {
+			log.Error("invalid recurring rule", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		rule := req.rule()
+		rule.ID = existing.ID
+		rule.MaterializedThrough = existing.MaterializedThrough
+
+		query := `UPDATE recurring_rules SET type = ?, category = ?, amount = ?, currency = ?, description = ?, freq = ?,
+		          freq_interval = ?, by_month_day = ?, by_weekday = ?, start_date = ?, until_date = ?, max_count = ? WHERE id = ?`
+		_, err := db.Exec(query, rule.Type, rule.Category, rule.Amount.Amount, rule.Amount.Currency, rule.Description, rule.Freq,
+			rule.Interval, rule.ByMonthDay, rule.ByWeekday, rule.StartDate, nullString(rule.Until), rule.Count, rule.ID)
+		if err != nil {
+			log.Error("failed to update recurring rule", slog.Int("ruleID", rule.ID), slog.Any("error", err))
+			http.Error(w, "Failed to update recurring rule", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("recurring rule updated successfully", slog.Int("ruleID", rule.ID), slog.String("userUID", userUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(rule)
+	}
+}
+
+// DeleteRuleHandler deletes a recurring rule
+// @Summary Delete Recurring Rule
+// @Description Stops a recurring rule. Incomes and expenses it already created are kept.
+// @Tags Recurring
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Success message"
+// @Failure 403 {string} string "Unauthorized to access this recurring rule"
+// @Failure 404 {string} string "Recurring rule not found"
+// @Failure 500 {string} string "Failed to delete recurring rule"
+// @Router /api/recurring/{id} [delete]
+func DeleteRuleHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule, ok := loadOwnedRule(db, w, r, log)
+		if !ok {
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Error("failed to start transaction", slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if _, err := tx.Exec(`DELETE FROM recurring_occurrences WHERE rule_id = ?`, rule.ID); err != nil {
+			tx.Rollback()
+			log.Error("failed to delete recurring occurrences", slog.Int("ruleID", rule.ID), slog.Any("error", err))
+			http.Error(w, "Failed to delete recurring rule", http.StatusInternalServerError)
+			return
+		}
+		if _, err := tx.Exec(`DELETE FROM recurring_rules WHERE id = ?`, rule.ID); err != nil {
+			tx.Rollback()
+			log.Error("failed to delete recurring rule", slog.Int("ruleID", rule.ID), slog.Any("error", err))
+			http.Error(w, "Failed to delete recurring rule", http.StatusInternalServerError)
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Error("failed to commit transaction", slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("recurring rule deleted successfully", slog.Int("ruleID", rule.ID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Recurring rule deleted successfully"}`))
+	}
+}
+
+// GetUpcomingHandler previews future occurrences
+// @Summary Upcoming Recurring Transactions
+// @Description Lists occurrences of all rules (or one rule) that have not been recorded yet, from today up to `to`.
+// @Tags Recurring
+// @Produce json
+// @Param to query string false "Last date to include (YYYY-MM-DD), defaults to 30 days ahead, at most a year"
+// @Param rule_id query int false "Only this rule"
+// @Security BearerAuth
+// @Success 200 {array} recurring.Occurrence "Upcoming occurrences"
+// @Failure 400 {string} string "Invalid parameters"
+// @Failure 500 {string} string "Failed to preview recurring transactions"
+// @Router /api/recurring/upcoming [get]
+func GetUpcomingHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		from := today(time.Now())
+		to := from.AddDate(0, 0, defaultPreviewDays)
+		if param := r.URL.Query().Get("to"); param != "" {
+			parsed, err := time.Parse(dateLayout, param)
+			if err != nil {
+				log.Error("invalid to date format", slog.Any("error", err))
+				http.Error(w, "Invalid to date format (YYYY-MM-DD)", http.StatusBadRequest)
+				return
+			}
+			if parsed.After(from.AddDate(0, 0, maxPreviewDays)) {
+				http.Error(w, "to must be at most a year ahead", http.StatusBadRequest)
+				return
+			}
+			to = parsed
+		}
+
+		rules, err := listRules(db, userUID)
+		if err != nil {
+			log.Error("failed to fetch recurring rules", slog.Any("error", err))
+			http.Error(w, "Failed to preview recurring transactions", http.StatusInternalServerError)
+			return
+		}
+
+		ruleID := r.URL.Query().Get("rule_id")
+		occurrences := []Occurrence{}
+		for _, rule := range rules {
+			if ruleID != "" && ruleID != strconv.Itoa(rule.ID) {
+				continue
+			}
+
+			ruleFrom := from
+			if rule.MaterializedThrough != "" {
+				last, err := time.Parse(dateLayout, rule.MaterializedThrough)
+				if err == nil && !last.Before(ruleFrom) {
+					ruleFrom = last.AddDate(0, 0, 1)
+				}
+			}
+
+			for _, date := range rule.Occurrences(ruleFrom, to) {
+				occurrences = append(occurrences, Occurrence{
+					RuleID:      rule.ID,
+					Date:        date.Format(dateLayout),
+					Type:        rule.Type,
+					Category:    rule.Category,
+					Amount:      rule.Amount,
+					Description: rule.Description,
+				})
+			}
+		}
+
+		sort.SliceStable(occurrences, func(i, j int) bool {
+			return occurrences[i].Date < occurrences[j].Date
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(occurrences)
+	}
+}
+
+// rule builds a rule from a validated request.
+func (req *RuleRequest) rule() Rule {
+	return Rule{
+		Type:        req.Type,
+		Category:    req.Category,
+		Amount:      req.Amount,
+		Description: req.Description,
+		Freq:        req.Freq,
+		Interval:    req.Interval,
+		ByMonthDay:  req.ByMonthDay,
+		ByWeekday:   req.ByWeekday,
+		StartDate:   req.StartDate,
+		Until:       req.Until,
+		Count:       req.Count,
+	}
+}
+
+// loadOwnedRule fetches the rule from the {id} URL parameter and checks that the caller owns it.
+// It writes the error response itself and reports whether the handler may continue.
+func loadOwnedRule(db *sql.DB, w http.ResponseWriter, r *http.Request, log *slog.Logger) (Rule, bool) {
+	ruleID := chi.URLParam(r, "id")
+	userUID := r.Context().Value("userUID").(string)
+
+	id, err := strconv.ParseInt(ruleID, 10, 64)
+	if err != nil {
+		log.Warn("invalid recurring rule ID parameter", slog.String("ruleID", ruleID))
+		http.Error(w, "Invalid recurring rule ID", http.StatusBadRequest)
+		return Rule{}, false
+	}
+
+	var rule Rule
+	var ownerUID string
+	err = scanRule(db.QueryRow(`SELECT `+ruleColumns+`, user_uid FROM recurring_rules WHERE id = ?`, id), &rule, &ownerUID)
+	if err == sql.ErrNoRows {
+		log.Warn("recurring rule not found", slog.String("ruleID", ruleID))
+		http.Error(w, "Recurring rule not found", http.StatusNotFound)
+		return Rule{}, false
+	} else if err != nil {
+		log.Error("failed to fetch recurring rule", slog.Any("error", err))
+		http.Error(w, "Failed to fetch recurring rule", http.StatusInternalServerError)
+		return Rule{}, false
+	}
+
+	if ownerUID != userUID {
+		log.Warn("unauthorized attempt to access recurring rule", slog.String("userUID", userUID), slog.String("ownerUID", ownerUID))
+		http.Error(w, "Unauthorized to access this recurring rule", http.StatusForbidden)
+		return Rule{}, false
+	}
+
+	return rule, true
+}
+
+func listRules(db *sql.DB, userUID string) ([]Rule, error) {
+	rows, err := db.Query(`SELECT `+ruleColumns+`, user_uid FROM recurring_rules WHERE user_uid = ? ORDER BY id`, userUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []Rule{}
+	for rows.Next() {
+		var rule Rule
+		var ownerUID string
+		if err := scanRule(rows, &rule, &ownerUID); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanRule reads the ruleColumns followed by user_uid.
+func scanRule(row scanner, rule *Rule, ownerUID *string) error {
+	var description, until, materializedThrough sql.NullString
+	err := row.Scan(&rule.ID, &rule.Type, &rule.Category, &rule.Amount.Amount, &rule.Amount.Currency, &description,
+		&rule.Freq, &rule.Interval, &rule.ByMonthDay, &rule.ByWeekday, &rule.StartDate, &until, &rule.Count,
+		&materializedThrough, ownerUID)
+	rule.Description = description.String
+	rule.Until = until.String
+	rule.MaterializedThrough = materializedThrough.String
+	return err
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
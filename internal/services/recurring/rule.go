@@ -0,0 +1,196 @@
+// Package recurring stores repeating incomes and expenses and turns their due
+// occurrences into ordinary income and expense records.
+package recurring
+
+import (
+	"fmt"
+	"tbank-go/internal/money"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Transaction types a rule can produce.
+const (
+	TypeIncome  = "income"
+	TypeExpense = "expense"
+)
+
+// Repetition frequencies.
+const (
+	FreqDaily   = "daily"
+	FreqWeekly  = "weekly"
+	FreqMonthly = "monthly"
+)
+
+// LastDayOfMonth as by_month_day schedules a monthly rule on the last day of every month.
+const LastDayOfMonth = -1
+
+// maxBackfill bounds how far in the past a rule may start. Every occurrence from the start date
+// up to today is recorded at once, so an old start date of a daily rule would create thousands
+// of transactions.
+const maxBackfill = 366 * 24 * time.Hour
+
+// Rule describes a repeating income or expense.
+type Rule struct {
+	ID          int         `json:"id"`
+	Type        string      `json:"type"`
+	Category    string      `json:"category"`
+	Amount      money.Money `json:"amount"`
+	Description string      `json:"description"`
+	Freq        string      `json:"freq"`
+	Interval    int         `json:"interval"`
+	ByMonthDay  int         `json:"by_month_day,omitempty"`
+	ByWeekday   int         `json:"by_weekday,omitempty"`
+	StartDate   string      `json:"start_date"`
+	Until       string      `json:"until,omitempty"`
+	Count       int         `json:"count,omitempty"`
+	// MaterializedThrough is the last day for which occurrences have been recorded.
+	MaterializedThrough string `json:"materialized_through,omitempty"`
+}
+
+// RuleRequest is the body of the create and update endpoints.
+type RuleRequest struct {
+	Type        string      `json:"type" example:"income"`
+	Category    string      `json:"category" example:"Salary"`
+	Amount      money.Money `json:"amount" swaggertype:"string" example:"85000"`
+	Description string      `json:"description,omitempty" example:"Monthly salary"`
+	Freq        string      `json:"freq" example:"monthly"`
+	Interval    int         `json:"interval,omitempty" example:"1"`
+	ByMonthDay  int         `json:"by_month_day,omitempty" example:"10"`       // monthly: 1..31, -1 for the last day; defaults to the start day
+	ByWeekday   int         `json:"by_weekday,omitempty" example:"1"`          // weekly: 1 (Monday)..7 (Sunday); defaults to the start weekday
+	StartDate   string      `json:"start_date,omitempty" example:"2024-01-10"` // defaults to today
+	Until       string      `json:"until,omitempty" example:"2024-12-31"`
+	Count       int         `json:"count,omitempty" example:"12"`
+}
+
+// validate normalizes the request and returns a user-facing message when it is invalid.
+// currentStart is the start date of the rule being replaced, it is kept even when it is older
+// than maxBackfill; it is empty for a new rule.
+func (req *RuleRequest) validate(currency, currentStart string) string {
+	if req.Type != TypeIncome && req.Type != TypeExpense {
+		return "type must be income or expense"
+	}
+	if req.Category == "" {
+		return "category is required"
+	}
+
+	amount, err := req.Amount.OrDefault(currency)
+	if err != nil || amount.Currency != currency || !amount.IsPositive() {
+		return fmt.Sprintf("amount must be a positive %s value", currency)
+	}
+	req.Amount = amount
+
+	switch req.Freq {
+	case FreqDaily, FreqWeekly, FreqMonthly:
+	default:
+		return "freq must be daily, weekly or monthly"
+	}
+	if req.Interval == 0 {
+		req.Interval = 1
+	}
+	if req.Interval < 1 {
+		return "interval must be positive"
+	}
+
+	if req.ByMonthDay != 0 && (req.Freq != FreqMonthly || req.ByMonthDay < LastDayOfMonth || req.ByMonthDay > 31) {
+		return "by_month_day must be 1..31 or -1 and is only allowed for monthly rules"
+	}
+	if req.ByWeekday != 0 && (req.Freq != FreqWeekly || req.ByWeekday < 1 || req.ByWeekday > 7) {
+		return "by_weekday must be 1 (Monday)..7 (Sunday) and is only allowed for weekly rules"
+	}
+
+	if req.StartDate == "" {
+		req.StartDate = time.Now().Format(dateLayout)
+	}
+	if _, err := time.Parse(dateLayout, req.StartDate); err != nil {
+		return "Invalid start_date format (YYYY-MM-DD)"
+	}
+	earliest := today(time.Now()).Add(-maxBackfill).Format(dateLayout)
+	if req.StartDate < earliest && req.StartDate != currentStart {
+		return fmt.Sprintf("start_date must not be before %s", earliest)
+	}
+	if req.Until != "" {
+		if _, err := time.Parse(dateLayout, req.Until); err != nil {
+			return "Invalid until format (YYYY-MM-DD)"
+		}
+		if req.Until < req.StartDate {
+			return "until must not be before start_date"
+		}
+	}
+	if req.Count < 0 {
+		return "count must not be negative"
+	}
+
+	return ""
+}
+
+// Occurrences returns the dates in [from, to] on which the rule fires. Count and Until are
+// applied from the start date, so a window in the middle of the series stays correct.
+func (rule Rule) Occurrences(from, to time.Time) []time.Time {
+	start, err := time.Parse(dateLayout, rule.StartDate)
+	if err != nil {
+		return nil
+	}
+	if rule.Until != "" {
+		until, err := time.Parse(dateLayout, rule.Until)
+		if err == nil && until.Before(to) {
+			to = until
+		}
+	}
+
+	var dates []time.Time
+	emitted := 0
+	for n := 0; ; n++ {
+		date := rule.nth(start, n)
+		if date.After(to) {
+			break
+		}
+		if date.Before(start) {
+			continue
+		}
+		if rule.Count > 0 && emitted >= rule.Count {
+			break
+		}
+		emitted++
+		if !date.Before(from) {
+			dates = append(dates, date)
+		}
+	}
+
+	return dates
+}
+
+// nth returns the n-th candidate date of the series. Candidates before start are
+// possible for monthly and weekly rules and are skipped by the caller.
+func (rule Rule) nth(start time.Time, n int) time.Time {
+	interval := max(rule.Interval, 1)
+
+	switch rule.Freq {
+	case FreqWeekly:
+		first := start
+		if rule.ByWeekday != 0 {
+			weekday := time.Weekday(rule.ByWeekday % 7)
+			first = start.AddDate(0, 0, (int(weekday)-int(start.Weekday())+7)%7)
+		}
+		return first.AddDate(0, 0, 7*interval*n)
+	case FreqMonthly:
+		monthStart := time.Date(start.Year(), start.Month()+time.Month(interval*n), 1, 0, 0, 0, 0, time.UTC)
+		lastDay := monthStart.AddDate(0, 1, -1).Day()
+		day := rule.ByMonthDay
+		if day == 0 {
+			day = start.Day()
+		}
+		if day == LastDayOfMonth || day > lastDay {
+			day = lastDay
+		}
+		return monthStart.AddDate(0, 0, day-1)
+	default:
+		return start.AddDate(0, 0, interval*n)
+	}
+}
+
+// today returns the current local date as midnight UTC, the form all schedule math uses.
+func today(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,107 @@
+package recurring
+
+import (
+	"strings"
+	"tbank-go/internal/money"
+	"testing"
+	"time"
+)
+
+func TestValidateStartDateBound(t *testing.T) {
+	now := today(time.Now())
+	recent := now.AddDate(0, -11, 0).Format(dateLayout)
+	old := now.AddDate(-2, 0, 0).Format(dateLayout)
+
+	tests := []struct {
+		name         string
+		startDate    string
+		currentStart string
+		wantErr      bool
+	}{
+		{name: "today", startDate: now.Format(dateLayout)},
+		{name: "eleven months ago", startDate: recent},
+		{name: "two years ago", startDate: old, wantErr: true},
+		{name: "far past", startDate: "1900-01-01", wantErr: true},
+		{name: "kept start of an old rule", startDate: old, currentStart: old},
+		{name: "moved further back", startDate: "1900-01-01", currentStart: old, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := RuleRequest{
+				Type:      TypeExpense,
+				Category:  "Food",
+				Amount:    money.New(10000, "RUB"),
+				Freq:      FreqDaily,
+				StartDate: tt.startDate,
+			}
+			msg := req.validate("RUB", tt.currentStart)
+			if tt.wantErr && !strings.Contains(msg, "start_date must not be before") {
+				t.Errorf("validate = %q, want a start_date error", msg)
+			}
+			if !tt.wantErr && msg != "" {
+				t.Errorf("validate = %q, want no error", msg)
+			}
+		})
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     Rule
+		from, to string
+		want     []string
+	}{
+		{name: "daily", rule: Rule{Freq: FreqDaily, StartDate: "2024-01-01"}, from: "2024-01-01", to: "2024-01-03",
+			want: []string{"2024-01-01", "2024-01-02", "2024-01-03"}},
+		{name: "every third day", rule: Rule{Freq: FreqDaily, Interval: 3, StartDate: "2024-01-01"}, from: "2024-01-01", to: "2024-01-10",
+			want: []string{"2024-01-01", "2024-01-04", "2024-01-07", "2024-01-10"}},
+		{name: "weekly on the start weekday", rule: Rule{Freq: FreqWeekly, StartDate: "2024-01-03"}, from: "2024-01-01", to: "2024-01-20",
+			want: []string{"2024-01-03", "2024-01-10", "2024-01-17"}},
+		{name: "every other Monday", rule: Rule{Freq: FreqWeekly, Interval: 2, ByWeekday: 1, StartDate: "2024-01-03"}, from: "2024-01-01", to: "2024-02-10",
+			want: []string{"2024-01-08", "2024-01-22", "2024-02-05"}},
+		{name: "Sundays", rule: Rule{Freq: FreqWeekly, ByWeekday: 7, StartDate: "2024-01-01"}, from: "2024-01-01", to: "2024-01-14",
+			want: []string{"2024-01-07", "2024-01-14"}},
+		{name: "the 31st in short months and a leap February", rule: Rule{Freq: FreqMonthly, StartDate: "2024-01-31"}, from: "2024-01-01", to: "2024-04-30",
+			want: []string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30"}},
+		{name: "the 30th in a non-leap February", rule: Rule{Freq: FreqMonthly, ByMonthDay: 30, StartDate: "2023-01-01"}, from: "2023-01-01", to: "2023-03-31",
+			want: []string{"2023-01-30", "2023-02-28", "2023-03-30"}},
+		{name: "last day of month", rule: Rule{Freq: FreqMonthly, ByMonthDay: LastDayOfMonth, StartDate: "2023-01-15"}, from: "2023-01-01", to: "2023-04-30",
+			want: []string{"2023-01-31", "2023-02-28", "2023-03-31", "2023-04-30"}},
+		{name: "month day before the start day", rule: Rule{Freq: FreqMonthly, ByMonthDay: 10, StartDate: "2024-01-15"}, from: "2024-01-01", to: "2024-03-31",
+			want: []string{"2024-02-10", "2024-03-10"}},
+		{name: "every other month from the 31st", rule: Rule{Freq: FreqMonthly, Interval: 2, StartDate: "2024-01-31"}, from: "2024-01-01", to: "2024-12-31",
+			want: []string{"2024-01-31", "2024-03-31", "2024-05-31", "2024-07-31", "2024-09-30", "2024-11-30"}},
+		{name: "quarterly across a year", rule: Rule{Freq: FreqMonthly, Interval: 3, StartDate: "2023-11-30"}, from: "2023-11-01", to: "2024-06-30",
+			want: []string{"2023-11-30", "2024-02-29", "2024-05-30"}},
+		{name: "count", rule: Rule{Freq: FreqDaily, Count: 3, StartDate: "2024-01-01"}, from: "2024-01-01", to: "2024-01-31",
+			want: []string{"2024-01-01", "2024-01-02", "2024-01-03"}},
+		{name: "count from the start date, not from the window", rule: Rule{Freq: FreqDaily, Count: 5, StartDate: "2024-01-01"}, from: "2024-01-04", to: "2024-01-31",
+			want: []string{"2024-01-04", "2024-01-05"}},
+		{name: "count skips candidates before the start", rule: Rule{Freq: FreqMonthly, ByMonthDay: 10, Count: 2, StartDate: "2024-01-15"}, from: "2024-01-01", to: "2024-12-31",
+			want: []string{"2024-02-10", "2024-03-10"}},
+		{name: "until is inclusive", rule: Rule{Freq: FreqWeekly, StartDate: "2024-01-01", Until: "2024-01-15"}, from: "2024-01-01", to: "2024-01-31",
+			want: []string{"2024-01-01", "2024-01-08", "2024-01-15"}},
+		{name: "until after the window", rule: Rule{Freq: FreqMonthly, StartDate: "2024-01-05", Until: "2024-12-31"}, from: "2024-02-01", to: "2024-03-31",
+			want: []string{"2024-02-05", "2024-03-05"}},
+		{name: "count and until, whichever comes first", rule: Rule{Freq: FreqDaily, Count: 10, StartDate: "2024-01-01", Until: "2024-01-02"}, from: "2024-01-01", to: "2024-01-31",
+			want: []string{"2024-01-01", "2024-01-02"}},
+		{name: "window before the start", rule: Rule{Freq: FreqDaily, StartDate: "2024-01-01"}, from: "2023-12-01", to: "2023-12-31"},
+		{name: "invalid start date", rule: Rule{Freq: FreqDaily, StartDate: "01.01.2024"}, from: "2024-01-01", to: "2024-01-31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, _ := time.Parse(dateLayout, tt.from)
+			to, _ := time.Parse(dateLayout, tt.to)
+			var got []string
+			for _, date := range tt.rule.Occurrences(from, to) {
+				got = append(got, date.Format(dateLayout))
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("Occurrences = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package recurring
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/repository/sqlstore"
+	"tbank-go/internal/user-service"
+	"time"
+)
+
+// Scheduler periodically records due occurrences of every rule as incomes and expenses.
+type Scheduler struct {
+	db       *sql.DB
+	incomes  *sqlstore.IncomeRepository
+	expenses *sqlstore.ExpenseRepository
+	log      *slog.Logger
+	interval time.Duration
+}
+
+// NewScheduler creates a scheduler that wakes up every interval.
+func NewScheduler(db *sql.DB, incomes *sqlstore.IncomeRepository, expenses *sqlstore.ExpenseRepository, log *slog.Logger, interval time.Duration) *Scheduler {
+	return &Scheduler{db: db, incomes: incomes, expenses: expenses, log: log, interval: interval}
+}
+
+// Run materializes due occurrences right away and then on every tick until ctx is cancelled.
+// Occurrences missed while the server was down are caught up on the first run.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.log.Info("recurring scheduler started", slog.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.MaterializeDue(ctx, time.Now()); err != nil {
+			s.log.Error("failed to materialize recurring transactions", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			s.log.Info("recurring scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// MaterializeDue records every occurrence up to and including the day of now.
+// A failing rule is logged and skipped so it does not block the others.
+func (s *Scheduler) MaterializeDue(ctx context.Context, now time.Time) error {
+	query := `SELECT ` + ruleColumns + `, user_uid FROM recurring_rules ORDER BY id`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	type ownedRule struct {
+		rule     Rule
+		ownerUID string
+	}
+	var rules []ownedRule
+	for rows.Next() {
+		var owned ownedRule
+		if err := scanRule(rows, &owned.rule, &owned.ownerUID); err != nil {
+			rows.Close()
+			return err
+		}
+		rules = append(rules, owned)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	total := 0
+	for _, owned := range rules {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		created, err := materializeRule(ctx, s.db, s.incomes, s.expenses, owned.ownerUID, owned.rule, today(now))
+		if err != nil {
+			s.log.Error("failed to materialize recurring rule", slog.Int("ruleID", owned.rule.ID), slog.Any("error", err))
+			continue
+		}
+		total += created
+	}
+
+	if total > 0 {
+		s.log.Info("recurring transactions materialized", slog.Int("count", total))
+	}
+	return nil
+}
+
+// materializeRule records the rule's occurrences between its last materialized day and through
+// in one transaction and returns how many transactions were created. Every occurrence is first
+// claimed in recurring_occurrences, whose primary key makes a repeated run a no-op. The
+// transactions are stored through the income and expense repositories, which keep the balances.
+func materializeRule(ctx context.Context, db *sql.DB, incomes *sqlstore.IncomeRepository, expenses *sqlstore.ExpenseRepository,
+	userUID string, rule Rule, through time.Time) (int, error) {
+	from, err := time.Parse(dateLayout, rule.StartDate)
+	if err != nil {
+		return 0, err
+	}
+	if rule.MaterializedThrough != "" {
+		last, err := time.Parse(dateLayout, rule.MaterializedThrough)
+		if err != nil {
+			return 0, err
+		}
+		from = last.AddDate(0, 0, 1)
+	}
+	if from.After(through) {
+		return 0, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	// Occurrences go to the default account; a rule in another currency waits until it matches again.
+	accountID, accountCurrency, err := user_service.GetDefaultAccount(tx, userUID)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("default account: %w", err)
+	}
+	if accountCurrency != rule.Amount.Currency {
+		tx.Rollback()
+		return 0, fmt.Errorf("default account is in %s, rule is in %s", accountCurrency, rule.Amount.Currency)
+	}
+
+	create := incomes.CreateTx
+	if rule.Type == TypeExpense {
+		create = expenses.CreateTx
+	}
+
+	categoryID, categoryName, err := user_service.EnsureCategory(tx, userUID, rule.Type, rule.Category)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("category: %w", err)
+	}
+
+	created := 0
+	for _, date := range rule.Occurrences(from, through) {
+		day := date.Format(dateLayout)
+		now := time.Now().UTC().Format(time.RFC3339)
+
+		res, err := tx.ExecContext(ctx, `INSERT INTO recurring_occurrences (rule_id, occurrence_date, transaction_id, created_at)
+		                     VALUES (?, ?, 0, ?) ON CONFLICT DO NOTHING`, rule.ID, day, now)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		if claimed, err := res.RowsAffected(); err != nil || claimed == 0 {
+			continue
+		}
+
+		transaction := repository.Transaction{UserUID: userUID, AccountID: accountID, CategoryID: categoryID, Category: categoryName,
+			Amount: rule.Amount, Date: day, Description: rule.Description}
+		if err := create(ctx, tx, &transaction); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("insert occurrence %s: %w", day, err)
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE recurring_occurrences SET transaction_id = ? WHERE rule_id = ? AND occurrence_date = ?`,
+			transaction.ID, rule.ID, day)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		created++
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE recurring_rules SET materialized_through = ? WHERE id = ?`, through.Format(dateLayout), rule.ID)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return created, nil
+}
@@ -0,0 +1,86 @@
+package recurring
+
+import (
+	"context"
+	"database/sql"
+	"tbank-go/internal/repository/sqlstore"
+	"tbank-go/internal/services/servicetest"
+	"tbank-go/internal/storage/storagetest"
+	"testing"
+	"time"
+)
+
+func TestMaterializeDue(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		ctx := context.Background()
+		servicetest.CreateUser(t, repos, "alice")
+		scheduler := NewScheduler(db, sqlstore.NewIncomeRepository(db), sqlstore.NewExpenseRepository(db), servicetest.Discard, time.Hour)
+
+		insertRule := func(ruleType, category string, amount int64, freq string, byMonthDay int, startDate string) int64 {
+			t.Helper()
+			var id int64
+			err := db.QueryRow(`INSERT INTO recurring_rules (user_uid, type, category, amount, currency, description, freq, freq_interval,
+			                    by_month_day, by_weekday, start_date, until_date, max_count, created_at)
+			                    VALUES (?, ?, ?, ?, 'RUB', '', ?, 1, ?, 0, ?, NULL, 0, ?) RETURNING id`,
+				"alice", ruleType, category, amount, freq, byMonthDay, startDate, time.Now().UTC().Format(time.RFC3339)).Scan(&id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return id
+		}
+		insertRule(TypeIncome, "Salary", 8500000, FreqMonthly, LastDayOfMonth, "2024-01-01")
+		insertRule(TypeExpense, "Transport", 5000, FreqWeekly, 0, "2024-03-04")
+
+		count := func(table string) int {
+			t.Helper()
+			var n int
+			if err := db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE user_uid = ?`, "alice").Scan(&n); err != nil {
+				t.Fatal(err)
+			}
+			return n
+		}
+		assertState := func(step string) {
+			t.Helper()
+			// Salary on Jan 31, Feb 29 and Mar 31; transport on Mar 4, 11, 18 and 25.
+			if incomes, expenses := count("income"), count("expenses"); incomes != 3 || expenses != 4 {
+				t.Errorf("%s: %d incomes and %d expenses, want 3 and 4", step, incomes, expenses)
+			}
+			var occurrences int
+			if err := db.QueryRow(`SELECT COUNT(*) FROM recurring_occurrences WHERE transaction_id > 0`).Scan(&occurrences); err != nil {
+				t.Fatal(err)
+			}
+			if occurrences != 7 {
+				t.Errorf("%s: %d recorded occurrences, want 7", step, occurrences)
+			}
+			user, err := repos.Users.GetByUID(ctx, "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			account := servicetest.DefaultAccount(t, repos, "alice")
+			if user.IncomesBalance != 3*8500000 || user.ExpensesBalance != 4*5000 || account.Balance.Amount != 3*8500000-4*5000 {
+				t.Errorf("%s: incomes %d, expenses %d, account %d", step, user.IncomesBalance, user.ExpensesBalance, account.Balance.Amount)
+			}
+		}
+
+		now := time.Date(2024, time.March, 31, 9, 30, 0, 0, time.UTC)
+		if err := scheduler.MaterializeDue(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+		assertState("first run")
+
+		if err := scheduler.MaterializeDue(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+		assertState("second run")
+
+		// A run that starts over from the start date does not record the claimed occurrences again.
+		if _, err := db.Exec(`UPDATE recurring_rules SET materialized_through = NULL`); err != nil {
+			t.Fatal(err)
+		}
+		if err := scheduler.MaterializeDue(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+		assertState("run from the start date")
+	})
+}
@@ -0,0 +1,359 @@
+package reports
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"tbank-go/internal/money"
+	"tbank-go/internal/rates"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/user-service"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// CategoryTotal is the converted sum of one category.
+type CategoryTotal struct {
+	Type     string      `json:"type"` // income or expense
+	Category string      `json:"category"`
+	Amount   money.Money `json:"amount"`
+	Count    int         `json:"count"`
+}
+
+// CurrencyTotal is the unconverted sum of the transactions in one currency.
+type CurrencyTotal struct {
+	Currency string      `json:"currency"`
+	Incomes  money.Money `json:"incomes"`
+	Expenses money.Money `json:"expenses"`
+	// Unconverted counts transactions left out of the converted totals because no rate was
+	// known for their date.
+	Unconverted int `json:"unconverted"`
+}
+
+// Summary is the response of the summary report.
+type Summary struct {
+	From       string          `json:"from"`
+	To         string          `json:"to"`
+	Currency   string          `json:"currency"`
+	Incomes    money.Money     `json:"incomes"`
+	Expenses   money.Money     `json:"expenses"`
+	Net        money.Money     `json:"net"`
+	Categories []CategoryTotal `json:"categories"`
+	Currencies []CurrencyTotal `json:"currencies"`
+}
+
+// AccountBalance is an account balance with its converted value.
+type AccountBalance struct {
+	ID        int64        `json:"id"`
+	UserUID   string       `json:"user_uid,omitempty"` // the owner, in a household's report
+	Name      string       `json:"name"`
+	Balance   money.Money  `json:"balance"`
+	Converted *money.Money `json:"converted"` // null when no rate is known
+}
+
+// BalanceReport is the response of the balance report.
+type BalanceReport struct {
+	Date     string           `json:"date"`
+	Currency string           `json:"currency"`
+	Total    money.Money      `json:"total"`
+	Accounts []AccountBalance `json:"accounts"`
+}
+
+// targetCurrency returns the currency query parameter or, without one, the user's base currency.
+func targetCurrency(db *sql.DB, r *http.Request, userUID string) (string, bool, error) {
+	if currency := r.URL.Query().Get("currency"); currency != "" {
+		return currency, money.ValidCurrency(currency), nil
+	}
+	currency, err := user_service.GetUserCurrency(db, userUID)
+	return currency, true, err
+}
+
+// owner returns the condition selecting the incomes and expenses a report covers, with its
+// argument: the user's or, with X-Household-ID, those shared with the household by all its members,
+// like the listings of /api/income and /api/expense.
+func owner(r *http.Request, userUID string) (string, any) {
+	if householdID, _ := r.Context().Value("householdID").(int64); householdID != 0 {
+		return "household_id = ?", householdID
+	}
+	return "user_uid = ?", userUID
+}
+
+// GetSummaryHandler reports incomes and expenses converted to one currency
+// @Summary Multi-currency Summary
+// @Description Sums incomes and expenses between two dates in the user's base currency (or `currency`).
+// @Description Every transaction is converted at the exchange rate of its own date; the latest rate published on or before that date is used.
+// @Description Transactions without a known rate are left out of the converted totals and counted per currency.
+// @Description With X-Household-ID the incomes and expenses shared with the household by all its members are summed instead.
+// @Tags Reports
+// @Produce json
+// @Param X-Household-ID header int false "Household to summarize the shared incomes and expenses of"
+// @Param from query string true "Start date (YYYY-MM-DD)"
+// @Param to query string true "End date (YYYY-MM-DD)"
+// @Param currency query string false "Report currency, defaults to the user's base currency"
+// @Security BearerAuth
+// @Success 200 {object} reports.Summary "Summary"
+// @Failure 400 {string} string "Invalid date format or missing parameters"
+// @Failure 500 {string} string "Failed to build report"
+// @Router /api/reports/summary [get]
+func GetSummaryHandler(db *sql.DB, store *rates.Store, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from := r.URL.Query().Get("from")
+		to := r.URL.Query().Get("to")
+		if from == "" || to == "" {
+			http.Error(w, "Both from and to are required", http.StatusBadRequest)
+			return
+		}
+		if _, err := time.Parse(dateLayout, from); err != nil {
+			http.Error(w, "Invalid from format (YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		if _, err := time.Parse(dateLayout, to); err != nil {
+			http.Error(w, "Invalid to format (YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+
+		userUID := r.Context().Value("userUID").(string)
+
+		currency, ok, err := targetCurrency(db, r, userUID)
+		if err != nil {
+			log.Error("failed to fetch user currency", slog.Any("error", err))
+			http.Error(w, "Failed to build report", http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(w, "Invalid currency", http.StatusBadRequest)
+			return
+		}
+
+		condition, arg := owner(r, userUID)
+		summary, err := buildSummary(r.Context(), db, rates.NewConverter(store), condition, arg, from, to, currency)
+		if err != nil {
+			log.Error("failed to build summary report", slog.Any("error", err))
+			http.Error(w, "Failed to build report", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(summary)
+	}
+}
+
+// buildSummary converts the daily totals per type, category and currency, so a rate is looked
+// up once per currency and day rather than once per transaction. condition and arg select the
+// owner's rows, see owner.
+func buildSummary(ctx context.Context, db *sql.DB, converter *rates.Converter, condition string, arg any, from, to, currency string) (Summary, error) {
+	summary := Summary{
+		From:       from,
+		To:         to,
+		Currency:   currency,
+		Incomes:    money.New(0, currency),
+		Expenses:   money.New(0, currency),
+		Categories: []CategoryTotal{},
+		Currencies: []CurrencyTotal{},
+	}
+
+	query := `
+		SELECT 'income', category, currency, date, SUM(amount), COUNT(*) FROM income
+		WHERE ` + condition + ` AND date BETWEEN ? AND ? GROUP BY category, currency, date
+		UNION ALL
+		SELECT 'expense', category, currency, date, SUM(amount), COUNT(*) FROM expenses
+		WHERE ` + condition + ` AND date BETWEEN ? AND ? GROUP BY category, currency, date`
+	rows, err := db.QueryContext(ctx, query, arg, from, to, arg, from, to)
+	if err != nil {
+		return summary, err
+	}
+	defer rows.Close()
+
+	type group struct {
+		kind, category, date string
+		amount               money.Money
+		count                int
+	}
+	var groups []group
+	for rows.Next() {
+		var g group
+		if err := rows.Scan(&g.kind, &g.category, &g.amount.Currency, &g.date, &g.amount.Amount, &g.count); err != nil {
+			return summary, err
+		}
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return summary, err
+	}
+	rows.Close()
+
+	categories := make(map[[2]string]*CategoryTotal)
+	currencies := make(map[string]*CurrencyTotal)
+	for _, g := range groups {
+		byCurrency, ok := currencies[g.amount.Currency]
+		if !ok {
+			byCurrency = &CurrencyTotal{
+				Currency: g.amount.Currency,
+				Incomes:  money.New(0, g.amount.Currency),
+				Expenses: money.New(0, g.amount.Currency),
+			}
+			currencies[g.amount.Currency] = byCurrency
+		}
+		if g.kind == "income" {
+			byCurrency.Incomes.Amount += g.amount.Amount
+		} else {
+			byCurrency.Expenses.Amount += g.amount.Amount
+		}
+
+		converted, err := converter.Convert(ctx, g.amount, currency, g.date)
+		if errors.Is(err, rates.ErrNoRate) {
+			byCurrency.Unconverted += g.count
+			continue
+		} else if err != nil {
+			return summary, err
+		}
+
+		if g.kind == "income" {
+			summary.Incomes.Amount += converted.Amount
+		} else {
+			summary.Expenses.Amount += converted.Amount
+		}
+
+		key := [2]string{g.kind, g.category}
+		byCategory, ok := categories[key]
+		if !ok {
+			byCategory = &CategoryTotal{Type: g.kind, Category: g.category, Amount: money.New(0, currency)}
+			categories[key] = byCategory
+		}
+		byCategory.Amount.Amount += converted.Amount
+		byCategory.Count += g.count
+	}
+	summary.Net = money.New(summary.Incomes.Amount-summary.Expenses.Amount, currency)
+
+	for _, c := range categories {
+		summary.Categories = append(summary.Categories, *c)
+	}
+	sort.Slice(summary.Categories, func(i, j int) bool {
+		a, b := summary.Categories[i], summary.Categories[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Amount.Amount != b.Amount.Amount {
+			return a.Amount.Amount > b.Amount.Amount
+		}
+		return a.Category < b.Category
+	})
+
+	for _, c := range currencies {
+		summary.Currencies = append(summary.Currencies, *c)
+	}
+	sort.Slice(summary.Currencies, func(i, j int) bool {
+		return summary.Currencies[i].Currency < summary.Currencies[j].Currency
+	})
+
+	return summary, nil
+}
+
+// GetBalanceHandler reports account balances converted to one currency
+// @Summary Multi-currency Balance
+// @Description Converts every account balance to the user's base currency (or `currency`) at the rate in effect on `date` (today by default) and sums them.
+// @Description Accounts without a known rate have a null converted value and are left out of the total.
+// @Description With X-Household-ID the accounts of all the household's members are reported.
+// @Tags Reports
+// @Produce json
+// @Param X-Household-ID header int false "Household to report the members' accounts of"
+// @Param date query string false "Rate date (YYYY-MM-DD)"
+// @Param currency query string false "Report currency, defaults to the user's base currency"
+// @Security BearerAuth
+// @Success 200 {object} reports.BalanceReport "Balances"
+// @Failure 400 {string} string "Invalid date format"
+// @Failure 500 {string} string "Failed to build report"
+// @Router /api/reports/balance [get]
+func GetBalanceHandler(db *sql.DB, households repository.HouseholdRepository, store *rates.Store, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		date := r.URL.Query().Get("date")
+		if date == "" {
+			date = time.Now().Format(dateLayout)
+		} else if _, err := time.Parse(dateLayout, date); err != nil {
+			http.Error(w, "Invalid date format (YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+
+		userUID := r.Context().Value("userUID").(string)
+
+		currency, ok, err := targetCurrency(db, r, userUID)
+		if err != nil {
+			log.Error("failed to fetch user currency", slog.Any("error", err))
+			http.Error(w, "Failed to build report", http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(w, "Invalid currency", http.StatusBadRequest)
+			return
+		}
+
+		owners := []any{userUID}
+		householdID, _ := r.Context().Value("householdID").(int64)
+		if householdID != 0 {
+			members, err := households.Members(r.Context(), householdID)
+			if err != nil {
+				log.Error("failed to fetch household members", slog.Int64("householdID", householdID), slog.Any("error", err))
+				http.Error(w, "Failed to build report", http.StatusInternalServerError)
+				return
+			}
+			owners = owners[:0]
+			for _, m := range members {
+				owners = append(owners, m.UserUID)
+			}
+		}
+
+		rows, err := db.QueryContext(r.Context(), `SELECT id, user_uid, name, balance, currency FROM accounts
+			WHERE user_uid IN (?`+strings.Repeat(", ?", len(owners)-1)+`) ORDER BY is_default DESC, id`, owners...)
+		if err != nil {
+			log.Error("failed to fetch accounts", slog.Any("error", err))
+			http.Error(w, "Failed to build report", http.StatusInternalServerError)
+			return
+		}
+		defer rows.Close()
+
+		report := BalanceReport{Date: date, Currency: currency, Total: money.New(0, currency), Accounts: []AccountBalance{}}
+		for rows.Next() {
+			var account AccountBalance
+			if err := rows.Scan(&account.ID, &account.UserUID, &account.Name, &account.Balance.Amount, &account.Balance.Currency); err != nil {
+				log.Error("failed to scan account", slog.Any("error", err))
+				http.Error(w, "Failed to build report", http.StatusInternalServerError)
+				return
+			}
+			if householdID == 0 {
+				account.UserUID = ""
+			}
+			report.Accounts = append(report.Accounts, account)
+		}
+		if err := rows.Err(); err != nil {
+			log.Error("failed to fetch accounts", slog.Any("error", err))
+			http.Error(w, "Failed to build report", http.StatusInternalServerError)
+			return
+		}
+		rows.Close()
+
+		converter := rates.NewConverter(store)
+		for i, account := range report.Accounts {
+			converted, err := converter.Convert(r.Context(), account.Balance, currency, date)
+			if errors.Is(err, rates.ErrNoRate) {
+				continue
+			} else if err != nil {
+				log.Error("failed to convert balance", slog.Int64("accountID", account.ID), slog.Any("error", err))
+				http.Error(w, "Failed to build report", http.StatusInternalServerError)
+				return
+			}
+			report.Accounts[i].Converted = &converted
+			report.Total.Amount += converted.Amount
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(report)
+	}
+}
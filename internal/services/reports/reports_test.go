@@ -0,0 +1,186 @@
+package reports
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"tbank-go/internal/money"
+	"tbank-go/internal/rates"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/repository/sqlstore"
+	"tbank-go/internal/services/servicetest"
+	"tbank-go/internal/storage/storagetest"
+	"testing"
+)
+
+func TestGetSummary(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		ctx := context.Background()
+		servicetest.CreateUser(t, repos, "alice")
+		account := servicetest.DefaultAccount(t, repos, "alice")
+
+		store := rates.NewStore(db)
+		if err := store.Save(ctx, []rates.Rate{{Date: "2024-03-01", Currency: "USD", Nominal: 1, Value: 900000}}); err != nil {
+			t.Fatal(err)
+		}
+
+		income := repository.Income{UserUID: "alice", AccountID: account.ID, Category: "Salary", Amount: money.New(5000000, "RUB"), Date: "2024-03-01"}
+		if err := repos.Incomes.Create(ctx, &income); err != nil {
+			t.Fatal(err)
+		}
+		for _, expense := range []repository.Expense{
+			{Category: "Food", Amount: money.New(100000, "RUB"), Date: "2024-03-05"},
+			// Converted at the rate of March 1, the latest one published by March 5.
+			{Category: "Food", Amount: money.New(1000, "USD"), Date: "2024-03-05"},
+			// No USD rate is known yet in February, and none for EUR at all.
+			{Category: "Travel", Amount: money.New(2000, "USD"), Date: "2024-02-15"},
+			{Category: "Travel", Amount: money.New(500, "EUR"), Date: "2024-03-10"},
+			// Outside the period.
+			{Category: "Food", Amount: money.New(700, "RUB"), Date: "2024-04-01"},
+		} {
+			expense.UserUID = "alice"
+			if err := repos.Expenses.Create(ctx, &expense); err != nil {
+				t.Fatal(err)
+			}
+		}
+		servicetest.CreateUser(t, repos, "bob")
+		other := repository.Expense{UserUID: "bob", Category: "Food", Amount: money.New(99900, "RUB"), Date: "2024-03-05"}
+		if err := repos.Expenses.Create(ctx, &other); err != nil {
+			t.Fatal(err)
+		}
+
+		handler := GetSummaryHandler(db, store, servicetest.Discard)
+		summary := func(query string) Summary {
+			t.Helper()
+			w := httptest.NewRecorder()
+			handler(w, servicetest.NewRequest(http.MethodGet, "/api/reports/summary?"+query, "", "alice"))
+			return servicetest.Decode[Summary](t, w, http.StatusOK)
+		}
+
+		got := summary("from=2024-02-01&to=2024-03-31")
+		if got.Currency != "RUB" || got.Incomes != money.New(5000000, "RUB") || got.Expenses != money.New(190000, "RUB") || got.Net != money.New(4810000, "RUB") {
+			t.Errorf("RUB totals = %s, %v, %v, %v", got.Currency, got.Incomes, got.Expenses, got.Net)
+		}
+		wantCategories := []CategoryTotal{
+			{Type: "expense", Category: "Food", Amount: money.New(190000, "RUB"), Count: 2},
+			{Type: "income", Category: "Salary", Amount: money.New(5000000, "RUB"), Count: 1},
+		}
+		if len(got.Categories) != len(wantCategories) {
+			t.Fatalf("RUB categories = %+v", got.Categories)
+		}
+		for i := range wantCategories {
+			if got.Categories[i] != wantCategories[i] {
+				t.Errorf("RUB category %d = %+v, want %+v", i, got.Categories[i], wantCategories[i])
+			}
+		}
+		wantCurrencies := []CurrencyTotal{
+			{Currency: "EUR", Incomes: money.New(0, "EUR"), Expenses: money.New(500, "EUR"), Unconverted: 1},
+			{Currency: "RUB", Incomes: money.New(5000000, "RUB"), Expenses: money.New(100000, "RUB")},
+			{Currency: "USD", Incomes: money.New(0, "USD"), Expenses: money.New(3000, "USD"), Unconverted: 1},
+		}
+		if len(got.Currencies) != len(wantCurrencies) {
+			t.Fatalf("RUB currencies = %+v", got.Currencies)
+		}
+		for i := range wantCurrencies {
+			if got.Currencies[i] != wantCurrencies[i] {
+				t.Errorf("RUB currency %d = %+v, want %+v", i, got.Currencies[i], wantCurrencies[i])
+			}
+		}
+
+		// In USD the February expense needs no rate, while rubles are converted at 90.
+		got = summary("from=2024-02-01&to=2024-03-31&currency=USD")
+		if got.Incomes != money.New(55556, "USD") || got.Expenses != money.New(4111, "USD") || got.Net != money.New(51445, "USD") {
+			t.Errorf("USD totals = %v, %v, %v", got.Incomes, got.Expenses, got.Net)
+		}
+		for _, c := range got.Currencies {
+			if want := map[string]int{"EUR": 1}[c.Currency]; c.Unconverted != want {
+				t.Errorf("USD report: %d unconverted %s transactions, want %d", c.Unconverted, c.Currency, want)
+			}
+		}
+
+		for _, query := range []string{"from=2024-02-01", "from=01.02.2024&to=2024-03-31", "from=2024-02-01&to=2024-03-31&currency=usd"} {
+			w := httptest.NewRecorder()
+			handler(w, servicetest.NewRequest(http.MethodGet, "/api/reports/summary?"+query, "", "alice"))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: status %d, want 400", query, w.Code)
+			}
+		}
+	})
+}
+
+func TestHouseholdReports(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		ctx := context.Background()
+		for _, uid := range []string{"alice", "bob", "carol"} {
+			servicetest.CreateUser(t, repos, uid)
+		}
+		household := servicetest.CreateHousehold(t, repos, "alice", "bob")
+
+		for _, income := range []repository.Income{
+			{UserUID: "bob", Category: "Salary", Amount: money.New(100000, "RUB"), Date: "2024-03-01", HouseholdID: household},
+			{UserUID: "carol", Category: "Salary", Amount: money.New(900000, "RUB"), Date: "2024-03-01"},
+		} {
+			income.AccountID = servicetest.DefaultAccount(t, repos, income.UserUID).ID
+			if err := repos.Incomes.Create(ctx, &income); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, expense := range []repository.Expense{
+			{UserUID: "alice", Category: "Food", Amount: money.New(10000, "RUB"), Date: "2024-03-02", HouseholdID: household},
+			{UserUID: "bob", Category: "Food", Amount: money.New(20000, "RUB"), Date: "2024-03-03", HouseholdID: household},
+			{UserUID: "alice", Category: "Taxi", Amount: money.New(5000, "RUB"), Date: "2024-03-04"},
+		} {
+			expense.AccountID = servicetest.DefaultAccount(t, repos, expense.UserUID).ID
+			if err := repos.Expenses.Create(ctx, &expense); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		store := rates.NewStore(db)
+		request := func(target string, householdID int64) *http.Request {
+			r := servicetest.NewRequest(http.MethodGet, target, "", "alice")
+			if householdID != 0 {
+				r = servicetest.WithHousehold(r, householdID, repository.RoleOwner)
+			}
+			return r
+		}
+
+		tests := []struct {
+			household         int64
+			incomes, expenses int64
+		}{
+			{household: household, incomes: 100000, expenses: 30000},
+			{expenses: 15000},
+		}
+		for _, tt := range tests {
+			w := httptest.NewRecorder()
+			GetSummaryHandler(db, store, servicetest.Discard)(w, request("/api/reports/summary?from=2024-03-01&to=2024-03-31", tt.household))
+			got := servicetest.Decode[Summary](t, w, http.StatusOK)
+			if got.Incomes != money.New(tt.incomes, "RUB") || got.Expenses != money.New(tt.expenses, "RUB") {
+				t.Errorf("household %d: summary %v, %v, want %d and %d", tt.household, got.Incomes, got.Expenses, tt.incomes, tt.expenses)
+			}
+		}
+
+		// The household's balance report lists the accounts of both members, but not Carol's.
+		w := httptest.NewRecorder()
+		GetBalanceHandler(db, repos.Households, store, servicetest.Discard)(w, request("/api/reports/balance?currency=RUB", household))
+		report := servicetest.Decode[BalanceReport](t, w, http.StatusOK)
+		owners := map[string]int64{}
+		for _, account := range report.Accounts {
+			owners[account.UserUID] += account.Balance.Amount
+		}
+		if len(owners) != 2 || owners["alice"] != -15000 || owners["bob"] != 80000 || report.Total != money.New(65000, "RUB") {
+			t.Errorf("household balance = %+v", report)
+		}
+
+		w = httptest.NewRecorder()
+		GetBalanceHandler(db, repos.Households, store, servicetest.Discard)(w, request("/api/reports/balance?currency=RUB", 0))
+		report = servicetest.Decode[BalanceReport](t, w, http.StatusOK)
+		if len(report.Accounts) != 1 || report.Accounts[0].UserUID != "" || report.Total != money.New(-15000, "RUB") {
+			t.Errorf("personal balance = %+v", report)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+// Package servicetest holds the fixtures shared by the handler tests of the services.
+package servicetest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"tbank-go/internal/repository"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Discard is a logger for handlers under test.
+var Discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// NewRequest builds a request of the authenticated user as the router and the auth middleware
+// would. params are pairs of URL parameter names and values, e.g. "id", "42".
+func NewRequest(method, target, body, userUID string, params ...string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	routeCtx := chi.NewRouteContext()
+	for i := 0; i+1 < len(params); i += 2 {
+		routeCtx.URLParams.Add(params[i], params[i+1])
+	}
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx)
+	return r.WithContext(context.WithValue(ctx, "userUID", userUID))
+}
+
+// WithHousehold returns r scoped to the household with the caller's role in it, as the auth
+// middleware does for a request with the X-Household-ID header.
+func WithHousehold(r *http.Request, householdID int64, role string) *http.Request {
+	ctx := context.WithValue(r.Context(), "householdID", householdID)
+	return r.WithContext(context.WithValue(ctx, "householdRole", role))
+}
+
+// ID formats a record ID as a URL parameter.
+func ID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// CreateUser registers a user whose username is its UID. The user gets the default account and
+// categories like one created through the registration endpoint.
+func CreateUser(t *testing.T, repos repository.Repositories, uid string) repository.User {
+	t.Helper()
+	user := repository.User{UID: uid, Username: uid}
+	if err := repos.Users.Create(context.Background(), &user); err != nil {
+		t.Fatalf("create user %s: %v", uid, err)
+	}
+	return user
+}
+
+// DefaultAccount returns the default account of the user.
+func DefaultAccount(t *testing.T, repos repository.Repositories, uid string) repository.Account {
+	t.Helper()
+	account, err := repos.Accounts.GetDefault(context.Background(), uid)
+	if err != nil {
+		t.Fatalf("default account of %s: %v", uid, err)
+	}
+	return account
+}
+
+// CreateHousehold creates a household owned by ownerUID with the other users as editors and
+// returns its ID.
+func CreateHousehold(t *testing.T, repos repository.Repositories, ownerUID string, editorUIDs ...string) int64 {
+	t.Helper()
+	ctx := context.Background()
+	household := repository.Household{Name: "Home"}
+	if err := repos.Households.Create(ctx, &household, ownerUID); err != nil {
+		t.Fatalf("create household: %v", err)
+	}
+	for _, uid := range editorUIDs {
+		invite := repository.HouseholdInvite{HouseholdID: household.ID, InviteeUID: uid, InviterUID: ownerUID, Role: repository.RoleEditor}
+		if err := repos.Households.CreateInvite(ctx, &invite); err != nil {
+			t.Fatalf("invite %s: %v", uid, err)
+		}
+		if err := repos.Households.AcceptInvite(ctx, invite.ID); err != nil {
+			t.Fatalf("accept invite of %s: %v", uid, err)
+		}
+	}
+	return household.ID
+}
+
+// Decode fails the test unless the response has the given status and returns its JSON body.
+func Decode[T any](t *testing.T, w *httptest.ResponseRecorder, status int) T {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status %d %s, want %d", w.Code, strings.TrimSpace(w.Body.String()), status)
+	}
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	return v
+}
@@ -0,0 +1,240 @@
+package splits
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"tbank-go/internal/repository"
+	"unicode/utf8"
+)
+
+// Contact is a person without an account the user shares expenses with.
+type Contact struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+}
+
+func newContact(c repository.Contact) Contact {
+	return Contact{ID: c.ID, Name: c.Name, CreatedAt: c.CreatedAt}
+}
+
+// ContactRequest is the body of the create and rename contact endpoints.
+type ContactRequest struct {
+	Name string `json:"name" example:"Anna"`
+}
+
+// validate normalizes the request and returns a user-facing message when it is invalid.
+func (req *ContactRequest) validate() string {
+	req.Name = strings.Join(strings.Fields(req.Name), " ")
+	if req.Name == "" {
+		return "name is required"
+	}
+	if utf8.RuneCountInString(req.Name) > 64 {
+		return "name must be at most 64 characters"
+	}
+	return ""
+}
+
+// CreateContactHandler creates a contact
+// @Summary Create Contact
+// @Description Creates a named contact to split expenses with people who have no account.
+// @Tags Splits
+// @Accept json
+// @Produce json
+// @Param contact body splits.ContactRequest true "Contact details"
+// @Security BearerAuth
+// @Success 201 {object} splits.Contact "Created contact"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 409 {string} string "Contact already exists"
+// @Failure 500 {string} string "Failed to create contact"
+// @Router /api/contacts [post]
+func CreateContactHandler(contacts repository.ContactRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		var req ContactRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for contact", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+		if msg := req.validate(); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		contact := repository.Contact{UserUID: userUID, Name: req.Name}
+		err := contacts.Create(r.Context(), &contact)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			http.Error(w, "Contact with this name already exists", http.StatusConflict)
+			return
+		} else if err != nil {
+			log.Error("failed to create contact", slog.Any("error", err))
+			http.Error(w, "Failed to create contact", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("contact created successfully", slog.Int64("contactID", contact.ID), slog.String("userUID", userUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(newContact(contact))
+	}
+}
+
+// GetContactsHandler lists the user's contacts
+// @Summary List Contacts
+// @Description Returns the contacts of the authenticated user sorted by name.
+// @Tags Splits
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} splits.Contact "Contacts"
+// @Failure 500 {string} string "Failed to fetch contacts"
+// @Router /api/contacts [get]
+func GetContactsHandler(contacts repository.ContactRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		stored, err := contacts.List(r.Context(), userUID)
+		if err != nil {
+			log.Error("failed to fetch contacts", slog.Any("error", err))
+			http.Error(w, "Failed to fetch contacts", http.StatusInternalServerError)
+			return
+		}
+
+		list := make([]Contact, 0, len(stored))
+		for _, contact := range stored {
+			list = append(list, newContact(contact))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// RenameContactHandler renames a contact
+// @Summary Rename Contact
+// @Description Changes the name of a contact. Splits and settlements show the new name.
+// @Tags Splits
+// @Accept json
+// @Produce json
+// @Param id path int true "Contact ID"
+// @Param contact body splits.ContactRequest true "New name"
+// @Security BearerAuth
+// @Success 200 {object} splits.Contact "Renamed contact"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Unauthorized to access this contact"
+// @Failure 404 {string} string "Contact not found"
+// @Failure 409 {string} string "Contact already exists"
+// @Failure 500 {string} string "Failed to rename contact"
+// @Router /api/contacts/{id} [patch]
+func RenameContactHandler(contacts repository.ContactRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ContactRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for contact", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+		if msg := req.validate(); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		contact, ok := loadOwnedContact(contacts, w, r, log)
+		if !ok {
+			return
+		}
+
+		err := contacts.Rename(r.Context(), contact.ID, req.Name)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			http.Error(w, "Contact with this name already exists", http.StatusConflict)
+			return
+		} else if err != nil {
+			log.Error("failed to rename contact", slog.Int64("contactID", contact.ID), slog.Any("error", err))
+			http.Error(w, "Failed to rename contact", http.StatusInternalServerError)
+			return
+		}
+		contact.Name = req.Name
+
+		log.Info("contact renamed successfully", slog.Int64("contactID", contact.ID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(newContact(contact))
+	}
+}
+
+// DeleteContactHandler deletes a contact
+// @Summary Delete Contact
+// @Description Deletes a contact that takes part in no split or settlement.
+// @Tags Splits
+// @Produce json
+// @Param id path int true "Contact ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Success message"
+// @Failure 403 {string} string "Unauthorized to access this contact"
+// @Failure 404 {string} string "Contact not found"
+// @Failure 409 {string} string "Contact is used by splits or settlements"
+// @Failure 500 {string} string "Failed to delete contact"
+// @Router /api/contacts/{id} [delete]
+func DeleteContactHandler(contacts repository.ContactRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		contact, ok := loadOwnedContact(contacts, w, r, log)
+		if !ok {
+			return
+		}
+
+		err := contacts.Delete(r.Context(), contact.ID)
+		if errors.Is(err, repository.ErrInUse) {
+			http.Error(w, "Contact is used by splits or settlements, delete them first", http.StatusConflict)
+			return
+		} else if err != nil {
+			log.Error("failed to delete contact", slog.Int64("contactID", contact.ID), slog.Any("error", err))
+			http.Error(w, "Failed to delete contact", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("contact deleted successfully", slog.Int64("contactID", contact.ID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Contact deleted successfully"}`))
+	}
+}
+
+// loadOwnedContact fetches the contact from the {id} URL parameter and checks that the caller owns it.
+// It writes the error response itself and reports whether the handler may continue.
+func loadOwnedContact(contacts repository.ContactRepository, w http.ResponseWriter, r *http.Request, log *slog.Logger) (repository.Contact, bool) {
+	contactID := chi.URLParam(r, "id")
+	userUID := r.Context().Value("userUID").(string)
+
+	id, err := strconv.ParseInt(contactID, 10, 64)
+	if err != nil {
+		log.Warn("invalid contact ID parameter", slog.String("contactID", contactID))
+		http.Error(w, "Invalid contact ID", http.StatusBadRequest)
+		return repository.Contact{}, false
+	}
+
+	contact, err := contacts.Get(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Warn("contact not found", slog.String("contactID", contactID))
+		http.Error(w, "Contact not found", http.StatusNotFound)
+		return repository.Contact{}, false
+	} else if err != nil {
+		log.Error("failed to fetch contact", slog.Any("error", err))
+		http.Error(w, "Failed to fetch contact", http.StatusInternalServerError)
+		return repository.Contact{}, false
+	}
+
+	if contact.UserUID != userUID {
+		log.Warn("unauthorized attempt to access contact", slog.String("userUID", userUID), slog.String("ownerUID", contact.UserUID))
+		http.Error(w, "Unauthorized to access this contact", http.StatusForbidden)
+		return repository.Contact{}, false
+	}
+
+	return contact, true
+}
@@ -0,0 +1,442 @@
+package splits
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"time"
+	"unicode/utf8"
+)
+
+// Balance is the net position of a party: positive when the party is owed money, negative
+// when it owes.
+type Balance struct {
+	Party
+	Net money.Money `json:"net"`
+}
+
+// Debt is money one party owes another.
+type Debt struct {
+	From   Party       `json:"from"`
+	To     Party       `json:"to"`
+	Amount money.Money `json:"amount"`
+}
+
+// Ledger shows who owes whom over the splits and settlements the user sees.
+type Ledger struct {
+	Balances []Balance `json:"balances"`
+	// Debts are what every participant owes the payers of the splits, less the settlements
+	// between them, netted per pair of parties.
+	Debts []Debt `json:"debts"`
+	// Payments settle all balances with fewer payments than Debts: at most one less than the
+	// number of parties with a balance in each currency.
+	Payments []Debt `json:"payments"`
+}
+
+// Settlement is a repayment of debts from one party to another.
+type Settlement struct {
+	ID          int64       `json:"id"`
+	From        Party       `json:"from"`
+	To          Party       `json:"to"`
+	Amount      money.Money `json:"amount"`
+	Date        string      `json:"date"`
+	Description string      `json:"description"`
+	CreatedAt   string      `json:"created_at"`
+
+	recorderUID string
+}
+
+func newSettlement(s repository.Settlement) Settlement {
+	return Settlement{
+		ID:          s.ID,
+		From:        newParty(s.From),
+		To:          newParty(s.To),
+		Amount:      s.Amount,
+		Date:        s.Date,
+		Description: s.Description,
+		CreatedAt:   s.CreatedAt,
+		recorderUID: s.UserUID,
+	}
+}
+
+// SettleRequest is the body of the settle endpoint.
+type SettleRequest struct {
+	From        PartyRef    `json:"from"` // the caller when omitted
+	To          PartyRef    `json:"to"`   // the caller when omitted
+	Amount      money.Money `json:"amount" swaggertype:"string" example:"1200"`
+	Date        string      `json:"date,omitempty" example:"2024-10-05"`
+	Description string      `json:"description,omitempty"`
+}
+
+// validate normalizes the request and returns a user-facing message when it is invalid.
+func (req *SettleRequest) validate(currency string) string {
+	amount, err := req.Amount.OrDefault(currency)
+	if err != nil || !amount.IsPositive() {
+		return fmt.Sprintf("amount must be a positive %s value", currency)
+	}
+	req.Amount = amount
+
+	if req.Date == "" {
+		req.Date = time.Now().Format(dateLayout)
+	}
+	if _, err := time.Parse(dateLayout, req.Date); err != nil {
+		return "Invalid date format (YYYY-MM-DD)"
+	}
+
+	req.Description = strings.TrimSpace(req.Description)
+	if utf8.RuneCountInString(req.Description) > 200 {
+		return "description must be at most 200 characters"
+	}
+	return ""
+}
+
+// GetLedgerHandler reports who owes whom
+// @Summary Split Ledger
+// @Description Returns, per currency, the balance of every party of the splits and settlements the authenticated user sees,
+// @Description the debts between pairs of parties and a simplified list of payments that settles all balances.
+// @Tags Splits
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} splits.Ledger "Ledger"
+// @Failure 500 {string} string "Failed to compute ledger"
+// @Router /api/splits/ledger [get]
+func GetLedgerHandler(splits repository.SplitRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		storedSplits, err := splits.List(r.Context(), userUID)
+		if err != nil {
+			log.Error("failed to fetch splits", slog.Any("error", err))
+			http.Error(w, "Failed to compute ledger", http.StatusInternalServerError)
+			return
+		}
+		storedSettlements, err := splits.Settlements(r.Context(), userUID)
+		if err != nil {
+			log.Error("failed to fetch settlements", slog.Any("error", err))
+			http.Error(w, "Failed to compute ledger", http.StatusInternalServerError)
+			return
+		}
+
+		list := make([]Split, 0, len(storedSplits))
+		for _, split := range storedSplits {
+			list = append(list, newSplit(split))
+		}
+		settlements := make([]Settlement, 0, len(storedSettlements))
+		for _, settlement := range storedSettlements {
+			settlements = append(settlements, newSettlement(settlement))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(newLedger(list, settlements))
+	}
+}
+
+// SettleHandler records a repayment
+// @Summary Settle Up
+// @Description Records that one party paid another back. The caller must be one of the two parties unless a contact of the
+// @Description caller is involved; a payment between two other registered users is recorded by one of them.
+// @Tags Splits
+// @Accept json
+// @Produce json
+// @Param settlement body splits.SettleRequest true "Settlement details"
+// @Security BearerAuth
+// @Success 201 {object} splits.Settlement "Recorded settlement"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "A payment between two other users must be recorded by one of them"
+// @Failure 500 {string} string "Failed to record settlement"
+// @Router /api/splits/settle [post]
+func SettleHandler(users repository.UserRepository, contacts repository.ContactRepository, splits repository.SplitRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		var req SettleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for settlement", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		resolver, err := newResolver(r.Context(), users, contacts, userUID)
+		if err != nil {
+			log.Error("failed to fetch user", slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if msg := req.validate(resolver.currency); msg != "" {
+			log.Error("invalid settlement", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+		settlement := Settlement{
+			Amount:      req.Amount,
+			Date:        req.Date,
+			Description: req.Description,
+			CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+			recorderUID: userUID,
+		}
+		for _, side := range []struct {
+			ref   PartyRef
+			party *Party
+		}{{req.From, &settlement.From}, {req.To, &settlement.To}} {
+			party, msg, err := resolver.resolve(side.ref)
+			if err != nil {
+				log.Error("failed to resolve party", slog.Any("error", err))
+				http.Error(w, "Failed to record settlement", http.StatusInternalServerError)
+				return
+			} else if msg != "" {
+				http.Error(w, msg, http.StatusBadRequest)
+				return
+			}
+			*side.party = party
+		}
+
+		if settlement.From == settlement.To {
+			http.Error(w, "from and to must be different parties", http.StatusBadRequest)
+			return
+		}
+		if settlement.From.UserUID != "" && settlement.To.UserUID != "" &&
+			settlement.From.UserUID != userUID && settlement.To.UserUID != userUID {
+			http.Error(w, "A payment between two other users must be recorded by one of them", http.StatusForbidden)
+			return
+		}
+
+		stored := repository.Settlement{
+			UserUID:     userUID,
+			From:        settlement.From.stored(),
+			To:          settlement.To.stored(),
+			Amount:      settlement.Amount,
+			Date:        settlement.Date,
+			Description: settlement.Description,
+			CreatedAt:   settlement.CreatedAt,
+		}
+		if err := splits.CreateSettlement(r.Context(), &stored); err != nil {
+			log.Error("failed to record settlement", slog.Any("error", err))
+			http.Error(w, "Failed to record settlement", http.StatusInternalServerError)
+			return
+		}
+		settlement.ID = stored.ID
+
+		log.Info("settlement recorded successfully", slog.Int64("settlementID", settlement.ID), slog.String("userUID", userUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(settlement)
+	}
+}
+
+// GetSettlementsHandler lists settlements
+// @Summary List Settlements
+// @Description Returns the settlements the authenticated user recorded, paid or received, newest first.
+// @Tags Splits
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} splits.Settlement "Settlements"
+// @Failure 500 {string} string "Failed to fetch settlements"
+// @Router /api/splits/settlements [get]
+func GetSettlementsHandler(splits repository.SplitRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		stored, err := splits.Settlements(r.Context(), userUID)
+		if err != nil {
+			log.Error("failed to fetch settlements", slog.Any("error", err))
+			http.Error(w, "Failed to fetch settlements", http.StatusInternalServerError)
+			return
+		}
+
+		settlements := make([]Settlement, 0, len(stored))
+		for _, settlement := range stored {
+			settlements = append(settlements, newSettlement(settlement))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(settlements)
+	}
+}
+
+// DeleteSettlementHandler deletes a settlement
+// @Summary Delete Settlement
+// @Description Deletes a settlement recorded by the authenticated user; the debts it repaid are open again.
+// @Tags Splits
+// @Produce json
+// @Param id path int true "Settlement ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Success message"
+// @Failure 403 {string} string "Unauthorized to delete this settlement"
+// @Failure 404 {string} string "Settlement not found"
+// @Failure 500 {string} string "Failed to delete settlement"
+// @Router /api/splits/settlements/{id} [delete]
+func DeleteSettlementHandler(splits repository.SplitRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		settlementID := chi.URLParam(r, "id")
+		userUID := r.Context().Value("userUID").(string)
+
+		id, err := strconv.ParseInt(settlementID, 10, 64)
+		if err != nil {
+			log.Warn("invalid settlement ID parameter", slog.String("settlementID", settlementID))
+			http.Error(w, "Invalid settlement ID", http.StatusBadRequest)
+			return
+		}
+
+		settlement, err := splits.GetSettlement(r.Context(), id)
+		if errors.Is(err, repository.ErrNotFound) {
+			log.Warn("settlement not found", slog.String("settlementID", settlementID))
+			http.Error(w, "Settlement not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to fetch settlement", slog.Any("error", err))
+			http.Error(w, "Failed to delete settlement", http.StatusInternalServerError)
+			return
+		}
+		if settlement.UserUID != userUID {
+			log.Warn("unauthorized attempt to delete settlement", slog.String("userUID", userUID), slog.String("recorderUID", settlement.UserUID))
+			http.Error(w, "Unauthorized to delete this settlement", http.StatusForbidden)
+			return
+		}
+
+		if err := splits.DeleteSettlement(r.Context(), id); err != nil {
+			log.Error("failed to delete settlement", slog.Int64("settlementID", id), slog.Any("error", err))
+			http.Error(w, "Failed to delete settlement", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("settlement deleted successfully", slog.Int64("settlementID", id))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Settlement deleted successfully"}`))
+	}
+}
+
+// pair is an ordered pair of parties in one currency.
+type pair struct {
+	from, to Party
+	currency string
+}
+
+// newLedger sums the splits and settlements into balances, pairwise debts and simplified payments.
+func newLedger(splits []Split, settlements []Settlement) Ledger {
+	net := make(map[string]map[Party]int64) // currency -> party -> balance
+	owed := make(map[pair]int64)            // what from owes to
+	move := func(debtor, creditor Party, amount money.Money) {
+		if net[amount.Currency] == nil {
+			net[amount.Currency] = make(map[Party]int64)
+		}
+		net[amount.Currency][creditor] += amount.Amount
+		net[amount.Currency][debtor] -= amount.Amount
+		if debtor != creditor {
+			owed[pair{debtor, creditor, amount.Currency}] += amount.Amount
+		}
+	}
+
+	for _, split := range splits {
+		for _, share := range split.Shares {
+			move(share.Party, split.PaidBy, share.Amount)
+		}
+	}
+	// Paying someone back works like that person owing the payer.
+	for _, s := range settlements {
+		move(s.To, s.From, s.Amount)
+	}
+
+	ledger := Ledger{Balances: []Balance{}, Debts: []Debt{}, Payments: []Debt{}}
+
+	for p, amount := range owed {
+		if amount -= owed[pair{p.to, p.from, p.currency}]; amount > 0 {
+			ledger.Debts = append(ledger.Debts, Debt{From: p.from, To: p.to, Amount: money.New(amount, p.currency)})
+		}
+	}
+	slices.SortFunc(ledger.Debts, func(a, b Debt) int {
+		return cmp.Or(cmp.Compare(a.Amount.Currency, b.Amount.Currency), compareParties(a.From, b.From), compareParties(a.To, b.To))
+	})
+
+	currencies := make([]string, 0, len(net))
+	for currency := range net {
+		currencies = append(currencies, currency)
+	}
+	slices.Sort(currencies)
+
+	for _, currency := range currencies {
+		balances := make([]Balance, 0, len(net[currency]))
+		for party, amount := range net[currency] {
+			if amount != 0 {
+				balances = append(balances, Balance{Party: party, Net: money.New(amount, currency)})
+			}
+		}
+		slices.SortFunc(balances, func(a, b Balance) int {
+			return cmp.Or(cmp.Compare(b.Net.Amount, a.Net.Amount), compareParties(a.Party, b.Party))
+		})
+		ledger.Balances = append(ledger.Balances, balances...)
+		ledger.Payments = append(ledger.Payments, simplify(balances)...)
+	}
+
+	return ledger
+}
+
+// simplify returns payments that bring the balances, all in one currency and sorted from the
+// largest credit down, to zero. Exact matches of a debt and a credit are paid first since they
+// clear two parties with one payment; after that the largest debtor repeatedly pays the largest
+// creditor, which settles at least one of them with every payment.
+func simplify(balances []Balance) []Debt {
+	type position struct {
+		party  Party
+		amount int64
+	}
+	var creditors, debtors []position
+	for _, b := range balances {
+		if b.Net.Amount > 0 {
+			creditors = append(creditors, position{b.Party, b.Net.Amount})
+		} else {
+			debtors = append(debtors, position{b.Party, -b.Net.Amount})
+		}
+	}
+	// Balances are sorted by net amount, so the largest debt is the last one.
+	slices.Reverse(debtors)
+
+	var payments []Debt
+	pay := func(debtor, creditor *position, amount int64) {
+		payments = append(payments, Debt{From: debtor.party, To: creditor.party, Amount: money.New(amount, balances[0].Net.Currency)})
+		debtor.amount -= amount
+		creditor.amount -= amount
+	}
+
+	for i := range debtors {
+		for j := range creditors {
+			if creditors[j].amount > 0 && creditors[j].amount == debtors[i].amount {
+				pay(&debtors[i], &creditors[j], debtors[i].amount)
+				break
+			}
+		}
+	}
+
+	largest := func(positions []position) int {
+		index := -1
+		for i, p := range positions {
+			if p.amount > 0 && (index < 0 || p.amount > positions[index].amount) {
+				index = i
+			}
+		}
+		return index
+	}
+	for {
+		i, j := largest(debtors), largest(creditors)
+		if i < 0 || j < 0 {
+			return payments
+		}
+		pay(&debtors[i], &creditors[j], min(debtors[i].amount, creditors[j].amount))
+	}
+}
+
+// compareParties orders parties by name, then users before contacts.
+func compareParties(a, b Party) int {
+	return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.UserUID, b.UserUID), cmp.Compare(a.ContactID, b.ContactID))
+}
@@ -0,0 +1,560 @@
+package splits
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"time"
+	"unicode/utf8"
+)
+
+const dateLayout = "2006-01-02"
+
+// Ways to divide the amount of a split among its participants.
+const (
+	MethodEqual  = "equal"  // everyone pays the same, the first participants take the leftover minor units
+	MethodShares = "shares" // in proportion to the participants' shares, e.g. 2:1:1
+	MethodExact  = "exact"  // every participant's amount is given and they add up to the total
+)
+
+const (
+	maxParticipants = 50
+	maxShares       = 1000
+)
+
+// Party is someone taking part in splits and settlements: a registered user or a contact of
+// the user who recorded the split.
+type Party struct {
+	UserUID   string `json:"user_uid,omitempty"`
+	ContactID int64  `json:"contact_id,omitempty"`
+	Name      string `json:"name"` // username of a user, name of a contact
+}
+
+func newParty(p repository.SplitParty) Party {
+	return Party{UserUID: p.UserUID, ContactID: p.ContactID, Name: p.Name}
+}
+
+func (p Party) stored() repository.SplitParty {
+	return repository.SplitParty{UserUID: p.UserUID, ContactID: p.ContactID, Name: p.Name}
+}
+
+// PartyRef names a party in a request: a registered user by username or one of the caller's
+// contacts by ID. An empty reference stands for the caller.
+type PartyRef struct {
+	Username  string `json:"username,omitempty" example:"bob"`
+	ContactID int64  `json:"contact_id,omitempty"`
+}
+
+// Share is what a participant owes for a split.
+type Share struct {
+	Party
+	Amount money.Money `json:"amount"`
+}
+
+// Split is an expense one party paid and several parties share.
+type Split struct {
+	ID          int64       `json:"id"`
+	Description string      `json:"description"`
+	Amount      money.Money `json:"amount"`
+	Method      string      `json:"method"`
+	Date        string      `json:"date"`
+	PaidBy      Party       `json:"paid_by"`
+	Shares      []Share     `json:"shares"`
+	ExpenseID   int64       `json:"expense_id,omitempty"` // the expense of the recording user that was split
+	CreatedAt   string      `json:"created_at"`
+
+	creatorUID string
+}
+
+func newSplit(s repository.Split) Split {
+	split := Split{
+		ID:          s.ID,
+		Description: s.Description,
+		Amount:      s.Amount,
+		Method:      s.Method,
+		Date:        s.Date,
+		PaidBy:      newParty(s.Payer),
+		Shares:      make([]Share, 0, len(s.Shares)),
+		ExpenseID:   s.ExpenseID,
+		CreatedAt:   s.CreatedAt,
+		creatorUID:  s.UserUID,
+	}
+	for _, share := range s.Shares {
+		split.Shares = append(split.Shares, Share{Party: newParty(share.SplitParty), Amount: share.Amount})
+	}
+	return split
+}
+
+func (s Split) stored() repository.Split {
+	split := repository.Split{
+		ID:          s.ID,
+		UserUID:     s.creatorUID,
+		ExpenseID:   s.ExpenseID,
+		Payer:       s.PaidBy.stored(),
+		Description: s.Description,
+		Amount:      s.Amount,
+		Method:      s.Method,
+		Date:        s.Date,
+		CreatedAt:   s.CreatedAt,
+	}
+	for _, share := range s.Shares {
+		split.Shares = append(split.Shares, repository.SplitShare{SplitParty: share.Party.stored(), Amount: share.Amount})
+	}
+	return split
+}
+
+// involves reports whether the user recorded, paid or takes part in the split.
+func (s Split) involves(userUID string) bool {
+	return s.creatorUID == userUID || s.PaidBy.UserUID == userUID ||
+		slices.ContainsFunc(s.Shares, func(share Share) bool { return share.UserUID == userUID })
+}
+
+// ParticipantRequest is a participant of a new split.
+type ParticipantRequest struct {
+	PartyRef
+	Shares int64        `json:"shares,omitempty" example:"1"`                         // required by the shares method
+	Amount *money.Money `json:"amount,omitempty" swaggertype:"string" example:"1200"` // required by the exact method
+}
+
+// SplitRequest is the body of the create split endpoint.
+type SplitRequest struct {
+	// ExpenseID splits one of the caller's expenses. The caller is then the payer, and the
+	// amount, date and description default to the expense's.
+	ExpenseID    int64                `json:"expense_id,omitempty" example:"42"`
+	Description  string               `json:"description,omitempty" example:"Dinner"`
+	Amount       money.Money          `json:"amount" swaggertype:"string" example:"3600"`
+	Date         string               `json:"date,omitempty" example:"2024-10-05"`
+	PaidBy       PartyRef             `json:"paid_by"` // the caller when omitted
+	Method       string               `json:"method" example:"equal"`
+	Participants []ParticipantRequest `json:"participants"`
+}
+
+// validate normalizes the request and returns a user-facing message when it is invalid.
+// currency is the currency of the split.
+func (req *SplitRequest) validate(currency string) string {
+	req.Description = strings.TrimSpace(req.Description)
+	if utf8.RuneCountInString(req.Description) > 200 {
+		return "description must be at most 200 characters"
+	}
+
+	amount, err := req.Amount.OrDefault(currency)
+	if err != nil || amount.Currency != currency || !amount.IsPositive() {
+		return fmt.Sprintf("amount must be a positive %s value", currency)
+	}
+	req.Amount = amount
+
+	if req.Date == "" {
+		req.Date = time.Now().Format(dateLayout)
+	}
+	if _, err := time.Parse(dateLayout, req.Date); err != nil {
+		return "Invalid date format (YYYY-MM-DD)"
+	}
+
+	if req.Method != MethodEqual && req.Method != MethodShares && req.Method != MethodExact {
+		return "method must be equal, shares or exact"
+	}
+	if len(req.Participants) == 0 || len(req.Participants) > maxParticipants {
+		return fmt.Sprintf("between 1 and %d participants are required", maxParticipants)
+	}
+	for i := range req.Participants {
+		p := &req.Participants[i]
+		switch req.Method {
+		case MethodShares:
+			if p.Shares < 1 || p.Shares > maxShares {
+				return fmt.Sprintf("every participant needs shares between 1 and %d", maxShares)
+			}
+		case MethodExact:
+			if p.Amount == nil {
+				return "every participant needs an amount for the exact method"
+			}
+			amount, err := p.Amount.OrDefault(currency)
+			if err != nil || amount.Currency != currency || !amount.IsPositive() {
+				return fmt.Sprintf("participant amounts must be positive %s values", currency)
+			}
+			p.Amount = &amount
+		}
+		if req.Method != MethodShares && p.Shares != 0 {
+			return "shares are only accepted by the shares method"
+		}
+		if req.Method != MethodExact && p.Amount != nil {
+			return "participant amounts are only accepted by the exact method"
+		}
+	}
+
+	return ""
+}
+
+// allocate divides total among the participants of a validated request.
+func allocate(method string, total int64, participants []ParticipantRequest) ([]int64, string) {
+	amounts := make([]int64, len(participants))
+
+	if method == MethodExact {
+		var sum int64
+		for i, p := range participants {
+			amounts[i] = p.Amount.Amount
+			sum += amounts[i]
+		}
+		if sum != total {
+			return nil, "participant amounts must add up to the split amount"
+		}
+		return amounts, ""
+	}
+
+	weights := make([]int64, len(participants))
+	var sum int64
+	for i, p := range participants {
+		weights[i] = 1
+		if method == MethodShares {
+			weights[i] = p.Shares
+		}
+		sum += weights[i]
+	}
+
+	remainders := make([]int64, len(participants))
+	left := total
+	for i, weight := range weights {
+		amounts[i] = total / sum * weight
+		remainders[i] = total % sum * weight
+		amounts[i] += remainders[i] / sum
+		remainders[i] %= sum
+		left -= amounts[i]
+	}
+
+	// The minor units lost to rounding down go to the largest remainders, earlier participants first.
+	order := make([]int, len(participants))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return cmp.Compare(remainders[b], remainders[a]) })
+	for _, i := range order[:left] {
+		amounts[i]++
+	}
+
+	return amounts, ""
+}
+
+// CreateSplitHandler records a split expense
+// @Summary Create Split
+// @Description Records an expense paid by one party and shared by the participants. Parties are registered users,
+// @Description named by username, or contacts of the caller, named by contact_id; an empty reference is the caller.
+// @Description The amount is divided equally, by shares or by exact amounts. The caller must be the payer or a participant.
+// @Description With expense_id one of the caller's expenses is split and the caller is the payer.
+// @Tags Splits
+// @Accept json
+// @Produce json
+// @Param split body splits.SplitRequest true "Split details"
+// @Security BearerAuth
+// @Success 201 {object} splits.Split "Created split"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 409 {string} string "Expense is already split"
+// @Failure 500 {string} string "Failed to create split"
+// @Router /api/splits [post]
+func CreateSplitHandler(users repository.UserRepository, expenses repository.ExpenseRepository, contacts repository.ContactRepository,
+	splits repository.SplitRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		var req SplitRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for split", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		resolver, err := newResolver(r.Context(), users, contacts, userUID)
+		if err != nil {
+			log.Error("failed to fetch user", slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		currency := resolver.currency
+		if req.ExpenseID != 0 {
+			expense, err := expenses.Get(r.Context(), req.ExpenseID)
+			if errors.Is(err, repository.ErrNotFound) || (err == nil && expense.UserUID != userUID) {
+				http.Error(w, "Expense not found", http.StatusBadRequest)
+				return
+			} else if err != nil {
+				log.Error("failed to fetch expense", slog.Any("error", err))
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if req.PaidBy != (PartyRef{}) {
+				http.Error(w, "paid_by must be omitted when splitting an expense", http.StatusBadRequest)
+				return
+			}
+
+			if req.Amount == (money.Money{}) {
+				req.Amount = expense.Amount
+			}
+			if amount, err := req.Amount.OrDefault(expense.Amount.Currency); err != nil || amount != expense.Amount {
+				http.Error(w, "amount must equal the amount of the expense", http.StatusBadRequest)
+				return
+			}
+			if req.Date == "" {
+				req.Date = expense.Date
+			}
+			if req.Description == "" {
+				req.Description = expense.Description
+			}
+			currency = expense.Amount.Currency
+		}
+
+		if msg := req.validate(currency); msg != "" {
+			log.Error("invalid split", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		split := Split{
+			Description: req.Description,
+			Amount:      req.Amount,
+			Method:      req.Method,
+			Date:        req.Date,
+			ExpenseID:   req.ExpenseID,
+			CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+			creatorUID:  userUID,
+		}
+
+		var msg string
+		if split.PaidBy, msg, err = resolver.resolve(req.PaidBy); err != nil {
+			log.Error("failed to resolve payer", slog.Any("error", err))
+			http.Error(w, "Failed to create split", http.StatusInternalServerError)
+			return
+		} else if msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		amounts, msg := allocate(req.Method, req.Amount.Amount, req.Participants)
+		if msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+		onlyPayer := true
+		for i, p := range req.Participants {
+			party, msg, err := resolver.resolve(p.PartyRef)
+			if err != nil {
+				log.Error("failed to resolve participant", slog.Any("error", err))
+				http.Error(w, "Failed to create split", http.StatusInternalServerError)
+				return
+			} else if msg != "" {
+				http.Error(w, msg, http.StatusBadRequest)
+				return
+			}
+			if slices.ContainsFunc(split.Shares, func(s Share) bool { return s.Party == party }) {
+				http.Error(w, fmt.Sprintf("%s takes part in the split twice", party.Name), http.StatusBadRequest)
+				return
+			}
+			onlyPayer = onlyPayer && party == split.PaidBy
+			split.Shares = append(split.Shares, Share{Party: party, Amount: money.New(amounts[i], currency)})
+		}
+		if onlyPayer {
+			http.Error(w, "At least one participant other than the payer is required", http.StatusBadRequest)
+			return
+		}
+		if split.PaidBy.UserUID != userUID && !slices.ContainsFunc(split.Shares, func(s Share) bool { return s.UserUID == userUID }) {
+			http.Error(w, "You must be the payer or a participant of the split", http.StatusBadRequest)
+			return
+		}
+
+		stored := split.stored()
+		err = splits.Create(r.Context(), &stored)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			http.Error(w, "Expense is already split", http.StatusConflict)
+			return
+		} else if err != nil {
+			log.Error("failed to create split", slog.Any("error", err))
+			http.Error(w, "Failed to create split", http.StatusInternalServerError)
+			return
+		}
+		split.ID = stored.ID
+
+		log.Info("split created successfully", slog.Int64("splitID", split.ID), slog.String("userUID", userUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(split)
+	}
+}
+
+// GetSplitsHandler lists the splits of the user
+// @Summary List Splits
+// @Description Returns the splits the authenticated user recorded, paid or takes part in, newest first.
+// @Tags Splits
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} splits.Split "Splits"
+// @Failure 500 {string} string "Failed to fetch splits"
+// @Router /api/splits [get]
+func GetSplitsHandler(splits repository.SplitRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		stored, err := splits.List(r.Context(), userUID)
+		if err != nil {
+			log.Error("failed to fetch splits", slog.Any("error", err))
+			http.Error(w, "Failed to fetch splits", http.StatusInternalServerError)
+			return
+		}
+
+		list := make([]Split, 0, len(stored))
+		for _, split := range stored {
+			list = append(list, newSplit(split))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// GetSplitHandler returns one split
+// @Summary Get Split
+// @Description Returns a split the authenticated user recorded, paid or takes part in.
+// @Tags Splits
+// @Produce json
+// @Param id path int true "Split ID"
+// @Security BearerAuth
+// @Success 200 {object} splits.Split "Split"
+// @Failure 403 {string} string "Unauthorized to access this split"
+// @Failure 404 {string} string "Split not found"
+// @Failure 500 {string} string "Failed to fetch split"
+// @Router /api/splits/{id} [get]
+func GetSplitHandler(splits repository.SplitRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		split, ok := loadSplit(splits, w, r, log)
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(split)
+	}
+}
+
+// DeleteSplitHandler deletes a split
+// @Summary Delete Split
+// @Description Deletes a split together with its shares. Only the user who recorded it may do this; the split expense is kept.
+// @Tags Splits
+// @Produce json
+// @Param id path int true "Split ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Success message"
+// @Failure 403 {string} string "Only the user who recorded the split can delete it"
+// @Failure 404 {string} string "Split not found"
+// @Failure 500 {string} string "Failed to delete split"
+// @Router /api/splits/{id} [delete]
+func DeleteSplitHandler(splits repository.SplitRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		split, ok := loadSplit(splits, w, r, log)
+		if !ok {
+			return
+		}
+		if split.creatorUID != userUID {
+			http.Error(w, "Only the user who recorded the split can delete it", http.StatusForbidden)
+			return
+		}
+
+		if err := splits.Delete(r.Context(), split.ID); err != nil {
+			log.Error("failed to delete split", slog.Int64("splitID", split.ID), slog.Any("error", err))
+			http.Error(w, "Failed to delete split", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("split deleted successfully", slog.Int64("splitID", split.ID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Split deleted successfully"}`))
+	}
+}
+
+// resolver turns party references of a request into parties.
+type resolver struct {
+	ctx      context.Context
+	users    repository.UserRepository
+	contacts repository.ContactRepository
+	caller   Party
+	currency string // the caller's main currency
+}
+
+func newResolver(ctx context.Context, users repository.UserRepository, contacts repository.ContactRepository, userUID string) (resolver, error) {
+	user, err := users.GetByUID(ctx, userUID)
+	if err != nil {
+		return resolver{}, err
+	}
+	return resolver{ctx: ctx, users: users, contacts: contacts, caller: Party{UserUID: user.UID, Name: user.Username}, currency: user.Currency}, nil
+}
+
+// resolve returns the party ref names. The message is set when the reference is invalid.
+func (res resolver) resolve(ref PartyRef) (Party, string, error) {
+	switch {
+	case ref.Username != "" && ref.ContactID != 0:
+		return Party{}, "Give either username or contact_id of a party", nil
+	case ref.Username != "":
+		user, err := res.users.GetByUsername(res.ctx, ref.Username)
+		if errors.Is(err, repository.ErrNotFound) {
+			return Party{}, fmt.Sprintf("Unknown user %q", ref.Username), nil
+		} else if err != nil {
+			return Party{}, "", err
+		}
+		return Party{UserUID: user.UID, Name: user.Username}, "", nil
+	case ref.ContactID != 0:
+		contact, err := res.contacts.Get(res.ctx, ref.ContactID)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && contact.UserUID != res.caller.UserUID) {
+			return Party{}, fmt.Sprintf("Unknown contact_id %d", ref.ContactID), nil
+		} else if err != nil {
+			return Party{}, "", err
+		}
+		return Party{ContactID: contact.ID, Name: contact.Name}, "", nil
+	default:
+		return res.caller, "", nil
+	}
+}
+
+// loadSplit fetches the split from the {id} URL parameter and checks that the caller recorded,
+// paid or takes part in it. It writes the error response itself and reports whether the handler
+// may continue.
+func loadSplit(splits repository.SplitRepository, w http.ResponseWriter, r *http.Request, log *slog.Logger) (Split, bool) {
+	splitID := chi.URLParam(r, "id")
+	userUID := r.Context().Value("userUID").(string)
+
+	id, err := strconv.ParseInt(splitID, 10, 64)
+	if err != nil {
+		log.Warn("invalid split ID parameter", slog.String("splitID", splitID))
+		http.Error(w, "Invalid split ID", http.StatusBadRequest)
+		return Split{}, false
+	}
+
+	stored, err := splits.Get(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Warn("split not found", slog.String("splitID", splitID))
+		http.Error(w, "Split not found", http.StatusNotFound)
+		return Split{}, false
+	} else if err != nil {
+		log.Error("failed to fetch split", slog.Any("error", err))
+		http.Error(w, "Failed to fetch split", http.StatusInternalServerError)
+		return Split{}, false
+	}
+
+	split := newSplit(stored)
+	if !split.involves(userUID) {
+		log.Warn("unauthorized attempt to access split", slog.String("userUID", userUID), slog.Int64("splitID", id))
+		http.Error(w, "Unauthorized to access this split", http.StatusForbidden)
+		return Split{}, false
+	}
+
+	return split, true
+}
@@ -0,0 +1,165 @@
+package splits
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/repository/memory"
+	"tbank-go/internal/services/servicetest"
+	"testing"
+)
+
+func TestAllocate(t *testing.T) {
+	amount := func(v int64) *money.Money { m := money.New(v, "RUB"); return &m }
+
+	tests := []struct {
+		name         string
+		method       string
+		total        int64
+		participants []ParticipantRequest
+		want         []int64
+		wantMsg      string
+	}{
+		{name: "equal without remainder", method: MethodEqual, total: 900,
+			participants: make([]ParticipantRequest, 3), want: []int64{300, 300, 300}},
+		{name: "equal remainder goes to the first participants", method: MethodEqual, total: 1001,
+			participants: make([]ParticipantRequest, 3), want: []int64{334, 334, 333}},
+		{name: "equal total below the participant count", method: MethodEqual, total: 2,
+			participants: make([]ParticipantRequest, 3), want: []int64{1, 1, 0}},
+		{name: "shares without remainder", method: MethodShares, total: 1000,
+			participants: []ParticipantRequest{{Shares: 2}, {Shares: 1}, {Shares: 1}}, want: []int64{500, 250, 250}},
+		{name: "shares remainder goes to the largest remainder", method: MethodShares, total: 1000,
+			participants: []ParticipantRequest{{Shares: 1}, {Shares: 2}}, want: []int64{333, 667}},
+		{name: "shares remainder spread over equal remainders", method: MethodShares, total: 1003,
+			participants: []ParticipantRequest{{Shares: 3}, {Shares: 1}, {Shares: 1}, {Shares: 1}}, want: []int64{502, 167, 167, 167}},
+		{name: "exact", method: MethodExact, total: 1000,
+			participants: []ParticipantRequest{{Amount: amount(600)}, {Amount: amount(400)}}, want: []int64{600, 400}},
+		{name: "exact below the total", method: MethodExact, total: 1000,
+			participants: []ParticipantRequest{{Amount: amount(600)}, {Amount: amount(300)}},
+			wantMsg:      "participant amounts must add up to the split amount"},
+		{name: "exact above the total", method: MethodExact, total: 1000,
+			participants: []ParticipantRequest{{Amount: amount(600)}, {Amount: amount(500)}},
+			wantMsg:      "participant amounts must add up to the split amount"},
+	}
+	for _, tt := range tests {
+		got, msg := allocate(tt.method, tt.total, tt.participants)
+		if msg != tt.wantMsg || !slices.Equal(got, tt.want) {
+			t.Errorf("%s: allocate = %v, %q, want %v, %q", tt.name, got, msg, tt.want, tt.wantMsg)
+		}
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	tests := []struct {
+		name     string
+		balances []int64 // of parties p0, p1, ... sorted from the largest credit down
+		want     int     // number of payments
+	}{
+		{name: "nothing to settle", balances: nil, want: 0},
+		{name: "one debt", balances: []int64{100, -100}, want: 1},
+		{name: "one creditor", balances: []int64{300, -100, -200}, want: 2},
+		{name: "exact matches", balances: []int64{50, 30, -30, -50}, want: 2},
+		{name: "exact match before the largest debtor", balances: []int64{70, 40, -40, -70}, want: 2},
+		{name: "chain of partial payments", balances: []int64{60, 40, -50, -50}, want: 3},
+		{name: "many small debtors", balances: []int64{100, -25, -25, -25, -25}, want: 4},
+	}
+	for _, tt := range tests {
+		var balances []Balance
+		for i, amount := range tt.balances {
+			balances = append(balances, Balance{Party: Party{Name: "p" + strconv.Itoa(i)}, Net: money.New(amount, "RUB")})
+		}
+
+		payments := simplify(balances)
+		if len(payments) != tt.want {
+			t.Errorf("%s: %d payments %+v, want %d", tt.name, len(payments), payments, tt.want)
+		}
+		left := make(map[Party]int64)
+		for _, b := range balances {
+			left[b.Party] = b.Net.Amount
+		}
+		for _, p := range payments {
+			if !p.Amount.IsPositive() || p.Amount.Currency != "RUB" {
+				t.Errorf("%s: payment %+v", tt.name, p)
+			}
+			left[p.From] += p.Amount.Amount
+			left[p.To] -= p.Amount.Amount
+		}
+		for party, amount := range left {
+			if amount != 0 {
+				t.Errorf("%s: %s is left with %d", tt.name, party.Name, amount)
+			}
+		}
+	}
+}
+
+func TestSplitExpenseWithContact(t *testing.T) {
+	repos := memory.New()
+	servicetest.CreateUser(t, repos, "alice")
+	servicetest.CreateUser(t, repos, "bob")
+	account := servicetest.DefaultAccount(t, repos, "alice")
+	expense := repository.Expense{UserUID: "alice", AccountID: account.ID, Category: "Food", Amount: money.New(3000, "RUB"),
+		Date: "2024-03-01", Description: "Dinner"}
+	if err := repos.Expenses.Create(context.Background(), &expense); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	CreateContactHandler(repos.Contacts, servicetest.Discard)(w, servicetest.NewRequest(http.MethodPost, "/api/splits", `{"name": " Anna "}`, "alice"))
+	anna := servicetest.Decode[Contact](t, w, http.StatusCreated)
+	w = httptest.NewRecorder()
+	CreateContactHandler(repos.Contacts, servicetest.Discard)(w, servicetest.NewRequest(http.MethodPost, "/api/splits", `{"name": "Anna"}`, "alice"))
+	if w.Code != http.StatusConflict {
+		t.Errorf("duplicate contact: status %d", w.Code)
+	}
+
+	createSplit := CreateSplitHandler(repos.Users, repos.Expenses, repos.Contacts, repos.Splits, servicetest.Discard)
+	body := `{"expense_id": ` + servicetest.ID(expense.ID) + `, "method": "equal",
+		"participants": [{}, {"contact_id": ` + servicetest.ID(anna.ID) + `}, {"username": "bob"}]}`
+	w = httptest.NewRecorder()
+	createSplit(w, servicetest.NewRequest(http.MethodPost, "/api/splits", body, "alice"))
+	split := servicetest.Decode[Split](t, w, http.StatusCreated)
+	if split.Description != "Dinner" || split.PaidBy.UserUID != "alice" || len(split.Shares) != 3 ||
+		split.Shares[1].Name != "Anna" || split.Shares[1].Amount != money.New(1000, "RUB") {
+		t.Errorf("split = %+v", split)
+	}
+
+	w = httptest.NewRecorder()
+	createSplit(w, servicetest.NewRequest(http.MethodPost, "/api/splits", body, "alice"))
+	if w.Code != http.StatusConflict {
+		t.Errorf("second split of the expense: status %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	DeleteContactHandler(repos.Contacts, servicetest.Discard)(w, servicetest.NewRequest(http.MethodDelete, "/api/splits", "", "alice", "id", servicetest.ID(anna.ID)))
+	if w.Code != http.StatusConflict {
+		t.Errorf("delete of a contact in a split: status %d", w.Code)
+	}
+
+	// bob sees the split alice recorded but may not delete it.
+	w = httptest.NewRecorder()
+	GetSplitHandler(repos.Splits, servicetest.Discard)(w, servicetest.NewRequest(http.MethodGet, "/api/splits", "", "bob", "id", servicetest.ID(split.ID)))
+	if w.Code != http.StatusOK {
+		t.Errorf("get by a participant: status %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	DeleteSplitHandler(repos.Splits, servicetest.Discard)(w, servicetest.NewRequest(http.MethodDelete, "/api/splits", "", "bob", "id", servicetest.ID(split.ID)))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("delete by a participant: status %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	SettleHandler(repos.Users, repos.Contacts, repos.Splits, servicetest.Discard)(w,
+		servicetest.NewRequest(http.MethodPost, "/api/splits", `{"from": {"contact_id": `+servicetest.ID(anna.ID)+`}, "amount": "10"}`, "alice"))
+	servicetest.Decode[Settlement](t, w, http.StatusCreated)
+
+	w = httptest.NewRecorder()
+	GetLedgerHandler(repos.Splits, servicetest.Discard)(w, servicetest.NewRequest(http.MethodGet, "/api/splits", "", "alice"))
+	ledger := servicetest.Decode[Ledger](t, w, http.StatusOK)
+	want := []Debt{{From: Party{UserUID: "bob", Name: "bob"}, To: Party{UserUID: "alice", Name: "alice"}, Amount: money.New(1000, "RUB")}}
+	if !slices.Equal(ledger.Payments, want) {
+		t.Errorf("payments = %+v, want %+v", ledger.Payments, want)
+	}
+}
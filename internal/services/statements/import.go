@@ -0,0 +1,352 @@
+package statements
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/repository/sqlstore"
+	"tbank-go/internal/user-service"
+)
+
+const (
+	FormatTBankCSV = "tbank_csv"
+	FormatOFX      = "ofx"
+
+	maxStatementSize = 10 << 20 // 10 MB
+	defaultCategory  = "Uncategorized"
+)
+
+// Row statuses in the import result.
+const (
+	StatusNew       = "new"
+	StatusDuplicate = "duplicate"
+	StatusSkipped   = "skipped"
+)
+
+// ImportRow describes what happens (or, in dry-run mode, would happen) with one statement line.
+type ImportRow struct {
+	Line        int         `json:"line"`
+	Type        string      `json:"type"` // income or expense
+	Date        string      `json:"date"`
+	Category    string      `json:"category"`
+	Amount      money.Money `json:"amount"`
+	Description string      `json:"description"`
+	Status      string      `json:"status"` // new, duplicate or skipped
+	Reason      string      `json:"reason,omitempty"`
+}
+
+// ImportResult is the response of the import endpoint.
+type ImportResult struct {
+	DryRun     bool        `json:"dry_run"`
+	Imported   int         `json:"imported"`
+	Duplicates int         `json:"duplicates"`
+	Skipped    int         `json:"skipped"`
+	Rows       []ImportRow `json:"rows"`
+}
+
+// ImportStatementHandler imports a bank statement file
+// @Summary Import Bank Statement
+// @Description Imports a T-Bank CSV export or an OFX/QFX statement. Debits become expenses, credits become incomes.
+// @Description Operations already present (same date, amount and description) are reported as duplicates and skipped.
+// @Description With dry_run=true nothing is written and the response is a preview.
+// @Tags Import
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Statement file"
+// @Param format formData string false "tbank_csv or ofx, detected from the file when omitted"
+// @Param category formData string false "Category for operations without one (default Uncategorized)"
+// @Param account_id formData int false "Account to import into (default account when omitted)"
+// @Param dry_run formData bool false "Preview only"
+// @Security BearerAuth
+// @Success 200 {object} statements.ImportResult "Import result"
+// @Failure 400 {string} string "Invalid statement"
+// @Failure 413 {string} string "File too large"
+// @Failure 500 {string} string "Failed to import statement"
+// @Router /api/import [post]
+func ImportStatementHandler(db *sql.DB, incomes *sqlstore.IncomeRepository, expenses *sqlstore.ExpenseRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxStatementSize)
+		if err := r.ParseMultipartForm(maxStatementSize); err != nil {
+			log.Error("failed to parse statement upload", slog.Any("error", err))
+			if strings.Contains(err.Error(), "too large") {
+				http.Error(w, "File too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			log.Error("statement file is missing", slog.Any("error", err))
+			http.Error(w, "file is required", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		var accountID int64
+		if raw := r.FormValue("account_id"); raw != "" {
+			accountID, err = strconv.ParseInt(raw, 10, 64)
+			if err != nil || accountID <= 0 {
+				http.Error(w, "Invalid account_id", http.StatusBadRequest)
+				return
+			}
+		}
+
+		dryRun := r.FormValue("dry_run") == "true"
+		category := strings.TrimSpace(r.FormValue("category"))
+		if category == "" {
+			category = defaultCategory
+		}
+
+		reader := bufio.NewReader(file)
+		format := r.FormValue("format")
+		if format == "" {
+			format = detectFormat(header.Filename, reader)
+		}
+
+		var transactions []Transaction
+		switch format {
+		case FormatTBankCSV:
+			transactions, err = ParseTBankCSV(reader)
+		case FormatOFX:
+			transactions, err = ParseOFX(reader)
+		default:
+			http.Error(w, "format must be tbank_csv or ofx", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Error("failed to parse statement", slog.String("format", format), slog.Any("error", err))
+			http.Error(w, fmt.Sprintf("Invalid statement: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		tx, err := db.BeginTx(r.Context(), nil)
+		if err != nil {
+			log.Error("failed to start transaction", slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		account, err := loadAccount(tx, userUID, accountID)
+		if err == sql.ErrNoRows {
+			tx.Rollback()
+			log.Warn("account not found", slog.Int64("accountID", accountID), slog.String("userUID", userUID))
+			http.Error(w, "Account not found", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			tx.Rollback()
+			log.Error("failed to fetch account", slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		result, err := importTransactions(r.Context(), tx, incomes, expenses, userUID, account, transactions, category, dryRun)
+		if err != nil {
+			tx.Rollback()
+			log.Error("failed to import statement", slog.Any("error", err))
+			http.Error(w, "Failed to import statement", http.StatusInternalServerError)
+			return
+		}
+
+		if dryRun {
+			tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			log.Error("failed to commit transaction", slog.Any("error", err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("statement imported", slog.String("userUID", userUID), slog.String("format", format),
+			slog.Bool("dryRun", dryRun), slog.Int("imported", result.Imported), slog.Int("duplicates", result.Duplicates))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// importAccount is the account a statement is imported into.
+type importAccount struct {
+	ID       int64
+	Currency string
+}
+
+// loadAccount returns the user's account with the given ID, or the default account when id is 0.
+func loadAccount(tx *sql.Tx, userUID string, id int64) (importAccount, error) {
+	var account importAccount
+	var err error
+	if id == 0 {
+		account.ID, account.Currency, err = user_service.GetDefaultAccount(tx, userUID)
+		return account, err
+	}
+	err = tx.QueryRow(`SELECT id, currency FROM accounts WHERE id = ? AND user_uid = ?`, id, userUID).Scan(&account.ID, &account.Currency)
+	return account, err
+}
+
+// importTransactions classifies every statement line and, unless dryRun is set, records the new
+// ones through the income and expense repositories, which keep the balances.
+func importTransactions(ctx context.Context, tx *sql.Tx, incomes *sqlstore.IncomeRepository, expenses *sqlstore.ExpenseRepository,
+	userUID string, account importAccount, transactions []Transaction, category string, dryRun bool) (ImportResult, error) {
+	result := ImportResult{DryRun: dryRun, Rows: make([]ImportRow, 0, len(transactions))}
+	if len(transactions) == 0 {
+		return result, nil
+	}
+
+	currency := account.Currency
+
+	minDate, maxDate := transactions[0].Date, transactions[0].Date
+	for _, t := range transactions {
+		minDate = min(minDate, t.Date)
+		maxDate = max(maxDate, t.Date)
+	}
+
+	existing, err := existingFingerprints(tx, userUID, minDate, maxDate)
+	if err != nil {
+		return result, err
+	}
+
+	// seen counts identical operations inside the file: the n-th copy is a duplicate
+	// only if the database already holds at least n of them.
+	seen := make(map[string]int)
+
+	for _, t := range transactions {
+		row := ImportRow{
+			Line:        t.Line,
+			Type:        "income",
+			Date:        t.Date,
+			Category:    t.Category,
+			Amount:      t.Amount,
+			Description: t.Description,
+		}
+		if row.Category == "" {
+			row.Category = category
+		}
+		if t.Amount.Amount < 0 {
+			row.Type = "expense"
+			row.Amount = t.Amount.Neg()
+		}
+
+		switch {
+		case t.Amount.Amount == 0:
+			row.Status, row.Reason = StatusSkipped, "zero amount"
+		case t.Amount.Currency != currency:
+			row.Status, row.Reason = StatusSkipped, fmt.Sprintf("only %s operations are supported", currency)
+		default:
+			key := fingerprint(t.Date, t.Amount, t.Description)
+			seen[key]++
+			if seen[key] <= existing[key] {
+				row.Status = StatusDuplicate
+			} else {
+				row.Status = StatusNew
+			}
+		}
+
+		switch row.Status {
+		case StatusSkipped:
+			result.Skipped++
+		case StatusDuplicate:
+			result.Duplicates++
+		case StatusNew:
+			result.Imported++
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	// Bank categories the user has no category for yet are created on the fly.
+	type categoryRef struct {
+		id   int64
+		name string
+	}
+	categories := make(map[string]categoryRef)
+
+	for i, row := range result.Rows {
+		if row.Status != StatusNew {
+			continue
+		}
+		key := row.Type + "/" + repository.CategoryKey(row.Category)
+		ref, ok := categories[key]
+		if !ok {
+			ref.id, ref.name, err = user_service.EnsureCategory(tx, userUID, row.Type, row.Category)
+			if err != nil {
+				return result, fmt.Errorf("category of line %d: %w", row.Line, err)
+			}
+			categories[key] = ref
+		}
+		row.Category = ref.name
+		result.Rows[i].Category = ref.name
+
+		create := incomes.CreateTx
+		if row.Type == "expense" {
+			create = expenses.CreateTx
+		}
+		transaction := repository.Transaction{UserUID: userUID, AccountID: account.ID, CategoryID: ref.id, Category: row.Category,
+			Amount: row.Amount, Date: row.Date, Description: row.Description}
+		if err := create(ctx, tx, &transaction); err != nil {
+			return result, fmt.Errorf("insert line %d: %w", row.Line, err)
+		}
+	}
+
+	return result, nil
+}
+
+// existingFingerprints counts stored incomes and expenses per fingerprint within the date range.
+func existingFingerprints(tx *sql.Tx, userUID string, from, to string) (map[string]int, error) {
+	query := `
+		SELECT date, amount, currency, description FROM income WHERE user_uid = ? AND date BETWEEN ? AND ?
+		UNION ALL
+		SELECT date, -amount, currency, description FROM expenses WHERE user_uid = ? AND date BETWEEN ? AND ?`
+	rows, err := tx.Query(query, userUID, from, to, userUID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var date string
+		var amount money.Money
+		var description sql.NullString
+		if err := rows.Scan(&date, &amount.Amount, &amount.Currency, &description); err != nil {
+			return nil, err
+		}
+		counts[fingerprint(date, amount, description.String)]++
+	}
+
+	return counts, rows.Err()
+}
+
+// detectFormat guesses the statement format from the file name and, failing that, its first bytes.
+func detectFormat(filename string, reader *bufio.Reader) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatTBankCSV
+	case ".ofx", ".qfx":
+		return FormatOFX
+	}
+
+	head, err := reader.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return ""
+	}
+	upper := strings.ToUpper(string(head))
+	if strings.Contains(upper, "OFXHEADER") || strings.Contains(upper, "<OFX>") {
+		return FormatOFX
+	}
+	return FormatTBankCSV
+}
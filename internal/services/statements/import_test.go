@@ -0,0 +1,125 @@
+package statements
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/repository/sqlstore"
+	"tbank-go/internal/services/servicetest"
+	"tbank-go/internal/storage/storagetest"
+	"testing"
+)
+
+// importRequest uploads the statement file with the given form fields.
+func importRequest(t *testing.T, userUID, filename string, fields map[string]string) *http.Request {
+	t.Helper()
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	writer.Close()
+
+	r := servicetest.NewRequest(http.MethodPost, "/api/import", body.String(), userUID)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	return r
+}
+
+func TestImportStatement(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		ctx := context.Background()
+		servicetest.CreateUser(t, repos, "alice")
+		account := servicetest.DefaultAccount(t, repos, "alice")
+		handler := ImportStatementHandler(db, sqlstore.NewIncomeRepository(db), sqlstore.NewExpenseRepository(db), servicetest.Discard)
+
+		// The same purchase is already recorded by hand, so the import must not count it twice.
+		existing := repository.Expense{UserUID: "alice", AccountID: account.ID, Category: "Food", Amount: money.New(125050, "RUB"),
+			Date: "2024-01-15", Description: "пятёрочка  супермаркеты"}
+		if err := repos.Expenses.Create(ctx, &existing); err != nil {
+			t.Fatal(err)
+		}
+
+		assertBalances := func(step string, balance, incomes, expenses int64) {
+			t.Helper()
+			account := servicetest.DefaultAccount(t, repos, "alice")
+			user, err := repos.Users.GetByUID(ctx, "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if account.Balance.Amount != balance || user.IncomesBalance != incomes || user.ExpensesBalance != expenses {
+				t.Errorf("%s: balance %d, incomes %d, expenses %d, want %d, %d, %d", step,
+					account.Balance.Amount, user.IncomesBalance, user.ExpensesBalance, balance, incomes, expenses)
+			}
+		}
+
+		// A dry run reports what would happen and writes nothing.
+		w := httptest.NewRecorder()
+		handler(w, importRequest(t, "alice", "testdata/statement_xml.qfx", map[string]string{"dry_run": "true", "category": "Bank"}))
+		preview := servicetest.Decode[ImportResult](t, w, http.StatusOK)
+		if !preview.DryRun || preview.Imported != 1 || preview.Duplicates != 1 || preview.Skipped != 0 || len(preview.Rows) != 2 {
+			t.Fatalf("dry run = %+v", preview)
+		}
+		if row := preview.Rows[0]; row.Type != "expense" || row.Status != StatusDuplicate || row.Amount != money.New(125050, "RUB") {
+			t.Errorf("dry run row 1 = %+v", row)
+		}
+		if row := preview.Rows[1]; row.Type != "income" || row.Status != StatusNew || row.Category != "Bank" || row.Amount != money.New(5000000, "RUB") {
+			t.Errorf("dry run row 2 = %+v", row)
+		}
+		incomes, err := repos.Incomes.List(ctx, "alice", "2024-01-01", "2024-01-31")
+		if err != nil || len(incomes) != 0 {
+			t.Errorf("incomes after dry run = %+v, %v", incomes, err)
+		}
+		assertBalances("dry run", -125050, 0, 125050)
+
+		// The import records the new income only.
+		w = httptest.NewRecorder()
+		handler(w, importRequest(t, "alice", "testdata/statement_xml.qfx", map[string]string{"category": "Bank"}))
+		result := servicetest.Decode[ImportResult](t, w, http.StatusOK)
+		if result.DryRun || result.Imported != 1 || result.Duplicates != 1 {
+			t.Fatalf("import = %+v", result)
+		}
+		incomes, err = repos.Incomes.List(ctx, "alice", "2024-01-01", "2024-01-31")
+		if err != nil || len(incomes) != 1 {
+			t.Fatalf("incomes after import = %+v, %v", incomes, err)
+		}
+		if income := incomes[0]; income.AccountID != account.ID || income.Category != "Bank" || income.CategoryID == 0 ||
+			income.Date != "2024-01-20" || income.Description != "Зарплата" {
+			t.Errorf("imported income = %+v", income)
+		}
+		assertBalances("import", 5000000-125050, 5000000, 125050)
+
+		// Importing the file again finds only duplicates.
+		w = httptest.NewRecorder()
+		handler(w, importRequest(t, "alice", "testdata/statement_xml.qfx", map[string]string{"category": "Bank"}))
+		again := servicetest.Decode[ImportResult](t, w, http.StatusOK)
+		if again.Imported != 0 || again.Duplicates != 2 {
+			t.Errorf("second import = %+v", again)
+		}
+		assertBalances("second import", 5000000-125050, 5000000, 125050)
+
+		// A statement in another currency than the account is skipped line by line.
+		w = httptest.NewRecorder()
+		handler(w, importRequest(t, "alice", "testdata/statement_sgml.ofx", nil))
+		skipped := servicetest.Decode[ImportResult](t, w, http.StatusOK)
+		if skipped.Imported != 0 || skipped.Skipped != 3 || skipped.Rows[0].Reason != "only RUB operations are supported" {
+			t.Errorf("USD statement = %+v", skipped)
+		}
+		assertBalances("USD statement", 5000000-125050, 5000000, 125050)
+	})
+}
@@ -0,0 +1,107 @@
+package statements
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"tbank-go/internal/money"
+	"time"
+)
+
+// ParseOFX reads OFX 1.x (SGML, tags are not closed) and OFX 2.x / QFX (XML) statements.
+// Line numbers of the returned transactions are their ordinal numbers in the file.
+func ParseOFX(r io.Reader) ([]Transaction, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	body := string(raw)
+	start := strings.Index(strings.ToUpper(body), "<OFX>")
+	if start < 0 {
+		return nil, fmt.Errorf("not an OFX document: <OFX> element is missing")
+	}
+	body = body[start:]
+
+	type rawTransaction struct {
+		fields map[string]string
+	}
+
+	currency := "RUB"
+	var (
+		pending []rawTransaction
+		current *rawTransaction
+	)
+
+	for len(body) > 0 {
+		open := strings.IndexByte(body, '<')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(body[open:], '>')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated OFX tag")
+		}
+		tag := strings.ToUpper(strings.TrimSpace(body[open+1 : open+end]))
+		body = body[open+end+1:]
+
+		next := strings.IndexByte(body, '<')
+		if next < 0 {
+			next = len(body)
+		}
+		text := strings.TrimSpace(html.UnescapeString(body[:next]))
+
+		switch {
+		case tag == "STMTTRN":
+			pending = append(pending, rawTransaction{fields: map[string]string{}})
+			current = &pending[len(pending)-1]
+		case tag == "/STMTTRN" || tag == "/BANKTRANLIST":
+			current = nil
+		case strings.HasPrefix(tag, "/"):
+		case tag == "CURDEF" && text != "":
+			currency = strings.ToUpper(text)
+		case current != nil && text != "":
+			current.fields[tag] = text
+		}
+	}
+
+	transactions := make([]Transaction, 0, len(pending))
+	for i, p := range pending {
+		date, err := parseOFXDate(p.fields["DTPOSTED"])
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i+1, err)
+		}
+
+		amount, err := money.Parse(p.fields["TRNAMT"], currency)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i+1, err)
+		}
+
+		description := p.fields["NAME"]
+		if memo := p.fields["MEMO"]; memo != "" && memo != description {
+			description = strings.TrimSpace(description + " " + memo)
+		}
+
+		transactions = append(transactions, Transaction{
+			Line:        i + 1,
+			Date:        date,
+			Amount:      amount,
+			Description: description,
+		})
+	}
+
+	return transactions, nil
+}
+
+// parseOFXDate accepts YYYYMMDD optionally followed by time and timezone, e.g. 20240105120000.000[+3:MSK].
+func parseOFXDate(value string) (string, error) {
+	if len(value) < 8 {
+		return "", fmt.Errorf("invalid posting date %q", value)
+	}
+	t, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return "", fmt.Errorf("invalid posting date %q", value)
+	}
+	return t.Format(dateLayout), nil
+}
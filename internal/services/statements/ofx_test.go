@@ -0,0 +1,71 @@
+package statements
+
+import (
+	"os"
+	"strings"
+	"tbank-go/internal/money"
+	"testing"
+)
+
+func TestParseOFX(t *testing.T) {
+	tests := []struct {
+		file string
+		want []Transaction
+	}{
+		{
+			// OFX 1.x: unclosed tags, CURDEF USD, an escaped NAME and dates with and without a timezone.
+			file: "testdata/statement_sgml.ofx",
+			want: []Transaction{
+				{Line: 1, Date: "2024-01-05", Amount: money.New(-1250, "USD"), Description: "Coffee & Co Card *1234"},
+				{Line: 2, Date: "2024-01-10", Amount: money.New(150000, "USD"), Description: "Salary"},
+				{Line: 3, Date: "2024-01-12", Amount: money.New(-399, "USD"), Description: "App Store"},
+			},
+		},
+		{
+			// OFX 2.x / QFX: XML with closing tags and CURDEF RUB.
+			file: "testdata/statement_xml.qfx",
+			want: []Transaction{
+				{Line: 1, Date: "2024-01-15", Amount: money.New(-125050, "RUB"), Description: "Пятёрочка Супермаркеты"},
+				{Line: 2, Date: "2024-01-20", Amount: money.New(5000000, "RUB"), Description: "Зарплата"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		f, err := os.Open(tt.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParseOFX(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: ParseOFX: %v", tt.file, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: got %d transactions, want %d: %+v", tt.file, len(got), len(tt.want), got)
+		}
+		for i := range tt.want {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: transaction %d = %+v, want %+v", tt.file, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestParseOFXErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{input: "OFXHEADER:100\n\n", wantErr: "<OFX> element is missing"},
+		{input: "<OFX><STMTTRN><DTPOSTED>2024<TRNAMT>-1.00</STMTTRN></OFX>", wantErr: `invalid posting date "2024"`},
+		{input: "<OFX><STMTTRN><DTPOSTED>20241305<TRNAMT>-1.00</STMTTRN></OFX>", wantErr: "invalid posting date"},
+		{input: "<OFX><STMTTRN><DTPOSTED>20240105<TRNAMT>1e3</STMTTRN></OFX>", wantErr: "transaction 1"},
+		{input: "<OFX><STMTTRN", wantErr: "unterminated OFX tag"},
+	}
+	for _, tt := range tests {
+		_, err := ParseOFX(strings.NewReader(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseOFX(%q) = %v, want error %q", tt.input, err, tt.wantErr)
+		}
+	}
+}
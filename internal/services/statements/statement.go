@@ -0,0 +1,29 @@
+// Package statements imports bank statements into incomes and expenses.
+package statements
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"tbank-go/internal/money"
+)
+
+const dateLayout = "2006-01-02"
+
+// Transaction is one parsed statement line. Negative amounts are debits.
+type Transaction struct {
+	Line        int
+	Date        string // YYYY-MM-DD
+	Amount      money.Money
+	Category    string
+	Description string
+}
+
+// fingerprint identifies a transaction for duplicate detection by its date, signed amount and
+// a hash of the normalized description.
+func fingerprint(date string, amount money.Money, description string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(description)), " ")
+	descriptionHash := sha256.Sum256([]byte(normalized))
+	return fmt.Sprintf("%s|%d|%s|%s", date, amount.Amount, amount.Currency, hex.EncodeToString(descriptionHash[:8]))
+}
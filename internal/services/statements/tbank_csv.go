@@ -0,0 +1,129 @@
+package statements
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"tbank-go/internal/money"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ParseTBankCSV reads the CSV statement exported from the T-Bank (Tinkoff) web or mobile app.
+// The export is semicolon separated and usually windows-1251 encoded; UTF-8 files are accepted too.
+// Only operations with status OK are returned.
+func ParseTBankCSV(r io.Reader) ([]Transaction, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	raw = bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(raw) {
+		raw, err = charmap.Windows1251.NewDecoder().Bytes(raw)
+		if err != nil {
+			return nil, fmt.Errorf("decode windows-1251: %w", err)
+		}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(raw))
+	reader.Comma = ';'
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	dateCol, ok := columns["Дата операции"]
+	if !ok {
+		return nil, fmt.Errorf("not a T-Bank statement: column %q is missing", "Дата операции")
+	}
+	// "Сумма платежа" is in the card currency, "Сумма операции" in the merchant's. Amounts of a
+	// statement without the currency column are in rubles.
+	amountCol, ok := columns["Сумма платежа"]
+	currencyCol, hasCurrency := columns["Валюта платежа"]
+	if !ok {
+		amountCol, ok = columns["Сумма операции"]
+		if !ok {
+			return nil, fmt.Errorf("not a T-Bank statement: no amount column")
+		}
+		currencyCol, hasCurrency = columns["Валюта операции"]
+	}
+	statusCol, hasStatus := columns["Статус"]
+	categoryCol, hasCategory := columns["Категория"]
+	descriptionCol, hasDescription := columns["Описание"]
+
+	field := func(record []string, i int) string {
+		if i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var transactions []Transaction
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		if hasStatus && field(record, statusCol) != "OK" {
+			continue
+		}
+
+		date, err := parseTBankDate(field(record, dateCol))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		currency := "RUB"
+		if hasCurrency {
+			currency = strings.ToUpper(field(record, currencyCol))
+		}
+		if currency == "RUR" || currency == "" {
+			currency = "RUB"
+		}
+		if !money.ValidCurrency(currency) {
+			return nil, fmt.Errorf("line %d: invalid currency %q", line, currency)
+		}
+		amount, err := money.Parse(field(record, amountCol), currency)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		tx := Transaction{Line: line, Date: date, Amount: amount}
+		if hasCategory {
+			tx.Category = field(record, categoryCol)
+		}
+		if hasDescription {
+			tx.Description = field(record, descriptionCol)
+		}
+		transactions = append(transactions, tx)
+	}
+
+	return transactions, nil
+}
+
+func parseTBankDate(value string) (string, error) {
+	for _, layout := range []string{"02.01.2006 15:04:05", "02.01.2006 15:04", "02.01.2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format(dateLayout), nil
+		}
+	}
+	return "", fmt.Errorf("invalid operation date %q", value)
+}
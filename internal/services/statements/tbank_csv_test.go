@@ -0,0 +1,141 @@
+package statements
+
+import (
+	"strings"
+	"tbank-go/internal/money"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+const tbankHeader = "Дата операции;Дата платежа;Номер карты;Статус;Сумма операции;Валюта операции;Сумма платежа;Валюта платежа;Категория;Описание\n"
+
+func TestParseTBankCSV(t *testing.T) {
+	input := tbankHeader +
+		"05.01.2024 12:30:00;05.01.2024;*1234;OK;-1250,50;RUR;-1250,50;RUR;Супермаркеты;Пятёрочка\n" +
+		"06.01.2024 09:00:00;06.01.2024;*1234;FAILED;-99,00;RUB;-99,00;RUB;Такси;Яндекс Такси\n" +
+		"07.01.2024 18:45:10;07.01.2024;*1234;OK;-20,00;USD;-1800,00;RUB;Сервисы;Netflix\n" +
+		"10.01.2024;10.01.2024;;OK;50000;RUB;50000;RUB;Пополнения;Зарплата\n"
+
+	got, err := ParseTBankCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseTBankCSV: %v", err)
+	}
+
+	want := []Transaction{
+		{Line: 2, Date: "2024-01-05", Amount: money.New(-125050, "RUB"), Category: "Супермаркеты", Description: "Пятёрочка"},
+		{Line: 4, Date: "2024-01-07", Amount: money.New(-180000, "RUB"), Category: "Сервисы", Description: "Netflix"},
+		{Line: 5, Date: "2024-01-10", Amount: money.New(5000000, "RUB"), Category: "Пополнения", Description: "Зарплата"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d transactions, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("transaction %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseTBankCSVWindows1251(t *testing.T) {
+	input := tbankHeader + "05.01.2024 12:30:00;05.01.2024;*1234;OK;-300,00;RUB;-300,00;RUB;Транспорт;Метро\n"
+	encoded, err := charmap.Windows1251.NewEncoder().String(input)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	got, err := ParseTBankCSV(strings.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("ParseTBankCSV: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d transactions, want 1", len(got))
+	}
+	if got[0].Category != "Транспорт" || got[0].Description != "Метро" {
+		t.Errorf("text decoded as %q / %q", got[0].Category, got[0].Description)
+	}
+	if got[0].Amount != money.New(-30000, "RUB") {
+		t.Errorf("amount = %v", got[0].Amount)
+	}
+}
+
+func TestParseTBankCSVUTF8BOM(t *testing.T) {
+	input := "\xef\xbb\xbf" + tbankHeader + "05.01.2024;05.01.2024;;OK;-1;RUB;-1;RUB;;\n"
+	got, err := ParseTBankCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseTBankCSV: %v", err)
+	}
+	if len(got) != 1 || got[0].Date != "2024-01-05" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestParseTBankCSVOptionalColumns(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Transaction
+	}{
+		{
+			name:  "no currency column",
+			input: "Дата операции;Сумма платежа;Описание\n05.01.2024 12:30:00;-150,25;Кофе\n",
+			want:  Transaction{Line: 2, Date: "2024-01-05", Amount: money.New(-15025, "RUB"), Description: "Кофе"},
+		},
+		{
+			name:  "operation amount only",
+			input: "Дата операции;Сумма операции;Валюта операции\n05.01.2024;-10,00;EUR\n",
+			want:  Transaction{Line: 2, Date: "2024-01-05", Amount: money.New(-1000, "EUR")},
+		},
+		{
+			name:  "operation amount without currency",
+			input: "Дата операции;Сумма операции\n05.01.2024;-10,00\n",
+			want:  Transaction{Line: 2, Date: "2024-01-05", Amount: money.New(-1000, "RUB")},
+		},
+		{
+			name:  "empty currency",
+			input: "Дата операции;Сумма платежа;Валюта платежа\n05.01.2024;7;\n",
+			want:  Transaction{Line: 2, Date: "2024-01-05", Amount: money.New(700, "RUB")},
+		},
+		{
+			name:  "no status column",
+			input: "Дата операции;Сумма платежа;Валюта платежа\n05.01.2024;7;rur\n",
+			want:  Transaction{Line: 2, Date: "2024-01-05", Amount: money.New(700, "RUB")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTBankCSV(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ParseTBankCSV: %v", err)
+			}
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("got %+v, want [%+v]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTBankCSVErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "empty file", input: "", want: "read header"},
+		{name: "no date column", input: "Сумма платежа;Валюта платежа\n-1;RUB\n", want: "Дата операции"},
+		{name: "no amount column", input: "Дата операции;Валюта платежа\n05.01.2024;RUB\n", want: "no amount column"},
+		{name: "invalid date", input: "Дата операции;Сумма платежа\n2024-01-05;-1\n", want: "line 2: invalid operation date"},
+		{name: "invalid amount", input: "Дата операции;Сумма платежа\n05.01.2024;abc\n", want: "line 2: invalid amount"},
+		{name: "invalid currency", input: "Дата операции;Сумма платежа;Валюта платежа\n05.01.2024;-1;рубли\n", want: "line 2: invalid currency"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTBankCSV(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
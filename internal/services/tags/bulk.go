@@ -0,0 +1,147 @@
+package tags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"tbank-go/internal/repository"
+)
+
+// maxBulkTransactions limits the incomes and expenses of one bulk request.
+const maxBulkTransactions = 500
+
+// BulkTagRequest adds and removes tags on many incomes and expenses at once.
+type BulkTagRequest struct {
+	IncomeIDs  []int64 `json:"income_ids,omitempty" example:"3,4"`
+	ExpenseIDs []int64 `json:"expense_ids,omitempty" example:"12,15"`
+	// Add names the tags to attach; missing ones are created.
+	Add []string `json:"add,omitempty" example:"reimbursable"`
+	// Remove names the tags to detach; they must exist.
+	Remove []string `json:"remove,omitempty" example:"trip"`
+}
+
+// BulkTagHandler tags incomes and expenses in bulk
+// @Summary Bulk Tag
+// @Description Attaches the `add` tags to and detaches the `remove` tags from every listed income and expense. Tags to add are created when missing.
+// @Description The user must be allowed to change every transaction: its author, or an owner or editor of the household it is shared with.
+// @Description Nothing is changed when one of the transactions is not found or not allowed.
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Param request body tags.BulkTagRequest true "Transactions and tags"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Success message"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Unauthorized to update this income or expense"
+// @Failure 404 {string} string "Income or expense not found"
+// @Failure 500 {string} string "Failed to update tags"
+// @Router /api/tags/bulk [post]
+func BulkTagHandler(tags repository.TagRepository, incomes repository.IncomeRepository, expenses repository.ExpenseRepository,
+	households repository.HouseholdRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		var req BulkTagRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for bulk tagging", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		count := len(req.IncomeIDs) + len(req.ExpenseIDs)
+		if count == 0 {
+			http.Error(w, "income_ids or expense_ids is required", http.StatusBadRequest)
+			return
+		}
+		if count > maxBulkTransactions {
+			http.Error(w, fmt.Sprintf("At most %d transactions can be tagged at once", maxBulkTransactions), http.StatusBadRequest)
+			return
+		}
+		if len(req.Add) == 0 && len(req.Remove) == 0 {
+			http.Error(w, "add or remove is required", http.StatusBadRequest)
+			return
+		}
+
+		for _, kind := range []struct {
+			label string
+			ids   []int64
+			get   func(context.Context, int64) (repository.Transaction, error)
+		}{{"Income", req.IncomeIDs, incomes.Get}, {"Expense", req.ExpenseIDs, expenses.Get}} {
+			for _, id := range kind.ids {
+				t, err := kind.get(r.Context(), id)
+				if errors.Is(err, repository.ErrNotFound) {
+					http.Error(w, fmt.Sprintf("%s %d not found", kind.label, id), http.StatusNotFound)
+					return
+				} else if err != nil {
+					log.Error("failed to fetch transaction", slog.String("kind", kind.label), slog.Int64("id", id), slog.Any("error", err))
+					http.Error(w, "Failed to update tags", http.StatusInternalServerError)
+					return
+				}
+				allowed, err := repository.CanModify(r.Context(), households, userUID, t)
+				if err != nil {
+					log.Error("failed to check household role", slog.Any("error", err))
+					http.Error(w, "Failed to update tags", http.StatusInternalServerError)
+					return
+				}
+				if !allowed {
+					log.Warn("unauthorized attempt to tag transaction", slog.String("userUID", userUID), slog.String("ownerUID", t.UserUID))
+					http.Error(w, fmt.Sprintf("Unauthorized to update %s %d", strings.ToLower(kind.label), id), http.StatusForbidden)
+					return
+				}
+			}
+		}
+
+		var removeIDs []int64
+		for _, name := range req.Remove {
+			tag, err := tags.FindByName(r.Context(), userUID, name)
+			if errors.Is(err, repository.ErrNotFound) {
+				http.Error(w, fmt.Sprintf("Unknown tag %q", name), http.StatusBadRequest)
+				return
+			} else if err != nil {
+				log.Error("failed to fetch tag", slog.Any("error", err))
+				http.Error(w, "Failed to update tags", http.StatusInternalServerError)
+				return
+			}
+			removeIDs = append(removeIDs, tag.ID)
+		}
+
+		added, err := repository.ResolveTags(r.Context(), tags, userUID, req.Add)
+		if errors.Is(err, repository.ErrInvalidTagName) {
+			http.Error(w, fmt.Sprintf("Tag names must be 1 to %d characters long", repository.MaxTagNameLength), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Error("failed to resolve tags", slog.Any("error", err))
+			http.Error(w, "Failed to update tags", http.StatusInternalServerError)
+			return
+		}
+		addIDs := make([]int64, 0, len(added))
+		for _, tag := range added {
+			addIDs = append(addIDs, tag.ID)
+		}
+
+		for _, kind := range []struct {
+			name string
+			ids  []int64
+		}{{repository.EntryIncome, req.IncomeIDs}, {repository.EntryExpense, req.ExpenseIDs}} {
+			if err := tags.Attach(r.Context(), kind.name, kind.ids, addIDs); err != nil {
+				log.Error("failed to attach tags", slog.Any("error", err))
+				http.Error(w, "Failed to update tags", http.StatusInternalServerError)
+				return
+			}
+			if err := tags.Detach(r.Context(), kind.name, kind.ids, removeIDs); err != nil {
+				log.Error("failed to detach tags", slog.Any("error", err))
+				http.Error(w, "Failed to update tags", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		log.Info("transactions tagged successfully", slog.String("userUID", userUID), slog.Int("count", count))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Tags updated successfully"}`))
+	}
+}
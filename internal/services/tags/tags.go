@@ -0,0 +1,300 @@
+package tags
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"tbank-go/internal/repository"
+	"unicode/utf8"
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// Tag marks incomes and expenses across categories.
+type Tag struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Color     string `json:"color"`
+	CreatedAt string `json:"created_at"`
+}
+
+func newTag(tag repository.Tag) Tag {
+	return Tag{
+		ID:        tag.ID,
+		Name:      tag.Name,
+		Color:     tag.Color,
+		CreatedAt: tag.CreatedAt,
+	}
+}
+
+// CreateTagRequest is the body of the create endpoint.
+type CreateTagRequest struct {
+	Name  string `json:"name" example:"Trip to Kazan"`
+	Color string `json:"color,omitempty" example:"#00ACC1"`
+}
+
+// PatchTagRequest holds the fields of a tag to change. Omitted fields are kept.
+type PatchTagRequest struct {
+	Name  *string `json:"name,omitempty"`
+	Color *string `json:"color,omitempty"`
+}
+
+// validateFields checks the fields shared by create and update and returns a user-facing
+// message when one is invalid.
+func validateFields(tag *repository.Tag) string {
+	tag.Name = strings.Join(strings.Fields(tag.Name), " ")
+	if tag.Name == "" {
+		return "name is required"
+	}
+	if utf8.RuneCountInString(tag.Name) > repository.MaxTagNameLength {
+		return fmt.Sprintf("name must be at most %d characters", repository.MaxTagNameLength)
+	}
+	if tag.Color != "" {
+		if !colorPattern.MatchString(tag.Color) {
+			return "color must be #RRGGBB"
+		}
+		tag.Color = strings.ToUpper(tag.Color)
+	}
+	return ""
+}
+
+// CreateTagHandler creates a tag
+// @Summary Create Tag
+// @Description Creates a tag to mark incomes and expenses across categories. Names are unique regardless of case and extra spaces.
+// @Description Tags are also created on the fly when incomes and expenses are added with new tag names.
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Param tag body tags.CreateTagRequest true "Tag details"
+// @Security BearerAuth
+// @Success 201 {object} tags.Tag "Created tag"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 409 {string} string "Tag already exists"
+// @Failure 500 {string} string "Failed to create tag"
+// @Router /api/tags [post]
+func CreateTagHandler(tags repository.TagRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		var req CreateTagRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for tag", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		tag := repository.Tag{UserUID: userUID, Name: req.Name, Color: req.Color}
+		if msg := validateFields(&tag); msg != "" {
+			log.Error("invalid tag", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		err := tags.Create(r.Context(), &tag)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			http.Error(w, "Tag already exists", http.StatusConflict)
+			return
+		} else if err != nil {
+			log.Error("failed to create tag", slog.Any("error", err))
+			http.Error(w, "Failed to create tag", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("tag created successfully", slog.Int64("tagID", tag.ID), slog.String("userUID", userUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(newTag(tag))
+	}
+}
+
+// GetTagsHandler lists the user's tags
+// @Summary List Tags
+// @Description Returns the tags of the authenticated user ordered by name.
+// @Tags Tags
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} tags.Tag "Tags"
+// @Failure 500 {string} string "Failed to fetch tags"
+// @Router /api/tags [get]
+func GetTagsHandler(tags repository.TagRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+
+		records, err := tags.List(r.Context(), userUID)
+		if err != nil {
+			log.Error("failed to fetch tags", slog.Any("error", err))
+			http.Error(w, "Failed to fetch tags", http.StatusInternalServerError)
+			return
+		}
+
+		list := make([]Tag, 0, len(records))
+		for _, tag := range records {
+			list = append(list, newTag(tag))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// GetTagHandler returns one tag
+// @Summary Get Tag
+// @Description Returns a tag of the authenticated user.
+// @Tags Tags
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Security BearerAuth
+// @Success 200 {object} tags.Tag "Tag"
+// @Failure 403 {string} string "Unauthorized to access this tag"
+// @Failure 404 {string} string "Tag not found"
+// @Router /api/tags/{id} [get]
+func GetTagHandler(tags repository.TagRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tag, ok := loadOwnedTag(tags, w, r, log)
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(newTag(tag))
+	}
+}
+
+// PatchTagHandler renames or recolors a tag
+// @Summary Patch Tag
+// @Description Changes the name or the color of a tag. The tagged incomes and expenses show the new name.
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Param tag body tags.PatchTagRequest true "Fields to change"
+// @Security BearerAuth
+// @Success 200 {object} tags.Tag "Updated tag"
+// @Failure 400 {string} string "Invalid input"
+// @Failure 403 {string} string "Unauthorized to access this tag"
+// @Failure 404 {string} string "Tag not found"
+// @Failure 409 {string} string "Tag already exists"
+// @Failure 500 {string} string "Failed to update tag"
+// @Router /api/tags/{id} [patch]
+func PatchTagHandler(tags repository.TagRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tag, ok := loadOwnedTag(tags, w, r, log)
+		if !ok {
+			return
+		}
+
+		var req PatchTagRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("invalid input for tag update", slog.Any("error", err))
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+
+		if req.Name != nil {
+			tag.Name = *req.Name
+		}
+		if req.Color != nil {
+			tag.Color = *req.Color
+		}
+		if msg := validateFields(&tag); msg != "" {
+			log.Error("invalid tag", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		err := tags.Update(r.Context(), tag)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			http.Error(w, "Tag already exists", http.StatusConflict)
+			return
+		} else if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Tag not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to update tag", slog.Int64("tagID", tag.ID), slog.Any("error", err))
+			http.Error(w, "Failed to update tag", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("tag updated successfully", slog.Int64("tagID", tag.ID), slog.String("userUID", tag.UserUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(newTag(tag))
+	}
+}
+
+// DeleteTagHandler deletes a tag
+// @Summary Delete Tag
+// @Description Deletes a tag and removes it from all incomes and expenses; the transactions themselves are kept.
+// @Tags Tags
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Success message"
+// @Failure 403 {string} string "Unauthorized to access this tag"
+// @Failure 404 {string} string "Tag not found"
+// @Failure 500 {string} string "Failed to delete tag"
+// @Router /api/tags/{id} [delete]
+func DeleteTagHandler(tags repository.TagRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tag, ok := loadOwnedTag(tags, w, r, log)
+		if !ok {
+			return
+		}
+
+		err := tags.Delete(r.Context(), tag.ID)
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Tag not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Error("failed to delete tag", slog.Int64("tagID", tag.ID), slog.Any("error", err))
+			http.Error(w, "Failed to delete tag", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("tag deleted successfully", slog.Int64("tagID", tag.ID), slog.String("userUID", tag.UserUID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "Tag deleted successfully"}`))
+	}
+}
+
+// loadOwnedTag fetches the tag from the {id} URL parameter and checks that the caller owns it.
+// It writes the error response itself and reports whether the handler may continue.
+func loadOwnedTag(tags repository.TagRepository, w http.ResponseWriter, r *http.Request, log *slog.Logger) (repository.Tag, bool) {
+	tagID := chi.URLParam(r, "id")
+	userUID := r.Context().Value("userUID").(string)
+
+	id, err := strconv.ParseInt(tagID, 10, 64)
+	if err != nil {
+		log.Warn("invalid tag ID parameter", slog.String("tagID", tagID))
+		http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+		return repository.Tag{}, false
+	}
+
+	tag, err := tags.Get(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Warn("tag not found", slog.String("tagID", tagID))
+		http.Error(w, "Tag not found", http.StatusNotFound)
+		return repository.Tag{}, false
+	} else if err != nil {
+		log.Error("failed to fetch tag", slog.Any("error", err))
+		http.Error(w, "Failed to fetch tag", http.StatusInternalServerError)
+		return repository.Tag{}, false
+	}
+
+	if tag.UserUID != userUID {
+		log.Warn("unauthorized attempt to access tag", slog.String("userUID", userUID), slog.String("ownerUID", tag.UserUID))
+		http.Error(w, "Unauthorized to access this tag", http.StatusForbidden)
+		return repository.Tag{}, false
+	}
+
+	return tag, true
+}
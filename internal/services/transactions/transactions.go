@@ -0,0 +1,136 @@
+package transactions
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/services/listing"
+)
+
+var entryTypes = []string{repository.EntryIncome, repository.EntryExpense, repository.EntryTransfer}
+
+// Entry is an income, an expense or one side of a transfer.
+type Entry struct {
+	Type      string `json:"type"`               // income, expense or transfer
+	ID        int64  `json:"id"`                 // ID of the income, expense or transfer
+	UserUID   string `json:"user_uid,omitempty"` // the author, in a household's feed
+	AccountID int64  `json:"account_id,omitempty"`
+	// CounterpartAccountID is the other account of a transfer.
+	CounterpartAccountID int64       `json:"counterpart_account_id,omitempty"`
+	CategoryID           int64       `json:"category_id,omitempty"`
+	Category             string      `json:"category,omitempty"`
+	Amount               money.Money `json:"amount"`  // negative for expenses and outgoing transfers
+	Balance              money.Money `json:"balance"` // running total in the entry's currency
+	Date                 string      `json:"date"`
+	Description          string      `json:"description"`
+	Tags                 []string    `json:"tags,omitempty"` // tag names, transfers have none
+}
+
+// newEntry converts a feed entry, naming its author when the feed is a household's.
+func newEntry(e repository.FeedEntry, household bool) Entry {
+	entry := Entry{
+		Type:                 e.Type,
+		ID:                   e.ID,
+		AccountID:            e.AccountID,
+		CounterpartAccountID: e.CounterpartAccountID,
+		CategoryID:           e.CategoryID,
+		Category:             e.Category,
+		Amount:               e.Amount,
+		Balance:              e.Balance,
+		Date:                 e.Date,
+		Description:          e.Description,
+	}
+	for _, tag := range e.Tags {
+		entry.Tags = append(entry.Tags, tag.Name)
+	}
+	if household {
+		entry.UserUID = e.UserUID
+	}
+	return entry
+}
+
+// GetTransactionsHandler returns incomes, expenses and transfers as one feed
+// @Summary Transactions Feed
+// @Description Returns a page of the user's incomes, expenses and transfers interleaved by date. Amounts are signed: expenses and
+// @Description the source side of a transfer are negative. A transfer appears once per account it touches.
+// @Description balance is the running total of the matching entries in date order, kept per currency; entries before `from` are included in it.
+// @Description The filters and pagination are those of /api/expense; amount bounds and sort=amount use the absolute amount.
+// @Description With X-Household-ID the incomes and expenses shared with the household by all its members are listed instead; transfers are personal and left out.
+// @Tags Transactions
+// @Produce json
+// @Param X-Household-ID header int false "Household to list the shared incomes and expenses of"
+// @Param type query []string false "income, expense or transfer" collectionFormat(multi)
+// @Param account_id query int false "Only entries of this account"
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD)"
+// @Param category query []string false "Category names" collectionFormat(multi)
+// @Param category_id query []int false "Category IDs" collectionFormat(multi)
+// @Param tags query []string false "Tag names; entries with any of them, which excludes transfers" collectionFormat(multi)
+// @Param currency query string false "Only entries in this currency"
+// @Param min_amount query string false "Minimum absolute amount"
+// @Param max_amount query string false "Maximum absolute amount"
+// @Param description query string false "Substring of the description"
+// @Param sort query string false "date (default) or amount"
+// @Param order query string false "asc (default) or desc"
+// @Param limit query int false "Page size, 50 by default, at most 500"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Security BearerAuth
+// @Success 200 {object} listing.Page[transactions.Entry] "Page of entries"
+// @Failure 400 {string} string "Invalid parameters"
+// @Failure 500 {string} string "Failed to fetch transactions"
+// @Router /api/transactions [get]
+func GetTransactionsHandler(accounts repository.AccountRepository, categories repository.CategoryRepository, tags repository.TagRepository, households repository.HouseholdRepository, feed repository.FeedRepository, log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := r.Context().Value("userUID").(string)
+		query := r.URL.Query()
+
+		var filter repository.TransactionFilter
+		var msg string
+		var err error
+		householdID, _ := r.Context().Value("householdID").(int64)
+		if householdID != 0 {
+			filter, msg, err = listing.ParseHouseholdFilter(r.Context(), query, userUID, householdID, "", accounts, categories, tags, households)
+		} else {
+			filter, msg, err = listing.ParseTransactionFilter(r.Context(), query, userUID, "", accounts, categories, tags)
+		}
+		if err != nil {
+			log.Error("failed to parse transactions filter", slog.Any("error", err))
+			http.Error(w, "Failed to fetch transactions", http.StatusInternalServerError)
+			return
+		}
+		for _, value := range query["type"] {
+			for _, t := range strings.Split(value, ",") {
+				if !slices.Contains(entryTypes, t) {
+					msg = fmt.Sprintf("type must be one of %s", strings.Join(entryTypes, ", "))
+				}
+				filter.Types = append(filter.Types, t)
+			}
+		}
+		if msg != "" {
+			log.Warn("invalid transactions filter", slog.String("reason", msg))
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		page, err := feed.Find(r.Context(), filter)
+		if err != nil {
+			log.Error("failed to fetch transactions", slog.Any("error", err))
+			http.Error(w, "Failed to fetch transactions", http.StatusInternalServerError)
+			return
+		}
+
+		list := make([]Entry, 0, len(page.Entries))
+		for _, e := range page.Entries {
+			list = append(list, newEntry(e, householdID != 0))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(listing.NewPage(list, page.TotalCount, filter, page.Next))
+	}
+}
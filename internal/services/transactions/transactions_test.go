@@ -0,0 +1,228 @@
+package transactions
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/repository/sqlstore"
+	"tbank-go/internal/services/listing"
+	"tbank-go/internal/services/servicetest"
+	"tbank-go/internal/storage/storagetest"
+	"testing"
+)
+
+// summary describes an entry as "type amount balance", with the account ID when it is not the
+// default account, so that whole pages compare as one string.
+func summary(entries []Entry, defaultAccount int64) string {
+	var lines []string
+	for _, e := range entries {
+		line := fmt.Sprintf("%s %s %s", e.Type, e.Amount, e.Balance)
+		if e.AccountID != defaultAccount {
+			line += fmt.Sprintf(" @%d", e.AccountID)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "; ")
+}
+
+func TestGetTransactions(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		ctx := context.Background()
+		servicetest.CreateUser(t, repos, "alice")
+		servicetest.CreateUser(t, repos, "bob")
+		main := servicetest.DefaultAccount(t, repos, "alice").ID
+		savings := repository.Account{UserUID: "alice", Name: "Savings", Type: "savings", Balance: money.New(0, "RUB")}
+		dollars := repository.Account{UserUID: "alice", Name: "Dollars", Type: "cash", Balance: money.New(0, "USD")}
+		for _, account := range []*repository.Account{&savings, &dollars} {
+			if err := repos.Accounts.Create(ctx, account); err != nil {
+				t.Fatal(err)
+			}
+		}
+		coffee, err := repository.ResolveTags(ctx, repos.Tags, "alice", []string{"coffee"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, income := range []repository.Income{
+			{UserUID: "alice", AccountID: main, Category: "Salary", Amount: money.New(50000, "RUB"), Date: "2024-02-20"}, // before from
+			{UserUID: "alice", AccountID: main, Category: "Salary", Amount: money.New(100000, "RUB"), Date: "2024-03-01", Description: "March"},
+			{UserUID: "bob", Category: "Salary", Amount: money.New(70000, "RUB"), Date: "2024-03-02"},
+		} {
+			if err := repos.Incomes.Create(ctx, &income); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, expense := range []repository.Expense{
+			{UserUID: "alice", AccountID: main, Category: "Food", Amount: money.New(20000, "RUB"), Date: "2024-03-02", Tags: coffee},
+			{UserUID: "alice", AccountID: main, Category: "Taxi", Amount: money.New(5000, "RUB"), Date: "2024-03-05"},
+		} {
+			if err := repos.Expenses.Create(ctx, &expense); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, transfer := range []repository.Transfer{
+			{UserUID: "alice", FromAccountID: main, ToAccountID: savings.ID, Amount: money.New(30000, "RUB"), ToAmount: money.New(30000, "RUB"), Date: "2024-03-03"},
+			{UserUID: "alice", FromAccountID: main, ToAccountID: dollars.ID, Amount: money.New(10000, "RUB"), ToAmount: money.New(110, "USD"), Date: "2024-03-04"},
+		} {
+			if err := repos.Transfers.Create(ctx, &transfer); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		handler := GetTransactionsHandler(repos.Accounts, repos.Categories, repos.Tags, repos.Households, repos.Feed, servicetest.Discard)
+		get := func(query string, status int) listing.Page[Entry] {
+			t.Helper()
+			w := httptest.NewRecorder()
+			handler(w, servicetest.NewRequest(http.MethodGet, "/api/transactions?"+query, "", "alice"))
+			if status != http.StatusOK {
+				if w.Code != status {
+					t.Errorf("%s: status %d, want %d", query, w.Code, status)
+				}
+				return listing.Page[Entry]{}
+			}
+			return servicetest.Decode[listing.Page[Entry]](t, w, status)
+		}
+
+		// The February income is left out but still counts towards the balance.
+		page := get("from=2024-03-01", http.StatusOK)
+		want := fmt.Sprintf("income 1000.00 RUB 1500.00 RUB; expense -200.00 RUB 1300.00 RUB; transfer -300.00 RUB 1000.00 RUB; transfer 300.00 RUB 1300.00 RUB @%d; "+
+			"transfer -100.00 RUB 1200.00 RUB; transfer 1.10 USD 1.10 USD @%d; expense -50.00 RUB 1150.00 RUB", savings.ID, dollars.ID)
+		if got := summary(page.Items, main); got != want || page.TotalCount != 7 || page.NextCursor != "" {
+			t.Errorf("feed = %s (total_count %d), want %s", got, page.TotalCount, want)
+		}
+		if e := page.Items[1]; e.Category != "Food" || len(e.Tags) != 1 || e.Tags[0] != "coffee" {
+			t.Errorf("expense entry = %+v", e)
+		}
+		if e := page.Items[3]; e.CounterpartAccountID != main || e.Amount.Currency != "RUB" || len(e.Tags) != 0 {
+			t.Errorf("incoming transfer entry = %+v", e)
+		}
+
+		tests := []struct {
+			query string
+			want  string
+		}{
+			// Balances only add up the matching entries.
+			{query: "type=transfer", want: fmt.Sprintf("transfer -300.00 RUB -300.00 RUB; transfer 300.00 RUB 0.00 RUB @%d; transfer -100.00 RUB -100.00 RUB; transfer 1.10 USD 1.10 USD @%d", savings.ID, dollars.ID)},
+			{query: "type=income,expense&from=2024-03-02", want: "expense -200.00 RUB 1300.00 RUB; expense -50.00 RUB 1250.00 RUB"},
+			{query: fmt.Sprintf("account_id=%d", savings.ID), want: fmt.Sprintf("transfer 300.00 RUB 300.00 RUB @%d", savings.ID)},
+			{query: "currency=USD", want: fmt.Sprintf("transfer 1.10 USD 1.10 USD @%d", dollars.ID)},
+			{query: "min_amount=300&max_amount=300", want: fmt.Sprintf("transfer -300.00 RUB -300.00 RUB; transfer 300.00 RUB 0.00 RUB @%d", savings.ID)},
+			{query: "tags=coffee", want: "expense -200.00 RUB -200.00 RUB"},
+			{query: "from=2024-03-01&sort=amount&order=desc&limit=3", want: fmt.Sprintf("income 1000.00 RUB 1500.00 RUB; transfer 300.00 RUB 1300.00 RUB @%d; transfer -300.00 RUB 1000.00 RUB", savings.ID)},
+		}
+		for _, tt := range tests {
+			if got := summary(get(tt.query, http.StatusOK).Items, main); got != tt.want {
+				t.Errorf("%s: %s, want %s", tt.query, got, tt.want)
+			}
+		}
+
+		var pages []string
+		page = get("from=2024-03-01&order=desc&limit=3", http.StatusOK)
+		for len(pages) < 5 {
+			if page.TotalCount != 7 {
+				t.Errorf("total_count = %d, want 7", page.TotalCount)
+			}
+			var dates []string
+			for _, e := range page.Items {
+				dates = append(dates, e.Date[len("2024-03-"):])
+			}
+			pages = append(pages, strings.Join(dates, ","))
+			if page.NextCursor == "" {
+				break
+			}
+			page = get("from=2024-03-01&order=desc&limit=3&cursor="+url.QueryEscape(page.NextCursor), http.StatusOK)
+		}
+		if got := strings.Join(pages, "|"); got != "05,04,04|03,03,02|01" {
+			t.Errorf("pages = %s", got)
+		}
+
+		get("type=refund", http.StatusBadRequest)
+		get("tags=unknown", http.StatusBadRequest)
+		get("sort=amount&cursor="+url.QueryEscape(get("limit=1", http.StatusOK).NextCursor), http.StatusBadRequest)
+	})
+}
+
+func TestGetHouseholdTransactions(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		repos := sqlstore.New(db)
+		ctx := context.Background()
+		for _, uid := range []string{"alice", "bob"} {
+			servicetest.CreateUser(t, repos, uid)
+		}
+		household := servicetest.CreateHousehold(t, repos, "alice", "bob")
+		alice, bob := servicetest.DefaultAccount(t, repos, "alice").ID, servicetest.DefaultAccount(t, repos, "bob").ID
+
+		income := repository.Income{UserUID: "bob", AccountID: bob, Category: "Salary", Amount: money.New(100000, "RUB"), Date: "2024-03-03", HouseholdID: household}
+		if err := repos.Incomes.Create(ctx, &income); err != nil {
+			t.Fatal(err)
+		}
+		for _, expense := range []repository.Expense{
+			{UserUID: "alice", AccountID: alice, Category: "Food", Amount: money.New(10000, "RUB"), Date: "2024-03-01", HouseholdID: household},
+			{UserUID: "alice", AccountID: alice, Category: "Food", Amount: money.New(5000, "RUB"), Date: "2024-03-02"},
+			{UserUID: "bob", AccountID: bob, Category: "Taxi", Amount: money.New(3000, "RUB"), Date: "2024-03-04", HouseholdID: household},
+			{UserUID: "bob", AccountID: bob, Category: "Food", Amount: money.New(7000, "RUB"), Date: "2024-03-05"},
+		} {
+			category, err := repos.Categories.FindByName(ctx, expense.UserUID, repository.CategoryExpense, expense.Category)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expense.CategoryID = category.ID
+			if err := repos.Expenses.Create(ctx, &expense); err != nil {
+				t.Fatal(err)
+			}
+		}
+		savings := repository.Account{UserUID: "alice", Name: "Savings", Type: "savings", Balance: money.New(0, "RUB")}
+		if err := repos.Accounts.Create(ctx, &savings); err != nil {
+			t.Fatal(err)
+		}
+		transfer := repository.Transfer{UserUID: "alice", FromAccountID: alice, ToAccountID: savings.ID, Amount: money.New(2000, "RUB"), ToAmount: money.New(2000, "RUB"), Date: "2024-03-01"}
+		if err := repos.Transfers.Create(ctx, &transfer); err != nil {
+			t.Fatal(err)
+		}
+
+		handler := GetTransactionsHandler(repos.Accounts, repos.Categories, repos.Tags, repos.Households, repos.Feed, servicetest.Discard)
+		get := func(query string, householdID int64) []Entry {
+			t.Helper()
+			w := httptest.NewRecorder()
+			r := servicetest.NewRequest(http.MethodGet, "/api/transactions?"+query, "", "alice")
+			if householdID != 0 {
+				r = servicetest.WithHousehold(r, householdID, repository.RoleOwner)
+			}
+			handler(w, r)
+			return servicetest.Decode[listing.Page[Entry]](t, w, http.StatusOK).Items
+		}
+		describe := func(entries []Entry) string {
+			var lines []string
+			for _, e := range entries {
+				lines = append(lines, fmt.Sprintf("%s %s %s %s", e.UserUID, e.Type, e.Amount, e.Balance))
+			}
+			return strings.Join(lines, "; ")
+		}
+
+		// The shared records of every member, without transfers, and the personal ones of none.
+		tests := []struct {
+			query     string
+			household int64
+			want      string
+		}{
+			{household: household, want: "alice expense -100.00 RUB -100.00 RUB; bob income 1000.00 RUB 900.00 RUB; bob expense -30.00 RUB 870.00 RUB"},
+			// Food is the Food category of each member.
+			{query: "category=Food", household: household, want: "alice expense -100.00 RUB -100.00 RUB"},
+			{query: "type=transfer", household: household, want: ""},
+			// The personal feed has the user's own records, shared or not, and no authors.
+			{want: " expense -100.00 RUB -100.00 RUB;  transfer -20.00 RUB -120.00 RUB;  transfer 20.00 RUB -100.00 RUB;  expense -50.00 RUB -150.00 RUB"},
+		}
+		for _, tt := range tests {
+			if got := describe(get(tt.query, tt.household)); got != tt.want {
+				t.Errorf("%q in household %d: %s, want %s", tt.query, tt.household, got, tt.want)
+			}
+		}
+	})
+}
@@ -1,10 +1,11 @@
 package users
 
 import (
-	"database/sql"
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
 )
 
 // GetUserInfoHandler fetches all user information
@@ -16,29 +17,35 @@ import (
 // @Success 200 {object} map[string]interface{} "User info"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /api/users/ [get]
-func GetUserInfoHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+func GetUserInfoHandler(users repository.UserRepository, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userUID := r.Context().Value("userUID").(string)
 
-		query := `SELECT id, uid, username, first_name, second_name, incomes_balance, expenses_balance FROM users WHERE uid = ?`
-		row := db.QueryRow(query, userUID)
-
-		var user struct {
-			ID              int    `json:"id"`
-			UID             string `json:"uid"`
-			Username        string `json:"username"`
-			FirstName       string `json:"first_name,omitempty"`
-			SecondName      string `json:"second_name,omitempty"`
-			IncomesBalance  int    `json:"incomes_balance"`
-			ExpensesBalance int    `json:"expenses_balance"`
-		}
-
-		if err := row.Scan(&user.ID, &user.UID, &user.Username, &user.FirstName, &user.SecondName, &user.IncomesBalance, &user.ExpensesBalance); err != nil {
+		stored, err := users.GetByUID(r.Context(), userUID)
+		if err != nil {
 			log.Error("failed to fetch user info", slog.Any("error", err))
 			http.Error(w, "Failed to fetch user info", http.StatusInternalServerError)
 			return
 		}
 
+		user := struct {
+			ID              int64       `json:"id"`
+			UID             string      `json:"uid"`
+			Username        string      `json:"username"`
+			FirstName       string      `json:"first_name,omitempty"`
+			SecondName      string      `json:"second_name,omitempty"`
+			IncomesBalance  money.Money `json:"incomes_balance"`
+			ExpensesBalance money.Money `json:"expenses_balance"`
+		}{
+			ID:              stored.ID,
+			UID:             stored.UID,
+			Username:        stored.Username,
+			FirstName:       stored.FirstName,
+			SecondName:      stored.SecondName,
+			IncomesBalance:  money.New(stored.IncomesBalance, stored.Currency),
+			ExpensesBalance: money.New(stored.ExpensesBalance, stored.Currency),
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(user)
@@ -1,10 +1,10 @@
 package users
 
 import (
-	"database/sql"
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"tbank-go/internal/repository"
 )
 
 // UpdateUserNamesRequest defines the structure for the request body
@@ -25,7 +25,7 @@ type UpdateUserNamesRequest struct {
 // @Failure 400 {object} map[string]string "Invalid input"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /api/users/ [put]
-func UpdateUserNamesHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
+func UpdateUserNamesHandler(users repository.UserRepository, log *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Parse the request body
 		var data UpdateUserNamesRequest
@@ -43,9 +43,7 @@ func UpdateUserNamesHandler(db *sql.DB, log *slog.Logger) http.HandlerFunc {
 
 		userUID := r.Context().Value("userUID").(string)
 
-		query := `UPDATE users SET first_name = ?, second_name = ? WHERE uid = ?`
-		_, err := db.Exec(query, data.FirstName, data.SecondName, userUID)
-		if err != nil {
+		if err := users.UpdateNames(r.Context(), userUID, data.FirstName, data.SecondName); err != nil {
 			log.Error("failed to update user names", slog.Any("error", err))
 			http.Error(w, "Failed to update user names", http.StatusInternalServerError)
 			return
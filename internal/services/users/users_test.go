@@ -0,0 +1,90 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"tbank-go/internal/money"
+	"tbank-go/internal/repository"
+	"tbank-go/internal/repository/memory"
+	"tbank-go/internal/services/servicetest"
+	"testing"
+)
+
+func TestGetUserInfo(t *testing.T) {
+	repos := memory.New()
+	ctx := context.Background()
+	user := repository.User{UID: "alice-uid", Username: "alice", FirstName: "Alice", Currency: "EUR"}
+	if err := repos.Users.Create(ctx, &user); err != nil {
+		t.Fatal(err)
+	}
+	account, err := repos.Accounts.GetDefault(ctx, user.UID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Incomes.Create(ctx, &repository.Income{UserUID: user.UID, AccountID: account.ID,
+		Amount: money.New(250000, "EUR"), Date: "2024-03-01"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Expenses.Create(ctx, &repository.Expense{UserUID: user.UID, AccountID: account.ID,
+		Amount: money.New(4550, "EUR"), Date: "2024-03-02"}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/users/", nil)
+	r = r.WithContext(context.WithValue(r.Context(), "userUID", user.UID))
+	w := httptest.NewRecorder()
+	GetUserInfoHandler(repos.Users, servicetest.Discard)(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s)", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	var got struct {
+		ID              int64       `json:"id"`
+		UID             string      `json:"uid"`
+		Username        string      `json:"username"`
+		FirstName       string      `json:"first_name"`
+		IncomesBalance  money.Money `json:"incomes_balance"`
+		ExpensesBalance money.Money `json:"expenses_balance"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	if got.ID != user.ID || got.UID != "alice-uid" || got.Username != "alice" || got.FirstName != "Alice" {
+		t.Errorf("user = %+v", got)
+	}
+	if got.IncomesBalance != money.New(250000, "EUR") {
+		t.Errorf("incomes_balance = %v, want 2500.00 EUR", got.IncomesBalance)
+	}
+	if got.ExpensesBalance != money.New(4550, "EUR") {
+		t.Errorf("expenses_balance = %v, want 45.50 EUR", got.ExpensesBalance)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &fields); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fields["second_name"]; ok {
+		t.Errorf("empty second_name is not omitted: %s", w.Body)
+	}
+	if _, ok := fields["password_hash"]; ok {
+		t.Errorf("password hash is exposed: %s", w.Body)
+	}
+}
+
+func TestGetUserInfoUnknownUser(t *testing.T) {
+	repos := memory.New()
+	r := httptest.NewRequest(http.MethodGet, "/api/users/", nil)
+	r = r.WithContext(context.WithValue(r.Context(), "userUID", "missing"))
+	w := httptest.NewRecorder()
+	GetUserInfoHandler(repos.Users, servicetest.Discard)(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", w.Code)
+	}
+}
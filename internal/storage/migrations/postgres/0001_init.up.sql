@@ -0,0 +1,31 @@
+-- Базовая схема для PostgreSQL. Даты хранятся строками YYYY-MM-DD, как и в SQLite,
+-- чтобы запросы с BETWEEN и сортировкой работали одинаково.
+CREATE TABLE IF NOT EXISTS users (
+	id BIGSERIAL PRIMARY KEY,
+	uid TEXT NOT NULL UNIQUE,
+	username TEXT NOT NULL UNIQUE,
+	password TEXT NOT NULL,
+	first_name TEXT,
+	second_name TEXT,
+	incomes_balance DOUBLE PRECISION,
+	expenses_balance DOUBLE PRECISION,
+	registered_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS income (
+	id BIGSERIAL PRIMARY KEY,
+	user_uid TEXT NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+	category TEXT NOT NULL,
+	amount DOUBLE PRECISION NOT NULL,
+	date TEXT NOT NULL,
+	description TEXT
+);
+
+CREATE TABLE IF NOT EXISTS expenses (
+	id BIGSERIAL PRIMARY KEY,
+	user_uid TEXT NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+	category TEXT NOT NULL,
+	amount DOUBLE PRECISION NOT NULL,
+	date TEXT NOT NULL,
+	description TEXT
+);
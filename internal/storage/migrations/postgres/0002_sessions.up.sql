@@ -0,0 +1,15 @@
+-- Выданные refresh-токены (храним только хеш)
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	id BIGSERIAL PRIMARY KEY,
+	user_uid TEXT NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+	token_hash TEXT NOT NULL UNIQUE,
+	expires_at TEXT NOT NULL,
+	revoked INTEGER NOT NULL DEFAULT 0,
+	created_at TEXT NOT NULL
+);
+
+-- Отозванные access-токены (по jti) до истечения их срока действия
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	jti TEXT PRIMARY KEY,
+	expires_at TEXT NOT NULL
+);
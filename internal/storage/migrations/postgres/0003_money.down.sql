@@ -0,0 +1,10 @@
+ALTER TABLE income DROP COLUMN currency;
+ALTER TABLE income ALTER COLUMN amount TYPE DOUBLE PRECISION USING amount / 100.0;
+
+ALTER TABLE expenses DROP COLUMN currency;
+ALTER TABLE expenses ALTER COLUMN amount TYPE DOUBLE PRECISION USING amount / 100.0;
+
+ALTER TABLE users ALTER COLUMN incomes_balance TYPE DOUBLE PRECISION USING incomes_balance / 100.0;
+ALTER TABLE users ALTER COLUMN expenses_balance TYPE DOUBLE PRECISION USING expenses_balance / 100.0;
+
+ALTER TABLE users DROP COLUMN currency;
@@ -0,0 +1,15 @@
+-- Суммы хранятся в минимальных единицах валюты (копейках) вместе с кодом валюты.
+ALTER TABLE users ADD COLUMN currency TEXT NOT NULL DEFAULT 'RUB';
+
+ALTER TABLE income ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+ALTER TABLE income ADD COLUMN currency TEXT NOT NULL DEFAULT 'RUB';
+
+ALTER TABLE expenses ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+ALTER TABLE expenses ADD COLUMN currency TEXT NOT NULL DEFAULT 'RUB';
+
+ALTER TABLE users ALTER COLUMN incomes_balance TYPE BIGINT USING 0;
+ALTER TABLE users ALTER COLUMN expenses_balance TYPE BIGINT USING 0;
+
+UPDATE users SET
+	incomes_balance = (SELECT COALESCE(SUM(amount), 0) FROM income WHERE income.user_uid = users.uid),
+	expenses_balance = (SELECT COALESCE(SUM(amount), 0) FROM expenses WHERE expenses.user_uid = users.uid);
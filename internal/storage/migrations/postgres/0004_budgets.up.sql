@@ -0,0 +1,16 @@
+-- Лимиты расходов по категориям. carry_over: none, unused, all
+CREATE TABLE IF NOT EXISTS budgets (
+	id BIGSERIAL PRIMARY KEY,
+	user_uid TEXT NOT NULL,
+	category TEXT NOT NULL,
+	period TEXT NOT NULL,
+	amount BIGINT NOT NULL,
+	currency TEXT NOT NULL,
+	carry_over TEXT NOT NULL DEFAULT 'none',
+	start_date TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	UNIQUE(user_uid, category, period),
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_expenses_user_category_date ON expenses(user_uid, category, date);
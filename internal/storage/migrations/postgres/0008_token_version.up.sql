@@ -0,0 +1,3 @@
+-- Версия токенов пользователя попадает в каждый JWT. Увеличение версии (например, при смене пароля)
+-- делает недействительными все ранее выданные токены доступа.
+ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
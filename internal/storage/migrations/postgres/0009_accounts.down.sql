@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_expenses_account;
+DROP INDEX IF EXISTS idx_income_account;
+ALTER TABLE expenses DROP COLUMN account_id;
+ALTER TABLE income DROP COLUMN account_id;
+
+DROP INDEX IF EXISTS idx_transfers_user_date;
+DROP TABLE IF EXISTS transfers;
+DROP INDEX IF EXISTS idx_accounts_default;
+DROP INDEX IF EXISTS idx_accounts_user;
+DROP TABLE IF EXISTS accounts;
@@ -0,0 +1,9 @@
+-- Курсы валют ЦБ РФ: сколько рублей стоят nominal единиц валюты на дату.
+-- value хранится в десятитысячных долях рубля, как в ежедневном XML ЦБ (4 знака после запятой).
+CREATE TABLE IF NOT EXISTS exchange_rates (
+	date TEXT NOT NULL,
+	currency TEXT NOT NULL,
+	nominal BIGINT NOT NULL,
+	value BIGINT NOT NULL,
+	PRIMARY KEY (currency, date)
+);
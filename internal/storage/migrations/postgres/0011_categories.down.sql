@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_expenses_category_id;
+DROP INDEX IF EXISTS idx_income_category_id;
+ALTER TABLE expenses DROP COLUMN category_id;
+ALTER TABLE income DROP COLUMN category_id;
+
+DROP INDEX IF EXISTS idx_categories_parent;
+DROP INDEX IF EXISTS idx_categories_user_name;
+DROP TABLE IF EXISTS categories;
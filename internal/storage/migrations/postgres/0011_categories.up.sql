@@ -0,0 +1,49 @@
+-- Категории доходов и расходов пользователя. Иерархия двухуровневая: parent_id указывает
+-- на категорию верхнего уровня того же типа. name_key — имя в нижнем регистре со схлопнутыми
+-- пробелами, по нему имена уникальны в пределах пользователя и типа ("Food" и "food" — одна категория).
+CREATE TABLE IF NOT EXISTS categories (
+	id BIGSERIAL PRIMARY KEY,
+	user_uid TEXT NOT NULL,
+	parent_id BIGINT,
+	name TEXT NOT NULL,
+	name_key TEXT NOT NULL,
+	type TEXT NOT NULL,
+	icon TEXT NOT NULL DEFAULT '',
+	color TEXT NOT NULL DEFAULT '',
+	created_at TEXT NOT NULL,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE,
+	FOREIGN KEY(parent_id) REFERENCES categories(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_user_name ON categories(user_uid, type, name_key);
+CREATE INDEX IF NOT EXISTS idx_categories_parent ON categories(parent_id);
+
+-- Текстовый столбец category остаётся как денормализованное имя категории.
+ALTER TABLE income ADD COLUMN category_id BIGINT REFERENCES categories(id);
+ALTER TABLE expenses ADD COLUMN category_id BIGINT REFERENCES categories(id);
+CREATE INDEX IF NOT EXISTS idx_income_category_id ON income(category_id);
+CREATE INDEX IF NOT EXISTS idx_expenses_category_id ON expenses(category_id);
+
+-- Категории существующих пользователей собираем из их операций, бюджетов и регулярных платежей.
+INSERT INTO categories (user_uid, name, name_key, type, created_at)
+SELECT user_uid, MIN(name), LOWER(name), type, to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+FROM (
+	SELECT user_uid, TRIM(category) AS name, 'income' AS type FROM income
+	UNION ALL
+	SELECT user_uid, TRIM(category), 'expense' FROM expenses
+	UNION ALL
+	SELECT user_uid, TRIM(category), 'expense' FROM budgets
+	UNION ALL
+	SELECT user_uid, TRIM(category), type FROM recurring_rules
+) AS used
+WHERE name <> ''
+GROUP BY user_uid, type, LOWER(name);
+
+UPDATE income SET category_id = (SELECT id FROM categories c WHERE c.user_uid = income.user_uid
+                                 AND c.type = 'income' AND c.name_key = LOWER(TRIM(income.category)));
+UPDATE expenses SET category_id = (SELECT id FROM categories c WHERE c.user_uid = expenses.user_uid
+                                   AND c.type = 'expense' AND c.name_key = LOWER(TRIM(expenses.category)));
+
+-- Имя операции приводим к имени её категории.
+UPDATE income SET category = (SELECT name FROM categories c WHERE c.id = income.category_id) WHERE category_id IS NOT NULL;
+UPDATE expenses SET category = (SELECT name FROM categories c WHERE c.id = expenses.category_id) WHERE category_id IS NOT NULL;
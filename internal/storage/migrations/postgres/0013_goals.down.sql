@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_transfers_goal;
+ALTER TABLE transfers DROP COLUMN goal_id;
+
+DROP INDEX IF EXISTS idx_goals_user;
+DROP TABLE IF EXISTS goals;
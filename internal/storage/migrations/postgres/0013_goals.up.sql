@@ -0,0 +1,21 @@
+-- Цели накопления: сколько и к какому сроку нужно отложить.
+-- account_id — необязательный счёт, на который поступают взносы; валюта цели совпадает с его валютой.
+CREATE TABLE IF NOT EXISTS goals (
+	id BIGSERIAL PRIMARY KEY,
+	user_uid TEXT NOT NULL,
+	name TEXT NOT NULL,
+	target_amount BIGINT NOT NULL,
+	currency TEXT NOT NULL,
+	deadline TEXT,
+	account_id BIGINT,
+	created_at TEXT NOT NULL,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE,
+	FOREIGN KEY(account_id) REFERENCES accounts(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_goals_user ON goals(user_uid);
+
+-- Взносы в цель — это обычные переводы, помеченные goal_id.
+-- При удалении цели переводы остаются, теряется только пометка.
+ALTER TABLE transfers ADD COLUMN goal_id BIGINT REFERENCES goals(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_transfers_goal ON transfers(goal_id);
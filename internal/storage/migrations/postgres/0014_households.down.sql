@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS idx_budgets_household;
+DROP INDEX IF EXISTS idx_budgets_personal;
+DELETE FROM budgets WHERE household_id IS NOT NULL;
+ALTER TABLE budgets DROP COLUMN household_id;
+ALTER TABLE budgets ADD CONSTRAINT budgets_user_uid_category_period_key UNIQUE (user_uid, category, period);
+
+DROP INDEX IF EXISTS idx_expenses_household_category_date;
+DROP INDEX IF EXISTS idx_expenses_household_date;
+DROP INDEX IF EXISTS idx_income_household_date;
+ALTER TABLE expenses DROP COLUMN household_id;
+ALTER TABLE income DROP COLUMN household_id;
+
+DROP INDEX IF EXISTS idx_household_invites_invitee;
+DROP TABLE IF EXISTS household_invites;
+DROP INDEX IF EXISTS idx_household_members_user;
+DROP TABLE IF EXISTS household_members;
+DROP TABLE IF EXISTS households;
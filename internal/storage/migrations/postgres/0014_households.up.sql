@@ -0,0 +1,47 @@
+-- Домохозяйства: несколько пользователей ведут общие доходы, расходы и бюджеты.
+CREATE TABLE IF NOT EXISTS households (
+	id BIGSERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	created_at TEXT NOT NULL
+);
+
+-- Роли: owner управляет составом, editor меняет общие записи, viewer только смотрит.
+CREATE TABLE IF NOT EXISTS household_members (
+	household_id BIGINT NOT NULL,
+	user_uid TEXT NOT NULL,
+	role TEXT NOT NULL,
+	joined_at TEXT NOT NULL,
+	PRIMARY KEY(household_id, user_uid),
+	FOREIGN KEY(household_id) REFERENCES households(id) ON DELETE CASCADE,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_household_members_user ON household_members(user_uid);
+
+-- Приглашение ждёт, пока приглашённый его не примет или не отклонит.
+CREATE TABLE IF NOT EXISTS household_invites (
+	id BIGSERIAL PRIMARY KEY,
+	household_id BIGINT NOT NULL,
+	invitee_uid TEXT NOT NULL,
+	inviter_uid TEXT NOT NULL,
+	role TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	UNIQUE(household_id, invitee_uid),
+	FOREIGN KEY(household_id) REFERENCES households(id) ON DELETE CASCADE,
+	FOREIGN KEY(invitee_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_household_invites_invitee ON household_invites(invitee_uid);
+
+-- Доход или расход с household_id виден всем участникам домохозяйства.
+ALTER TABLE income ADD COLUMN household_id BIGINT REFERENCES households(id) ON DELETE SET NULL;
+ALTER TABLE expenses ADD COLUMN household_id BIGINT REFERENCES households(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_income_household_date ON income(household_id, date);
+CREATE INDEX IF NOT EXISTS idx_expenses_household_date ON expenses(household_id, date);
+CREATE INDEX IF NOT EXISTS idx_expenses_household_category_date ON expenses(household_id, category, date);
+
+-- Бюджет домохозяйства уникален в пределах домохозяйства, личный — в пределах пользователя.
+ALTER TABLE budgets ADD COLUMN household_id BIGINT REFERENCES households(id) ON DELETE CASCADE;
+ALTER TABLE budgets DROP CONSTRAINT IF EXISTS budgets_user_uid_category_period_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_personal ON budgets(user_uid, category, period) WHERE household_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_household ON budgets(household_id, category, period) WHERE household_id IS NOT NULL;
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_settlements_to;
+DROP INDEX IF EXISTS idx_settlements_from;
+DROP INDEX IF EXISTS idx_settlements_user;
+DROP TABLE IF EXISTS settlements;
+
+DROP INDEX IF EXISTS idx_split_shares_user;
+DROP INDEX IF EXISTS idx_split_shares_split;
+DROP TABLE IF EXISTS split_shares;
+
+DROP INDEX IF EXISTS idx_splits_payer;
+DROP INDEX IF EXISTS idx_splits_user;
+DROP TABLE IF EXISTS splits;
+
+DROP TABLE IF EXISTS contacts;
@@ -0,0 +1,62 @@
+-- Контакты — люди без учётной записи, с которыми пользователь делит расходы.
+CREATE TABLE IF NOT EXISTS contacts (
+	id BIGSERIAL PRIMARY KEY,
+	user_uid TEXT NOT NULL,
+	name TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	UNIQUE(user_uid, name),
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+-- Разделённый расход: один участник заплатил amount, доли остальных записаны в split_shares.
+-- Плательщик и участники — либо пользователь (*_uid), либо контакт создателя (*contact_id).
+-- expense_id — необязательный расход создателя, который делится; при его удалении связь обнуляется.
+CREATE TABLE IF NOT EXISTS splits (
+	id BIGSERIAL PRIMARY KEY,
+	user_uid TEXT NOT NULL,
+	expense_id BIGINT UNIQUE REFERENCES expenses(id) ON DELETE SET NULL,
+	payer_uid TEXT,
+	payer_contact_id BIGINT REFERENCES contacts(id),
+	description TEXT NOT NULL DEFAULT '',
+	amount BIGINT NOT NULL,
+	currency TEXT NOT NULL,
+	method TEXT NOT NULL,
+	date TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_splits_user ON splits(user_uid);
+CREATE INDEX IF NOT EXISTS idx_splits_payer ON splits(payer_uid);
+
+CREATE TABLE IF NOT EXISTS split_shares (
+	id BIGSERIAL PRIMARY KEY,
+	split_id BIGINT NOT NULL,
+	user_uid TEXT,
+	contact_id BIGINT REFERENCES contacts(id),
+	amount BIGINT NOT NULL,
+	FOREIGN KEY(split_id) REFERENCES splits(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_split_shares_split ON split_shares(split_id);
+CREATE INDEX IF NOT EXISTS idx_split_shares_user ON split_shares(user_uid);
+
+-- Возврат долга: from заплатил to. Записывает его user_uid.
+CREATE TABLE IF NOT EXISTS settlements (
+	id BIGSERIAL PRIMARY KEY,
+	user_uid TEXT NOT NULL,
+	from_uid TEXT,
+	from_contact_id BIGINT REFERENCES contacts(id),
+	to_uid TEXT,
+	to_contact_id BIGINT REFERENCES contacts(id),
+	amount BIGINT NOT NULL,
+	currency TEXT NOT NULL,
+	date TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	created_at TEXT NOT NULL,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_settlements_user ON settlements(user_uid);
+CREATE INDEX IF NOT EXISTS idx_settlements_from ON settlements(from_uid);
+CREATE INDEX IF NOT EXISTS idx_settlements_to ON settlements(to_uid);
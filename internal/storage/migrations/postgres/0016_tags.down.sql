@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_transaction_tags_tag;
+DROP TABLE IF EXISTS transaction_tags;
+
+DROP INDEX IF EXISTS idx_tags_user_name;
+DROP TABLE IF EXISTS tags;
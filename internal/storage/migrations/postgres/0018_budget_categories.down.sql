@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_budgets_personal;
+DROP INDEX IF EXISTS idx_budgets_household;
+DROP INDEX IF EXISTS idx_budgets_category_id;
+ALTER TABLE budgets DROP COLUMN category_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_personal ON budgets(user_uid, category, period) WHERE household_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_household ON budgets(household_id, category, period) WHERE household_id IS NOT NULL;
@@ -0,0 +1,29 @@
+-- Бюджет ссылается на категорию расходов по id, столбец category остаётся денормализованным
+-- именем, как у операций.
+ALTER TABLE budgets ADD COLUMN category_id BIGINT REFERENCES categories(id);
+CREATE INDEX IF NOT EXISTS idx_budgets_category_id ON budgets(category_id);
+
+-- Бюджеты, созданные после 0011 с новым именем, могли остаться без категории.
+INSERT INTO categories (user_uid, name, name_key, type, created_at)
+SELECT user_uid, MIN(TRIM(category)), LOWER(TRIM(category)), 'expense', to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+FROM budgets b
+WHERE TRIM(category) <> '' AND NOT EXISTS (
+	SELECT 1 FROM categories c WHERE c.user_uid = b.user_uid AND c.type = 'expense' AND c.name_key = LOWER(TRIM(b.category))
+)
+GROUP BY user_uid, LOWER(TRIM(category));
+
+UPDATE budgets SET category_id = (SELECT id FROM categories c WHERE c.user_uid = budgets.user_uid
+                                  AND c.type = 'expense' AND c.name_key = LOWER(TRIM(budgets.category)));
+UPDATE budgets SET category = (SELECT name FROM categories c WHERE c.id = budgets.category_id) WHERE category_id IS NOT NULL;
+
+-- Бюджет уникален по категории, а не по имени: имена "Food" и "food " вели к одной категории.
+DELETE FROM budgets WHERE EXISTS (
+	SELECT 1 FROM budgets other
+	WHERE other.id < budgets.id AND other.category_id = budgets.category_id AND other.period = budgets.period
+	  AND (other.household_id = budgets.household_id
+	       OR (other.household_id IS NULL AND budgets.household_id IS NULL AND other.user_uid = budgets.user_uid))
+);
+DROP INDEX IF EXISTS idx_budgets_personal;
+DROP INDEX IF EXISTS idx_budgets_household;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_personal ON budgets(user_uid, category_id, period) WHERE household_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_household ON budgets(household_id, category_id, period) WHERE household_id IS NOT NULL;
@@ -0,0 +1,33 @@
+-- Базовая схема. IF NOT EXISTS позволяет принять базы,
+-- созданные до появления миграций.
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	uid TEXT NOT NULL UNIQUE,
+	username TEXT NOT NULL UNIQUE,
+	password TEXT NOT NULL,
+	first_name TEXT,
+	second_name TEXT,
+	incomes_balance INTEGER,
+	expenses_balance INTEGER,
+	registered_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS income (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_uid TEXT NOT NULL,
+	category TEXT NOT NULL,
+	amount REAL NOT NULL,
+	date TEXT NOT NULL,
+	description TEXT,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS expenses (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_uid TEXT NOT NULL,
+	category TEXT NOT NULL,
+	amount REAL NOT NULL,
+	date TEXT NOT NULL,
+	description TEXT,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
@@ -0,0 +1,37 @@
+CREATE TABLE income_old (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_uid TEXT NOT NULL,
+	category TEXT NOT NULL,
+	amount REAL NOT NULL,
+	date TEXT NOT NULL,
+	description TEXT,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+INSERT INTO income_old (id, user_uid, category, amount, date, description)
+SELECT id, user_uid, category, amount / 100.0, date, description FROM income;
+
+DROP TABLE income;
+ALTER TABLE income_old RENAME TO income;
+
+CREATE TABLE expenses_old (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_uid TEXT NOT NULL,
+	category TEXT NOT NULL,
+	amount REAL NOT NULL,
+	date TEXT NOT NULL,
+	description TEXT,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+INSERT INTO expenses_old (id, user_uid, category, amount, date, description)
+SELECT id, user_uid, category, amount / 100.0, date, description FROM expenses;
+
+DROP TABLE expenses;
+ALTER TABLE expenses_old RENAME TO expenses;
+
+UPDATE users SET
+	incomes_balance = CAST((SELECT COALESCE(SUM(amount), 0) FROM income WHERE income.user_uid = users.uid) AS INTEGER),
+	expenses_balance = CAST((SELECT COALESCE(SUM(amount), 0) FROM expenses WHERE expenses.user_uid = users.uid) AS INTEGER);
+
+ALTER TABLE users DROP COLUMN currency;
@@ -0,0 +1,41 @@
+-- Суммы хранятся в минимальных единицах валюты (копейках) вместе с кодом валюты.
+ALTER TABLE users ADD COLUMN currency TEXT NOT NULL DEFAULT 'RUB';
+
+CREATE TABLE income_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_uid TEXT NOT NULL,
+	category TEXT NOT NULL,
+	amount INTEGER NOT NULL,
+	currency TEXT NOT NULL DEFAULT 'RUB',
+	date TEXT NOT NULL,
+	description TEXT,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+INSERT INTO income_new (id, user_uid, category, amount, currency, date, description)
+SELECT id, user_uid, category, CAST(ROUND(amount * 100) AS INTEGER), 'RUB', date, description FROM income;
+
+DROP TABLE income;
+ALTER TABLE income_new RENAME TO income;
+
+CREATE TABLE expenses_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_uid TEXT NOT NULL,
+	category TEXT NOT NULL,
+	amount INTEGER NOT NULL,
+	currency TEXT NOT NULL DEFAULT 'RUB',
+	date TEXT NOT NULL,
+	description TEXT,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+INSERT INTO expenses_new (id, user_uid, category, amount, currency, date, description)
+SELECT id, user_uid, category, CAST(ROUND(amount * 100) AS INTEGER), 'RUB', date, description FROM expenses;
+
+DROP TABLE expenses;
+ALTER TABLE expenses_new RENAME TO expenses;
+
+-- Старые счётчики накапливали ошибку округления, поэтому пересчитываем их по записям.
+UPDATE users SET
+	incomes_balance = (SELECT COALESCE(SUM(amount), 0) FROM income WHERE income.user_uid = users.uid),
+	expenses_balance = (SELECT COALESCE(SUM(amount), 0) FROM expenses WHERE expenses.user_uid = users.uid);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_expenses_user_category_date;
+DROP TABLE IF EXISTS budgets;
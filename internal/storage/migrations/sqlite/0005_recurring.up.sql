@@ -0,0 +1,31 @@
+-- Правила повторяющихся операций. freq: daily, weekly, monthly
+CREATE TABLE IF NOT EXISTS recurring_rules (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_uid TEXT NOT NULL,
+	type TEXT NOT NULL,
+	category TEXT NOT NULL,
+	amount INTEGER NOT NULL,
+	currency TEXT NOT NULL,
+	description TEXT,
+	freq TEXT NOT NULL,
+	freq_interval INTEGER NOT NULL DEFAULT 1,
+	by_month_day INTEGER NOT NULL DEFAULT 0,
+	by_weekday INTEGER NOT NULL DEFAULT 0,
+	start_date TEXT NOT NULL,
+	until_date TEXT,
+	max_count INTEGER NOT NULL DEFAULT 0,
+	materialized_through TEXT,
+	created_at TEXT NOT NULL,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+-- Уже созданные операции. Первичный ключ гарантирует, что каждое
+-- повторение будет записано ровно один раз, даже после перезапуска.
+CREATE TABLE IF NOT EXISTS recurring_occurrences (
+	rule_id INTEGER NOT NULL,
+	occurrence_date TEXT NOT NULL,
+	transaction_id INTEGER NOT NULL,
+	created_at TEXT NOT NULL,
+	PRIMARY KEY(rule_id, occurrence_date),
+	FOREIGN KEY(rule_id) REFERENCES recurring_rules(id) ON DELETE CASCADE
+);
@@ -0,0 +1,28 @@
+-- TOTP (RFC 6238). Пока totp_enabled = 0, секрет считается неподтверждённым.
+-- totp_last_step хранит последний принятый временной шаг и защищает от повторного ввода кода.
+ALTER TABLE users ADD COLUMN totp_secret TEXT;
+ALTER TABLE users ADD COLUMN totp_enabled INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN totp_last_step INTEGER NOT NULL DEFAULT 0;
+
+-- Одноразовые коды восстановления (храним только хеш)
+CREATE TABLE IF NOT EXISTS recovery_codes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_uid TEXT NOT NULL,
+	code_hash TEXT NOT NULL,
+	used_at TEXT,
+	created_at TEXT NOT NULL,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user ON recovery_codes(user_uid);
+
+-- Второй шаг входа: токен выдаётся после проверки пароля и обменивается на пару токенов по коду
+CREATE TABLE IF NOT EXISTS mfa_challenges (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_uid TEXT NOT NULL,
+	token_hash TEXT NOT NULL UNIQUE,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	expires_at TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+-- Защита от перебора пароля: счётчик неудачных попыток входа подряд
+-- и момент (RFC 3339), до которого вход заблокирован.
+ALTER TABLE users ADD COLUMN failed_logins INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN locked_until TEXT;
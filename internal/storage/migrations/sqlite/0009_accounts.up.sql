@@ -0,0 +1,54 @@
+-- Счета пользователя: наличные, дебетовые и кредитные карты, накопительные счета.
+-- balance хранится в минимальных единицах валюты счёта и может быть отрицательным (кредитная карта).
+CREATE TABLE IF NOT EXISTS accounts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_uid TEXT NOT NULL,
+	name TEXT NOT NULL,
+	type TEXT NOT NULL,
+	currency TEXT NOT NULL,
+	balance INTEGER NOT NULL DEFAULT 0,
+	is_default INTEGER NOT NULL DEFAULT 0,
+	created_at TEXT NOT NULL,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_accounts_user ON accounts(user_uid);
+-- У каждого пользователя ровно один счёт по умолчанию
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_default ON accounts(user_uid) WHERE is_default = 1;
+
+-- Переводы между счетами не считаются ни доходом, ни расходом.
+-- amount списывается в валюте счёта-источника, to_amount зачисляется в валюте счёта-получателя.
+CREATE TABLE IF NOT EXISTS transfers (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_uid TEXT NOT NULL,
+	from_account_id INTEGER NOT NULL,
+	to_account_id INTEGER NOT NULL,
+	amount INTEGER NOT NULL,
+	currency TEXT NOT NULL,
+	to_amount INTEGER NOT NULL,
+	to_currency TEXT NOT NULL,
+	date TEXT NOT NULL,
+	description TEXT,
+	created_at TEXT NOT NULL,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE,
+	FOREIGN KEY(from_account_id) REFERENCES accounts(id),
+	FOREIGN KEY(to_account_id) REFERENCES accounts(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfers_user_date ON transfers(user_uid, date);
+
+-- SQLite не умеет удалять столбец с внешним ключом, поэтому связь со счётом
+-- поддерживается приложением (в PostgreSQL это настоящий FOREIGN KEY).
+ALTER TABLE income ADD COLUMN account_id INTEGER;
+ALTER TABLE expenses ADD COLUMN account_id INTEGER;
+CREATE INDEX IF NOT EXISTS idx_income_account ON income(account_id);
+CREATE INDEX IF NOT EXISTS idx_expenses_account ON expenses(account_id);
+
+-- Существующие данные переносим на счёт по умолчанию: его остаток равен доходам минус расходы.
+INSERT INTO accounts (user_uid, name, type, currency, balance, is_default, created_at)
+SELECT uid, 'Main account', 'debit_card', currency,
+       COALESCE(incomes_balance, 0) - COALESCE(expenses_balance, 0), 1, strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+FROM users;
+
+UPDATE income SET account_id = (SELECT id FROM accounts WHERE accounts.user_uid = income.user_uid AND is_default = 1);
+UPDATE expenses SET account_id = (SELECT id FROM accounts WHERE accounts.user_uid = expenses.user_uid AND is_default = 1);
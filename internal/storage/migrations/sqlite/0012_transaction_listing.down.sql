@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_expenses_user_amount_id;
+DROP INDEX IF EXISTS idx_expenses_user_date_id;
+DROP INDEX IF EXISTS idx_income_user_amount_id;
+DROP INDEX IF EXISTS idx_income_user_date_id;
@@ -0,0 +1,6 @@
+-- Индексы для постраничной выдачи доходов и расходов: сортировка по дате или сумме,
+-- при равенстве по id (см. курсор в /api/income и /api/expense).
+CREATE INDEX IF NOT EXISTS idx_income_user_date_id ON income(user_uid, date, id);
+CREATE INDEX IF NOT EXISTS idx_income_user_amount_id ON income(user_uid, amount, id);
+CREATE INDEX IF NOT EXISTS idx_expenses_user_date_id ON expenses(user_uid, date, id);
+CREATE INDEX IF NOT EXISTS idx_expenses_user_amount_id ON expenses(user_uid, amount, id);
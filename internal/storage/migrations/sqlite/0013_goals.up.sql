@@ -0,0 +1,20 @@
+-- Цели накопления: сколько и к какому сроку нужно отложить.
+-- account_id — необязательный счёт, на который поступают взносы; валюта цели совпадает с его валютой.
+CREATE TABLE IF NOT EXISTS goals (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_uid TEXT NOT NULL,
+	name TEXT NOT NULL,
+	target_amount INTEGER NOT NULL,
+	currency TEXT NOT NULL,
+	deadline TEXT,
+	account_id INTEGER,
+	created_at TEXT NOT NULL,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_goals_user ON goals(user_uid);
+
+-- Взносы в цель — это обычные переводы, помеченные goal_id.
+-- Связь поддерживается приложением, как и account_id у доходов и расходов.
+ALTER TABLE transfers ADD COLUMN goal_id INTEGER;
+CREATE INDEX IF NOT EXISTS idx_transfers_goal ON transfers(goal_id);
@@ -0,0 +1,33 @@
+-- Бюджеты домохозяйств удаляются, личные возвращаются в таблицу с прежним ограничением UNIQUE.
+CREATE TABLE budgets_old (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_uid TEXT NOT NULL,
+	category TEXT NOT NULL,
+	period TEXT NOT NULL,
+	amount INTEGER NOT NULL,
+	currency TEXT NOT NULL,
+	carry_over TEXT NOT NULL DEFAULT 'none',
+	start_date TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	UNIQUE(user_uid, category, period),
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+INSERT INTO budgets_old (id, user_uid, category, period, amount, currency, carry_over, start_date, created_at)
+SELECT id, user_uid, category, period, amount, currency, carry_over, start_date, created_at FROM budgets
+WHERE household_id IS NULL;
+
+DROP TABLE budgets;
+ALTER TABLE budgets_old RENAME TO budgets;
+
+DROP INDEX IF EXISTS idx_expenses_household_category_date;
+DROP INDEX IF EXISTS idx_expenses_household_date;
+DROP INDEX IF EXISTS idx_income_household_date;
+ALTER TABLE expenses DROP COLUMN household_id;
+ALTER TABLE income DROP COLUMN household_id;
+
+DROP INDEX IF EXISTS idx_household_invites_invitee;
+DROP TABLE IF EXISTS household_invites;
+DROP INDEX IF EXISTS idx_household_members_user;
+DROP TABLE IF EXISTS household_members;
+DROP TABLE IF EXISTS households;
@@ -0,0 +1,67 @@
+-- Домохозяйства: несколько пользователей ведут общие доходы, расходы и бюджеты.
+CREATE TABLE IF NOT EXISTS households (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	created_at TEXT NOT NULL
+);
+
+-- Роли: owner управляет составом, editor меняет общие записи, viewer только смотрит.
+CREATE TABLE IF NOT EXISTS household_members (
+	household_id INTEGER NOT NULL,
+	user_uid TEXT NOT NULL,
+	role TEXT NOT NULL,
+	joined_at TEXT NOT NULL,
+	PRIMARY KEY(household_id, user_uid),
+	FOREIGN KEY(household_id) REFERENCES households(id) ON DELETE CASCADE,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_household_members_user ON household_members(user_uid);
+
+-- Приглашение ждёт, пока приглашённый его не примет или не отклонит.
+CREATE TABLE IF NOT EXISTS household_invites (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	household_id INTEGER NOT NULL,
+	invitee_uid TEXT NOT NULL,
+	inviter_uid TEXT NOT NULL,
+	role TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	UNIQUE(household_id, invitee_uid),
+	FOREIGN KEY(household_id) REFERENCES households(id) ON DELETE CASCADE,
+	FOREIGN KEY(invitee_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_household_invites_invitee ON household_invites(invitee_uid);
+
+-- Доход или расход с household_id виден всем участникам домохозяйства.
+-- Связь поддерживается приложением, как и account_id.
+ALTER TABLE income ADD COLUMN household_id INTEGER;
+ALTER TABLE expenses ADD COLUMN household_id INTEGER;
+CREATE INDEX IF NOT EXISTS idx_income_household_date ON income(household_id, date);
+CREATE INDEX IF NOT EXISTS idx_expenses_household_date ON expenses(household_id, date);
+CREATE INDEX IF NOT EXISTS idx_expenses_household_category_date ON expenses(household_id, category, date);
+
+-- Бюджет домохозяйства уникален в пределах домохозяйства, личный — в пределах пользователя.
+-- SQLite не умеет удалять ограничение UNIQUE, поэтому таблица пересоздаётся.
+CREATE TABLE budgets_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_uid TEXT NOT NULL,
+	household_id INTEGER,
+	category TEXT NOT NULL,
+	period TEXT NOT NULL,
+	amount INTEGER NOT NULL,
+	currency TEXT NOT NULL,
+	carry_over TEXT NOT NULL DEFAULT 'none',
+	start_date TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+INSERT INTO budgets_new (id, user_uid, category, period, amount, currency, carry_over, start_date, created_at)
+SELECT id, user_uid, category, period, amount, currency, carry_over, start_date, created_at FROM budgets;
+
+DROP TABLE budgets;
+ALTER TABLE budgets_new RENAME TO budgets;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_personal ON budgets(user_uid, category, period) WHERE household_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_household ON budgets(household_id, category, period) WHERE household_id IS NOT NULL;
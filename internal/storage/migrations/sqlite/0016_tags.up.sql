@@ -0,0 +1,25 @@
+-- Метки пользователя: отмечают доходы и расходы поверх категорий (поездка, проект, «к возмещению»).
+-- name_key, как у категорий, — имя в нижнем регистре со схлопнутыми пробелами; по нему имена уникальны.
+CREATE TABLE IF NOT EXISTS tags (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_uid TEXT NOT NULL,
+	name TEXT NOT NULL,
+	name_key TEXT NOT NULL,
+	color TEXT NOT NULL DEFAULT '',
+	created_at TEXT NOT NULL,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_user_name ON tags(user_uid, name_key);
+
+-- Связь многие-ко-многим: kind — 'income' или 'expense', transaction_id — строка income или expenses.
+-- Связи удалённых операций и меток удаляет приложение.
+CREATE TABLE IF NOT EXISTS transaction_tags (
+	kind TEXT NOT NULL,
+	transaction_id INTEGER NOT NULL,
+	tag_id INTEGER NOT NULL,
+	PRIMARY KEY(kind, transaction_id, tag_id),
+	FOREIGN KEY(tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_tags_tag ON transaction_tags(tag_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_attachments_transaction;
+DROP TABLE IF EXISTS attachments;
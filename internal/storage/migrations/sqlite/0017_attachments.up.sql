@@ -0,0 +1,18 @@
+-- Чеки и документы к доходам и расходам. Сам файл лежит в хранилище файлов (локальный каталог или S3)
+-- под ключом blob_key, миниатюра изображения — под thumbnail_key, пустым, если её нет.
+-- kind — 'income' или 'expense'; вложения удалённых операций удаляет приложение.
+CREATE TABLE IF NOT EXISTS attachments (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_uid TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	transaction_id INTEGER NOT NULL,
+	filename TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	size INTEGER NOT NULL,
+	blob_key TEXT NOT NULL,
+	thumbnail_key TEXT NOT NULL DEFAULT '',
+	created_at TEXT NOT NULL,
+	FOREIGN KEY(user_uid) REFERENCES users(uid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_transaction ON attachments(kind, transaction_id);
@@ -0,0 +1,7 @@
+//go:build postgres
+
+package storage
+
+// Registers the "pgx" database/sql driver. Built only with -tags postgres so that
+// SQLite-only binaries do not link the PostgreSQL client.
+import _ "github.com/jackc/pgx/v5/stdlib"
@@ -0,0 +1,107 @@
+// Package storage opens the SQL database selected in the config and keeps its schema up to date.
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"tbank-go/internal/config"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Dialect identifies the SQL flavour of an open database.
+type Dialect string
+
+const (
+	SQLite   Dialect = "sqlite"
+	Postgres Dialect = "postgres"
+)
+
+// sqliteDriver is go-sqlite3 with lower() replaced by a Unicode-aware version: the built-in
+// one only folds ASCII letters, so case-insensitive searches missed Cyrillic text.
+const sqliteDriver = "sqlite3_unicode"
+
+func init() {
+	sql.Register(sqliteDriver, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("lower", strings.ToLower, true)
+		},
+	})
+}
+
+// postgresDriver is the database/sql driver used for PostgreSQL. It is registered by
+// postgres.go, which is only compiled with the "postgres" build tag.
+const postgresDriver = "pgx"
+
+// InitializeDatabase opens the database and applies all pending migrations.
+func InitializeDatabase(cfg *config.Config, log *slog.Logger) (*sql.DB, error) {
+	db, err := Open(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
+	err = Migrate(db, log)
+	if err != nil {
+		log.Error("failed to migrate database", slog.String("driver", cfg.Storage.Driver), slog.Any("error", err))
+		db.Close()
+		return nil, err
+	}
+
+	log.Info("Database initialized successfully", slog.String("driver", cfg.Storage.Driver))
+	return db, nil
+}
+
+// Open opens the database without touching its schema.
+//
+// For PostgreSQL the connection is wrapped so that the "?" placeholders used throughout
+// the code base are rewritten to "$1", "$2", ... before they reach the server.
+func Open(cfg *config.Config, log *slog.Logger) (*sql.DB, error) {
+	var db *sql.DB
+	var err error
+
+	switch Dialect(cfg.Storage.Driver) {
+	case SQLite:
+		dsn := cfg.Storage.DSN
+		if dsn == "" {
+			dsn = cfg.StoragePath
+		}
+		if dsn == "" {
+			err = fmt.Errorf("storage_path is required for the sqlite driver")
+			break
+		}
+		db, err = sql.Open(sqliteDriver, dsn)
+	case Postgres:
+		if cfg.Storage.DSN == "" {
+			err = fmt.Errorf("storage.dsn is required for the postgres driver")
+			break
+		}
+		if !slices.Contains(sql.Drivers(), postgresDriver) {
+			err = fmt.Errorf("postgres support is not compiled in, rebuild with -tags postgres")
+			break
+		}
+		db, err = openRebinding(postgresDriver, cfg.Storage.DSN)
+		if err == nil && cfg.Storage.MaxOpenConns > 0 {
+			db.SetMaxOpenConns(cfg.Storage.MaxOpenConns)
+		}
+	default:
+		err = fmt.Errorf("unknown storage driver %q, expected sqlite or postgres", cfg.Storage.Driver)
+	}
+	if err != nil {
+		log.Error("failed to open database", slog.String("driver", cfg.Storage.Driver), slog.Any("error", err))
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// DialectOf reports which SQL flavour db speaks. Use it for the few queries that
+// cannot be written portably.
+func DialectOf(db *sql.DB) Dialect {
+	if _, ok := db.Driver().(rebindDriver); ok {
+		return Postgres
+	}
+	return SQLite
+}
@@ -0,0 +1,115 @@
+package storage_test
+
+import (
+	"database/sql"
+	"io"
+	"log/slog"
+	"tbank-go/internal/storage"
+	"tbank-go/internal/storage/storagetest"
+	"testing"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "no placeholders", query: `SELECT 1`, want: `SELECT 1`},
+		{name: "numbered in order", query: `SELECT * FROM t WHERE a = ? AND b IN (?, ?)`, want: `SELECT * FROM t WHERE a = $1 AND b IN ($2, $3)`},
+		{name: "string literal", query: `SELECT '?' , ? FROM t WHERE c = 'what?'`, want: `SELECT '?' , $1 FROM t WHERE c = 'what?'`},
+		{name: "escaped quote", query: `SELECT 'it''s?', ?`, want: `SELECT 'it''s?', $1`},
+		{name: "quoted identifier", query: `SELECT "odd?name" FROM t WHERE id = ?`, want: `SELECT "odd?name" FROM t WHERE id = $1`},
+		{name: "line comment", query: "SELECT ? -- really?\nFROM t WHERE id = ?", want: "SELECT $1 -- really?\nFROM t WHERE id = $2"},
+		{name: "comment at the end", query: "SELECT ? -- why?", want: "SELECT $1 -- why?"},
+		{name: "unterminated literal", query: `SELECT ? 'oops?`, want: `SELECT $1 'oops?`},
+		{name: "minus is not a comment", query: `SELECT a - ? FROM t`, want: `SELECT a - $1 FROM t`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := storage.Rebind(tt.query); got != tt.want {
+				t.Errorf("Rebind(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialectOf(t *testing.T) {
+	if got := storage.DialectOf(storagetest.SQLite(t)); got != storage.SQLite {
+		t.Errorf("DialectOf(sqlite) = %q", got)
+	}
+	t.Run("postgres", func(t *testing.T) {
+		db := storagetest.Postgres(t)
+		if got := storage.DialectOf(db); got != storage.Postgres {
+			t.Errorf("DialectOf(postgres) = %q", got)
+		}
+		// "?" placeholders reach the server as $n.
+		var sum int
+		if err := db.QueryRow(`SELECT CAST(? AS INTEGER) + CAST(? AS INTEGER)`, 2, 3).Scan(&sum); err != nil || sum != 5 {
+			t.Errorf("rebound query = %d, %v", sum, err)
+		}
+	})
+}
+
+func TestMigrationsMatch(t *testing.T) {
+	sqlite, err := storage.LoadMigrations(storage.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	postgres, err := storage.LoadMigrations(storage.Postgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sqlite) != len(postgres) {
+		t.Fatalf("%d sqlite migrations, %d postgres migrations", len(sqlite), len(postgres))
+	}
+	for i := range sqlite {
+		if sqlite[i].Version != postgres[i].Version || sqlite[i].Name != postgres[i].Name {
+			t.Errorf("migration %d: sqlite %04d_%s, postgres %04d_%s", i,
+				sqlite[i].Version, sqlite[i].Name, postgres[i].Version, postgres[i].Name)
+		}
+	}
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	storagetest.ForEach(t, func(t *testing.T, db *sql.DB) {
+		migrations, err := storage.LoadMigrations(storage.DialectOf(db))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := storage.MigrateDown(db, len(migrations), discard); err != nil {
+			t.Fatalf("migrate down: %v", err)
+		}
+		assertApplied(t, db, 0)
+
+		if err := storage.Migrate(db, discard); err != nil {
+			t.Fatalf("migrate up: %v", err)
+		}
+		assertApplied(t, db, len(migrations))
+
+		// A second run has nothing to do.
+		if err := storage.Migrate(db, discard); err != nil {
+			t.Fatalf("migrate again: %v", err)
+		}
+	})
+}
+
+func assertApplied(t *testing.T, db *sql.DB, want int) {
+	t.Helper()
+	status, err := storage.Status(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied := 0
+	for _, s := range status {
+		if s.Applied {
+			applied++
+		}
+	}
+	if applied != want {
+		t.Errorf("%d migrations applied, want %d", applied, want)
+	}
+}
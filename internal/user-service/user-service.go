@@ -5,6 +5,8 @@ import (
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"log/slog"
+	"strings"
+	"tbank-go/internal/repository"
 	"time"
 )
 
@@ -26,49 +28,45 @@ func (u *User) HashPassword(log *slog.Logger) error {
 
 	}
 	u.Password = string(hashedPassword)
-	u.RegisteredAt = time.Now().Format(time.RFC3339)
 	log.Info("password hashed successfully", slog.String("username", u.Username))
 	return nil
 }
 
-func (u *User) Create(db *sql.DB, log *slog.Logger) error {
-	_, err := db.Exec(
-		`INSERT INTO users (uid, username, password, registered_at, first_name, second_name, incomes_balance, expenses_balance) 
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		u.UID, u.Username, u.Password, u.RegisteredAt, "", "", 0, 0,
-	)
+// Querier is implemented by both *sql.DB and *sql.Tx.
+type Querier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
 
-	if err != nil {
-		log.Error("failed to create user", slog.String("username", u.Username), slog.Any("error", err))
-		return err
-	}
-	log.Info("user added successfully", slog.String("username", u.Username))
-	return nil
+// GetUserCurrency returns the currency the user's balance counters are kept in.
+func GetUserCurrency(q Querier, uid string) (string, error) {
+	var currency string
+	err := q.QueryRow("SELECT currency FROM users WHERE uid = ?", uid).Scan(&currency)
+	return currency, err
 }
 
-func GetUserByUsername(db *sql.DB, username string, log *slog.Logger) (User, error) {
-	var user User
-	err := db.QueryRow(
-		"SELECT uid, username, password, registered_at FROM users WHERE username = ?", username,
-	).Scan(&user.UID, &user.Username, &user.Password, &user.RegisteredAt)
-	if err != nil {
-		log.Error("failed to get user by username", slog.String("username", username), slog.Any("error", err))
-		return user, err
-	}
-	log.Info("user retrieved successfully", slog.String("username", username))
-	return user, err
+// GetDefaultAccount returns the ID and currency of the user's default account.
+func GetDefaultAccount(q Querier, uid string) (int64, string, error) {
+	var id int64
+	var currency string
+	err := q.QueryRow("SELECT id, currency FROM accounts WHERE user_uid = ? AND is_default = 1", uid).Scan(&id, &currency)
+	return id, currency, err
 }
 
-func UpdatePassword(db *sql.DB, log *slog.Logger, user *User) error {
-	_, err := db.Exec(
-		"UPDATE users SET password = ? WHERE username = ?",
-		user.Password, user.Username,
-	)
-	if err != nil {
-		log.Error("failed to update password", slog.String("username", user.Username), slog.Any("error", err))
-		return err
+// EnsureCategory returns the ID and name of the user's category of the given type with the given
+// name, creating a top-level category when there is none. It is used where transactions are
+// created from data the user did not pick a category for, such as statements and recurring rules.
+func EnsureCategory(q Querier, uid, categoryType, name string) (int64, string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+
+	var id int64
+	var stored string
+	err := q.QueryRow("SELECT id, name FROM categories WHERE user_uid = ? AND type = ? AND (name_key = ? OR name = ?)",
+		uid, categoryType, repository.CategoryKey(name), name).Scan(&id, &stored)
+	if err != sql.ErrNoRows {
+		return id, stored, err
 	}
 
-	log.Info("password updated successfully", slog.String("username", user.Username))
-	return nil
+	err = q.QueryRow("INSERT INTO categories (user_uid, name, name_key, type, created_at) VALUES (?, ?, ?, ?, ?) RETURNING id",
+		uid, name, repository.CategoryKey(name), categoryType, time.Now().UTC().Format(time.RFC3339)).Scan(&id)
+	return id, name, err
 }
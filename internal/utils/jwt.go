@@ -1,23 +1,33 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"time"
 )
 
 type Claims struct {
 	UID string `json:"uid"`
+	// TokenVersion must match users.token_version; bumping the column invalidates every issued token.
+	TokenVersion int `json:"ver"`
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(uid string, jwtSecret string, jwtLifetime time.Duration) (string, error) {
+func GenerateJWT(uid string, tokenVersion int, jwtSecret string, jwtLifetime time.Duration) (string, error) {
 	expirationTime := time.Now().Add(jwtLifetime)
 	claims := &Claims{
-		UID: uid,
+		UID:          uid,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
@@ -30,6 +40,17 @@ func CheckPassword(hashedPassword, password string) error {
 }
 
 func ParseJWT(tokenString string, jwtSecret string) (string, error) {
+	claims, err := ParseJWTClaims(tokenString, jwtSecret)
+	if err != nil {
+		return "", err
+	}
+
+	return claims.UID, nil
+}
+
+// ParseJWTClaims validates the token and returns all of its claims,
+// including the token ID (jti) used for revocation.
+func ParseJWTClaims(tokenString string, jwtSecret string) (*Claims, error) {
 	claims := &Claims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
@@ -40,12 +61,28 @@ func ParseJWT(tokenString string, jwtSecret string) (string, error) {
 	})
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if !token.Valid {
-		return "", errors.New("invalid token")
+		return nil, errors.New("invalid token")
 	}
 
-	return claims.UID, nil
+	return claims, nil
+}
+
+// GenerateRefreshToken returns a random opaque refresh token.
+// Only its hash (see HashToken) is ever stored.
+func GenerateRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken returns the hex encoded SHA-256 of an opaque token.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of RFC 6238 appendix B, "12345678901234567890", in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// The RFC lists 8-digit codes; 6-digit codes are their last six digits.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},          // 94287082
+		{unix: 1111111109, want: "081804"},  // 07081804
+		{unix: 1111111111, want: "050471"},  // 14050471
+		{unix: 1234567890, want: "005924"},  // 89005924
+		{unix: 2000000000, want: "279037"},  // 69279037
+		{unix: 20000000000, want: "353130"}, // 65353130
+	}
+	for _, tt := range tests {
+		step := TOTPStep(time.Unix(tt.unix, 0))
+		got, err := TOTPCode(rfcSecret, step)
+		if err != nil || got != tt.want {
+			t.Errorf("TOTPCode at %d = %q, %v, want %q", tt.unix, got, err, tt.want)
+		}
+	}
+
+	if got, err := TOTPCode(strings.ToLower(rfcSecret), 1); err != nil || got != "287082" {
+		t.Errorf("lower-case secret: %q, %v", got, err)
+	}
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("invalid secret accepted")
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := TOTPStep(now)
+	code := func(step int64) string {
+		c, err := TOTPCode(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current step", code: code(current), wantStep: current, wantOK: true},
+		{name: "with spaces", code: code(current)[:3] + " " + code(current)[3:], wantStep: current, wantOK: true},
+		{name: "previous step", code: code(current - 1), wantStep: current - 1, wantOK: true},
+		{name: "next step", code: code(current + 1), wantStep: current + 1, wantOK: true},
+		{name: "two steps back", code: code(current - 2)},
+		{name: "two steps ahead", code: code(current + 2)},
+		{name: "replayed step", code: code(current), lastStep: current},
+		{name: "step before the last used one", code: code(current - 1), lastStep: current},
+		{name: "step after the last used one", code: code(current + 1), lastStep: current, wantStep: current + 1, wantOK: true},
+		{name: "wrong code", code: "000000"},
+		{name: "too short", code: code(current)[:5]},
+		{name: "too long", code: code(current) + "0"},
+	}
+	for _, tt := range tests {
+		step, ok := ValidateTOTP(rfcSecret, tt.code, now, tt.lastStep)
+		if ok != tt.wantOK || step != tt.wantStep {
+			t.Errorf("%s: ValidateTOTP = %d, %v, want %d, %v", tt.name, step, ok, tt.wantStep, tt.wantOK)
+		}
+	}
+}
+
+func TestRecoveryCode(t *testing.T) {
+	code, err := GenerateRecoveryCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(code, "-")
+	if len(parts) != 3 || len(parts[0]) != 4 || len(parts[1]) != 4 || len(parts[2]) != 4 {
+		t.Errorf("recovery code %q is not xxxx-xxxx-xxxx", code)
+	}
+
+	// The code is accepted however the user types it.
+	want := HashRecoveryCode(code)
+	for _, typed := range []string{strings.ToUpper(code), strings.ReplaceAll(code, "-", ""), strings.ReplaceAll(code, "-", " ")} {
+		if HashRecoveryCode(typed) != want {
+			t.Errorf("%q hashes differently from %q", typed, code)
+		}
+	}
+	if HashRecoveryCode("aaaa-bbbb-cccc") == HashRecoveryCode("aaaa-bbbb-cccd") {
+		t.Error("different codes have the same hash")
+	}
+}
@@ -1,16 +1,40 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"tbank-go/internal/advice"
+	"tbank-go/internal/blob"
 	"tbank-go/internal/config"
+	"tbank-go/internal/ratelimit"
+	"tbank-go/internal/rates"
+	"tbank-go/internal/repository/sqlstore"
+	"tbank-go/internal/services/accounts"
+	"tbank-go/internal/services/analytics"
+	"tbank-go/internal/services/attachments"
 	"tbank-go/internal/services/auth"
+	"tbank-go/internal/services/budgets"
+	"tbank-go/internal/services/categories"
+	"tbank-go/internal/services/exchange"
 	"tbank-go/internal/services/expenses"
+	"tbank-go/internal/services/export"
 	"tbank-go/internal/services/geminiAnalysis"
+	"tbank-go/internal/services/goals"
+	"tbank-go/internal/services/households"
 	"tbank-go/internal/services/incomes"
+	"tbank-go/internal/services/recurring"
+	"tbank-go/internal/services/reports"
+	"tbank-go/internal/services/splits"
+	"tbank-go/internal/services/statements"
+	"tbank-go/internal/services/tags"
+	"tbank-go/internal/services/transactions"
 	"tbank-go/internal/services/users"
-	"tbank-go/internal/sqlite"
+	"tbank-go/internal/storage"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -38,7 +62,14 @@ func main() {
 	cfg := config.MustLoadConfig()
 	log := setupLogger(cfg.Env)
 
-	db, err := sqlite.InitializeDatabase(cfg.StoragePath, log)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(cfg, log, os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "rates" {
+		os.Exit(runRates(cfg, log, os.Args[2:]))
+	}
+
+	db, err := storage.InitializeDatabase(cfg, log)
 	if err != nil {
 		log.Error("failed to initialize database", slog.Any("error", err))
 		os.Exit(1)
@@ -49,15 +80,51 @@ func main() {
 		}
 	}()
 
+	repos := sqlstore.New(db)
+	// The scheduler and the statement import store transactions within transactions of their own.
+	incomeStore, expenseStore := sqlstore.NewIncomeRepository(db), sqlstore.NewExpenseRepository(db)
+	rateStore := rates.NewStore(db)
+
+	adviceProvider, err := advice.NewProvider(context.Background(), cfg.Advice)
+	if err != nil {
+		log.Error("failed to initialize advice provider", slog.String("provider", cfg.Advice.Provider), slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer adviceProvider.Close()
+
+	blobs, err := blob.NewStore(cfg.Attachments)
+	if err != nil {
+		log.Error("failed to initialize attachment store", slog.String("store", cfg.Attachments.Store), slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if cfg.Recurring.Interval > 0 {
+		go recurring.NewScheduler(db, incomeStore, expenseStore, log, cfg.Recurring.Interval).Run(ctx)
+	}
+
 	log.Info("config loaded", slog.String("env", cfg.Env))
 	log.Debug("debug messages enabled")
 
+	// Brute-force protection of the endpoints that accept a password
+	ipLimiter := ratelimit.ByIP(ratelimit.NewMemory(cfg.RateLimit.IPInterval, cfg.RateLimit.IPBurst), log)
+	throttle := auth.NewThrottle(cfg.RateLimit)
+
+	passwordPolicy, err := auth.LoadPasswordPolicy(cfg.Password)
+	if err != nil {
+		log.Error("failed to load password policy", slog.Any("error", err))
+		os.Exit(1)
+	}
+	log.Info("password policy loaded", slog.Int("minLength", passwordPolicy.MinLength), slog.Int("breachedPasswords", passwordPolicy.BreachedCount()))
+
 	router := chi.NewRouter()
 
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:63342"}, // Allow specific origin
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", auth.HouseholdHeader},
 		AllowCredentials: true, // Allow cookies, authorization headers, etc.
 		MaxAge:           300,  // Cache preflight requests for 5 minutes
 	}))
@@ -68,40 +135,199 @@ func main() {
 	router.Get("/swagger/*", httpSwagger.WrapHandler)
 
 	router.Route("/api", func(r chi.Router) {
-		r.Post("/register", func(w http.ResponseWriter, r *http.Request) {
-			auth.Register(db, w, r, log)
+		r.With(ipLimiter).Post("/register", func(w http.ResponseWriter, r *http.Request) {
+			auth.Register(repos.Users, throttle, passwordPolicy, w, r, log)
+		})
+		r.With(ipLimiter).Post("/login", func(w http.ResponseWriter, r *http.Request) {
+			auth.Login(db, repos.Users, throttle, w, r, log, cfg.JwtSecret, cfg.JwtLifetime, cfg.RefreshLifetime)
+		})
+		r.With(ipLimiter).Post("/login/2fa", func(w http.ResponseWriter, r *http.Request) {
+			auth.VerifyTwoFactorLogin(db, w, r, log, cfg.JwtSecret, cfg.JwtLifetime, cfg.RefreshLifetime)
 		})
-		r.Post("/login", func(w http.ResponseWriter, r *http.Request) {
-			auth.Login(db, w, r, log, cfg.JwtSecret, cfg.JwtLifetime)
+		r.Post("/token/refresh", func(w http.ResponseWriter, r *http.Request) {
+			auth.Refresh(db, w, r, log, cfg.JwtSecret, cfg.JwtLifetime, cfg.RefreshLifetime)
 		})
-		r.Post("/change-password", func(w http.ResponseWriter, r *http.Request) {
-			auth.ChangePassword(db, w, r, log)
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Post("/logout", func(w http.ResponseWriter, r *http.Request) {
+			auth.Logout(db, w, r, log)
 		})
-		r.With(auth.AuthMiddleware(cfg.JwtSecret, log)).Route("/income", func(r chi.Router) {
-			r.Post("/", incomes.AddIncomeHandler(db, log))
-			r.Get("/", incomes.GetIncomesHandler(db, log))
-			r.Delete("/{id}", incomes.DeleteIncomeHandler(db, log))
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/2fa", func(r chi.Router) {
+			r.Post("/enroll", func(w http.ResponseWriter, r *http.Request) {
+				auth.EnrollTwoFactor(db, repos.Users, w, r, log)
+			})
+			r.Post("/confirm", func(w http.ResponseWriter, r *http.Request) {
+				auth.ConfirmTwoFactor(db, w, r, log)
+			})
+			r.Post("/recovery-codes", func(w http.ResponseWriter, r *http.Request) {
+				auth.RegenerateRecoveryCodes(db, w, r, log)
+			})
+			r.Post("/disable", func(w http.ResponseWriter, r *http.Request) {
+				auth.DisableTwoFactor(db, repos.Users, w, r, log)
+			})
 		})
-		r.With(auth.AuthMiddleware(cfg.JwtSecret, log)).Route("/expense", func(r chi.Router) {
-			r.Post("/", expenses.AddExpenseHandler(db, log))
-			r.Get("/", expenses.GetExpensesHandler(db, log))
-			r.Delete("/{id}", expenses.DeleteExpenseHandler(db, log))
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Post("/change-password", func(w http.ResponseWriter, r *http.Request) {
+			auth.ChangePassword(repos.Users, throttle, passwordPolicy, w, r, log)
 		})
-		r.With(auth.AuthMiddleware(cfg.JwtSecret, log)).Route("/users", func(r chi.Router) {
-			r.Put("/", users.UpdateUserNamesHandler(db, log))
-			r.Get("/", users.GetUserInfoHandler(db, log))
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/income", func(r chi.Router) {
+			r.Post("/", incomes.AddIncomeHandler(repos.Accounts, repos.Categories, repos.Incomes, repos.Tags, log))
+			r.Get("/", incomes.GetIncomesHandler(repos.Accounts, repos.Categories, repos.Incomes, repos.Tags, repos.Households, log))
+			r.Put("/{id}", incomes.UpdateIncomeHandler(repos.Accounts, repos.Categories, repos.Incomes, repos.Households, log))
+			r.Patch("/{id}", incomes.PatchIncomeHandler(repos.Accounts, repos.Categories, repos.Incomes, repos.Households, log))
+			r.Delete("/{id}", incomes.DeleteIncomeHandler(repos.Incomes, repos.Households, blobs, log))
+			r.Post("/{id}/attachments", attachments.UploadIncomeAttachmentHandler(repos.Incomes, repos.Households, repos.Attachments, blobs, cfg.Attachments.MaxSize, log))
+			r.Get("/{id}/attachments", attachments.GetIncomeAttachmentsHandler(repos.Incomes, repos.Households, repos.Attachments, log))
 		})
-		r.With(auth.AuthMiddleware(cfg.JwtSecret, log)).Route("/ai-advice", func(r chi.Router) {
-			r.Get("/", geminiAnalysis.GenerateFinancialAdviceHandler(db, log))
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/expense", func(r chi.Router) {
+			r.Post("/", expenses.AddExpenseHandler(repos.Accounts, repos.Categories, repos.Expenses, repos.Tags, log))
+			r.Get("/", expenses.GetExpensesHandler(repos.Accounts, repos.Categories, repos.Expenses, repos.Tags, repos.Households, log))
+			r.Put("/{id}", expenses.UpdateExpenseHandler(repos.Accounts, repos.Categories, repos.Expenses, repos.Households, log))
+			r.Patch("/{id}", expenses.PatchExpenseHandler(repos.Accounts, repos.Categories, repos.Expenses, repos.Households, log))
+			r.Delete("/{id}", expenses.DeleteExpenseHandler(repos.Expenses, repos.Households, blobs, log))
+			r.Post("/{id}/attachments", attachments.UploadExpenseAttachmentHandler(repos.Expenses, repos.Households, repos.Attachments, blobs, cfg.Attachments.MaxSize, log))
+			r.Get("/{id}/attachments", attachments.GetExpenseAttachmentsHandler(repos.Expenses, repos.Households, repos.Attachments, log))
+		})
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/attachments", func(r chi.Router) {
+			r.Get("/{id}", attachments.GetAttachmentHandler(repos.Incomes, repos.Expenses, repos.Households, repos.Attachments, log))
+			r.Delete("/{id}", attachments.DeleteAttachmentHandler(repos.Incomes, repos.Expenses, repos.Households, repos.Attachments, blobs, log))
+			r.Get("/{id}/file", attachments.GetAttachmentFileHandler(repos.Incomes, repos.Expenses, repos.Households, repos.Attachments, blobs, log))
+			r.Get("/{id}/thumbnail", attachments.GetAttachmentThumbnailHandler(repos.Incomes, repos.Expenses, repos.Households, repos.Attachments, blobs, log))
+		})
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Get("/transactions", transactions.GetTransactionsHandler(repos.Accounts, repos.Categories, repos.Tags, repos.Households, repos.Feed, log))
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/categories", func(r chi.Router) {
+			r.Post("/", categories.CreateCategoryHandler(repos.Categories, log))
+			r.Get("/", categories.GetCategoriesHandler(repos.Categories, log))
+			r.Get("/{id}", categories.GetCategoryHandler(repos.Categories, log))
+			r.Patch("/{id}", categories.PatchCategoryHandler(repos.Categories, log))
+			r.Delete("/{id}", categories.DeleteCategoryHandler(repos.Categories, log))
+			r.Post("/{id}/merge", categories.MergeCategoryHandler(repos.Categories, log))
+		})
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/tags", func(r chi.Router) {
+			r.Post("/", tags.CreateTagHandler(repos.Tags, log))
+			r.Get("/", tags.GetTagsHandler(repos.Tags, log))
+			r.Post("/bulk", tags.BulkTagHandler(repos.Tags, repos.Incomes, repos.Expenses, repos.Households, log))
+			r.Get("/{id}", tags.GetTagHandler(repos.Tags, log))
+			r.Patch("/{id}", tags.PatchTagHandler(repos.Tags, log))
+			r.Delete("/{id}", tags.DeleteTagHandler(repos.Tags, log))
+		})
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/accounts", func(r chi.Router) {
+			r.Post("/", accounts.CreateAccountHandler(repos.Users, repos.Accounts, log))
+			r.Get("/", accounts.GetAccountsHandler(repos.Accounts, log))
+			r.Get("/{id}", accounts.GetAccountHandler(repos.Accounts, log))
+			r.Patch("/{id}", accounts.PatchAccountHandler(repos.Accounts, log))
+			r.Delete("/{id}", accounts.DeleteAccountHandler(repos.Accounts, log))
+		})
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/transfers", func(r chi.Router) {
+			r.Post("/", accounts.CreateTransferHandler(repos.Accounts, repos.Transfers, log))
+			r.Get("/", accounts.GetTransfersHandler(repos.Transfers, log))
+			r.Delete("/{id}", accounts.DeleteTransferHandler(repos.Transfers, log))
+		})
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/budgets", func(r chi.Router) {
+			r.Post("/", budgets.CreateBudgetHandler(db, repos.Categories, log))
+			r.Get("/", budgets.GetBudgetsHandler(db, log))
+			r.Get("/status", budgets.GetBudgetStatusHandler(db, log))
+			r.Get("/{id}", budgets.GetBudgetHandler(db, log))
+			r.Put("/{id}", budgets.UpdateBudgetHandler(db, repos.Categories, log))
+			r.Delete("/{id}", budgets.DeleteBudgetHandler(db, log))
+		})
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/households", func(r chi.Router) {
+			r.Post("/", households.CreateHouseholdHandler(repos.Households, log))
+			r.Get("/", households.GetHouseholdsHandler(repos.Households, log))
+			r.Get("/invites", households.GetMyInvitesHandler(repos.Households, log))
+			r.Post("/invites/{inviteID}/accept", households.AcceptInviteHandler(repos.Households, log))
+			r.Post("/invites/{inviteID}/decline", households.DeclineInviteHandler(repos.Households, log))
+			r.Get("/{id}", households.GetHouseholdHandler(repos.Households, log))
+			r.Patch("/{id}", households.RenameHouseholdHandler(repos.Households, log))
+			r.Delete("/{id}", households.DeleteHouseholdHandler(repos.Households, log))
+			r.Patch("/{id}/members/{uid}", households.UpdateMemberHandler(repos.Households, log))
+			r.Delete("/{id}/members/{uid}", households.RemoveMemberHandler(repos.Households, log))
+			r.Post("/{id}/invites", households.CreateInviteHandler(repos.Users, repos.Households, log))
+			r.Get("/{id}/invites", households.GetHouseholdInvitesHandler(repos.Households, log))
+			r.Delete("/{id}/invites/{inviteID}", households.RevokeInviteHandler(repos.Households, log))
+		})
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/goals", func(r chi.Router) {
+			r.Post("/", goals.CreateGoalHandler(repos.Users, repos.Accounts, repos.Goals, log))
+			r.Get("/", goals.GetGoalsHandler(repos.Goals, log))
+			r.Get("/{id}", goals.GetGoalHandler(repos.Goals, log))
+			r.Put("/{id}", goals.UpdateGoalHandler(repos.Users, repos.Accounts, repos.Goals, log))
+			r.Delete("/{id}", goals.DeleteGoalHandler(repos.Goals, log))
+			r.Get("/{id}/progress", goals.GetProgressHandler(repos.Goals, log))
+			r.Post("/{id}/contributions", goals.CreateContributionHandler(repos.Accounts, repos.Goals, repos.Transfers, log))
+			r.Get("/{id}/contributions", goals.GetContributionsHandler(repos.Goals, log))
+			r.Delete("/{id}/contributions/{contributionID}", goals.DeleteContributionHandler(repos.Goals, repos.Transfers, log))
+		})
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/contacts", func(r chi.Router) {
+			r.Post("/", splits.CreateContactHandler(repos.Contacts, log))
+			r.Get("/", splits.GetContactsHandler(repos.Contacts, log))
+			r.Patch("/{id}", splits.RenameContactHandler(repos.Contacts, log))
+			r.Delete("/{id}", splits.DeleteContactHandler(repos.Contacts, log))
+		})
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/splits", func(r chi.Router) {
+			r.Post("/", splits.CreateSplitHandler(repos.Users, repos.Expenses, repos.Contacts, repos.Splits, log))
+			r.Get("/", splits.GetSplitsHandler(repos.Splits, log))
+			r.Get("/ledger", splits.GetLedgerHandler(repos.Splits, log))
+			r.Post("/settle", splits.SettleHandler(repos.Users, repos.Contacts, repos.Splits, log))
+			r.Get("/settlements", splits.GetSettlementsHandler(repos.Splits, log))
+			r.Delete("/settlements/{id}", splits.DeleteSettlementHandler(repos.Splits, log))
+			r.Get("/{id}", splits.GetSplitHandler(repos.Splits, log))
+			r.Delete("/{id}", splits.DeleteSplitHandler(repos.Splits, log))
+		})
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/recurring", func(r chi.Router) {
+			r.Post("/", recurring.CreateRuleHandler(db, incomeStore, expenseStore, log))
+			r.Get("/", recurring.GetRulesHandler(db, log))
+			r.Get("/upcoming", recurring.GetUpcomingHandler(db, log))
+			r.Get("/{id}", recurring.GetRuleHandler(db, log))
+			r.Put("/{id}", recurring.UpdateRuleHandler(db, log))
+			r.Delete("/{id}", recurring.DeleteRuleHandler(db, log))
+		})
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/rates", func(r chi.Router) {
+			r.Get("/", exchange.GetRatesHandler(rateStore, log))
+			if cfg.Rates.AllowUpload {
+				r.Post("/import", exchange.ImportRatesHandler(rateStore, log))
+			}
+		})
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/reports", func(r chi.Router) {
+			r.Get("/summary", reports.GetSummaryHandler(db, rateStore, log))
+			r.Get("/balance", reports.GetBalanceHandler(db, repos.Households, rateStore, log))
+		})
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/analytics", func(r chi.Router) {
+			r.Get("/summary", analytics.GetSummaryHandler(db, log))
+		})
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Post("/import", statements.ImportStatementHandler(db, incomeStore, expenseStore, log))
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Get("/export", export.ExportHandler(db, log))
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/users", func(r chi.Router) {
+			r.Put("/", users.UpdateUserNamesHandler(repos.Users, log))
+			r.Get("/", users.GetUserInfoHandler(repos.Users, log))
+		})
+		r.With(auth.AuthMiddleware(db, cfg.JwtSecret, log)).Route("/ai-advice", func(r chi.Router) {
+			r.Get("/", geminiAnalysis.GenerateFinancialAdviceHandler(db, adviceProvider, log))
 		})
 	})
 
+	server := &http.Server{
+		Addr:              cfg.Address,
+		Handler:           router,
+		ReadHeaderTimeout: cfg.Timeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Error("failed to shut down HTTPS server", slog.Any("error", err))
+		}
+	}()
+
 	// Start the HTTPS server
 	log.Info("starting HTTPS server", slog.String("address", cfg.Address))
-	if err := http.ListenAndServeTLS(cfg.Address, "server.crt", "server.key", router); err != nil {
+	if err := server.ListenAndServeTLS("server.crt", "server.key"); err != nil && err != http.ErrServerClosed {
 		log.Error("HTTPS server failed", slog.Any("error", err))
 		os.Exit(1)
 	}
+	<-shutdownDone
+	log.Info("HTTPS server stopped")
 }
 
 func setupLogger(env string) *slog.Logger {
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"tbank-go/internal/config"
+	"tbank-go/internal/storage"
+)
+
+const migrateUsage = `usage: tbank-go migrate <command>
+
+commands:
+  status      list migrations and whether they are applied
+  up          apply all pending migrations
+  down [n]    revert the last n applied migrations (default 1)`
+
+// runMigrate implements the "migrate" subcommand and returns the process exit code.
+func runMigrate(cfg *config.Config, log *slog.Logger, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	db, err := storage.Open(cfg, log)
+	if err != nil {
+		return 1
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "status":
+		statuses, err := storage.Status(db)
+		if err != nil {
+			log.Error("failed to read migration status", slog.Any("error", err))
+			return 1
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt
+			}
+			fmt.Printf("%04d  %-30s %s\n", s.Version, s.Name, state)
+		}
+	case "up":
+		if err := storage.Migrate(db, log); err != nil {
+			return 1
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				fmt.Fprintln(os.Stderr, migrateUsage)
+				return 2
+			}
+		}
+		if err := storage.MigrateDown(db, steps, log); err != nil {
+			return 1
+		}
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	return 0
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"tbank-go/internal/config"
+	"tbank-go/internal/rates"
+	"tbank-go/internal/storage"
+	"time"
+)
+
+const ratesUsage = `usage: tbank-go rates <command>
+
+commands:
+  import <file>...   load CBR daily XML files (https://www.cbr.ru/scripts/XML_daily.asp)
+  list [date]        print the rates in effect on date (default today)`
+
+// runRates implements the "rates" subcommand and returns the process exit code.
+func runRates(cfg *config.Config, log *slog.Logger, args []string) int {
+	if len(args) == 0 || (args[0] == "import" && len(args) < 2) {
+		fmt.Fprintln(os.Stderr, ratesUsage)
+		return 2
+	}
+
+	db, err := storage.InitializeDatabase(cfg, log)
+	if err != nil {
+		return 1
+	}
+	defer db.Close()
+
+	store := rates.NewStore(db)
+	ctx := context.Background()
+
+	switch args[0] {
+	case "import":
+		for _, path := range args[1:] {
+			file, err := os.Open(path)
+			if err != nil {
+				log.Error("failed to open rates file", slog.String("file", path), slog.Any("error", err))
+				return 1
+			}
+			parsed, err := rates.ParseCBR(file)
+			file.Close()
+			if err != nil {
+				log.Error("failed to parse rates file", slog.String("file", path), slog.Any("error", err))
+				return 1
+			}
+			if err := store.Save(ctx, parsed); err != nil {
+				log.Error("failed to save rates", slog.String("file", path), slog.Any("error", err))
+				return 1
+			}
+			fmt.Printf("%s  %s  %d rates\n", path, parsed[0].Date, len(parsed))
+		}
+	case "list":
+		date := time.Now().Format("2006-01-02")
+		if len(args) > 1 {
+			date = args[1]
+		}
+		list, err := store.List(ctx, date)
+		if err != nil {
+			log.Error("failed to list rates", slog.Any("error", err))
+			return 1
+		}
+		for _, rate := range list {
+			fmt.Printf("%s  %s  %6d  %s\n", rate.Date, rate.Currency, rate.Nominal, rate.Decimal())
+		}
+	default:
+		fmt.Fprintln(os.Stderr, ratesUsage)
+		return 2
+	}
+
+	return 0
+}